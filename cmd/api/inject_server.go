@@ -9,6 +9,8 @@ import (
 	"github.com/praveenmsp23/trackdocs/handler/api"
 	"github.com/praveenmsp23/trackdocs/handler/health"
 	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
 	"github.com/praveenmsp23/trackdocs/pkg/token"
 )
 
@@ -17,9 +19,21 @@ var serverSet = wire.NewSet(
 	health.NewHealth,
 	api.NewApi,
 	provideRouter,
+	provideApplication,
 )
 
-func provideRouter(cfg *config.Config, manager *token.Manager, health *health.Health, api *api.Api) *gin.Engine {
+// application is the server and the services whose background jobs run
+// next to it
+type application struct {
+	server  *server.Server
+	service *service.Service
+}
+
+func provideApplication(server *server.Server, srv *service.Service) *application {
+	return &application{server: server, service: srv}
+}
+
+func provideRouter(cfg *config.Config, manager *token.Manager, health *health.Health, api *api.Api) (*gin.Engine, error) {
 	if cfg.Env == config.ApplicationEnvLocal {
 		gin.SetMode(gin.DebugMode)
 	} else {
@@ -28,6 +42,12 @@ func provideRouter(cfg *config.Config, manager *token.Manager, health *health.He
 	engine := gin.New()
 	engine.RedirectTrailingSlash = false
 	engine.MaxMultipartMemory = 2 << 20 // 2 MiB
+	// client ips come from the headers only behind a trusted proxy, anyone
+	// else could pick the ip they are rate limited by
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+	engine.RemoteIPHeaders = cfg.ClientIPHeaders
 	engine.Use(gin.Recovery())
 	engine.Use(CORSMiddleware(cfg))
 	health.Routes(engine.Group("/health"))
@@ -39,7 +59,7 @@ func provideRouter(cfg *config.Config, manager *token.Manager, health *health.He
 		c.JSON(http.StatusMethodNotAllowed, gin.H{"success": false, "error_code": http.StatusMethodNotAllowed, "error_message": "method not allowed"})
 	})
 	go manager.GC()
-	return engine
+	return engine, nil
 }
 
 func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
@@ -47,8 +67,8 @@ func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
 		path := c.Request.URL.Path
 		if strings.HasPrefix(path, "/api/") && authCORS(c) {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, accept, origin, Cache-Control, X-Requested-With, sentry-trace, baggage,"+cfg.TokenHeader)
-			c.Writer.Header().Set("Access-Control-Expose-Headers", cfg.TokenHeader)
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, accept, origin, Cache-Control, X-Requested-With, sentry-trace, baggage, Last-Event-ID,"+cfg.TokenHeader)
+			c.Writer.Header().Set("Access-Control-Expose-Headers", "RateLimit, RateLimit-Policy, Retry-After, "+cfg.TokenHeader)
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT")
 			if cfg.Env != config.ApplicationEnvLocal {
 				c.Writer.Header().Set("Access-Control-Max-Age", "86400")
@@ -12,12 +12,13 @@ func main() {
 	ctx := signal.WithContext(
 		context.Background(),
 	)
-	server, e := Initialize(ctx)
+	app, e := Initialize()
 	if e != nil {
 		logger.Fatal(e)
 	}
-	logger.Infof("Starting server on %s:%s", server.Listen, server.Port)
-	if err := server.ListenAndServe(ctx); err != nil {
+	app.service.Start(ctx)
+	logger.Infof("Starting server on %s:%s", app.server.Listen, app.server.Port)
+	if err := app.server.ListenAndServe(ctx); err != nil {
 		logger.Fatalf("could not run server: %v", err)
 	}
 }
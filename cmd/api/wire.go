@@ -4,29 +4,38 @@
 package main
 
 import (
-	"context"
 	"github.com/google/wire"
 	"github.com/praveenmsp23/trackdocs/pkg/cache"
 	"github.com/praveenmsp23/trackdocs/pkg/config"
 	"github.com/praveenmsp23/trackdocs/pkg/db"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
 	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/ratelimit"
+	"github.com/praveenmsp23/trackdocs/pkg/search"
 	"github.com/praveenmsp23/trackdocs/pkg/server"
 	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/storage"
 	"github.com/praveenmsp23/trackdocs/pkg/store"
 	"github.com/praveenmsp23/trackdocs/pkg/token"
 )
 
-func Initialize(ctx context.Context) (*server.Server, error) {
+func Initialize() (*application, error) {
 	wire.Build(
 		config.NewConfig,
 		cache.NewCache,
 		lock.NewRedisLock,
+		jobs.NewQueue,
 		db.NewDB,
+		events.NewBus,
+		storage.NewStorage,
+		search.NewIndexer,
 		service.NewService,
 		store.NewStore,
 		token.NewManager,
+		ratelimit.NewPolicies,
 		serverSet,
 		server.InitServer,
 	)
-	return &server.Server{}, nil
+	return &application{}, nil
 }
@@ -7,22 +7,26 @@
 package main
 
 import (
-	"context"
 	"github.com/praveenmsp23/trackdocs/handler/api"
 	"github.com/praveenmsp23/trackdocs/handler/health"
 	"github.com/praveenmsp23/trackdocs/pkg/cache"
 	"github.com/praveenmsp23/trackdocs/pkg/config"
 	"github.com/praveenmsp23/trackdocs/pkg/db"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
 	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/ratelimit"
+	"github.com/praveenmsp23/trackdocs/pkg/search"
 	"github.com/praveenmsp23/trackdocs/pkg/server"
 	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/storage"
 	"github.com/praveenmsp23/trackdocs/pkg/store"
 	"github.com/praveenmsp23/trackdocs/pkg/token"
 )
 
 // Injectors from wire.go:
 
-func Initialize(ctx context.Context) (*server.Server, error) {
+func Initialize() (*application, error) {
 	configConfig, err := config.NewConfig()
 	if err != nil {
 		return nil, err
@@ -51,18 +55,42 @@ func Initialize(ctx context.Context) (*server.Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	serviceService, err := service.NewService(configConfig, storeStore)
+	storageStorage, err := storage.NewStorage(configConfig)
 	if err != nil {
 		return nil, err
 	}
-	apiApi, err := api.NewApi(configConfig, storeStore, manager, redisLock, serviceService, cacheCache)
+	indexer, err := search.NewIndexer(configConfig, gormDB)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := jobs.NewQueue(cacheCache, configConfig)
+	if err != nil {
+		return nil, err
+	}
+	bus, err := events.NewBus(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	serviceService, err := service.NewService(configConfig, storeStore, redisLock, cacheCache, storageStorage, indexer, queue, bus)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := ratelimit.NewPolicies(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	apiApi, err := api.NewApi(configConfig, storeStore, manager, redisLock, serviceService, cacheCache, policies)
+	if err != nil {
+		return nil, err
+	}
+	engine, err := provideRouter(configConfig, manager, healthHealth, apiApi)
 	if err != nil {
 		return nil, err
 	}
-	engine := provideRouter(configConfig, manager, healthHealth, apiApi)
 	serverServer, err := server.InitServer(configConfig, engine)
 	if err != nil {
 		return nil, err
 	}
-	return serverServer, nil
+	mainApplication := provideApplication(serverServer, serviceService)
+	return mainApplication, nil
 }
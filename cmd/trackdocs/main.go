@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/drone/signal"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+)
+
+const usage = `Usage: trackdocs <command> [flags]
+
+Commands:
+  reindex    push all documents to the configured search backend
+`
+
+func main() {
+	logger.Init()
+	defer logger.Sync()
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	ctx := signal.WithContext(
+		context.Background(),
+	)
+	switch os.Args[1] {
+	case "reindex":
+		reindex(ctx, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// reindex runs in the foreground, it resumes a reindex that was interrupted
+// unless -restart is given
+func reindex(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	restart := flags.Bool("restart", false, "start over instead of resuming an unfinished reindex")
+	flags.Parse(args)
+
+	reindex, err := InitializeReindex()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	progress, err := reindex.Run(ctx, *restart)
+	if err != nil {
+		logger.Fatalf("could not reindex: %v", err)
+	}
+	logger.Infof("Reindexed %d of %d documents", progress.Indexed, progress.Total)
+}
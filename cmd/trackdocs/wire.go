@@ -0,0 +1,31 @@
+//go:build wireinject
+// +build wireinject
+
+package main
+
+import (
+	"github.com/google/wire"
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/db"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/search"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+func InitializeReindex() (*service.Reindex, error) {
+	wire.Build(
+		config.NewConfig,
+		cache.NewCache,
+		lock.NewRedisLock,
+		jobs.NewQueue,
+		db.NewDB,
+		search.NewIndexer,
+		store.NewStore,
+		service.NewSearchSync,
+		service.NewReindex,
+	)
+	return &service.Reindex{}, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/google/wire"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+)
+
+// wire set for loading the worker.
+var workerSet = wire.NewSet(
+	provideWorker,
+	provideApplication,
+)
+
+// application is the job worker and the services whose background jobs run
+// next to it
+type application struct {
+	worker  *jobs.Worker
+	service *service.Service
+}
+
+func provideApplication(worker *jobs.Worker, srv *service.Service) *application {
+	return &application{worker: worker, service: srv}
+}
+
+func provideWorker(cfg *config.Config, queue jobs.Queue, srv *service.Service) *jobs.Worker {
+	worker := jobs.NewWorker(cfg, queue)
+	srv.RegisterJobs(worker)
+	return worker
+}
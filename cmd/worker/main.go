@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"github.com/drone/signal"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+)
+
+func main() {
+	logger.Init()
+	defer logger.Sync()
+	ctx := signal.WithContext(
+		context.Background(),
+	)
+	app, e := Initialize()
+	if e != nil {
+		logger.Fatal(e)
+	}
+	app.service.Start(ctx)
+	app.worker.Run(ctx)
+	logger.Infof("Worker stopped")
+}
@@ -0,0 +1,35 @@
+//go:build wireinject
+// +build wireinject
+
+package main
+
+import (
+	"github.com/google/wire"
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/db"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/search"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/storage"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+func Initialize() (*application, error) {
+	wire.Build(
+		config.NewConfig,
+		cache.NewCache,
+		lock.NewRedisLock,
+		jobs.NewQueue,
+		db.NewDB,
+		events.NewBus,
+		storage.NewStorage,
+		search.NewIndexer,
+		service.NewService,
+		store.NewStore,
+		workerSet,
+	)
+	return &application{}, nil
+}
@@ -33,7 +33,7 @@ func HandleAccountUpdate(repo *store.Store) gin.HandlerFunc {
 			c.Error(err).SetType(gin.ErrorTypeBind)
 			return
 		}
-		account, err := repo.AccountStore.UpdateAccountFromRequest(account.Id, &json)
+		account, err := repo.AccountStore.UpdateAccountFromRequest(c.Request.Context(), account.Id, &json)
 		if err != nil {
 			c.Error(err)
 			return
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+// HandleReindexStart enqueues a job that pushes all documents to the search
+// backend. An unfinished reindex is resumed unless ?restart=true.
+func HandleReindexStart(srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		restart, err := strconv.ParseBool(c.DefaultQuery("restart", "false"))
+		if err != nil {
+			c.Error(models.ErrBadRequest)
+			return
+		}
+		if err := srv.Reindex.Start(c.Request.Context(), restart); err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse("reindex enqueued"))
+	})
+}
+
+// HandleReindexProgress returns the progress of the running or last reindex
+func HandleReindexProgress(srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		progress, err := srv.Reindex.Progress(c.Request.Context())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(progress))
+	})
+}
+
+// HandleCacheInvalidate deletes the cache entries of a workspace, or the keys
+// starting with a prefix of store.CachePrefixes, and returns how many were
+// deleted. Jobs, locks, tokens and rate limits are never deleted.
+func HandleCacheInvalidate(repo *store.Store, cache cache.Cache) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		var json dto.CacheInvalidateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		var deleted int64
+		var err error
+		if json.WorkspaceId != "" {
+			deleted, err = repo.WorkspaceStore.InvalidateCache(c.Request.Context(), json.WorkspaceId)
+		} else if store.IsCachePrefix(json.Prefix) {
+			deleted, err = cache.DeleteByPrefix(c.Request.Context(), json.Prefix)
+		} else {
+			err = models.ErrInvalidCachePrefix
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{"deleted": deleted}))
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/praveenmsp23/trackdocs/pkg/config"
 	"github.com/praveenmsp23/trackdocs/pkg/lock"
 	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/ratelimit"
 	"github.com/praveenmsp23/trackdocs/pkg/service"
 	"github.com/praveenmsp23/trackdocs/pkg/store"
 	"github.com/praveenmsp23/trackdocs/pkg/token"
@@ -19,13 +20,19 @@ type Api struct {
 	repo         *store.Store
 	srv          *service.Service
 	redisLock    *lock.RedisLock
-	cache        *cache.Cache
+	cache        cache.Cache
+	policies     *ratelimit.Policies
 }
 
 func (s *Api) Routes(router *gin.RouterGroup) {
 
-	// Public endpoints
 	router.Use(Errors(s.cfg))
+	// EventSource clients pass the token as access_token
+	router.Use(QueryTokenMiddleware(s.cfg, "/api/stream"))
+	router.Use(AccountMiddleware(s.repo, s.tokenManager))
+	router.Use(RateLimitMiddleware(s.cfg, s.policies, s.cache))
+
+	// Public endpoints
 	router.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, models.NewSuccessResponse("ok"))
 	})
@@ -33,14 +40,165 @@ func (s *Api) Routes(router *gin.RouterGroup) {
 	// Account endpoints
 	account := router.Group("/account")
 	account.Use(AuthMiddleware(s.repo, s.tokenManager))
-	account.Use(RateLimitMiddleware(100, s.cache)) // 100 requests per minute per account
 	{
 		account.GET("/me", HandleGetAccount())
 		account.POST("/me/update", HandleAccountUpdate(s.repo))
 		account.POST("/logout", HandleAccountLogout(s.tokenManager))
 	}
+
+	// Notification endpoints
+	notifications := router.Group("/notifications")
+	notifications.Use(AuthMiddleware(s.repo, s.tokenManager))
+	{
+		notifications.GET("", HandleNotificationList(s.repo))
+		notifications.GET("/unread-count", HandleNotificationUnreadCount(s.repo))
+		notifications.POST("/read-all", HandleNotificationReadAll(s.repo))
+		notifications.POST("/:id/read", HandleNotificationRead(s.repo))
+		notifications.GET("/preferences", HandleNotificationPreferences(s.repo))
+		notifications.POST("/preferences", HandleNotificationPreferencesUpdate(s.repo))
+	}
+
+	// Event stream
+	events := router.Group("/stream")
+	events.Use(AuthMiddleware(s.repo, s.tokenManager))
+	{
+		events.GET("", HandleStream(s.cfg, s.srv))
+	}
+
+	// Workspace endpoints
+	workspaces := router.Group("/workspaces")
+	workspaces.Use(AuthMiddleware(s.repo, s.tokenManager))
+	{
+		workspaces.GET("", HandleWorkspaceList(s.repo))
+		workspaces.POST("", HandleWorkspaceCreate(s.repo))
+		workspaces.GET("/:id", HandleWorkspaceGet(s.repo))
+		workspaces.POST("/:id/update", HandleWorkspaceUpdate(s.repo))
+		workspaces.GET("/:id/members", HandleWorkspaceMemberList(s.repo))
+		workspaces.POST("/:id/members", HandleWorkspaceMemberAdd(s.repo))
+		workspaces.POST("/:id/members/:member_id/update", HandleWorkspaceMemberUpdate(s.repo))
+		workspaces.POST("/:id/members/:member_id/delete", HandleWorkspaceMemberRemove(s.repo))
+		workspaces.GET("/:id/permissions", HandlePermissionList(s.repo))
+		workspaces.POST("/:id/permissions", HandlePermissionGrant(s.repo))
+		workspaces.POST("/:id/permissions/:permission_id/delete", HandlePermissionRevoke(s.repo))
+		workspaces.GET("/:id/projects", HandleProjectList(s.repo))
+		workspaces.POST("/:id/projects", HandleProjectCreate(s.repo))
+		workspaces.GET("/:id/documents", HandleWorkspaceDocuments(s.repo))
+		workspaces.GET("/:id/documents/expiring", HandleWorkspaceExpiringDocuments(s.repo))
+		workspaces.GET("/:id/metadata-fields", HandleMetadataFieldList(s.repo))
+		workspaces.POST("/:id/metadata-fields", HandleMetadataFieldCreate(s.repo))
+		workspaces.POST("/:id/metadata-fields/:field_id/update", HandleMetadataFieldUpdate(s.repo))
+		workspaces.POST("/:id/metadata-fields/:field_id/delete", HandleMetadataFieldDelete(s.repo))
+		workspaces.GET("/:id/tags", HandleTagAutocomplete(s.repo))
+		workspaces.GET("/:id/document-requests", HandleDocumentRequestList(s.repo))
+		workspaces.POST("/:id/document-requests", HandleDocumentRequestCreate(s.repo, s.srv))
+		workspaces.GET("/:id/workflow", HandleWorkflowGet(s.repo))
+		workspaces.POST("/:id/workflow", HandleWorkflowUpdate(s.repo))
+		workspaces.GET("/:id/webhooks", HandleWebhookList(s.repo))
+		workspaces.POST("/:id/webhooks", HandleWebhookCreate(s.repo))
+		workspaces.GET("/:id/webhooks/:webhook_id", HandleWebhookGet(s.repo))
+		workspaces.POST("/:id/webhooks/:webhook_id/update", HandleWebhookUpdate(s.repo))
+		workspaces.POST("/:id/webhooks/:webhook_id/delete", HandleWebhookDelete(s.repo))
+		workspaces.GET("/:id/webhooks/:webhook_id/deliveries", HandleWebhookDeliveryList(s.repo))
+		workspaces.GET("/:id/webhooks/:webhook_id/deliveries/:delivery_id", HandleWebhookDeliveryGet(s.repo))
+		workspaces.POST("/:id/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", HandleWebhookRedeliver(s.repo, s.srv))
+	}
+
+	// Project endpoints
+	projects := router.Group("/projects")
+	projects.Use(AuthMiddleware(s.repo, s.tokenManager))
+	{
+		projects.GET("/:id", HandleProjectGet(s.repo))
+		projects.POST("/:id/update", HandleProjectUpdate(s.repo))
+		projects.POST("/:id/delete", HandleProjectDelete(s.repo))
+		projects.GET("/:id/folders", HandleProjectFolders(s.repo))
+		projects.GET("/:id/documents", HandleProjectDocuments(s.repo))
+	}
+
+	// Folder endpoints
+	folders := router.Group("/folders")
+	folders.Use(AuthMiddleware(s.repo, s.tokenManager))
+	{
+		folders.POST("", HandleFolderCreate(s.repo))
+		folders.GET("/:id", HandleFolderGet(s.repo))
+		folders.GET("/:id/breadcrumbs", HandleFolderBreadcrumbs(s.repo))
+		folders.GET("/:id/folders", HandleFolderFolders(s.repo))
+		folders.GET("/:id/documents", HandleFolderDocuments(s.repo))
+		folders.POST("/:id/rename", HandleFolderRename(s.repo))
+		folders.POST("/:id/move", HandleFolderMove(s.repo))
+		folders.POST("/:id/copy", HandleFolderCopy(s.repo))
+		folders.POST("/:id/delete", HandleFolderDelete(s.repo))
+	}
+
+	// Document endpoints
+	documents := router.Group("/documents")
+	documents.Use(AuthMiddleware(s.repo, s.tokenManager))
+	{
+		documents.POST("", HandleDocumentCreate(s.repo))
+		documents.GET("/:id", HandleDocumentGet(s.repo))
+		documents.GET("/:id/breadcrumbs", HandleDocumentBreadcrumbs(s.repo))
+		documents.POST("/:id/update", HandleDocumentUpdate(s.repo))
+		documents.POST("/:id/tags", HandleDocumentTags(s.repo))
+		documents.POST("/:id/expiry", HandleDocumentExpiry(s.repo))
+		documents.GET("/:id/versions", HandleVersionList(s.repo))
+		documents.POST("/:id/versions", HandleVersionUpload(s.cfg, s.repo, s.srv))
+		documents.GET("/:id/versions/:version_id/download", HandleVersionDownload(s.repo, s.srv))
+		documents.GET("/:id/versions/:version_id/text", HandleVersionText(s.repo))
+		documents.POST("/:id/move", HandleDocumentMove(s.repo))
+		documents.POST("/:id/copy", HandleDocumentCopy(s.repo))
+		documents.POST("/:id/delete", HandleDocumentDelete(s.repo))
+		documents.GET("/:id/transitions", HandleTransitionList(s.repo))
+		documents.POST("/:id/transitions", HandleTransitionCreate(s.repo))
+		documents.GET("/:id/transitions/available", HandleTransitionAvailable(s.repo))
+		documents.GET("/:id/transitions/:transition_id", HandleTransitionGet(s.repo))
+		documents.POST("/:id/transitions/:transition_id/approve", HandleTransitionApprove(s.repo))
+		documents.POST("/:id/transitions/:transition_id/reject", HandleTransitionReject(s.repo))
+		documents.POST("/:id/transitions/:transition_id/cancel", HandleTransitionCancel(s.repo))
+		documents.GET("/:id/comments", HandleCommentList(s.repo))
+		documents.POST("/:id/comments", HandleCommentCreate(s.repo))
+		documents.GET("/:id/comments/:comment_id", HandleCommentGet(s.repo))
+		documents.POST("/:id/comments/:comment_id/update", HandleCommentUpdate(s.repo))
+		documents.GET("/:id/comments/:comment_id/history", HandleCommentHistory(s.repo))
+		documents.POST("/:id/comments/:comment_id/resolve", HandleCommentResolve(s.repo, true))
+		documents.POST("/:id/comments/:comment_id/reopen", HandleCommentResolve(s.repo, false))
+		documents.POST("/:id/comments/:comment_id/delete", HandleCommentDelete(s.repo))
+	}
+
+	// Document request endpoints
+	requests := router.Group("/document-requests")
+	requests.Use(AuthMiddleware(s.repo, s.tokenManager))
+	{
+		requests.GET("/:id", HandleDocumentRequestGet(s.repo))
+		requests.POST("/:id/remind", HandleDocumentRequestRemind(s.repo, s.srv))
+		requests.POST("/:id/cancel", HandleDocumentRequestCancel(s.repo))
+		requests.POST("/:id/items/:item_id/reject", HandleDocumentRequestItemReject(s.repo, s.srv))
+	}
+
+	// Search endpoints
+	searches := router.Group("/search")
+	searches.Use(AuthMiddleware(s.repo, s.tokenManager))
+	{
+		searches.GET("", HandleSearch(s.repo, s.srv))
+		searches.GET("/token", HandleSearchToken(s.cfg, s.repo, s.srv))
+	}
+
+	// Admin endpoints
+	admin := router.Group("/admin")
+	admin.Use(AuthMiddleware(s.repo, s.tokenManager))
+	admin.Use(AdminMiddleware(s.cfg))
+	{
+		admin.GET("/reindex", HandleReindexProgress(s.srv))
+		admin.POST("/reindex", HandleReindexStart(s.srv))
+		admin.POST("/cache/invalidate", HandleCacheInvalidate(s.repo, s.cache))
+	}
+
+	// Public upload portal of document requests, the link token authenticates
+	portal := router.Group("/portal")
+	{
+		portal.GET("/:token", HandlePortalGet(s.repo))
+		portal.POST("/:token/items/:item_id/upload", HandlePortalUpload(s.cfg, s.repo, s.srv))
+	}
 }
 
-func NewApi(cfg *config.Config, store *store.Store, token *token.Manager, lock *lock.RedisLock, srv *service.Service, cache *cache.Cache) (*Api, error) {
-	return &Api{cfg: cfg, repo: store, tokenManager: token, redisLock: lock, srv: srv, cache: cache}, nil
+func NewApi(cfg *config.Config, store *store.Store, token *token.Manager, lock *lock.RedisLock, srv *service.Service, cache cache.Cache, policies *ratelimit.Policies) (*Api, error) {
+	return &Api{cfg: cfg, repo: store, tokenManager: token, redisLock: lock, srv: srv, cache: cache, policies: policies}, nil
 }
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+// HandleCommentList lists the threads of the document with their replies.
+// ?resolved=true or false filters on their state, ?version_id on the version
+// they are anchored to.
+func HandleCommentList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		var resolved *bool
+		if value, ok := c.GetQuery("resolved"); ok {
+			r := value == "true"
+			resolved = &r
+		}
+		comments, total, err := repo.CommentStore.ListThreads(c.Request.Context(), c.Param("id"), resolved, c.Query("version_id"), models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(comments, total))
+	})
+}
+
+// HandleCommentCreate starts a thread or replies to one, everyone who can
+// view the document may comment
+func HandleCommentCreate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.CommentCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		comment, err := repo.CommentStore.NewCommentFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(comment))
+	})
+}
+
+func HandleCommentGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		comment, err := repo.CommentStore.FindCommentById(c.Request.Context(), c.Param("id"), c.Param("comment_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(comment))
+	})
+}
+
+// HandleCommentUpdate edits a comment, only its author may
+func HandleCommentUpdate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.CommentUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		comment, err := repo.CommentStore.UpdateCommentFromRequest(c.Request.Context(), c.Param("id"), c.Param("comment_id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(comment))
+	})
+}
+
+// HandleCommentHistory lists the earlier bodies of an edited comment
+func HandleCommentHistory(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		edits, err := repo.CommentStore.ListEdits(c.Request.Context(), c.Param("id"), c.Param("comment_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(edits))
+	})
+}
+
+// HandleCommentResolve resolves a thread, or reopens it when resolved is
+// false
+func HandleCommentResolve(repo *store.Store, resolved bool) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		comment, err := repo.CommentStore.Resolve(c.Request.Context(), c.Param("id"), c.Param("comment_id"), c.Account.Id, resolved)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(comment))
+	})
+}
+
+func HandleCommentDelete(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		err := repo.CommentStore.DeleteComment(c.Request.Context(), c.Param("id"), c.Param("comment_id"), c.Account.Id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{}))
+	})
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+func HandleDocumentCreate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.DocumentCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		resourceType, resourceId := containerResource(json.ProjectId, json.FolderId)
+		if _, ok := authorize(c, repo, resourceType, resourceId, models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		document, err := repo.DocumentStore.NewDocumentFromRequest(c.Request.Context(), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(document))
+	})
+}
+
+func HandleDocumentGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		document, err := repo.DocumentStore.GetDocument(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(document))
+	})
+}
+
+func HandleDocumentBreadcrumbs(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		document, err := repo.DocumentStore.FindDocumentById(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		crumbs, err := repo.DocumentStore.Breadcrumbs(c.Request.Context(), document)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(crumbs))
+	})
+}
+
+func HandleDocumentUpdate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.DocumentUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		document, err := repo.DocumentStore.UpdateDocumentFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(document))
+	})
+}
+
+func HandleDocumentMove(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.DocumentMoveRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		resourceType, resourceId := containerResource(json.ProjectId, json.FolderId)
+		if _, ok := authorize(c, repo, resourceType, resourceId, models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		document, err := repo.DocumentStore.MoveDocumentFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(document))
+	})
+}
+
+func HandleDocumentCopy(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.DocumentMoveRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		resourceType, resourceId := containerResource(json.ProjectId, json.FolderId)
+		if _, ok := authorize(c, repo, resourceType, resourceId, models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		document, err := repo.DocumentStore.CopyDocumentFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(document))
+	})
+}
+
+func HandleDocumentDelete(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		err := repo.DocumentStore.DeleteDocument(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{}))
+	})
+}
+
+func HandleDocumentTags(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.DocumentTagsRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		document, err := repo.DocumentStore.SetTagsFromRequest(c.Request.Context(), c.Param("id"), &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(document))
+	})
+}
+
+// HandleWorkspaceDocuments lists the documents of the whole workspace, usually
+// narrowed with tags and metadata filters
+func HandleWorkspaceDocuments(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleViewer)
+		if !ok {
+			return
+		}
+		documents, total, err := repo.DocumentStore.ListWorkspaceDocuments(c.Request.Context(), workspaceId, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(documents, total))
+	})
+}
+
+func HandleDocumentExpiry(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.DocumentExpiryRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		document, err := repo.DocumentStore.SetExpiryFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(document))
+	})
+}
+
+// HandleWorkspaceExpiringDocuments lists the documents expiring within the
+// next ?days=30 days, already expired documents included
+func HandleWorkspaceExpiringDocuments(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+		if err != nil || days < 0 || days > 3650 {
+			c.Error(models.ErrBadRequest)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleViewer)
+		if !ok {
+			return
+		}
+		before := time.Now().AddDate(0, 0, days)
+		documents, total, err := repo.DocumentStore.ListExpiringDocuments(c.Request.Context(), workspaceId, before, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(documents, total))
+	})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+// findDocumentRequest loads the request of the :id param and checks the account
+// holds role on the project or folder collecting the files
+func findDocumentRequest(c *models.TrackDocsContext, repo *store.Store, role models.WorkspaceRole) (*models.DocumentRequest, bool) {
+	request, err := repo.RequestStore.FindRequestById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	resourceType, resourceId := containerResource(request.ProjectId, request.FolderId)
+	if _, ok := authorize(c, repo, resourceType, resourceId, role); !ok {
+		return nil, false
+	}
+	return request, true
+}
+
+func HandleDocumentRequestCreate(repo *store.Store, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.DocumentRequestCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		resourceType, resourceId := containerResource(json.ProjectId, json.FolderId)
+		workspaceId, ok := authorize(c, repo, resourceType, resourceId, models.WorkspaceRoleEditor)
+		if !ok {
+			return
+		}
+		if workspaceId != c.Param("id") {
+			c.Error(models.ErrProjectNotFound)
+			return
+		}
+		request, err := srv.DocumentRequests.CreateFromRequest(c.Request.Context(), workspaceId, c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(request))
+	})
+}
+
+func HandleDocumentRequestList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleEditor)
+		if !ok {
+			return
+		}
+		requests, total, err := repo.RequestStore.ListRequests(c.Request.Context(), workspaceId, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(requests, total))
+	})
+}
+
+func HandleDocumentRequestGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		request, ok := findDocumentRequest(c, repo, models.WorkspaceRoleEditor)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(request))
+	})
+}
+
+func HandleDocumentRequestCancel(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := findDocumentRequest(c, repo, models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		request, err := repo.RequestStore.CancelRequest(c.Request.Context(), c.Param("id"), c.Account.Id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(request))
+	})
+}
+
+// HandleDocumentRequestRemind emails the recipient about the missing items
+// right away
+func HandleDocumentRequestRemind(repo *store.Store, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		request, ok := findDocumentRequest(c, repo, models.WorkspaceRoleEditor)
+		if !ok {
+			return
+		}
+		if err := srv.DocumentRequests.Remind(c.Request.Context(), request); err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{}))
+	})
+}
+
+func HandleDocumentRequestItemReject(repo *store.Store, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.DocumentRequestItemRejectRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := findDocumentRequest(c, repo, models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		request, err := srv.DocumentRequests.RejectItem(c.Request.Context(), c.Param("id"), c.Param("item_id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(request))
+	})
+}
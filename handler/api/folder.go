@@ -0,0 +1,230 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+// containerResource returns the folder, or the project when folderId is empty,
+// that is the target of a create, move or copy
+func containerResource(projectId, folderId string) (models.ResourceType, string) {
+	if folderId == "" {
+		return models.ResourceTypeProject, projectId
+	}
+	return models.ResourceTypeFolder, folderId
+}
+
+func HandleFolderCreate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.FolderCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		resourceType, resourceId := containerResource(json.ProjectId, json.ParentId)
+		if _, ok := authorize(c, repo, resourceType, resourceId, models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		folder, err := repo.FolderStore.NewFolderFromRequest(c.Request.Context(), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(folder))
+	})
+}
+
+func HandleFolderGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeFolder, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		folder, err := repo.FolderStore.FindFolderById(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(folder))
+	})
+}
+
+func HandleFolderBreadcrumbs(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeFolder, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		folder, err := repo.FolderStore.FindFolderById(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		crumbs, err := repo.FolderStore.Breadcrumbs(c.Request.Context(), folder)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(crumbs))
+	})
+}
+
+// HandleFolderFolders lists the direct sub folders of the folder
+func HandleFolderFolders(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeFolder, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		folder, err := repo.FolderStore.FindFolderById(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		folders, total, err := repo.FolderStore.ListFolders(c.Request.Context(), folder.ProjectId, folder.Id, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(folders, total))
+	})
+}
+
+// HandleFolderDocuments lists the documents of the folder, including those of
+// all sub folders with ?recursive=true
+func HandleFolderDocuments(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeFolder, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		folder, err := repo.FolderStore.FindFolderById(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		recursive := c.Query("recursive") == "true"
+		documents, total, err := repo.DocumentStore.ListDocuments(c.Request.Context(), folder.ProjectId, folder.Id, recursive, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(documents, total))
+	})
+}
+
+func HandleFolderRename(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.FolderRenameRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeFolder, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		folder, err := repo.FolderStore.RenameFolderFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(folder))
+	})
+}
+
+func HandleFolderMove(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.FolderMoveRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeFolder, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		resourceType, resourceId := containerResource(json.ProjectId, json.ParentId)
+		if _, ok := authorize(c, repo, resourceType, resourceId, models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		folder, err := repo.FolderStore.MoveFolderFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(folder))
+	})
+}
+
+func HandleFolderCopy(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.FolderMoveRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeFolder, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		resourceType, resourceId := containerResource(json.ProjectId, json.ParentId)
+		if _, ok := authorize(c, repo, resourceType, resourceId, models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		folder, err := repo.FolderStore.CopyFolderFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(folder))
+	})
+}
+
+func HandleFolderDelete(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeFolder, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		err := repo.FolderStore.DeleteFolder(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{}))
+	})
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+func HandleMetadataFieldList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleViewer)
+		if !ok {
+			return
+		}
+		fields, err := repo.MetadataStore.ListFields(c.Request.Context(), workspaceId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(fields))
+	})
+}
+
+func HandleMetadataFieldCreate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.MetadataFieldCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		field, err := repo.MetadataStore.NewFieldFromRequest(c.Request.Context(), workspaceId, c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(field))
+	})
+}
+
+func HandleMetadataFieldUpdate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.MetadataFieldUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		field, err := repo.MetadataStore.UpdateFieldFromRequest(c.Request.Context(), workspaceId, c.Param("field_id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(field))
+	})
+}
+
+func HandleMetadataFieldDelete(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		err := repo.MetadataStore.DeleteField(c.Request.Context(), workspaceId, c.Param("field_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{}))
+	})
+}
+
+// HandleTagAutocomplete suggests the most used tags starting with ?q=
+func HandleTagAutocomplete(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleViewer)
+		if !ok {
+			return
+		}
+		tags, err := repo.TagStore.Autocomplete(c.Request.Context(), workspaceId, c.Query("q"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(tags))
+	})
+}
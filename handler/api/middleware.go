@@ -1,9 +1,15 @@
 package api
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"github.com/go-redis/redis/v8"
+	"io"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,13 +22,40 @@ import (
 	"github.com/praveenmsp23/trackdocs/pkg/config"
 	"github.com/praveenmsp23/trackdocs/pkg/logger"
 	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/ratelimit"
 	"github.com/praveenmsp23/trackdocs/pkg/store"
 	"github.com/praveenmsp23/trackdocs/pkg/token"
 )
 
+// AccountMiddleware sets the account of requests carrying a valid token and
+// lets every request through, so the rate limits of all routes know the
+// account. AuthMiddleware rejects requests without one.
+func AccountMiddleware(s *store.Store, manager *token.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := manager.TokenGet(c)
+		if t == nil || t.TokenID() == "" {
+			c.Next()
+			return
+		}
+		if accountId, isExists := t.Get("account_id"); isExists && accountId != "" {
+			account, err := s.AccountStore.FindAccountById(c.Request.Context(), accountId)
+			if err == nil {
+				c.Set("account", account)
+			}
+		}
+		c.Next()
+	}
+}
+
+// AuthMiddleware rejects requests without an account, the account is taken
+// from AccountMiddleware when it ran before
 func AuthMiddleware(s *store.Store, manager *token.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		p := models.NewTrackDocsContext(c)
+		if p.Account != nil {
+			c.Next()
+			return
+		}
 		t := manager.TokenGet(p.Context)
 		if t == nil || t.TokenID() == "" {
 			c.JSON(http.StatusUnauthorized, models.NewErrorResponse(http.StatusUnauthorized, models.ErrUnauthorized))
@@ -35,7 +68,7 @@ func AuthMiddleware(s *store.Store, manager *token.Manager) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		account, err := s.AccountStore.FindAccountById(accountId)
+		account, err := s.AccountStore.FindAccountById(c.Request.Context(), accountId)
 		if err != nil {
 			logger.Error(err)
 			c.Error(err)
@@ -47,43 +80,240 @@ func AuthMiddleware(s *store.Store, manager *token.Manager) gin.HandlerFunc {
 	}
 }
 
-// RateLimitMiddleware is a Gin middleware that limits the rate of API authentication requests based on API key
-func RateLimitMiddleware(limit int, cache *cache.Cache) gin.HandlerFunc {
+// QueryTokenMiddleware takes the access token from the access_token query
+// parameter of the given routes when the token header is missing, browsers
+// cannot set headers on an EventSource. It runs before AccountMiddleware.
+func QueryTokenMiddleware(cfg *config.Config, routes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(cfg.TokenHeader) == "" && slices.Contains(routes, c.FullPath()) {
+			if accessToken := c.Query("access_token"); accessToken != "" {
+				c.Request.Header.Set(cfg.TokenHeader, accessToken)
+			}
+		}
+		c.Next()
+	}
+}
+
+// AdminMiddleware only lets accounts listed in cfg.AdminEmails through, it
+// runs after AuthMiddleware
+func AdminMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		p := models.NewTrackDocsContext(c)
-		account := p.Account
-		if account == nil {
+		if p.Account == nil {
 			c.JSON(http.StatusUnauthorized, models.NewErrorResponse(http.StatusUnauthorized, models.ErrUnauthorized))
 			c.Abort()
 			return
 		}
-		// Create a Redis key for the rate limiter
-		limitKey := fmt.Sprintf("ratelimit::%s", account.Id)
-
-		res, err := cache.Allow(limitKey, limit)
-		if err != nil && err != redis.Nil {
-			c.Error(err)
-			c.Abort()
-			return
+		for _, email := range cfg.AdminEmails {
+			if strings.EqualFold(strings.TrimSpace(email), p.Account.Email) {
+				c.Next()
+				return
+			}
 		}
+		c.Error(models.ErrForbidden)
+		c.Abort()
+	}
+}
 
-		c.Writer.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
-		c.Writer.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
-		c.Writer.Header().Set("X-RateLimit-Policy", fmt.Sprintf("%d;w=60", limit))
-		if res.Allowed == 0 {
-			retryAfter := strconv.Itoa(int(res.RetryAfter / time.Second))
-			c.Writer.Header().Set("X-RateLimit-Reset", retryAfter)
-			c.Writer.Header().Set("Retry-After", retryAfter)
-			c.JSON(http.StatusTooManyRequests, models.NewErrorResponse(http.StatusTooManyRequests, fmt.Errorf("exceeds rate limit, retry in %s second(s", retryAfter)))
-			c.Abort()
-			return
+// RateLimitMiddleware applies the rate limit policies matching the route,
+// it runs once for every route after AccountMiddleware. Policies whose key
+// the request does not have, like the account on public routes, are skipped.
+// The RateLimit-Policy and RateLimit headers of the IETF draft describe every
+// applied policy, X-RateLimit-* the one with the least remaining. A rejected
+// request gives back what the policies before took. Requests without a
+// content length are refused on routes whose cost depends on it.
+func RateLimitMiddleware(cfg *config.Config, policies *ratelimit.Policies, limiter cache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := models.NewTrackDocsContext(c)
+		var headers rateLimitHeaders
+		var taken []rateLimitTaken
+		matched := policies.Match(c.Request.Method, c.FullPath())
+		for _, policy := range matched {
+			// a chunked body would cost as little as an empty one
+			if policy.CostBytes > 0 && c.Request.ContentLength < 0 {
+				c.JSON(http.StatusLengthRequired, models.NewErrorResponse(http.StatusLengthRequired, models.ErrLengthRequired))
+				c.Abort()
+				return
+			}
 		}
-
+		for _, policy := range matched {
+			id := rateLimitKey(cfg, p, policy)
+			if id == "" {
+				continue
+			}
+			plan := ""
+			if p.Account != nil {
+				plan = p.Account.Plan
+			}
+			limitKey := fmt.Sprintf("ratelimit::%s::%s::%s", policy.Name, policy.Key, id)
+			limit := policy.Limit(plan)
+			cost := policy.CostOf(c.Request.ContentLength)
+			var res *cache.Result
+			var err error
+			if policy.AtMost {
+				res, err = limiter.AllowAtMost(c.Request.Context(), limitKey, limit, cost)
+			} else {
+				res, err = limiter.AllowLimit(c.Request.Context(), limitKey, limit, cost)
+			}
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			headers.add(policy.Name, res)
+			if res.Allowed == 0 {
+				refundRateLimits(c, limiter, taken)
+				headers.set(c)
+				retryAfter := strconv.Itoa(ceilSeconds(res.RetryAfter))
+				c.Writer.Header().Set("Retry-After", retryAfter)
+				c.JSON(http.StatusTooManyRequests, models.NewErrorResponse(http.StatusTooManyRequests, fmt.Errorf("exceeds rate limit, retry in %s second(s)", retryAfter)))
+				c.Abort()
+				return
+			}
+			taken = append(taken, rateLimitTaken{limitKey, limit, res.Allowed})
+		}
+		headers.set(c)
 		// Call the next handler in the chain
 		c.Next()
 	}
 }
 
+// rateLimitTaken is what a policy took from its limit
+type rateLimitTaken struct {
+	key   string
+	limit cache.Limit
+	n     int
+}
+
+// refundRateLimits gives back what was taken, failures are only logged as the
+// request is rejected anyway
+func refundRateLimits(c *gin.Context, limiter cache.Cache, taken []rateLimitTaken) {
+	for _, t := range taken {
+		if _, err := limiter.AllowLimit(c.Request.Context(), t.key, t.limit, -t.n); err != nil {
+			logger.Errorf("RateLimitMiddleware error while refunding %s:%s", t.key, err.Error())
+		}
+	}
+}
+
+// rateLimitBodyMax is how much of the body is read for the email of a request
+const rateLimitBodyMax = 64 << 10
+
+// rateLimitKey returns what policy counts the request by, empty when the
+// request has none
+func rateLimitKey(cfg *config.Config, p *models.TrackDocsContext, policy *ratelimit.Policy) string {
+	switch policy.Key {
+	case ratelimit.KeyAccount:
+		if p.Account != nil {
+			return p.Account.Id
+		}
+	case ratelimit.KeyApiKey:
+		// tokens are not kept in key names
+		if t := p.GetHeader(cfg.TokenHeader); t != "" {
+			return hashKey(t)
+		}
+	case ratelimit.KeyIP:
+		return p.ClientIP()
+	case ratelimit.KeyWorkspace:
+		return p.Param(policy.Param)
+	case ratelimit.KeyEmail:
+		field := policy.Param
+		if field == "" {
+			field = "email"
+		}
+		if email := strings.ToLower(strings.TrimSpace(requestField(p.Context, field))); email != "" {
+			return hashKey(email)
+		}
+	}
+	return ""
+}
+
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestField returns a string field of a JSON or form body, the body is
+// left for the handler. Other content types are not read, so uploads are not
+// buffered, and JSON keys match without case with the last one winning, like
+// encoding/json fills structs.
+func requestField(c *gin.Context, field string) string {
+	switch contentType := c.ContentType(); {
+	case contentType == gin.MIMEPOSTForm || contentType == gin.MIMEMultipartPOSTForm:
+		return c.PostForm(field)
+	case contentType != gin.MIMEJSON && !strings.HasSuffix(contentType, "+json"):
+		return ""
+	}
+	body := c.Request.Body
+	data, err := io.ReadAll(io.LimitReader(body, rateLimitBodyMax))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), body), body}
+	if err != nil {
+		return ""
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	if t, err := d.Token(); err != nil || t != json.Delim('{') {
+		return ""
+	}
+	value := ""
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			return ""
+		}
+		if key, _ := t.(string); strings.EqualFold(key, field) {
+			value, _ = v.(string)
+		}
+	}
+	return value
+}
+
+// rateLimitHeaders collects the results of the applied policies
+type rateLimitHeaders struct {
+	policies []string
+	limits   []string
+	tightest *cache.Result
+}
+
+func (h *rateLimitHeaders) add(name string, res *cache.Result) {
+	reset := ceilSeconds(res.ResetAfter)
+	if res.Allowed == 0 {
+		reset = ceilSeconds(res.RetryAfter)
+	}
+	h.policies = append(h.policies, fmt.Sprintf("%q;q=%d;w=%d", name, res.Limit.Rate, int(res.Limit.Period.Seconds())))
+	h.limits = append(h.limits, fmt.Sprintf("%q;r=%d;t=%d", name, res.Remaining, reset))
+	if h.tightest == nil || res.Allowed == 0 || res.Remaining < h.tightest.Remaining {
+		h.tightest = res
+	}
+}
+
+func (h *rateLimitHeaders) set(c *gin.Context) {
+	if h.tightest == nil {
+		return
+	}
+	c.Writer.Header().Set("RateLimit-Policy", strings.Join(h.policies, ", "))
+	c.Writer.Header().Set("RateLimit", strings.Join(h.limits, ", "))
+	reset := h.tightest.ResetAfter
+	if h.tightest.Allowed == 0 {
+		reset = h.tightest.RetryAfter
+	}
+	c.Writer.Header().Set("X-RateLimit-Limit", strconv.Itoa(h.tightest.Limit.Rate))
+	c.Writer.Header().Set("X-RateLimit-Remaining", strconv.Itoa(h.tightest.Remaining))
+	c.Writer.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d < 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
 func UcFirst(str string) string {
 	for i, v := range str {
 		return string(unicode.ToUpper(v)) + str[i+1:]
@@ -162,6 +392,8 @@ func ValidationErrorToText(e validator.FieldError) string {
 		return "invalid email format"
 	case "len":
 		return fmt.Sprintf("%s must be %s characters long", word, e.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", word, e.Param())
 	}
 	return fmt.Sprintf("%s is not valid", word)
 }
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/ratelimit"
+)
+
+func newRateLimitRouter(t *testing.T, policies string) (*gin.Engine, cache.Cache) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	file := filepath.Join(t.TempDir(), "policies.json")
+	if err := os.WriteFile(file, []byte(policies), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{RateLimitPolicies: file, TokenHeader: "X-Token"}
+	ps, err := ratelimit.NewPolicies(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := cache.NewMemory(cfg)
+	router := gin.New()
+	router.Use(RateLimitMiddleware(cfg, ps, limiter))
+	router.Any("/api/upload", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.Any("/api/workspaces/:workspace_id/documents/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router, limiter
+}
+
+func TestRateLimitRefundsOnRejection(t *testing.T) {
+	router, limiter := newRateLimitRouter(t, `[
+		{"name": "wide", "routes": ["/api/*"], "key": "ip", "rate": 10, "period": "1m"},
+		{"name": "tight", "routes": ["/api/upload"], "key": "ip", "rate": 1, "period": "1m"}
+	]`)
+	codes := []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests}
+	for i, code := range codes {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/upload", nil))
+		if w.Code != code {
+			t.Fatalf("request %d: got %d, want %d", i, w.Code, code)
+		}
+	}
+	// only the allowed request counts against the wide policy
+	limit := cache.Limit{Rate: 10, Burst: 10, Period: time.Minute}
+	res, err := limiter.AllowLimit(context.Background(), "ratelimit::wide::ip::192.0.2.1", limit, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Remaining != 9 {
+		t.Errorf("remaining %d, want 9", res.Remaining)
+	}
+}
+
+func TestRateLimitRequiresLengthForCostBytes(t *testing.T) {
+	router, _ := newRateLimitRouter(t, `[
+		{"name": "upload", "routes": ["POST /api/upload"], "key": "ip", "rate": 100, "period": "1m", "cost_bytes": 1024}
+	]`)
+	tests := []struct {
+		length int64
+		code   int
+	}{
+		{-1, http.StatusLengthRequired},
+		{4, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/upload", strings.NewReader("data"))
+		req.ContentLength = tt.length
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("length %d: got %d, want %d", tt.length, w.Code, tt.code)
+		}
+	}
+}
+
+func TestRateLimitByWorkspace(t *testing.T) {
+	router, _ := newRateLimitRouter(t, `[
+		{"name": "workspace", "routes": ["/api/workspaces/*"], "key": "workspace", "param": "workspace_id", "rate": 1, "period": "1m"}
+	]`)
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/api/workspaces/a/documents/1", http.StatusOK},
+		// another document of the same workspace
+		{"/api/workspaces/a/documents/2", http.StatusTooManyRequests},
+		{"/api/workspaces/b/documents/1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: got %d, want %d", tt.path, w.Code, tt.code)
+		}
+	}
+}
+
+func TestRateLimitAtMost(t *testing.T) {
+	router, _ := newRateLimitRouter(t, `[
+		{"name": "upload", "routes": ["POST /api/upload"], "key": "ip", "rate": 2, "period": "1h", "cost_bytes": 1, "at_most": true}
+	]`)
+	// larger than the burst but passes while anything is left
+	codes := []int{http.StatusOK, http.StatusTooManyRequests}
+	for i, code := range codes {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/upload", strings.NewReader("data")))
+		if w.Code != code {
+			t.Fatalf("request %d: got %d, want %d", i, w.Code, code)
+		}
+		if i == 0 && w.Header().Get("X-RateLimit-Remaining") != "0" {
+			t.Errorf("remaining %q, want 0", w.Header().Get("X-RateLimit-Remaining"))
+		}
+	}
+}
+
+func TestRequestField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{gin.MIMEJSON, `{"email": "ana@example.com"}`, "ana@example.com"},
+		{gin.MIMEJSON, `{"EMAIL": "ana@example.com"}`, "ana@example.com"},
+		{gin.MIMEJSON, `{"email": "a@example.com", "Email": "b@example.com"}`, "b@example.com"},
+		{gin.MIMEJSON, `{"name": {"email": "a@example.com"}}`, ""},
+		{gin.MIMEJSON, `{"email": 1}`, ""},
+		{gin.MIMEJSON, `not json`, ""},
+		{"application/merge-patch+json", `{"email": "ana@example.com"}`, "ana@example.com"},
+		{"text/plain", `{"email": "ana@example.com"}`, ""},
+		{"application/octet-stream", `{"email": "ana@example.com"}`, ""},
+		{gin.MIMEPOSTForm, `email=ana%40example.com`, "ana@example.com"},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/portal/:token", strings.NewReader(tt.body))
+		c.Request.Header.Set("Content-Type", tt.contentType)
+		if got := requestField(c, "email"); got != tt.want {
+			t.Errorf("%s %s: got %q, want %q", tt.contentType, tt.body, got, tt.want)
+		}
+		// the handler still reads the whole body
+		if rest, _ := io.ReadAll(c.Request.Body); tt.contentType != gin.MIMEPOSTForm && string(rest) != tt.body {
+			t.Errorf("body left %q", rest)
+		}
+	}
+}
+
+func TestQueryTokenMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{TokenHeader: "X-Token"}
+	router := gin.New()
+	router.Use(QueryTokenMiddleware(cfg, "/api/stream"))
+	handler := func(c *gin.Context) { c.String(http.StatusOK, c.GetHeader(cfg.TokenHeader)) }
+	router.GET("/api/stream", handler)
+	router.GET("/api/documents", handler)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/stream?access_token=secret", "secret"},
+		{"/api/documents?access_token=secret", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Body.String() != tt.want {
+			t.Errorf("%s: token %q, want %q", tt.path, w.Body.String(), tt.want)
+		}
+	}
+}
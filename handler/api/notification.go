@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+// HandleNotificationList lists the notifications of the account, newest
+// first, only the unread ones with ?unread=true
+func HandleNotificationList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		unread := c.Query("unread") == "true"
+		notifications, total, err := repo.NotificationStore.ListNotifications(c.Request.Context(), c.Account.Id, unread, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(notifications, total))
+	})
+}
+
+func HandleNotificationUnreadCount(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		count, err := repo.NotificationStore.UnreadCount(c.Request.Context(), c.Account.Id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{"count": count}))
+	})
+}
+
+func HandleNotificationRead(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		notification, err := repo.NotificationStore.MarkRead(c.Request.Context(), c.Account.Id, c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(notification))
+	})
+}
+
+func HandleNotificationReadAll(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		count, err := repo.NotificationStore.MarkAllRead(c.Request.Context(), c.Account.Id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{"count": count}))
+	})
+}
+
+// HandleNotificationPreferences lists where each notification type is
+// delivered to the account
+func HandleNotificationPreferences(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		preferences, err := repo.NotificationStore.Preferences(c.Request.Context(), c.Account.Id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(preferences))
+	})
+}
+
+// HandleNotificationPreferencesUpdate changes the listed notification types,
+// the others keep their channels
+func HandleNotificationPreferencesUpdate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.NotificationPreferencesUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		preferences, err := repo.NotificationStore.UpdatePreferencesFromRequest(c.Request.Context(), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(preferences))
+	})
+}
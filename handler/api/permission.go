@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+// HandlePermissionList lists the grants of the workspace, or of a single
+// resource with ?resource_id=
+func HandlePermissionList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		permissions, total, err := repo.PermissionStore.ListPermissions(c.Request.Context(), workspaceId, c.Query("resource_id"), models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(permissions, total))
+	})
+}
+
+func HandlePermissionGrant(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.PermissionGrantRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceType(json.ResourceType), json.ResourceId, models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		if workspaceId != c.Param("id") {
+			c.Error(models.ErrBadRequest)
+			return
+		}
+		permission, err := repo.PermissionStore.GrantFromRequest(c.Request.Context(), workspaceId, c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(permission))
+	})
+}
+
+func HandlePermissionRevoke(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		permission, err := repo.PermissionStore.FindPermissionById(c.Request.Context(), c.Param("id"), c.Param("permission_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if _, ok := authorize(c, repo, permission.ResourceType, permission.ResourceId, models.WorkspaceRoleAdmin); !ok {
+			return
+		}
+		err = repo.PermissionStore.Revoke(c.Request.Context(), permission.WorkspaceId, permission.Id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{}))
+	})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+// portalView is what the recipient of a document request gets to see
+func portalView(request *models.DocumentRequest) gin.H {
+	items := make([]gin.H, 0, len(request.Items))
+	for _, item := range request.Items {
+		items = append(items, gin.H{
+			"id":               item.Id,
+			"name":             item.Name,
+			"description":      item.Description,
+			"allowed_types":    item.AllowedTypes,
+			"max_size":         item.MaxSize,
+			"required":         item.Required,
+			"status":           item.Status,
+			"rejection_reason": item.RejectionReason,
+		})
+	}
+	return gin.H{
+		"title":          request.Title,
+		"message":        request.Message,
+		"recipient_name": request.RecipientName,
+		"due_at":         request.DueAt,
+		"items":          items,
+	}
+}
+
+// HandlePortalGet shows the checklist of a document request to the holder of
+// the upload link
+func HandlePortalGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		request, err := repo.RequestStore.FindOpenRequestByToken(c.Request.Context(), c.Param("token"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(portalView(request)))
+	})
+}
+
+// HandlePortalUpload receives the multipart "file" for one checklist item
+func HandlePortalUpload(cfg *config.Config, repo *store.Store, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		file, header, err := formFile(c, cfg)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		defer file.Close()
+		_, err = srv.DocumentRequests.Upload(c.Request.Context(), c.Param("token"), c.Param("item_id"), header.Filename, file)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		request, err := repo.RequestStore.FindRequestByToken(c.Request.Context(), c.Param("token"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(portalView(request)))
+	})
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+func HandleProjectCreate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.ProjectCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		project, err := repo.ProjectStore.NewProjectFromRequest(c.Request.Context(), workspaceId, c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(project))
+	})
+}
+
+func HandleProjectList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleViewer)
+		if !ok {
+			return
+		}
+		projects, total, err := repo.ProjectStore.ListProjects(c.Request.Context(), workspaceId, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(projects, total))
+	})
+}
+
+func HandleProjectGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeProject, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		project, err := repo.ProjectStore.FindProjectById(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(project))
+	})
+}
+
+func HandleProjectUpdate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.ProjectUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeProject, c.Param("id"), models.WorkspaceRoleAdmin); !ok {
+			return
+		}
+		project, err := repo.ProjectStore.UpdateProjectFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(project))
+	})
+}
+
+func HandleProjectDelete(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeProject, c.Param("id"), models.WorkspaceRoleAdmin); !ok {
+			return
+		}
+		err := repo.ProjectStore.DeleteProject(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{}))
+	})
+}
+
+// HandleProjectFolders lists the root folders of the project
+func HandleProjectFolders(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeProject, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		folders, total, err := repo.FolderStore.ListFolders(c.Request.Context(), c.Param("id"), "", models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(folders, total))
+	})
+}
+
+// HandleProjectDocuments lists the documents at the project root, or of the
+// whole project with ?recursive=true
+func HandleProjectDocuments(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeProject, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		recursive := c.Query("recursive") == "true"
+		documents, total, err := repo.DocumentStore.ListDocuments(c.Request.Context(), c.Param("id"), "", recursive, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(documents, total))
+	})
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/search"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const SearchTokenTTL = time.Hour
+
+type searchToken struct {
+	Token     string `json:"token"`
+	Host      string `json:"host"`
+	Index     string `json:"index"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// HandleSearch searches the documents of the workspaces the account is a
+// member of, or of ?workspace_id= only. tags, status, project_id and facets
+// take repeated or comma separated values.
+func HandleSearch(repo *store.Store, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.Error(models.ErrBadRequest)
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(search.DefaultLimit)))
+		if err != nil || limit < 0 {
+			c.Error(models.ErrBadRequest)
+			return
+		}
+		query := &search.Query{
+			Query:      c.Query("q"),
+			ProjectIds: queryList(c, "project_id"),
+			Tags:       queryList(c, "tags"),
+			Status:     queryList(c, "status"),
+			Facets:     queryList(c, "facets"),
+			Offset:     offset,
+			Limit:      limit,
+		}
+		if err := query.Normalize(); err != nil {
+			c.Error(models.ErrBadRequest)
+			return
+		}
+		workspaceIds, ok := searchWorkspaces(c, repo)
+		if !ok {
+			return
+		}
+		if len(workspaceIds) == 0 {
+			c.JSON(http.StatusOK, models.NewSuccessResponse(&search.Result{Hits: []*search.Hit{}, Facets: map[string]map[string]int64{}}))
+			return
+		}
+		query.WorkspaceIds = workspaceIds
+		result, err := srv.Indexer.Search(c.Request.Context(), query)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(result))
+	})
+}
+
+// HandleSearchToken returns a token to search the index directly, restricted
+// to the workspaces the account is a member of
+func HandleSearchToken(cfg *config.Config, repo *store.Store, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		issuer, ok := srv.Indexer.(search.TenantTokenIssuer)
+		if !ok {
+			c.Error(models.ErrBadRequest)
+			return
+		}
+		workspaceIds, ok := searchWorkspaces(c, repo)
+		if !ok {
+			return
+		}
+		expiresAt := time.Now().Add(SearchTokenTTL)
+		token, err := issuer.TenantToken(c.Request.Context(), workspaceIds, expiresAt)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(&searchToken{
+			Token:     token,
+			Host:      cfg.MeilisearchHost,
+			Index:     cfg.SearchIndex,
+			ExpiresAt: expiresAt.Unix(),
+		}))
+	})
+}
+
+// searchWorkspaces returns the workspace of ?workspace_id= when the account
+// can view it, or else every workspace the account is a member of
+func searchWorkspaces(c *models.TrackDocsContext, repo *store.Store) ([]string, bool) {
+	if workspaceId := c.Query("workspace_id"); workspaceId != "" {
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, workspaceId, models.WorkspaceRoleViewer)
+		return []string{workspaceId}, ok
+	}
+	workspaceIds, err := repo.WorkspaceStore.ListWorkspaceIds(c.Request.Context(), c.Account.Id)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return workspaceIds, true
+}
+
+func queryList(c *models.TrackDocsContext, key string) []string {
+	values := []string{}
+	for _, v := range c.QueryArray(key) {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
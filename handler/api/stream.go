@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/stream"
+)
+
+// StreamReplayLimit is the most messages replayed to a client that
+// reconnects, clients further behind are told to reset
+const StreamReplayLimit = 1000
+
+// HandleStream streams the events the account may see as Server-Sent
+// Events. A client that reconnects with Last-Event-ID first receives what it
+// missed, or a reset event when that is no longer buffered and it has to
+// reload its state.
+func HandleStream(cfg *config.Config, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		ctx := c.Request.Context()
+		lastId := c.GetHeader("Last-Event-ID")
+		if lastId == "" {
+			lastId = c.Query("last_event_id")
+		}
+		// subscribe before reading the buffer so nothing falls in between,
+		// messages seen in both are skipped by their id
+		subscription := srv.Stream.Subscribe()
+		defer subscription.Close()
+		filter := srv.Stream.NewFilter(c.Account.Id)
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		sent := ""
+		if lastId != "" {
+			messages, complete, err := srv.Stream.Replay(ctx, lastId, StreamReplayLimit)
+			if err != nil {
+				logger.Errorf("Stream error while replaying after %s:%s", lastId, err.Error())
+			}
+			if err != nil || !complete {
+				last, _ := srv.Stream.Last(ctx)
+				writeStreamEvent(c, last, "reset", gin.H{})
+				sent = last
+			} else {
+				for _, message := range messages {
+					if filter.Allowed(ctx, message) {
+						writeStreamEvent(c, message.Id, message.Event.Type, message.Event)
+					}
+					sent = message.Id
+				}
+				if sent == "" {
+					sent = lastId
+				}
+			}
+		} else {
+			last, err := srv.Stream.Last(ctx)
+			if err != nil {
+				logger.Errorf("Stream error while reading the latest id:%s", err.Error())
+			}
+			writeStreamEvent(c, last, "ready", gin.H{})
+			sent = last
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(cfg.StreamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				fmt.Fprint(c.Writer, ": heartbeat\n\n")
+				c.Writer.Flush()
+			case message, ok := <-subscription.C:
+				if !ok {
+					// fell behind or the hub lost Redis, the client
+					// reconnects and replays from the last id it received
+					return
+				}
+				if sent != "" && !stream.Before(sent, message.Id) {
+					continue
+				}
+				sent = message.Id
+				if !filter.Allowed(ctx, message) {
+					continue
+				}
+				writeStreamEvent(c, message.Id, message.Event.Type, message.Event)
+				c.Writer.Flush()
+			}
+		}
+	})
+}
+
+// writeStreamEvent writes one event frame, without an id the client keeps
+// the id it has
+func writeStreamEvent(c *models.TrackDocsContext, id, event string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Errorf("Stream error while encoding %s:%s", event, err.Error())
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(c.Writer, "id: %s\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload)
+}
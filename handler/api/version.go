@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+func HandleVersionList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		versions, total, err := repo.VersionStore.ListVersions(c.Request.Context(), c.Param("id"), models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(versions, total))
+	})
+}
+
+// HandleVersionUpload uploads the multipart "file" as a new version of the
+// document
+func HandleVersionUpload(cfg *config.Config, repo *store.Store, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		file, header, err := formFile(c, cfg)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		defer file.Close()
+		version, err := srv.Uploads.AddVersion(c.Request.Context(), c.Param("id"), c.Account.Id, header.Filename, file)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(version))
+	})
+}
+
+func HandleVersionDownload(repo *store.Store, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		version, err := repo.VersionStore.FindVersionById(c.Request.Context(), c.Param("id"), c.Param("version_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		file, err := srv.Uploads.Open(version)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		defer file.Close()
+		c.Header("Content-Type", version.ContentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", version.FileName))
+		http.ServeContent(c.Writer, c.Request, version.FileName, time.UnixMilli(version.Created), file)
+	})
+}
+
+type versionText struct {
+	VersionId     string               `json:"version_id"`
+	ExtractStatus models.ExtractStatus `json:"extract_status"`
+	PageCount     int                  `json:"page_count"`
+	Text          string               `json:"text"`
+}
+
+// HandleVersionText returns the text extracted from the version. Pages are
+// separated by form feeds.
+func HandleVersionText(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		version, err := repo.VersionStore.FindVersionById(c.Request.Context(), c.Param("id"), c.Param("version_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(&versionText{
+			VersionId:     version.Id,
+			ExtractStatus: version.ExtractStatus,
+			PageCount:     version.PageCount,
+			Text:          version.Text,
+		}))
+	})
+}
+
+// formFile returns the multipart "file" of the request, refusing bodies above
+// the configured upload size
+func formFile(c *models.TrackDocsContext, cfg *config.Config) (multipart.File, *multipart.FileHeader, error) {
+	// leave room for the multipart headers around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.UploadMaxSize+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, nil, models.ErrFileTooLarge
+	} else if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil, models.ErrFileMissing
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", models.ErrBadRequest, err.Error())
+	}
+	return file, header, nil
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/service"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+func HandleWebhookList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		webhooks, err := repo.WebhookStore.ListWebhooks(c.Request.Context(), workspaceId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(webhooks))
+	})
+}
+
+// HandleWebhookCreate creates a webhook, the response holds its signing
+// secret which is not returned again
+func HandleWebhookCreate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.WebhookCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		webhook, err := repo.WebhookStore.NewWebhookFromRequest(c.Request.Context(), workspaceId, c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(webhook))
+	})
+}
+
+func HandleWebhookGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		webhook, err := repo.WebhookStore.FindWebhookById(c.Request.Context(), workspaceId, c.Param("webhook_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(webhook))
+	})
+}
+
+func HandleWebhookUpdate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.WebhookUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		webhook, err := repo.WebhookStore.UpdateWebhookFromRequest(c.Request.Context(), workspaceId, c.Param("webhook_id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(webhook))
+	})
+}
+
+func HandleWebhookDelete(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		err := repo.WebhookStore.DeleteWebhook(c.Request.Context(), workspaceId, c.Param("webhook_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{}))
+	})
+}
+
+// HandleWebhookDeliveryList lists the deliveries of the webhook, newest first
+func HandleWebhookDeliveryList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		webhook, err := repo.WebhookStore.FindWebhookById(c.Request.Context(), workspaceId, c.Param("webhook_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		deliveries, total, err := repo.WebhookStore.ListDeliveries(c.Request.Context(), webhook.Id, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(deliveries, total))
+	})
+}
+
+// HandleWebhookDeliveryGet returns the delivery with the log of its attempts
+func HandleWebhookDeliveryGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		webhook, err := repo.WebhookStore.FindWebhookById(c.Request.Context(), workspaceId, c.Param("webhook_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		delivery, err := repo.WebhookStore.FindDeliveryById(c.Request.Context(), webhook.Id, c.Param("delivery_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(delivery))
+	})
+}
+
+// HandleWebhookRedeliver sends a succeeded or failed delivery again
+func HandleWebhookRedeliver(repo *store.Store, srv *service.Service) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		webhook, err := repo.WebhookStore.FindWebhookById(c.Request.Context(), workspaceId, c.Param("webhook_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		delivery, err := srv.Webhooks.Redeliver(c.Request.Context(), webhook, c.Param("delivery_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(delivery))
+	})
+}
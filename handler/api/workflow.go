@@ -0,0 +1,205 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+func HandleWorkflowGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleViewer)
+		if !ok {
+			return
+		}
+		transitions, err := repo.WorkflowStore.ListTransitions(c.Request.Context(), workspaceId)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(transitions))
+	})
+}
+
+func HandleWorkflowUpdate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.WorkflowUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		workspaceId, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin)
+		if !ok {
+			return
+		}
+		transitions, err := repo.WorkflowStore.UpdateWorkflowFromRequest(c.Request.Context(), workspaceId, c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(transitions))
+	})
+}
+
+// HandleTransitionList lists the transition history of the document
+func HandleTransitionList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		transitions, total, err := repo.WorkflowStore.ListHistory(c.Request.Context(), c.Param("id"), models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(transitions, total))
+	})
+}
+
+// HandleTransitionAvailable lists the transitions allowed from the current
+// status of the document
+func HandleTransitionAvailable(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		document, err := repo.DocumentStore.FindDocumentById(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		transitions, err := repo.WorkflowStore.AvailableTransitions(c.Request.Context(), document)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(transitions))
+	})
+}
+
+func HandleTransitionCreate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.TransitionCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		transition, err := repo.WorkflowStore.RequestTransitionFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(transition))
+	})
+}
+
+func HandleTransitionGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		transition, err := repo.WorkflowStore.FindTransitionById(c.Request.Context(), c.Param("id"), c.Param("transition_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(transition))
+	})
+}
+
+// HandleTransitionApprove approves a pending transition. Whether the account
+// may approve is decided by the transition's approver rules.
+func HandleTransitionApprove(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.TransitionApproveRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		transition, err := repo.WorkflowStore.Approve(c.Request.Context(), c.Param("id"), c.Param("transition_id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(transition))
+	})
+}
+
+func HandleTransitionReject(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.TransitionRejectRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		transition, err := repo.WorkflowStore.Reject(c.Request.Context(), c.Param("id"), c.Param("transition_id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(transition))
+	})
+}
+
+func HandleTransitionCancel(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeDocument, c.Param("id"), models.WorkspaceRoleEditor); !ok {
+			return
+		}
+		transition, err := repo.WorkflowStore.Cancel(c.Request.Context(), c.Param("id"), c.Param("transition_id"), c.Account.Id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(transition))
+	})
+}
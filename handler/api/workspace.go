@@ -0,0 +1,203 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/server"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+// authorize records an error on the request unless the current account holds
+// at least role on the resource. It returns the workspace of the resource.
+func authorize(c *models.TrackDocsContext, repo *store.Store, resourceType models.ResourceType, resourceId string, role models.WorkspaceRole) (string, bool) {
+	workspaceId, err := repo.PermissionStore.Authorize(c.Request.Context(), c.Account.Id, resourceType, resourceId, role)
+	if err != nil {
+		c.Error(err)
+		return workspaceId, false
+	}
+	return workspaceId, true
+}
+
+// authorizeMemberChange lets admins manage members, giving the owner role or
+// changing a member who is an owner takes an owner. memberId is empty for new
+// members.
+func authorizeMemberChange(c *models.TrackDocsContext, repo *store.Store, memberId string, role models.WorkspaceRole) bool {
+	if _, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin); !ok {
+		return false
+	}
+	owner := role == models.WorkspaceRoleOwner
+	if memberId != "" && !owner {
+		member, err := repo.WorkspaceStore.FindMemberById(c.Request.Context(), c.Param("id"), memberId)
+		if err != nil {
+			c.Error(err)
+			return false
+		}
+		owner = member.Role == models.WorkspaceRoleOwner
+	}
+	if owner {
+		_, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleOwner)
+		return ok
+	}
+	return true
+}
+
+func HandleWorkspaceCreate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.WorkspaceCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		workspace, err := repo.WorkspaceStore.NewWorkspaceFromRequest(c.Request.Context(), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(workspace))
+	})
+}
+
+func HandleWorkspaceList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		workspaces, total, err := repo.WorkspaceStore.ListWorkspaces(c.Request.Context(), c.Account.Id, models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(workspaces, total))
+	})
+}
+
+func HandleWorkspaceGet(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		workspace, err := repo.WorkspaceStore.FindWorkspaceById(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(workspace))
+	})
+}
+
+func HandleWorkspaceUpdate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.WorkspaceUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleAdmin); !ok {
+			return
+		}
+		workspace, err := repo.WorkspaceStore.UpdateWorkspaceFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(workspace))
+	})
+}
+
+func HandleWorkspaceMemberList(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if _, ok := authorize(c, repo, models.ResourceTypeWorkspace, c.Param("id"), models.WorkspaceRoleViewer); !ok {
+			return
+		}
+		members, total, err := repo.WorkspaceStore.ListMembers(c.Request.Context(), c.Param("id"), models.NewPageFromContext(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessPagingResponse(members, total))
+	})
+}
+
+func HandleWorkspaceMemberAdd(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.WorkspaceMemberAddRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if !authorizeMemberChange(c, repo, "", models.WorkspaceRole(json.Role)) {
+			return
+		}
+		member, err := repo.WorkspaceStore.AddMemberFromRequest(c.Request.Context(), c.Param("id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(member))
+	})
+}
+
+func HandleWorkspaceMemberUpdate(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		var json dto.WorkspaceMemberUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if !authorizeMemberChange(c, repo, c.Param("member_id"), models.WorkspaceRole(json.Role)) {
+			return
+		}
+		member, err := repo.WorkspaceStore.UpdateMemberFromRequest(c.Request.Context(), c.Param("id"), c.Param("member_id"), c.Account.Id, &json)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(member))
+	})
+}
+
+func HandleWorkspaceMemberRemove(repo *store.Store) gin.HandlerFunc {
+	return server.HandleFunc(func(c *models.TrackDocsContext) {
+		if c.Account == nil {
+			c.Error(models.ErrTokenExpired)
+			return
+		}
+		if !authorizeMemberChange(c, repo, c.Param("member_id"), "") {
+			return
+		}
+		err := repo.WorkspaceStore.RemoveMember(c.Request.Context(), c.Param("id"), c.Param("member_id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{}))
+	})
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+// Cache is a key value store with expiring keys, hashes, tags, rate limits
+// and the compare operations locks need. Missing keys fail with redis.Nil
+// whatever the backend. Every method has a variant bounded by a context.
+type Cache interface {
+	Allow(key string, limit int) (*Result, error)
+	AllowContext(ctx context.Context, key string, limit int) (*Result, error)
+	AllowN(key string, limit, n int) (*Result, error)
+	AllowNContext(ctx context.Context, key string, limit, n int) (*Result, error)
+	// AllowLimit reports whether n events may happen now under limit, a
+	// negative n gives back events allowed before
+	AllowLimit(ctx context.Context, key string, limit Limit, n int) (*Result, error)
+	// AllowAtMost allows up to n events under limit, Result.Allowed is how
+	// many
+	AllowAtMost(ctx context.Context, key string, limit Limit, n int) (*Result, error)
+
+	Get(key string, out interface{}) error
+	GetContext(ctx context.Context, key string, out interface{}) error
+	GetString(key string) (string, error)
+	GetStringContext(ctx context.Context, key string) (string, error)
+	GetInt(key string) (int, error)
+	GetIntContext(ctx context.Context, key string) (int, error)
+	GetInt64(key string) (int64, error)
+	GetInt64Context(ctx context.Context, key string) (int64, error)
+	Exists(key string) (bool, error)
+	ExistsContext(ctx context.Context, key string) (bool, error)
+
+	Set(key string, value interface{}) error
+	SetContext(ctx context.Context, key string, value interface{}) error
+	SetX(key string, value interface{}, expiry time.Duration) error
+	SetXContext(ctx context.Context, key string, value interface{}, expiry time.Duration) error
+	SetString(key, value string) error
+	SetStringContext(ctx context.Context, key, value string) error
+	SetInt(key string, value int) error
+	SetIntContext(ctx context.Context, key string, value int) error
+	SetInt64(key string, value int64) error
+	SetInt64Context(ctx context.Context, key string, value int64) error
+	SetXString(key, value string, expiry time.Duration) error
+	SetXStringContext(ctx context.Context, key, value string, expiry time.Duration) error
+	SetXInt(key string, value int, expiry time.Duration) error
+	SetXIntContext(ctx context.Context, key string, value int, expiry time.Duration) error
+	SetXInt64(key string, value int64, expiry time.Duration) error
+	SetXInt64Context(ctx context.Context, key string, value int64, expiry time.Duration) error
+	SetNX(key, value string, expiry time.Duration) (bool, error)
+	SetNXContext(ctx context.Context, key, value string, expiry time.Duration) (bool, error)
+	Del(key string) error
+	DelContext(ctx context.Context, key string) error
+
+	Expire(key string, expiry time.Duration) (bool, error)
+	ExpireContext(ctx context.Context, key string, expiry time.Duration) (bool, error)
+	PTTL(key string) (time.Duration, error)
+	PTTLContext(ctx context.Context, key string) (time.Duration, error)
+
+	HGet(key, field string, out interface{}) error
+	HGetContext(ctx context.Context, key, field string, out interface{}) error
+	HGetString(key, field string) (string, error)
+	HGetStringContext(ctx context.Context, key, field string) (string, error)
+	HGetInt(key, field string) (int, error)
+	HGetIntContext(ctx context.Context, key, field string) (int, error)
+	HGetInt64(key, field string) (int64, error)
+	HGetInt64Context(ctx context.Context, key, field string) (int64, error)
+	HGetAll(key string) (map[string]string, error)
+	HGetAllContext(ctx context.Context, key string) (map[string]string, error)
+	HSet(key, field string, value interface{}) error
+	HSetContext(ctx context.Context, key, field string, value interface{}) error
+	HSetString(key, field, value string) error
+	HSetStringContext(ctx context.Context, key, field, value string) error
+	HDel(key, field string) error
+	HDelContext(ctx context.Context, key, field string) error
+
+	// DelIfEqual deletes key when it holds value, it reports whether it did
+	DelIfEqual(ctx context.Context, key, value string) (bool, error)
+	// ExpireIfEqual resets the expiry of key when it holds value, it
+	// reports whether it did
+	ExpireIfEqual(ctx context.Context, key, value string, expiry time.Duration) (bool, error)
+
+	Keys(pattern string) ([]string, error)
+	KeysContext(ctx context.Context, pattern string) ([]string, error)
+	Scan(ctx context.Context, pattern string, batch int64) *KeyIterator
+	DeleteByPrefix(ctx context.Context, prefix string) (int64, error)
+	Tag(ctx context.Context, key string, tags ...string) error
+	InvalidateTags(ctx context.Context, tags ...string) (int64, error)
+	FlushAll() error
+	FlushAllContext(ctx context.Context) error
+}
+
+// NewCache returns the Cache of cfg.CacheMode, redis unless it is memory
+func NewCache(cfg *config.Config) (Cache, error) {
+	if cfg.CacheMode == ModeMemory {
+		return NewMemory(cfg), nil
+	}
+	return NewRedis(cfg)
+}
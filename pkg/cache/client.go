@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+const (
+	ModeSingle   = "single"
+	ModeSentinel = "sentinel"
+	ModeCluster  = "cluster"
+	// ModeMemory keeps everything in process, see Memory
+	ModeMemory = "memory"
+)
+
+// newClient connects to redis with the topology of cfg.CacheMode. Scripts and
+// transactions on several keys need them in one cluster slot, those keys
+// share a hash tag such as {jobs}.
+func newClient(cfg *config.Config) (redis.UniversalClient, error) {
+	addrs := cfg.CacheAddrs
+	if len(addrs) == 0 {
+		addrs = []string{cfg.CacheSource}
+	}
+	options := &redis.UniversalOptions{
+		Addrs:            addrs,
+		MasterName:       cfg.CacheMasterName,
+		Password:         cfg.CacheSourcePassword,
+		SentinelPassword: cfg.CacheSentinelPassword,
+		DB:               cfg.CacheDB,
+		PoolSize:         cfg.CachePoolSize,
+	}
+	if cfg.CacheTLS {
+		options.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	switch cfg.CacheMode {
+	case ModeSingle, "":
+		return redis.NewClient(options.Simple()), nil
+	case ModeSentinel:
+		return redis.NewFailoverClient(options.Failover()), nil
+	case ModeCluster:
+		if cfg.CacheDB != 0 {
+			return nil, fmt.Errorf("cache: cluster mode only has db 0, not %d", cfg.CacheDB)
+		}
+		return redis.NewClusterClient(options.Cluster()), nil
+	}
+	return nil, fmt.Errorf("cache: unknown mode %q", cfg.CacheMode)
+}
@@ -0,0 +1,54 @@
+package cache
+
+import "time"
+
+// gcra is the generic cell rate algorithm of the allowN and allowAtMost
+// scripts for caches without lua. tat is the theoretical arrival time stored
+// for the key and now the current time, both in seconds. It returns the
+// result and the tat to store, zero when nothing is stored.
+func gcra(limit Limit, tat, now float64, cost int, atMost bool) (*Result, float64) {
+	emissionInterval := limit.Period.Seconds() / float64(limit.Rate)
+	burstOffset := emissionInterval * float64(limit.Burst)
+	if tat < now {
+		tat = now
+	}
+	if !atMost {
+		newTat := tat + emissionInterval*float64(cost)
+		diff := now - (newTat - burstOffset)
+		remaining := diff / emissionInterval
+		if remaining < 0 {
+			return &Result{Limit: limit, RetryAfter: seconds(-diff), ResetAfter: seconds(tat - now)}, 0
+		}
+		return &Result{
+			Limit:      limit,
+			Allowed:    cost,
+			Remaining:  int(remaining),
+			RetryAfter: -1,
+			ResetAfter: seconds(newTat - now),
+		}, newTat
+	}
+	diff := now - (tat - burstOffset)
+	remaining := diff / emissionInterval
+	if remaining < 1 {
+		return &Result{Limit: limit, RetryAfter: seconds(emissionInterval - diff), ResetAfter: seconds(tat - now)}, 0
+	}
+	allowed := float64(cost)
+	if remaining < allowed {
+		allowed = remaining
+		remaining = 0
+	} else {
+		remaining -= allowed
+	}
+	newTat := tat + emissionInterval*allowed
+	return &Result{
+		Limit:      limit,
+		Allowed:    int(allowed),
+		Remaining:  int(remaining),
+		RetryAfter: -1,
+		ResetAfter: seconds(newTat - now),
+	}, newTat
+}
+
+func seconds(f float64) time.Duration {
+	return time.Duration(f * float64(time.Second))
+}
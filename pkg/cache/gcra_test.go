@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+// the expected results follow the allowN and allowAtMost scripts step by step
+func TestGCRA(t *testing.T) {
+	// one event a second with a burst of 5
+	limit := Limit{Rate: 10, Burst: 5, Period: 10 * time.Second}
+	tests := []struct {
+		name   string
+		tat    float64
+		cost   int
+		atMost bool
+		want   Result
+		newTat float64
+	}{
+		{"fresh", 0, 1, false, Result{Allowed: 1, Remaining: 4, RetryAfter: -1, ResetAfter: time.Second}, 101},
+		{"last of burst", 104, 1, false, Result{Allowed: 1, Remaining: 0, RetryAfter: -1, ResetAfter: 5 * time.Second}, 105},
+		{"exhausted", 105, 1, false, Result{RetryAfter: time.Second, ResetAfter: 5 * time.Second}, 0},
+		{"above burst", 0, 6, false, Result{RetryAfter: time.Second}, 0},
+		{"refund", 103, -2, false, Result{Allowed: -2, Remaining: 4, RetryAfter: -1, ResetAfter: time.Second}, 101},
+		{"at most all", 0, 3, true, Result{Allowed: 3, Remaining: 2, RetryAfter: -1, ResetAfter: 3 * time.Second}, 103},
+		{"at most part", 103, 5, true, Result{Allowed: 2, Remaining: 0, RetryAfter: -1, ResetAfter: 5 * time.Second}, 105},
+		{"at most exhausted", 105.5, 1, true, Result{RetryAfter: 1500 * time.Millisecond, ResetAfter: 5500 * time.Millisecond}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, newTat := gcra(limit, tt.tat, 100, tt.cost, tt.atMost)
+			tt.want.Limit = limit
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+			if newTat != tt.newTat {
+				t.Errorf("new tat %v, want %v", newTat, tt.newTat)
+			}
+		})
+	}
+}
+
+// TestGCRAMatchesLua compares the memory limiter with the scripts on the
+// redis at TRACKDOCS_TEST_CACHE_SOURCE
+func TestGCRAMatchesLua(t *testing.T) {
+	source := os.Getenv("TRACKDOCS_TEST_CACHE_SOURCE")
+	if source == "" {
+		t.Skip("TRACKDOCS_TEST_CACHE_SOURCE is not set")
+	}
+	cfg := &config.Config{CacheSource: source, CacheTimeout: time.Second}
+	r, err := NewRedis(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMemory(cfg)
+	ctx := context.Background()
+	// slow enough for nothing to recover during the test
+	limit := Limit{Rate: 10, Burst: 5, Period: time.Hour}
+	steps := []struct {
+		cost   int
+		atMost bool
+	}{
+		{1, false}, {2, false}, {3, false}, {-1, false}, {1, false}, {4, true}, {1, true}, {1, false},
+	}
+	key := fmt.Sprintf("test::gcra::%d", time.Now().UnixNano())
+	defer r.DelContext(ctx, redisPrefix+key)
+	for i, step := range steps {
+		allow := func(c Cache) *Result {
+			var res *Result
+			var err error
+			if step.atMost {
+				res, err = c.AllowAtMost(ctx, key, limit, step.cost)
+			} else {
+				res, err = c.AllowLimit(ctx, key, limit, step.cost)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			return res
+		}
+		want, got := allow(r), allow(m)
+		if got.Allowed != want.Allowed || got.Remaining != want.Remaining {
+			t.Errorf("step %d: memory allowed %d, %d remaining, redis %d, %d", i, got.Allowed, got.Remaining, want.Allowed, want.Remaining)
+		}
+	}
+}
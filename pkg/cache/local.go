@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Local is a size bounded in-process LRU of raw cache values. Entries expire
+// after a TTL so values changed by other replicas are picked up even when
+// an invalidation is missed.
+type Local struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type localEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewLocal(size int, ttl time.Duration) *Local {
+	return &Local{size: size, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Get returns the value of key when it is cached and not expired
+func (l *Local) Get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*localEntry)
+	if time.Now().After(entry.expires) {
+		l.remove(element)
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set caches value for the TTL of the cache, or for ttl when it is shorter,
+// evicting the least recently used entry when the cache is full
+func (l *Local) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 || ttl > l.ttl {
+		ttl = l.ttl
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*localEntry)
+		entry.value = value
+		entry.expires = time.Now().Add(ttl)
+		l.order.MoveToFront(element)
+		return
+	}
+	l.entries[key] = l.order.PushFront(&localEntry{key: key, value: value, expires: time.Now().Add(ttl)})
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+}
+
+// Del removes the keys from the cache
+func (l *Local) Del(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if element, ok := l.entries[key]; ok {
+			l.remove(element)
+		}
+	}
+}
+
+// Purge removes every entry from the cache
+func (l *Local) Purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order.Init()
+	l.entries = map[string]*list.Element{}
+}
+
+// Len returns the number of cached entries, expired ones included
+func (l *Local) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+func (l *Local) remove(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*localEntry).key)
+}
@@ -49,6 +49,9 @@ end
 local reset_after = new_tat - now
 if reset_after > 0 then
   redis.call("SET", rate_limit_key, new_tat, "EX", math.ceil(reset_after))
+else
+  -- a negative cost gave everything back
+  redis.call("DEL", rate_limit_key)
 end
 local retry_after = -1
 return {cost, remaining, tostring(retry_after), tostring(reset_after)}
@@ -112,3 +115,21 @@ return {
   tostring(reset_after),
 }
 `)
+
+// delIfEqual releases a lock only when it is still held with the value
+var delIfEqual = redis.NewScript(`
+if redis.call("get",KEYS[1]) == ARGV[1] then
+    return redis.call("del",KEYS[1])
+else
+    return 0
+end
+`)
+
+// expireIfEqual extends a lock only when it is still held with the value
+var expireIfEqual = redis.NewScript(`
+if redis.call("get",KEYS[1]) == ARGV[1] then
+    return redis.call("pexpire",KEYS[1],ARGV[2])
+else
+    return 0
+end
+`)
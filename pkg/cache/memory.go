@@ -0,0 +1,695 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+// MemorySweepInterval is how often expired keys are removed, they are
+// invisible as soon as they expire
+const MemorySweepInterval = time.Minute
+
+var errWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// Memory is a Cache kept in process, for tests and for running a single
+// binary without redis. It behaves like Redis, missing keys fail with
+// redis.Nil, but nothing is shared between processes.
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	timeout   time.Duration
+	lastSweep time.Time
+}
+
+// memoryEntry holds a string value, a hash or a set
+type memoryEntry struct {
+	value   []byte
+	hash    map[string]string
+	set     map[string]struct{}
+	expires time.Time
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+func NewMemory(cfg *config.Config) *Memory {
+	return &Memory{entries: map[string]*memoryEntry{}, timeout: cfg.CacheTimeout, lastSweep: time.Now()}
+}
+
+func (m *Memory) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, m.timeout)
+}
+
+// lookup returns the live entry of key, m.mu must be held
+func (m *Memory) lookup(key string) *memoryEntry {
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+	if entry.expired(time.Now()) {
+		delete(m.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// store replaces the entry of key, m.mu must be held
+func (m *Memory) store(key string, entry *memoryEntry, expiry time.Duration) {
+	now := time.Now()
+	if expiry > 0 {
+		entry.expires = now.Add(expiry)
+	}
+	m.entries[key] = entry
+	if now.Sub(m.lastSweep) > MemorySweepInterval {
+		m.lastSweep = now
+		for k, e := range m.entries {
+			if e.expired(now) {
+				delete(m.entries, k)
+			}
+		}
+	}
+}
+
+func (m *Memory) get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.lookup(key)
+	if entry == nil {
+		return nil, redis.Nil
+	}
+	if entry.value == nil {
+		return nil, errWrongType
+	}
+	return entry.value, nil
+}
+
+func (m *Memory) set(ctx context.Context, key string, value []byte, expiry time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store(key, &memoryEntry{value: append([]byte{}, value...)}, expiry)
+	return nil
+}
+
+// fill is set, there are no other replicas to keep in sync
+func (m *Memory) fill(ctx context.Context, key string, value []byte, expiry time.Duration) error {
+	return m.set(ctx, key, value, expiry)
+}
+
+func (m *Memory) del(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// hash returns the hash of key, created when create is set
+func (m *Memory) hash(key string, create bool) (map[string]string, error) {
+	entry := m.lookup(key)
+	if entry == nil {
+		if !create {
+			return nil, nil
+		}
+		entry = &memoryEntry{hash: map[string]string{}}
+		m.store(key, entry, 0)
+	}
+	if entry.hash == nil {
+		return nil, errWrongType
+	}
+	return entry.hash, nil
+}
+
+// Allow is a shortcut for AllowN(ctx, key, limit, 1).
+func (m *Memory) Allow(key string, limit int) (*Result, error) {
+	return m.AllowContext(context.Background(), key, limit)
+}
+
+// AllowContext is Allow bounded by ctx
+func (m *Memory) AllowContext(ctx context.Context, key string, limit int) (*Result, error) {
+	return m.AllowNContext(ctx, key, limit, 1)
+}
+
+// AllowN reports whether n events may happen at time now.
+func (m *Memory) AllowN(key string, limit, n int) (*Result, error) {
+	return m.AllowNContext(context.Background(), key, limit, n)
+}
+
+// AllowNContext is AllowN bounded by ctx
+func (m *Memory) AllowNContext(ctx context.Context, key string, limit, n int) (*Result, error) {
+	return m.allow(redisPrefix+key, PerMinute(limit), n, false)
+}
+
+// AllowLimit reports whether n events may happen now under limit
+func (m *Memory) AllowLimit(ctx context.Context, key string, limit Limit, n int) (*Result, error) {
+	return m.allow(redisPrefix+key, limit, n, false)
+}
+
+// AllowAtMost allows up to n events under limit, Result.Allowed is how many
+func (m *Memory) AllowAtMost(ctx context.Context, key string, limit Limit, n int) (*Result, error) {
+	return m.allow(redisPrefix+key, limit, n, true)
+}
+
+func (m *Memory) allow(key string, limit Limit, cost int, atMost bool) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+	tat := now
+	if entry := m.lookup(key); entry != nil {
+		stored, err := strconv.ParseFloat(string(entry.value), 64)
+		if err != nil {
+			return nil, err
+		}
+		tat = stored
+	}
+	result, newTat := gcra(limit, tat, now, cost, atMost)
+	if newTat > now {
+		expiry := time.Duration(math.Ceil(newTat-now)) * time.Second
+		m.store(key, &memoryEntry{value: []byte(strconv.FormatFloat(newTat, 'f', -1, 64))}, expiry)
+	} else if result.Allowed != 0 {
+		// a negative cost gave everything back
+		delete(m.entries, key)
+	}
+	return result, nil
+}
+
+// Get get the cache value for the key
+func (m *Memory) Get(key string, out interface{}) error {
+	return m.GetContext(context.Background(), key, out)
+}
+
+// GetContext is Get bounded by ctx
+func (m *Memory) GetContext(ctx context.Context, key string, out interface{}) error {
+	value, err := m.get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, out)
+}
+
+// GetString get the cache value for the key
+func (m *Memory) GetString(key string) (string, error) {
+	return m.GetStringContext(context.Background(), key)
+}
+
+// GetStringContext is GetString bounded by ctx
+func (m *Memory) GetStringContext(ctx context.Context, key string) (string, error) {
+	value, err := m.get(ctx, key)
+	return string(value), err
+}
+
+// GetInt get the cache value for the key
+func (m *Memory) GetInt(key string) (int, error) {
+	return m.GetIntContext(context.Background(), key)
+}
+
+// GetIntContext is GetInt bounded by ctx
+func (m *Memory) GetIntContext(ctx context.Context, key string) (int, error) {
+	value, err := m.get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(value))
+}
+
+// GetInt64 get the cache value for the key
+func (m *Memory) GetInt64(key string) (int64, error) {
+	return m.GetInt64Context(context.Background(), key)
+}
+
+// GetInt64Context is GetInt64 bounded by ctx
+func (m *Memory) GetInt64Context(ctx context.Context, key string) (int64, error) {
+	value, err := m.get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
+
+func (m *Memory) Exists(key string) (bool, error) {
+	return m.ExistsContext(context.Background(), key)
+}
+
+// ExistsContext is Exists bounded by ctx
+func (m *Memory) ExistsContext(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lookup(key) != nil, nil
+}
+
+// Set sets the cache value for the key
+func (m *Memory) Set(key string, value interface{}) error {
+	return m.SetContext(context.Background(), key, value)
+}
+
+// SetContext is Set bounded by ctx
+func (m *Memory) SetContext(ctx context.Context, key string, value interface{}) error {
+	return m.SetXContext(ctx, key, value, DefaultExpiry*time.Second)
+}
+
+// SetX sets the cache value for the key
+func (m *Memory) SetX(key string, value interface{}, expiry time.Duration) error {
+	return m.SetXContext(context.Background(), key, value, expiry)
+}
+
+// SetXContext is SetX bounded by ctx
+func (m *Memory) SetXContext(ctx context.Context, key string, value interface{}, expiry time.Duration) error {
+	val, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return m.set(ctx, key, val, expiry)
+}
+
+// SetString sets the cache value for the key
+func (m *Memory) SetString(key, value string) error {
+	return m.SetXStringContext(context.Background(), key, value, DefaultExpiry*time.Second)
+}
+
+// SetStringContext is SetString bounded by ctx
+func (m *Memory) SetStringContext(ctx context.Context, key, value string) error {
+	return m.SetXStringContext(ctx, key, value, DefaultExpiry*time.Second)
+}
+
+// SetInt sets the cache value for the key
+func (m *Memory) SetInt(key string, value int) error {
+	return m.SetXStringContext(context.Background(), key, strconv.Itoa(value), DefaultExpiry*time.Second)
+}
+
+// SetIntContext is SetInt bounded by ctx
+func (m *Memory) SetIntContext(ctx context.Context, key string, value int) error {
+	return m.SetXStringContext(ctx, key, strconv.Itoa(value), DefaultExpiry*time.Second)
+}
+
+// SetInt64 sets the cache value for the key
+func (m *Memory) SetInt64(key string, value int64) error {
+	return m.SetInt64Context(context.Background(), key, value)
+}
+
+// SetInt64Context is SetInt64 bounded by ctx
+func (m *Memory) SetInt64Context(ctx context.Context, key string, value int64) error {
+	return m.SetXStringContext(ctx, key, strconv.FormatInt(value, 10), DefaultExpiry*time.Second)
+}
+
+// SetXString sets the cache value for the key
+func (m *Memory) SetXString(key, value string, expiry time.Duration) error {
+	return m.SetXStringContext(context.Background(), key, value, expiry)
+}
+
+// SetXStringContext is SetXString bounded by ctx
+func (m *Memory) SetXStringContext(ctx context.Context, key, value string, expiry time.Duration) error {
+	return m.set(ctx, key, []byte(value), expiry)
+}
+
+// SetXInt sets the cache value for the key
+func (m *Memory) SetXInt(key string, value int, expiry time.Duration) error {
+	return m.SetXStringContext(context.Background(), key, strconv.Itoa(value), expiry)
+}
+
+// SetXIntContext is SetXInt bounded by ctx
+func (m *Memory) SetXIntContext(ctx context.Context, key string, value int, expiry time.Duration) error {
+	return m.SetXStringContext(ctx, key, strconv.Itoa(value), expiry)
+}
+
+// SetXInt64 sets the cache value for the key
+func (m *Memory) SetXInt64(key string, value int64, expiry time.Duration) error {
+	return m.SetXInt64Context(context.Background(), key, value, expiry)
+}
+
+// SetXInt64Context is SetXInt64 bounded by ctx
+func (m *Memory) SetXInt64Context(ctx context.Context, key string, value int64, expiry time.Duration) error {
+	return m.SetXStringContext(ctx, key, strconv.FormatInt(value, 10), expiry)
+}
+
+// SetNX sets key when it does not exist and reports whether it did
+func (m *Memory) SetNX(key, value string, expiry time.Duration) (bool, error) {
+	return m.SetNXContext(context.Background(), key, value, expiry)
+}
+
+// SetNXContext is SetNX bounded by ctx
+func (m *Memory) SetNXContext(ctx context.Context, key, value string, expiry time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lookup(key) != nil {
+		return false, nil
+	}
+	m.store(key, &memoryEntry{value: []byte(value)}, expiry)
+	return true, nil
+}
+
+// Del deletes the cache value for the key
+func (m *Memory) Del(key string) error {
+	return m.DelContext(context.Background(), key)
+}
+
+// DelContext is Del bounded by ctx
+func (m *Memory) DelContext(ctx context.Context, key string) error {
+	return m.del(ctx, key)
+}
+
+// Expire sets the expiry of key and reports whether it exists
+func (m *Memory) Expire(key string, expiry time.Duration) (bool, error) {
+	return m.ExpireContext(context.Background(), key, expiry)
+}
+
+// ExpireContext is Expire bounded by ctx
+func (m *Memory) ExpireContext(ctx context.Context, key string, expiry time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.lookup(key)
+	if entry == nil {
+		return false, nil
+	}
+	entry.expires = time.Now().Add(expiry)
+	return true, nil
+}
+
+// PTTL returns the time key has left, -1 when it does not expire and -2 when
+// it does not exist like redis
+func (m *Memory) PTTL(key string) (time.Duration, error) {
+	return m.PTTLContext(context.Background(), key)
+}
+
+// PTTLContext is PTTL bounded by ctx
+func (m *Memory) PTTLContext(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.lookup(key)
+	if entry == nil {
+		return -2, nil
+	}
+	if entry.expires.IsZero() {
+		return -1, nil
+	}
+	return time.Until(entry.expires).Truncate(time.Millisecond), nil
+}
+
+// HGet get the cache value for the key
+func (m *Memory) HGet(key, field string, out interface{}) error {
+	return m.HGetContext(context.Background(), key, field, out)
+}
+
+// HGetContext is HGet bounded by ctx
+func (m *Memory) HGetContext(ctx context.Context, key, field string, out interface{}) error {
+	value, err := m.HGetStringContext(ctx, key, field)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(value), out)
+}
+
+// HGetString get the cache value for the key
+func (m *Memory) HGetString(key, field string) (string, error) {
+	return m.HGetStringContext(context.Background(), key, field)
+}
+
+// HGetStringContext is HGetString bounded by ctx
+func (m *Memory) HGetStringContext(ctx context.Context, key, field string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.hash(key, false)
+	if err != nil {
+		return "", err
+	}
+	value, ok := hash[field]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+// HGetInt get the cache value for the key
+func (m *Memory) HGetInt(key, field string) (int, error) {
+	return m.HGetIntContext(context.Background(), key, field)
+}
+
+// HGetIntContext is HGetInt bounded by ctx
+func (m *Memory) HGetIntContext(ctx context.Context, key, field string) (int, error) {
+	value, err := m.HGetStringContext(ctx, key, field)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// HGetInt64 get the cache value for the key
+func (m *Memory) HGetInt64(key, field string) (int64, error) {
+	return m.HGetInt64Context(context.Background(), key, field)
+}
+
+// HGetInt64Context is HGetInt64 bounded by ctx
+func (m *Memory) HGetInt64Context(ctx context.Context, key, field string) (int64, error) {
+	value, err := m.HGetStringContext(ctx, key, field)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// HGetAll returns every field of the hash
+func (m *Memory) HGetAll(key string) (map[string]string, error) {
+	return m.HGetAllContext(context.Background(), key)
+}
+
+// HGetAllContext is HGetAll bounded by ctx
+func (m *Memory) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.hash(key, false)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(hash))
+	for field, value := range hash {
+		out[field] = value
+	}
+	return out, nil
+}
+
+// HSet sets the cache value for the key in hash
+func (m *Memory) HSet(key, field string, value interface{}) error {
+	return m.HSetContext(context.Background(), key, field, value)
+}
+
+// HSetContext is HSet bounded by ctx
+func (m *Memory) HSetContext(ctx context.Context, key, field string, value interface{}) error {
+	val, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return m.HSetStringContext(ctx, key, field, string(val))
+}
+
+// HSetString sets the cache value for the key in hash
+func (m *Memory) HSetString(key, field, value string) error {
+	return m.HSetStringContext(context.Background(), key, field, value)
+}
+
+// HSetStringContext is HSetString bounded by ctx
+func (m *Memory) HSetStringContext(ctx context.Context, key, field, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.hash(key, true)
+	if err != nil {
+		return err
+	}
+	hash[field] = value
+	return nil
+}
+
+// HDel deletes the cache value for the key
+func (m *Memory) HDel(key, field string) error {
+	return m.HDelContext(context.Background(), key, field)
+}
+
+// HDelContext is HDel bounded by ctx
+func (m *Memory) HDelContext(ctx context.Context, key, field string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, err := m.hash(key, false)
+	if err != nil {
+		return err
+	}
+	delete(hash, field)
+	if hash != nil && len(hash) == 0 {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// DelIfEqual deletes key when it holds value, it reports whether it did
+func (m *Memory) DelIfEqual(ctx context.Context, key, value string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.lookup(key)
+	if entry == nil || entry.value == nil || string(entry.value) != value {
+		return false, nil
+	}
+	delete(m.entries, key)
+	return true, nil
+}
+
+// ExpireIfEqual resets the expiry of key when it holds value, it reports
+// whether it did
+func (m *Memory) ExpireIfEqual(ctx context.Context, key, value string, expiry time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.lookup(key)
+	if entry == nil || entry.value == nil || string(entry.value) != value {
+		return false, nil
+	}
+	entry.expires = time.Now().Add(expiry)
+	return true, nil
+}
+
+// Keys returns the keys matching pattern
+func (m *Memory) Keys(pattern string) ([]string, error) {
+	return m.KeysContext(context.Background(), pattern)
+}
+
+// KeysContext is Keys bounded by ctx
+func (m *Memory) KeysContext(ctx context.Context, pattern string) ([]string, error) {
+	match, err := globRegexp(pattern)
+	if err != nil {
+		return []string{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	keys := []string{}
+	for key, entry := range m.entries {
+		if !entry.expired(now) && match.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Scan returns an iterator over a snapshot of the keys matching pattern
+func (m *Memory) Scan(ctx context.Context, pattern string, batch int64) *KeyIterator {
+	keys, err := m.KeysContext(ctx, pattern)
+	it := newKeysIterator(keys)
+	it.err = err
+	return it
+}
+
+// DeleteByPrefix deletes every key starting with prefix and returns how many
+// were deleted
+func (m *Memory) DeleteByPrefix(ctx context.Context, prefix string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	deleted := int64(0)
+	for key, entry := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			if !entry.expired(now) {
+				deleted++
+			}
+			delete(m.entries, key)
+		}
+	}
+	return deleted, nil
+}
+
+// Tag adds key to the sets of the tags, so it is deleted by InvalidateTags
+// of any of them
+func (m *Memory) Tag(ctx context.Context, key string, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, tag := range tags {
+		entry := m.lookup(getTagKey(tag))
+		if entry == nil {
+			entry = &memoryEntry{set: map[string]struct{}{}}
+		} else if entry.set == nil {
+			return errWrongType
+		}
+		entry.set[key] = struct{}{}
+		m.store(getTagKey(tag), entry, TagExpiry)
+	}
+	return nil
+}
+
+// InvalidateTags deletes the keys tagged with any of the tags, and the tags,
+// and returns how many keys were deleted
+func (m *Memory) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := int64(0)
+	for _, tag := range tags {
+		entry := m.lookup(getTagKey(tag))
+		if entry == nil {
+			continue
+		}
+		for key := range entry.set {
+			if m.lookup(key) != nil {
+				deleted++
+				delete(m.entries, key)
+			}
+		}
+		delete(m.entries, getTagKey(tag))
+	}
+	return deleted, nil
+}
+
+// FlushAll deletes every key
+func (m *Memory) FlushAll() error {
+	return m.FlushAllContext(context.Background())
+}
+
+// FlushAllContext is FlushAll bounded by ctx
+func (m *Memory) FlushAllContext(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = map[string]*memoryEntry{}
+	return nil
+}
+
+// globRegexp translates a redis glob pattern to a regular expression, * and
+// ? match newlines too like in redis
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	escaped := false
+	inClass := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case inClass:
+			if r == ']' {
+				inClass = false
+			}
+			b.WriteRune(r)
+		case r == '[':
+			inClass = true
+			b.WriteRune(r)
+		case r == '*':
+			b.WriteString(".*")
+		case r == '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
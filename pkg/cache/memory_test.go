@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+func TestMemoryExpiry(t *testing.T) {
+	tests := []struct {
+		name   string
+		expiry time.Duration
+		wait   time.Duration
+		found  bool
+		pttl   time.Duration // -1 without expiry, -2 when missing
+	}{
+		{"live", time.Minute, 0, true, 0},
+		{"expired", 20 * time.Millisecond, 40 * time.Millisecond, false, -2},
+		{"no expiry", 0, 20 * time.Millisecond, true, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(&config.Config{})
+			if _, err := m.SetNX("key", "value", tt.expiry); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(tt.wait)
+			value, err := m.GetString("key")
+			if tt.found && (err != nil || value != "value") {
+				t.Errorf("got %q, %v", value, err)
+			}
+			if !tt.found && !errors.Is(err, redis.Nil) {
+				t.Errorf("expected redis.Nil, got %q, %v", value, err)
+			}
+			pttl, err := m.PTTL("key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.pttl != 0 && pttl != tt.pttl {
+				t.Errorf("pttl %s, want %s", pttl, tt.pttl)
+			}
+			if tt.pttl == 0 && (pttl <= 0 || pttl > tt.expiry) {
+				t.Errorf("pttl %s, want up to %s", pttl, tt.expiry)
+			}
+		})
+	}
+}
+
+func TestMemoryExpiredKeysAreSwept(t *testing.T) {
+	m := NewMemory(&config.Config{})
+	if err := m.SetXString("old", "value", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	m.lastSweep = time.Now().Add(-2 * MemorySweepInterval)
+	if err := m.SetXString("new", "value", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.entries["old"]; ok {
+		t.Error("expired key was not swept")
+	}
+}
+
+func TestMemoryConditionalWrites(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		setup func(m *Memory)
+		run   func(m *Memory) (bool, error)
+		want  bool
+		value string // of key afterwards, empty when missing
+	}{
+		{
+			name: "setnx missing",
+			run:  func(m *Memory) (bool, error) { return m.SetNX("key", "a", time.Minute) },
+			want: true, value: "a",
+		},
+		{
+			name:  "setnx existing",
+			setup: func(m *Memory) { m.SetXString("key", "b", time.Minute) },
+			run:   func(m *Memory) (bool, error) { return m.SetNX("key", "a", time.Minute) },
+			want:  false, value: "b",
+		},
+		{
+			name: "setnx expired",
+			setup: func(m *Memory) {
+				m.SetXString("key", "b", time.Millisecond)
+				time.Sleep(5 * time.Millisecond)
+			},
+			run:  func(m *Memory) (bool, error) { return m.SetNX("key", "a", time.Minute) },
+			want: true, value: "a",
+		},
+		{
+			name:  "delifequal equal",
+			setup: func(m *Memory) { m.SetXString("key", "a", time.Minute) },
+			run:   func(m *Memory) (bool, error) { return m.DelIfEqual(ctx, "key", "a") },
+			want:  true, value: "",
+		},
+		{
+			name:  "delifequal other",
+			setup: func(m *Memory) { m.SetXString("key", "b", time.Minute) },
+			run:   func(m *Memory) (bool, error) { return m.DelIfEqual(ctx, "key", "a") },
+			want:  false, value: "b",
+		},
+		{
+			name: "delifequal missing",
+			run:  func(m *Memory) (bool, error) { return m.DelIfEqual(ctx, "key", "a") },
+			want: false, value: "",
+		},
+		{
+			name:  "delifequal hash",
+			setup: func(m *Memory) { m.HSetString("key", "field", "a") },
+			run:   func(m *Memory) (bool, error) { return m.DelIfEqual(ctx, "key", "a") },
+			want:  false, value: "",
+		},
+		{
+			name:  "expireifequal equal",
+			setup: func(m *Memory) { m.SetXString("key", "a", 10*time.Millisecond) },
+			run: func(m *Memory) (bool, error) {
+				ok, err := m.ExpireIfEqual(ctx, "key", "a", time.Minute)
+				time.Sleep(20 * time.Millisecond)
+				return ok, err
+			},
+			want: true, value: "a",
+		},
+		{
+			name:  "expireifequal other",
+			setup: func(m *Memory) { m.SetXString("key", "b", 10*time.Millisecond) },
+			run: func(m *Memory) (bool, error) {
+				ok, err := m.ExpireIfEqual(ctx, "key", "a", time.Minute)
+				time.Sleep(20 * time.Millisecond)
+				return ok, err
+			},
+			want: false, value: "",
+		},
+		{
+			name: "expireifequal missing",
+			run:  func(m *Memory) (bool, error) { return m.ExpireIfEqual(ctx, "key", "a", time.Minute) },
+			want: false, value: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(&config.Config{})
+			if tt.setup != nil {
+				tt.setup(m)
+			}
+			got, err := tt.run(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			value, err := m.GetString("key")
+			if err != nil && !errors.Is(err, redis.Nil) && !errors.Is(err, errWrongType) {
+				t.Fatal(err)
+			}
+			if value != tt.value {
+				t.Errorf("key holds %q, want %q", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"workflow_v1::*", "workflow_v1::0b8e", true},
+		{"workflow_v1::*", "workspace_member_v1::0b8e", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"foo.bar", "foo.bar", true},
+		{"foo.bar", "fooxbar", false},
+		{"a+b", "a+b", true},
+		{"a*", "a\nb", true},
+		{"prefix", "prefix::key", false},
+	}
+	for _, tt := range tests {
+		re, err := globRegexp(tt.pattern)
+		if err != nil {
+			t.Errorf("globRegexp(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got := re.MatchString(tt.key); got != tt.match {
+			t.Errorf("globRegexp(%q) matching %q = %v", tt.pattern, tt.key, got)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package cache
 import (
 	"context"
 	"github.com/go-redis/redis/v8"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"encoding/json"
@@ -10,10 +12,21 @@ import (
 	"github.com/praveenmsp23/trackdocs/pkg/config"
 )
 
-type Cache struct {
-	client  *redis.Client
+// Redis is the Cache backed by redis, optionally with an in-process tier for
+// hot keys
+type Redis struct {
+	client  redis.UniversalClient
 	limiter *Limiter
 	ctx     context.Context
+	// local is the optional in-process tier in front of redis for the keys
+	// starting with localPrefixes
+	local         *Local
+	localPrefixes []string
+	instance      string
+	generation    atomic.Uint64
+	listening     atomic.Bool
+	// timeout bounds every operation, on top of the deadline of its context
+	timeout time.Duration
 }
 
 const (
@@ -21,177 +34,268 @@ const (
 	DefaultExpiry            = 604800
 )
 
-func NewCache(cfg *config.Config) (*Cache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     cfg.CacheSource,
-		Password: cfg.CacheSourcePassword,
-		DB:       0,
-	})
+func NewRedis(cfg *config.Config) (*Redis, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
 	ctx := context.Background()
 	if err := client.Ping(ctx).Err(); err != nil {
 		return nil, err
 	}
 	limiter := NewLimiter(client)
-	return &Cache{client: client, ctx: ctx, limiter: limiter}, nil
+	c := &Redis{client: client, ctx: ctx, limiter: limiter, instance: newInstanceId(), timeout: cfg.CacheTimeout}
+	if cfg.CacheLocalSize > 0 && len(cfg.CacheLocalPrefixes) > 0 {
+		c.local = NewLocal(cfg.CacheLocalSize, cfg.CacheLocalTTL)
+		c.localPrefixes = cfg.CacheLocalPrefixes
+	}
+	return c, nil
 }
 
-// GetClient get internal redis client
-func (c *Cache) GetClient() *redis.Client {
+// GetClient get internal redis client, for the features only redis provides
+func (c *Redis) GetClient() redis.UniversalClient {
 	return c.client
 }
 
+// withTimeout bounds ctx by the operation timeout of the cache
+func (c *Redis) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
 // Allow is a shortcut for AllowN(ctx, key, limit, 1).
-func (c *Cache) Allow(key string, limit int) (*Result, error) {
-	return c.limiter.Allow(c.ctx, key, PerMinute(limit))
+func (c *Redis) Allow(key string, limit int) (*Result, error) {
+	return c.AllowContext(c.ctx, key, limit)
+}
+
+// AllowContext is Allow bounded by ctx
+func (c *Redis) AllowContext(ctx context.Context, key string, limit int) (*Result, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.limiter.Allow(ctx, key, PerMinute(limit))
 }
 
 // AllowN reports whether n events may happen at time now.
-func (c *Cache) AllowN(key string, limit, n int) (*Result, error) {
-	return c.limiter.AllowN(c.ctx, key, PerMinute(limit), n)
+func (c *Redis) AllowN(key string, limit, n int) (*Result, error) {
+	return c.AllowNContext(c.ctx, key, limit, n)
+}
+
+// AllowNContext is AllowN bounded by ctx
+func (c *Redis) AllowNContext(ctx context.Context, key string, limit, n int) (*Result, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.limiter.AllowN(ctx, key, PerMinute(limit), n)
+}
+
+// AllowLimit reports whether n events may happen now under limit
+func (c *Redis) AllowLimit(ctx context.Context, key string, limit Limit, n int) (*Result, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.limiter.AllowN(ctx, key, limit, n)
+}
+
+// AllowAtMost allows up to n events under limit, Result.Allowed is how many
+func (c *Redis) AllowAtMost(ctx context.Context, key string, limit Limit, n int) (*Result, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.limiter.AllowAtMost(ctx, key, limit, n)
 }
 
 // Set sets the cache value for the key
-func (c *Cache) Set(key string, value interface{}) error {
-	val, err := json.Marshal(value)
-	if err != nil {
-		return err
-	}
-	err = c.client.Set(c.ctx, key, string(val), DefaultExpiry*time.Second).Err()
-	if err != nil {
-		return err
-	}
-	return nil
+func (c *Redis) Set(key string, value interface{}) error {
+	return c.SetContext(c.ctx, key, value)
+}
+
+// SetContext is Set bounded by ctx
+func (c *Redis) SetContext(ctx context.Context, key string, value interface{}) error {
+	return c.SetXContext(ctx, key, value, DefaultExpiry*time.Second)
 }
 
 // SetX sets the cache value for the key
-func (c *Cache) SetX(key string, value interface{}, expiry time.Duration) error {
+func (c *Redis) SetX(key string, value interface{}, expiry time.Duration) error {
+	return c.SetXContext(c.ctx, key, value, expiry)
+}
+
+// SetXContext is SetX bounded by ctx
+func (c *Redis) SetXContext(ctx context.Context, key string, value interface{}, expiry time.Duration) error {
 	val, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
-	err = c.client.Set(c.ctx, key, string(val), expiry).Err()
-	if err != nil {
-		return err
-	}
-	return nil
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.set(ctx, key, val, expiry)
 }
 
 // Del deletes the cache value for the key
-func (c *Cache) Del(key string) error {
-	err := c.client.Del(c.ctx, key).Err()
-	if err != nil {
-		return err
-	}
-	return nil
+func (c *Redis) Del(key string) error {
+	return c.DelContext(c.ctx, key)
+}
+
+// DelContext is Del bounded by ctx
+func (c *Redis) DelContext(ctx context.Context, key string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.del(ctx, key)
 }
 
 // HDel deletes the cache value for the key
-func (c *Cache) HDel(key, field string) error {
-	err := c.client.HDel(c.ctx, key, field).Err()
+func (c *Redis) HDel(key, field string) error {
+	return c.HDelContext(c.ctx, key, field)
+}
+
+// HDelContext is HDel bounded by ctx
+func (c *Redis) HDelContext(ctx context.Context, key, field string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	err := c.client.HDel(ctx, key, field).Err()
 	if err != nil {
 		return err
 	}
+	c.changed(ctx, key)
 	return nil
 }
 
 // Get get the cache value for the key
-func (c *Cache) Get(key string, out interface{}) error {
-	res := c.client.Get(c.ctx, key)
+func (c *Redis) Get(key string, out interface{}) error {
+	return c.GetContext(c.ctx, key, out)
+}
 
-	if res.Err() != nil {
-		return res.Err()
-	}
-	bytes, err := res.Bytes()
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(bytes, out)
+// GetContext is Get bounded by ctx
+func (c *Redis) GetContext(ctx context.Context, key string, out interface{}) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	bytes, err := c.get(ctx, key)
 	if err != nil {
 		return err
 	}
-	return err
+	return json.Unmarshal(bytes, out)
 }
 
-func (c *Cache) Exists(key string) (bool, error) {
-	res := c.client.Exists(c.ctx, key)
+func (c *Redis) Exists(key string) (bool, error) {
+	return c.ExistsContext(c.ctx, key)
+}
 
-	if res.Err() != nil {
-		return false, res.Err()
-	}
-	val, err := res.Result()
+// ExistsContext is Exists bounded by ctx
+func (c *Redis) ExistsContext(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	val, err := c.client.Exists(ctx, key).Result()
 	if err != nil {
 		return false, err
 	}
 	return val > 0, nil
 }
 
-func (c *Cache) setAny(key string, value interface{}) error {
-	res := c.client.Set(c.ctx, key, value, DefaultExpiry*time.Second)
-	if res.Err() != nil {
-		return res.Err()
+func (c *Redis) setAny(ctx context.Context, key string, value interface{}, expiry time.Duration) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	err := c.client.Set(ctx, key, value, expiry).Err()
+	if err != nil {
+		return err
 	}
+	c.changed(ctx, key)
 	return nil
 }
 
-func (c *Cache) setAnyX(key string, value interface{}, expiry time.Duration) error {
-	res := c.client.Set(c.ctx, key, value, expiry)
-	if res.Err() != nil {
-		return res.Err()
-	}
-	return nil
+// SetString sets the cache value for the key
+func (c *Redis) SetString(key, value string) error {
+	return c.SetXStringContext(c.ctx, key, value, DefaultExpiry*time.Second)
 }
 
-// SetString sets the cache value for the key
-func (c *Cache) SetString(key, value string) error {
-	return c.setAny(key, value)
+// SetStringContext is SetString bounded by ctx
+func (c *Redis) SetStringContext(ctx context.Context, key, value string) error {
+	return c.SetXStringContext(ctx, key, value, DefaultExpiry*time.Second)
 }
 
 // SetInt sets the cache value for the key
-func (c *Cache) SetInt(key string, value int) error {
-	return c.setAny(key, value)
+func (c *Redis) SetInt(key string, value int) error {
+	return c.setAny(c.ctx, key, value, DefaultExpiry*time.Second)
+}
+
+// SetIntContext is SetInt bounded by ctx
+func (c *Redis) SetIntContext(ctx context.Context, key string, value int) error {
+	return c.setAny(ctx, key, value, DefaultExpiry*time.Second)
 }
 
 // SetInt64 sets the cache value for the key
-func (c *Cache) SetInt64(key string, value int64) error {
-	return c.setAny(key, value)
+func (c *Redis) SetInt64(key string, value int64) error {
+	return c.setAny(c.ctx, key, value, DefaultExpiry*time.Second)
+}
+
+// SetInt64Context is SetInt64 bounded by ctx
+func (c *Redis) SetInt64Context(ctx context.Context, key string, value int64) error {
+	return c.setAny(ctx, key, value, DefaultExpiry*time.Second)
 }
 
 // SetXString sets the cache value for the key
-func (c *Cache) SetXString(key, value string, expiry time.Duration) error {
-	return c.setAnyX(key, value, expiry)
+func (c *Redis) SetXString(key, value string, expiry time.Duration) error {
+	return c.SetXStringContext(c.ctx, key, value, expiry)
+}
+
+// SetXStringContext is SetXString bounded by ctx
+func (c *Redis) SetXStringContext(ctx context.Context, key, value string, expiry time.Duration) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.set(ctx, key, []byte(value), expiry)
 }
 
 // SetXInt sets the cache value for the key
-func (c *Cache) SetXInt(key string, value int, expiry time.Duration) error {
-	return c.setAnyX(key, value, expiry)
+func (c *Redis) SetXInt(key string, value int, expiry time.Duration) error {
+	return c.setAny(c.ctx, key, value, expiry)
+}
+
+// SetXIntContext is SetXInt bounded by ctx
+func (c *Redis) SetXIntContext(ctx context.Context, key string, value int, expiry time.Duration) error {
+	return c.setAny(ctx, key, value, expiry)
 }
 
 // SetXInt64 sets the cache value for the key
-func (c *Cache) SetXInt64(key string, value int64, expiry time.Duration) error {
-	return c.setAnyX(key, value, expiry)
+func (c *Redis) SetXInt64(key string, value int64, expiry time.Duration) error {
+	return c.setAny(c.ctx, key, value, expiry)
+}
+
+// SetXInt64Context is SetXInt64 bounded by ctx
+func (c *Redis) SetXInt64Context(ctx context.Context, key string, value int64, expiry time.Duration) error {
+	return c.setAny(ctx, key, value, expiry)
 }
 
 // SetNX Redis `SET key value [expiration] NX` command.
-func (c *Cache) SetNX(key, value string, expiry time.Duration) (bool, error) {
-	res := c.client.SetNX(c.ctx, key, value, expiry)
-	if res.Err() != nil {
-		return false, res.Err()
-	}
-	return res.Val(), nil
+func (c *Redis) SetNX(key, value string, expiry time.Duration) (bool, error) {
+	return c.SetNXContext(c.ctx, key, value, expiry)
+}
+
+// SetNXContext is SetNX bounded by ctx
+func (c *Redis) SetNXContext(ctx context.Context, key, value string, expiry time.Duration) (bool, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.client.SetNX(ctx, key, value, expiry).Result()
 }
 
 // Expire Redis `EXPIRE key [expiration]` command.
-func (c *Cache) Expire(key string, expiry time.Duration) (bool, error) {
-	res := c.client.Expire(c.ctx, key, expiry)
-	if res.Err() != nil {
-		return false, res.Err()
-	}
-	return res.Val(), nil
+func (c *Redis) Expire(key string, expiry time.Duration) (bool, error) {
+	return c.ExpireContext(c.ctx, key, expiry)
+}
+
+// ExpireContext is Expire bounded by ctx
+func (c *Redis) ExpireContext(ctx context.Context, key string, expiry time.Duration) (bool, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.client.Expire(ctx, key, expiry).Result()
 }
 
 // PTTL Redis `PTTL key` command.
-func (c *Cache) PTTL(key string) (time.Duration, error) {
-	res := c.client.PTTL(c.ctx, key)
+func (c *Redis) PTTL(key string) (time.Duration, error) {
+	return c.PTTLContext(c.ctx, key)
+}
+
+// PTTLContext is PTTL bounded by ctx
+func (c *Redis) PTTLContext(ctx context.Context, key string) (time.Duration, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	res := c.client.PTTL(ctx, key)
 	if res.Err() != nil {
 		return time.Millisecond, res.Err()
 	}
@@ -199,25 +303,27 @@ func (c *Cache) PTTL(key string) (time.Duration, error) {
 }
 
 // HGetString get the cache value for the key
-func (c *Cache) HGetString(key, field string) (string, error) {
-	res := c.client.HGet(c.ctx, key, field)
-	if res.Err() != nil {
-		return "", res.Err()
-	}
-	out, err := res.Result()
-	if err != nil {
-		return "", err
-	}
-	return out, nil
+func (c *Redis) HGetString(key, field string) (string, error) {
+	return c.HGetStringContext(c.ctx, key, field)
+}
+
+// HGetStringContext is HGetString bounded by ctx
+func (c *Redis) HGetStringContext(ctx context.Context, key, field string) (string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.client.HGet(ctx, key, field).Result()
 }
 
 // HGetAll
-func (c *Cache) HGetAll(key string) (map[string]string, error) {
-	res := c.client.HGetAll(c.ctx, key)
-	if res.Err() != nil {
-		return nil, res.Err()
-	}
-	out, err := res.Result()
+func (c *Redis) HGetAll(key string) (map[string]string, error) {
+	return c.HGetAllContext(c.ctx, key)
+}
+
+// HGetAllContext is HGetAll bounded by ctx
+func (c *Redis) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	out, err := c.client.HGetAll(ctx, key).Result()
 	if err != nil {
 		return nil, err
 	}
@@ -225,129 +331,207 @@ func (c *Cache) HGetAll(key string) (map[string]string, error) {
 }
 
 // HGetInt get the cache value for the key
-func (c *Cache) HGetInt(key, field string) (int, error) {
-	res := c.client.HGet(c.ctx, key, field)
-	if res.Err() != nil {
-		return 0, res.Err()
-	}
-	out, err := res.Int()
-	if err != nil {
-		return 0, err
-	}
-	return out, nil
+func (c *Redis) HGetInt(key, field string) (int, error) {
+	return c.HGetIntContext(c.ctx, key, field)
+}
+
+// HGetIntContext is HGetInt bounded by ctx
+func (c *Redis) HGetIntContext(ctx context.Context, key, field string) (int, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.client.HGet(ctx, key, field).Int()
 }
 
 // HGetInt64 get the cache value for the key
-func (c *Cache) HGetInt64(key, field string) (int64, error) {
-	res := c.client.HGet(c.ctx, key, field)
-	if res.Err() != nil {
-		return 0, res.Err()
-	}
-	out, err := res.Int64()
-	if err != nil {
-		return 0, err
-	}
-	return out, nil
+func (c *Redis) HGetInt64(key, field string) (int64, error) {
+	return c.HGetInt64Context(c.ctx, key, field)
+}
+
+// HGetInt64Context is HGetInt64 bounded by ctx
+func (c *Redis) HGetInt64Context(ctx context.Context, key, field string) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.client.HGet(ctx, key, field).Int64()
 }
 
 // GetString get the cache value for the key
-func (c *Cache) GetString(key string) (string, error) {
-	res := c.client.Get(c.ctx, key)
-	if res.Err() != nil {
-		return "", res.Err()
-	}
-	out, err := res.Result()
+func (c *Redis) GetString(key string) (string, error) {
+	return c.GetStringContext(c.ctx, key)
+}
+
+// GetStringContext is GetString bounded by ctx
+func (c *Redis) GetStringContext(ctx context.Context, key string) (string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	out, err := c.get(ctx, key)
 	if err != nil {
 		return "", err
 	}
-	return out, nil
+	return string(out), nil
 }
 
 // GetInt get the cache value for the key
-func (c *Cache) GetInt(key string) (int, error) {
-	res := c.client.Get(c.ctx, key)
-	if res.Err() != nil {
-		return 0, res.Err()
-	}
-	out, err := res.Int()
-	if err != nil {
-		return 0, err
-	}
-	return out, nil
+func (c *Redis) GetInt(key string) (int, error) {
+	return c.GetIntContext(c.ctx, key)
+}
+
+// GetIntContext is GetInt bounded by ctx
+func (c *Redis) GetIntContext(ctx context.Context, key string) (int, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.client.Get(ctx, key).Int()
 }
 
 // GetInt64 get the cache value for the key
-func (c *Cache) GetInt64(key string) (int64, error) {
-	res := c.client.Get(c.ctx, key)
-	if res.Err() != nil {
-		return 0, res.Err()
-	}
-	out, err := res.Int64()
-	if err != nil {
-		return 0, err
-	}
-	return out, nil
+func (c *Redis) GetInt64(key string) (int64, error) {
+	return c.GetInt64Context(c.ctx, key)
+}
+
+// GetInt64Context is GetInt64 bounded by ctx
+func (c *Redis) GetInt64Context(ctx context.Context, key string) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.client.Get(ctx, key).Int64()
 }
 
 // FlushAll flushes all cache. **WARNING** only for development
-func (c *Cache) FlushAll() error {
-	res := c.client.FlushAll(c.ctx)
-	if res.Err() != nil {
-		return res.Err()
+func (c *Redis) FlushAll() error {
+	return c.FlushAllContext(c.ctx)
+}
+
+// FlushAllContext is FlushAll bounded by ctx
+func (c *Redis) FlushAllContext(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	var err error
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return client.FlushAll(ctx).Err()
+		})
+	} else {
+		err = c.client.FlushAll(ctx).Err()
+	}
+	if err != nil {
+		return err
+	}
+	if c.local != nil {
+		c.local.Purge()
 	}
 	return nil
 }
 
 // HSet sets the cache value for the key in hash
-func (c *Cache) HSet(key, field string, value interface{}) error {
+func (c *Redis) HSet(key, field string, value interface{}) error {
+	return c.HSetContext(c.ctx, key, field, value)
+}
+
+// HSetContext is HSet bounded by ctx
+func (c *Redis) HSetContext(ctx context.Context, key, field string, value interface{}) error {
 	val, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
-	err = c.client.HSet(c.ctx, key, field, string(val)).Err()
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.HSetStringContext(ctx, key, field, string(val))
 }
 
 // HSet sets the cache value for the key in hash
-func (c *Cache) HSetString(key, field, value string) error {
-	err := c.client.HSet(c.ctx, key, field, value).Err()
+func (c *Redis) HSetString(key, field, value string) error {
+	return c.HSetStringContext(c.ctx, key, field, value)
+}
+
+// HSetStringContext is HSetString bounded by ctx
+func (c *Redis) HSetStringContext(ctx context.Context, key, field, value string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	err := c.client.HSet(ctx, key, field, value).Err()
 	if err != nil {
 		return err
 	}
+	c.changed(ctx, key)
 	return nil
 }
 
 // Get get the cache value for the key
-func (c *Cache) HGet(key, field string, out interface{}) error {
-	res := c.client.HGet(c.ctx, key, field)
+func (c *Redis) HGet(key, field string, out interface{}) error {
+	return c.HGetContext(c.ctx, key, field, out)
+}
 
-	if res.Err() != nil {
-		return res.Err()
-	}
-	bytes, err := res.Bytes()
+// HGetContext is HGet bounded by ctx
+func (c *Redis) HGetContext(ctx context.Context, key, field string, out interface{}) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	bytes, err := c.client.HGet(ctx, key, field).Bytes()
 	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(bytes, out)
-	if err != nil {
-		return err
-	}
-	return err
+	return json.Unmarshal(bytes, out)
 }
 
 // Keys returns the keys matching pattern
-func (c *Cache) Keys(pattern string) ([]string, error) {
-	res := c.client.Keys(c.ctx, pattern)
-	if res.Err() != nil {
-		return []string{}, res.Err()
+//
+// Deprecated: KEYS blocks redis while it walks every key, use Scan.
+func (c *Redis) Keys(pattern string) ([]string, error) {
+	return c.KeysContext(c.ctx, pattern)
+}
+
+// KeysContext is Keys bounded by ctx
+//
+// Deprecated: KEYS blocks redis while it walks every key, use Scan.
+func (c *Redis) KeysContext(ctx context.Context, pattern string) ([]string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	cluster, ok := c.client.(*redis.ClusterClient)
+	if !ok {
+		res := c.client.Keys(ctx, pattern)
+		if res.Err() != nil {
+			return []string{}, res.Err()
+		}
+		return res.Val(), nil
+	}
+	// every master holds a part of the keys
+	var mu sync.Mutex
+	keys := []string{}
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		res := client.Keys(ctx, pattern)
+		if res.Err() != nil {
+			return res.Err()
+		}
+		mu.Lock()
+		keys = append(keys, res.Val()...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return []string{}, err
 	}
-	return res.Val(), nil
+	return keys, nil
 }
 
 // Eval evaluates the lua script
-func (c *Cache) Eval(script string, keys []string, args ...interface{}) *redis.Cmd {
-	return c.client.Eval(c.ctx, script, keys, args...)
+func (c *Redis) Eval(script string, keys []string, args ...interface{}) *redis.Cmd {
+	return c.EvalContext(c.ctx, script, keys, args...)
+}
+
+// EvalContext is Eval bounded by ctx
+func (c *Redis) EvalContext(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.client.Eval(ctx, script, keys, args...)
+}
+
+// DelIfEqual deletes key when it holds value, it reports whether it did
+func (c *Redis) DelIfEqual(ctx context.Context, key, value string) (bool, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	result, err := delIfEqual.Run(ctx, c.client, []string{key}, value).Int()
+	return result == 1, err
+}
+
+// ExpireIfEqual resets the expiry of key when it holds value, it reports
+// whether it did
+func (c *Redis) ExpireIfEqual(ctx context.Context, key, value string, expiry time.Duration) (bool, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	result, err := expireIfEqual.Run(ctx, c.client, []string{key}, value, expiry.Milliseconds()).Int()
+	return result == 1, err
 }
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// DefaultScanBatch is the number of keys asked for per SCAN call
+	DefaultScanBatch = 500
+	// DeleteBatchSize is the number of keys unlinked per round trip
+	DeleteBatchSize = 500
+)
+
+// KeyIterator walks the keys matching a pattern with SCAN, which unlike KEYS
+// does not block redis. Keys changed during the walk may be missed or
+// returned twice. In cluster mode every master is walked in turn.
+type KeyIterator struct {
+	withTimeout func(context.Context) (context.Context, context.CancelFunc)
+	iterators   []*redis.ScanIterator
+	// keys is walked instead of iterators by caches without SCAN, pos is
+	// the current key
+	keys []string
+	pos  int
+	err  error
+}
+
+// newKeysIterator walks a snapshot of keys
+func newKeysIterator(keys []string) *KeyIterator {
+	return &KeyIterator{keys: keys, pos: -1}
+}
+
+// Scan returns an iterator over the keys matching pattern, asking for batch
+// keys per call
+func (c *Redis) Scan(ctx context.Context, pattern string, batch int64) *KeyIterator {
+	if batch <= 0 {
+		batch = DefaultScanBatch
+	}
+	it := &KeyIterator{withTimeout: c.withTimeout}
+	// the first SCAN of every iterator runs here
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	cluster, ok := c.client.(*redis.ClusterClient)
+	if !ok {
+		it.iterators = []*redis.ScanIterator{c.client.Scan(ctx, 0, pattern, batch).Iterator()}
+		return it
+	}
+	var mu sync.Mutex
+	it.err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		it.iterators = append(it.iterators, client.Scan(ctx, 0, pattern, batch).Iterator())
+		return nil
+	})
+	return it
+}
+
+// Next advances to the next key, it returns false when the walk is over or
+// failed
+func (it *KeyIterator) Next(ctx context.Context) bool {
+	if it.iterators == nil {
+		if it.pos < len(it.keys) {
+			it.pos++
+		}
+		return it.pos < len(it.keys)
+	}
+	for it.err == nil && len(it.iterators) > 0 {
+		ctx, cancel := it.withTimeout(ctx)
+		next := it.iterators[0].Next(ctx)
+		cancel()
+		if next {
+			return true
+		}
+		it.err = it.iterators[0].Err()
+		if it.err == nil {
+			it.iterators = it.iterators[1:]
+		}
+	}
+	return false
+}
+
+// Val returns the current key
+func (it *KeyIterator) Val() string {
+	if it.iterators == nil {
+		if it.pos < 0 || it.pos >= len(it.keys) {
+			return ""
+		}
+		return it.keys[it.pos]
+	}
+	if len(it.iterators) == 0 {
+		return ""
+	}
+	return it.iterators[0].Val()
+}
+
+// Err returns the error that stopped the walk
+func (it *KeyIterator) Err() error {
+	return it.err
+}
+
+// DeleteByPrefix unlinks every key starting with prefix in batches and
+// returns how many were deleted
+func (c *Redis) DeleteByPrefix(ctx context.Context, prefix string) (int64, error) {
+	it := c.Scan(ctx, escapePattern(prefix)+"*", DefaultScanBatch)
+	deleted := int64(0)
+	batch := make([]string, 0, DeleteBatchSize)
+	for it.Next(ctx) {
+		batch = append(batch, it.Val())
+		if len(batch) == DeleteBatchSize {
+			n, err := c.unlink(ctx, batch)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := it.Err(); err != nil {
+		return deleted, err
+	}
+	n, err := c.unlink(ctx, batch)
+	return deleted + n, err
+}
+
+// unlink deletes the keys in the background of redis. Every key gets its
+// own UNLINK in one pipeline, so keys of different cluster slots can be
+// mixed.
+func (c *Redis) unlink(ctx context.Context, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	cmds, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Unlink(ctx, key)
+		}
+		return nil
+	})
+	deleted := int64(0)
+	for _, cmd := range cmds {
+		if n, err := cmd.(*redis.IntCmd).Result(); err == nil {
+			deleted += n
+		}
+	}
+	if err != nil {
+		return deleted, err
+	}
+	c.changed(ctx, keys...)
+	return deleted, nil
+}
+
+// escapePattern escapes the glob characters of s for MATCH
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// TagPrefix prefixes the sets holding the keys of a tag
+	TagPrefix = "tag_v1::"
+	// TagExpiry is refreshed whenever a key is tagged. Sets may list keys that
+	// expired meanwhile, invalidating them is harmless.
+	TagExpiry = DefaultExpiry * time.Second
+)
+
+func getTagKey(tag string) string {
+	return TagPrefix + tag
+}
+
+// Tag adds key to the sets of the tags, so it is deleted by InvalidateTags
+// of any of them
+func (c *Redis) Tag(ctx context.Context, key string, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			pipe.SAdd(ctx, getTagKey(tag), key)
+			pipe.Expire(ctx, getTagKey(tag), TagExpiry)
+		}
+		return nil
+	})
+	return err
+}
+
+// InvalidateTags deletes the keys tagged with any of the tags, and the tags,
+// and returns how many keys were deleted
+func (c *Redis) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	deleted := int64(0)
+	for _, tag := range tags {
+		n, err := c.invalidateTag(ctx, getTagKey(tag))
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+func (c *Redis) invalidateTag(ctx context.Context, tagKey string) (int64, error) {
+	sctx, cancel := c.withTimeout(ctx)
+	it := c.client.SScan(sctx, tagKey, 0, "", DefaultScanBatch).Iterator()
+	cancel()
+	deleted := int64(0)
+	batch := make([]string, 0, DeleteBatchSize)
+	for {
+		sctx, cancel := c.withTimeout(ctx)
+		next := it.Next(sctx)
+		cancel()
+		if !next {
+			break
+		}
+		batch = append(batch, it.Val())
+		if len(batch) == DeleteBatchSize {
+			n, err := c.unlink(ctx, batch)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := it.Err(); err != nil {
+		return deleted, err
+	}
+	// the set goes last, a failure above leaves it for a retry
+	n, err := c.unlink(ctx, append(batch, tagKey))
+	if n > 0 {
+		// the tag set itself is not counted
+		n--
+	}
+	return deleted + n, err
+}
@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+)
+
+const (
+	// InvalidationChannel carries "instance key" messages for keys set or
+	// deleted by a replica, the others drop them from their local tier
+	InvalidationChannel      = "cache::invalidate"
+	invalidateReconnectDelay = time.Second
+)
+
+// tiered reports whether key belongs to the local tier, changes to it are
+// broadcast to the other replicas
+func (c *Redis) tiered(key string) bool {
+	if c.local == nil {
+		return false
+	}
+	for _, prefix := range c.localPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// locally reports whether key is served from the local tier, which is only
+// used while Listen keeps it in sync
+func (c *Redis) locally(key string) bool {
+	return c.listening.Load() && c.tiered(key)
+}
+
+// get returns the raw value of key, from the local tier when it holds it
+func (c *Redis) get(ctx context.Context, key string) ([]byte, error) {
+	if !c.locally(key) {
+		return c.client.Get(ctx, key).Bytes()
+	}
+	if value, ok := c.local.Get(key); ok {
+		return value, nil
+	}
+	// a value read before an invalidation arrives may already be stale, it
+	// is only kept when no invalidation was received meanwhile
+	generation := c.generation.Load()
+	value, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if c.generation.Load() == generation {
+		c.local.Set(key, value, 0)
+	}
+	return value, nil
+}
+
+// set writes the raw value of key and broadcasts the change to the local
+// tiers of the other replicas
+func (c *Redis) set(ctx context.Context, key string, value []byte, expiry time.Duration) error {
+	err := c.client.Set(ctx, key, value, expiry).Err()
+	if err != nil || !c.tiered(key) {
+		return err
+	}
+	if c.locally(key) {
+		c.local.Set(key, value, expiry)
+	}
+	c.invalidate(ctx, key)
+	return nil
+}
+
+// fill writes the raw value of key loaded on a miss. The value did not
+// change, so the other replicas keep their copies.
+func (c *Redis) fill(ctx context.Context, key string, value []byte, expiry time.Duration) error {
+	err := c.client.Set(ctx, key, value, expiry).Err()
+	if err == nil && c.locally(key) {
+		c.local.Set(key, value, expiry)
+	}
+	return err
+}
+
+// del deletes the keys and broadcasts their deletion to the local tiers of
+// the other replicas
+func (c *Redis) del(ctx context.Context, keys ...string) error {
+	err := c.client.Del(ctx, keys...).Err()
+	if err != nil {
+		return err
+	}
+	c.changed(ctx, keys...)
+	return nil
+}
+
+// changed drops keys written without the local tier from it and broadcasts
+// the change to the other replicas
+func (c *Redis) changed(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if c.tiered(key) {
+			c.local.Del(key)
+			c.invalidate(ctx, key)
+		}
+	}
+}
+
+func (c *Redis) invalidate(ctx context.Context, key string) {
+	err := c.client.Publish(ctx, InvalidationChannel, c.instance+" "+key).Err()
+	if err != nil {
+		logger.Errorf("Cache error while broadcasting the invalidation of %s:%s", key, err.Error())
+	}
+}
+
+// Listen drops the keys changed by other replicas from the local tier until
+// ctx is done, the local tier is only used meanwhile. Invalidations sent
+// while the subscription is down are lost, so the local tier is emptied
+// whenever it is made again.
+func (c *Redis) Listen(ctx context.Context) {
+	if c.local == nil {
+		return
+	}
+	c.listening.Store(true)
+	defer c.listening.Store(false)
+	for {
+		pubsub := c.client.Subscribe(ctx, InvalidationChannel)
+		c.generation.Add(1)
+		c.local.Purge()
+		c.receive(ctx, pubsub)
+		pubsub.Close()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(invalidateReconnectDelay):
+		}
+	}
+}
+
+func (c *Redis) receive(ctx context.Context, pubsub *redis.PubSub) {
+	for {
+		received, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Errorf("Cache error while receiving invalidations:%s", err.Error())
+			}
+			return
+		}
+		instance, key, ok := strings.Cut(received.Payload, " ")
+		if !ok || instance == c.instance {
+			continue
+		}
+		c.generation.Add(1)
+		c.local.Del(key)
+	}
+}
+
+func newInstanceId() string {
+	return crypto.GenerateId("cch", 16)
+}
@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultJitter spreads the expiry of entries by up to 10% of their TTL
+	DefaultJitter = 0.1
+	// notFoundMarker is cached for negative results, it is not valid JSON
+	// so it cannot be mistaken for a value
+	notFoundMarker = "!notfound"
+)
+
+// Loader loads a value on a cache miss
+type Loader[T any] func(ctx context.Context) (T, error)
+
+// Typed caches values of T as JSON. Concurrent loads of the same key in this
+// process share one call of the loader, and TTLs are jittered so entries
+// written together do not expire together.
+type Typed[T any] struct {
+	cache       rawCache
+	group       singleflight.Group
+	jitter      float64
+	notFound    error
+	negativeTTL time.Duration
+}
+
+// rawCache is the byte level access of Typed, Memory and Redis implement it
+// and other caches go through their exported methods
+type rawCache interface {
+	get(ctx context.Context, key string) ([]byte, error)
+	set(ctx context.Context, key string, value []byte, expiry time.Duration) error
+	// fill is set for values loaded from the source of truth, which other
+	// replicas need not drop
+	fill(ctx context.Context, key string, value []byte, expiry time.Duration) error
+	del(ctx context.Context, keys ...string) error
+	withTimeout(ctx context.Context) (context.Context, context.CancelFunc)
+}
+
+// exportedCache is the rawCache of a Cache implemented outside the package
+type exportedCache struct {
+	Cache
+}
+
+func (c exportedCache) get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.GetStringContext(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+func (c exportedCache) set(ctx context.Context, key string, value []byte, expiry time.Duration) error {
+	return c.SetXStringContext(ctx, key, string(value), expiry)
+}
+
+func (c exportedCache) fill(ctx context.Context, key string, value []byte, expiry time.Duration) error {
+	return c.set(ctx, key, value, expiry)
+}
+
+func (c exportedCache) del(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := c.DelContext(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withTimeout leaves the timeout to the methods of the cache
+func (c exportedCache) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return ctx, func() {}
+}
+
+// TypedOption configures a Typed cache
+type TypedOption func(*typedOptions)
+
+type typedOptions struct {
+	jitter      float64
+	notFound    error
+	negativeTTL time.Duration
+}
+
+// WithJitter sets the fraction of the TTL by which expiries are spread
+func WithJitter(fraction float64) TypedOption {
+	return func(o *typedOptions) {
+		o.jitter = fraction
+	}
+}
+
+// WithNegativeCaching caches loads failing with notFound for ttl, later
+// lookups fail with notFound without calling the loader
+func WithNegativeCaching(notFound error, ttl time.Duration) TypedOption {
+	return func(o *typedOptions) {
+		o.notFound = notFound
+		o.negativeTTL = ttl
+	}
+}
+
+func NewTyped[T any](cache Cache, options ...TypedOption) *Typed[T] {
+	o := &typedOptions{jitter: DefaultJitter}
+	for _, option := range options {
+		option(o)
+	}
+	raw, ok := cache.(rawCache)
+	if !ok {
+		raw = exportedCache{cache}
+	}
+	return &Typed[T]{cache: raw, jitter: o.jitter, notFound: o.notFound, negativeTTL: o.negativeTTL}
+}
+
+// Get returns the cached value of key, ok is false on a miss
+func (t *Typed[T]) Get(ctx context.Context, key string) (value T, ok bool, err error) {
+	ctx, cancel := t.cache.withTimeout(ctx)
+	defer cancel()
+	data, err := t.cache.get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return value, false, nil
+	} else if err != nil {
+		return value, false, err
+	}
+	if string(data) == notFoundMarker && t.notFound != nil {
+		return value, true, t.notFound
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		// written by an older version of T, load it again
+		return value, false, nil
+	}
+	return value, true, nil
+}
+
+// Set caches value for about ttl
+func (t *Typed[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return t.set(ctx, key, data, ttl)
+}
+
+func (t *Typed[T]) set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	ctx, cancel := t.cache.withTimeout(ctx)
+	defer cancel()
+	return t.cache.set(ctx, key, data, t.jittered(ttl))
+}
+
+// Del removes the cached value of key
+func (t *Typed[T]) Del(ctx context.Context, key string) error {
+	ctx, cancel := t.cache.withTimeout(ctx)
+	defer cancel()
+	return t.cache.del(ctx, key)
+}
+
+// GetOrLoad returns the cached value of key, or loads and caches it for
+// about ttl. Cache failures are logged and fall back to the loader. Callers
+// sharing a load each decode their own value, so pointers in T are never
+// shared between them.
+func (t *Typed[T]) GetOrLoad(ctx context.Context, key string, ttl time.Duration, loader Loader[T]) (T, error) {
+	value, ok, err := t.Get(ctx, key)
+	if ok {
+		return value, err
+	}
+	if err != nil {
+		logger.Errorf("Typed cache error while getting %s:%s", key, err.Error())
+	}
+	// the shared load must not fail because the caller that started it gave
+	// up, every caller still stops waiting when its own ctx is done
+	result := t.group.DoChan(key, func() (interface{}, error) {
+		return t.load(context.WithoutCancel(ctx), key, ttl, loader)
+	})
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case r := <-result:
+		var value T
+		if r.Err != nil {
+			return value, r.Err
+		}
+		if err := json.Unmarshal(r.Val.([]byte), &value); err != nil {
+			return value, err
+		}
+		return value, nil
+	}
+}
+
+// load returns the loaded value as JSON
+func (t *Typed[T]) load(ctx context.Context, key string, ttl time.Duration, loader Loader[T]) ([]byte, error) {
+	value, err := loader(ctx)
+	if err != nil {
+		if t.notFound != nil && errors.Is(err, t.notFound) {
+			if err := t.setNotFound(ctx, key); err != nil {
+				logger.Errorf("Typed cache error while setting %s:%s", key, err.Error())
+			}
+		}
+		return nil, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.fill(ctx, key, data, ttl); err != nil {
+		logger.Errorf("Typed cache error while setting %s:%s", key, err.Error())
+	}
+	return data, nil
+}
+
+// fill caches a loaded value, which is not broadcast as a change
+func (t *Typed[T]) fill(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	ctx, cancel := t.cache.withTimeout(ctx)
+	defer cancel()
+	return t.cache.fill(ctx, key, data, t.jittered(ttl))
+}
+
+func (t *Typed[T]) setNotFound(ctx context.Context, key string) error {
+	return t.fill(ctx, key, []byte(notFoundMarker), t.negativeTTL)
+}
+
+// jittered returns ttl moved randomly by up to the jitter fraction
+func (t *Typed[T]) jittered(ttl time.Duration) time.Duration {
+	if t.jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	spread := int64(float64(ttl) * t.jitter)
+	if spread <= 0 {
+		return ttl
+	}
+	return ttl - time.Duration(spread) + time.Duration(rand.Int63n(2*spread+1))
+}
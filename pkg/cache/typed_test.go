@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+type typedValue struct {
+	Name string
+}
+
+func TestTypedGetOrLoadCopiesPerCaller(t *testing.T) {
+	typed := NewTyped[*typedValue](NewMemory(&config.Config{}))
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (*typedValue, error) {
+		<-release
+		return &typedValue{Name: "shared"}, nil
+	}
+
+	const callers = 8
+	values := make([]*typedValue, callers)
+	var wg sync.WaitGroup
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := typed.GetOrLoad(context.Background(), "key", time.Minute, loader)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			values[i] = value
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	seen := map[*typedValue]bool{}
+	for _, value := range values {
+		if value == nil || value.Name != "shared" {
+			t.Fatalf("got %+v", value)
+		}
+		if seen[value] {
+			t.Fatal("callers share a value")
+		}
+		seen[value] = true
+		// callers own their value and may change it
+		value.Name = "changed"
+	}
+	value, err := typed.GetOrLoad(context.Background(), "key", time.Minute, loader)
+	if err != nil || value.Name != "shared" {
+		t.Errorf("cached value changed: %+v, %v", value, err)
+	}
+}
+
+// outsideCache hides the raw methods of Memory, like a Cache implemented in
+// another package
+type outsideCache struct {
+	Cache
+}
+
+func TestTypedOverOutsideCache(t *testing.T) {
+	ctx := context.Background()
+	typed := NewTyped[typedValue](outsideCache{NewMemory(&config.Config{})}, WithNegativeCaching(errors.New("not found"), time.Minute))
+	if err := typed.Set(ctx, "key", typedValue{Name: "set"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, err := typed.Get(ctx, "key"); err != nil || !ok || value.Name != "set" {
+		t.Fatalf("got %+v, %v, %v", value, ok, err)
+	}
+	if err := typed.Del(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := typed.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("deleted key: %v, %v", ok, err)
+	}
+	value, err := typed.GetOrLoad(ctx, "key", time.Minute, func(ctx context.Context) (typedValue, error) {
+		return typedValue{Name: "loaded"}, nil
+	})
+	if err != nil || value.Name != "loaded" {
+		t.Fatalf("got %+v, %v", value, err)
+	}
+}
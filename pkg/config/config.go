@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 	"github.com/pkg/errors"
 	"github.com/praveenmsp23/trackdocs/pkg/logger"
@@ -27,19 +29,67 @@ var (
 
 // Config for the environment
 type Config struct {
-	Port                 string         `envconfig:"PORT" default:"8080"`
-	Listen               string         `envconfig:"LISTEN" default:"0.0.0.0"`
-	Env                  ApplicationEnv `envconfig:"ENV" default:"local"`
-	APIUrl               string         `envconfig:"API_URL" default:"http://api:8080"`
-	TokenHeader          string         `envconfig:"TOKEN_HEADER" default:"X-Access-Token"`
-	TokenProvider        string         `envconfig:"TOKEN_PROVIDER" default:"redis"`
-	TokenLifeTime        int64          `envconfig:"TOKEN_LIFETIME" default:"86400"`
-	CacheSource          string         `envconfig:"CACHE_SOURCE" default:"redis:6379"`
-	CacheSourcePassword  string         `envconfig:"CACHE_SOURCE_PASSWORD" default:"password"`
-	MeilisearchHost      string         `envconfig:"MEILISEARCH_HOST" default:"http://meiliesearch:7700"`
-	MeilisearchMasterKey string         `envconfig:"MEILISEARCH_MASTER_KEY" default:"master_key"`
-	Secret               string         `envconfig:"SECRET" default:"k;r(>.]kW6M#NCXK=<EF&}an1JW9!q"` // encrypt and decrypt
-	Datasource           string         `envconfig:"DATASOURCE" default:"host=localhost user=trackdocs password=trackdocs dbname=trackdocs port=5432 sslmode=disable TimeZone=Asia/Kolkata"`
+	Port                  string         `envconfig:"PORT" default:"8080"`
+	Listen                string         `envconfig:"LISTEN" default:"0.0.0.0"`
+	Env                   ApplicationEnv `envconfig:"ENV" default:"local"`
+	APIUrl                string         `envconfig:"API_URL" default:"http://api:8080"`
+	TokenHeader           string         `envconfig:"TOKEN_HEADER" default:"X-Access-Token"`
+	TokenProvider         string         `envconfig:"TOKEN_PROVIDER" default:"redis"`
+	TokenLifeTime         int64          `envconfig:"TOKEN_LIFETIME" default:"86400"`
+	CacheSource           string         `envconfig:"CACHE_SOURCE" default:"redis:6379"`
+	CacheSourcePassword   string         `envconfig:"CACHE_SOURCE_PASSWORD" default:"password"`
+	CacheMode             string         `envconfig:"CACHE_MODE" default:"single"` // single, sentinel, cluster or memory
+	CacheAddrs            []string       `envconfig:"CACHE_ADDRS"`                 // sentinels or cluster nodes, CacheSource when empty
+	CacheMasterName       string         `envconfig:"CACHE_MASTER_NAME" default:"mymaster"`
+	CacheSentinelPassword string         `envconfig:"CACHE_SENTINEL_PASSWORD"`
+	CacheDB               int            `envconfig:"CACHE_DB" default:"0"`
+	CachePoolSize         int            `envconfig:"CACHE_POOL_SIZE" default:"0"` // 0 is 10 connections per CPU
+	CacheTLS              bool           `envconfig:"CACHE_TLS" default:"false"`
+	CacheTimeout          time.Duration  `envconfig:"CACHE_TIMEOUT" default:"1s"`       // per cache operation
+	CacheLocalSize        int            `envconfig:"CACHE_LOCAL_SIZE" default:"10000"` // entries kept in process, 0 disables the local tier
+	CacheLocalTTL         time.Duration  `envconfig:"CACHE_LOCAL_TTL" default:"30s"`
+	CacheLocalPrefixes    []string       `envconfig:"CACHE_LOCAL_PREFIXES" default:"account_v1::"` // keys served from the local tier
+	MeilisearchHost       string         `envconfig:"MEILISEARCH_HOST" default:"http://meilisearch:7700"`
+	MeilisearchMasterKey  string         `envconfig:"MEILISEARCH_MASTER_KEY" default:"master_key"`
+	SearchEngine          string         `envconfig:"SEARCH_ENGINE" default:"meilisearch"`
+	SearchIndex           string         `envconfig:"SEARCH_INDEX" default:"documents"`
+	SearchLanguage        string         `envconfig:"SEARCH_LANGUAGE" default:"english"` // postgres text search configuration
+	EventBus              string         `envconfig:"EVENT_BUS" default:"kafka"`         // kafka or memory
+	KafkaBrokers          []string       `envconfig:"KAFKA_BROKERS" default:"kafka:9092"`
+	KafkaTopicPrefix      string         `envconfig:"KAFKA_TOPIC_PREFIX" default:"trackdocs."`
+	Secret                string         `envconfig:"SECRET" default:"k;r(>.]kW6M#NCXK=<EF&}an1JW9!q"` // encrypt and decrypt
+	SchedulerInterval     time.Duration  `envconfig:"SCHEDULER_INTERVAL" default:"1h"`
+	StoragePath           string         `envconfig:"STORAGE_PATH" default:"/var/lib/trackdocs/files"`
+	UploadMaxSize         int64          `envconfig:"UPLOAD_MAX_SIZE" default:"26214400"`  // 25 MiB
+	ExtractMaxSize        int64          `envconfig:"EXTRACT_MAX_SIZE" default:"26214400"` // files above are not extracted
+	ExtractMaxText        int            `envconfig:"EXTRACT_MAX_TEXT" default:"262144"`   // bytes of text kept per version
+	ExtractTimeout        time.Duration  `envconfig:"EXTRACT_TIMEOUT" default:"30s"`
+	ExtractMaxAttempts    int            `envconfig:"EXTRACT_MAX_ATTEMPTS" default:"5"`
+	TrustedProxies        []string       `envconfig:"TRUSTED_PROXIES"`                                       // addresses or CIDRs whose client ip headers are believed
+	ClientIPHeaders       []string       `envconfig:"CLIENT_IP_HEADERS" default:"X-Forwarded-For,X-Real-IP"` // set by the trusted proxies
+	RateLimitPolicies     string         `envconfig:"RATE_LIMIT_POLICIES"`                                   // JSON file of rate limit policies, the defaults when empty
+	AdminEmails           []string       `envconfig:"ADMIN_EMAILS"`                                          // accounts allowed to use /api/admin
+	JobConcurrency        int            `envconfig:"JOB_CONCURRENCY" default:"10"`
+	JobTimeout            time.Duration  `envconfig:"JOB_TIMEOUT" default:"5m"`
+	JobVisibilityTimeout  time.Duration  `envconfig:"JOB_VISIBILITY_TIMEOUT" default:"1m"` // workers that stop extending a job lose it after
+	JobMaxAttempts        int            `envconfig:"JOB_MAX_ATTEMPTS" default:"10"`
+	WebhookTimeout        time.Duration  `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+	WebhookMaxAttempts    int            `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	WebhookDisableAfter   int            `envconfig:"WEBHOOK_DISABLE_AFTER" default:"50"` // failed attempts in a row
+	StreamBufferSize      int64          `envconfig:"STREAM_BUFFER_SIZE" default:"10000"` // events kept for clients that reconnect
+	StreamHeartbeat       time.Duration  `envconfig:"STREAM_HEARTBEAT" default:"25s"`
+	MailTransport         string         `envconfig:"MAIL_TRANSPORT" default:"log"` // smtp, file or log
+	MailFrom              string         `envconfig:"MAIL_FROM" default:"TrackDocs <no-reply@trackdocs.local>"`
+	MailDir               string         `envconfig:"MAIL_DIR" default:"/var/lib/trackdocs/mail"` // written by the file transport
+	MailLocale            string         `envconfig:"MAIL_LOCALE" default:"en"`                   // for recipients without a locale
+	MailTimeout           time.Duration  `envconfig:"MAIL_TIMEOUT" default:"30s"`
+	MailMaxAttempts       int            `envconfig:"MAIL_MAX_ATTEMPTS" default:"8"`
+	SmtpHost              string         `envconfig:"SMTP_HOST" default:"localhost"`
+	SmtpPort              int            `envconfig:"SMTP_PORT" default:"587"`
+	SmtpUsername          string         `envconfig:"SMTP_USERNAME"`
+	SmtpPassword          string         `envconfig:"SMTP_PASSWORD"`
+	PortalUrl             string         `envconfig:"PORTAL_URL" default:"http://localhost:8080/api/portal"`
+	Datasource            string         `envconfig:"DATASOURCE" default:"host=localhost user=trackdocs password=trackdocs dbname=trackdocs port=5432 sslmode=disable TimeZone=Asia/Kolkata"`
 }
 
 // NewConfig reads configuration from environment variables and validates it
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("002", &WorkspaceMigrationProvider{})
+}
+
+type Workspace struct {
+	Base
+	AuditBase
+	Name string `gorm:"size:256;not null;"`
+}
+
+type WorkspaceMember struct {
+	Base
+	AuditBase
+	WorkspaceId string `gorm:"size:36;not null;uniqueIndex:idx_workspace_members_workspace_account"`
+	AccountId   string `gorm:"size:36;not null;uniqueIndex:idx_workspace_members_workspace_account;index"`
+	Role        string `gorm:"size:20;not null;"`
+}
+
+type Project struct {
+	Base
+	AuditBase
+	WorkspaceId string `gorm:"size:36;not null;index"`
+	Name        string `gorm:"size:256;not null;"`
+	Description string `gorm:"size:1024;"`
+}
+
+type Folder struct {
+	Base
+	AuditBase
+	WorkspaceId string `gorm:"size:36;not null;index"`
+	ProjectId   string `gorm:"size:36;not null;index"`
+	ParentId    string `gorm:"size:36;index"`
+	Name        string `gorm:"size:256;not null;"`
+	Path        string `gorm:"size:2048;not null;"`
+	Depth       int    `gorm:"not null;default:0"`
+}
+
+type Document struct {
+	Base
+	AuditBase
+	WorkspaceId string `gorm:"size:36;not null;index"`
+	ProjectId   string `gorm:"size:36;not null;index"`
+	FolderId    string `gorm:"size:36;index"`
+	Name        string `gorm:"size:256;not null;"`
+	Description string `gorm:"size:1024;"`
+	OwnerId     string `gorm:"size:36;not null;index"`
+}
+
+type Permission struct {
+	Base
+	AuditBase
+	WorkspaceId  string `gorm:"size:36;not null;index"`
+	ResourceType string `gorm:"size:20;not null;"`
+	ResourceId   string `gorm:"size:36;not null;uniqueIndex:idx_permissions_resource_account"`
+	AccountId    string `gorm:"size:36;not null;uniqueIndex:idx_permissions_resource_account"`
+	Role         string `gorm:"size:20;not null;"`
+}
+
+type WorkspaceMigrationProvider struct{}
+
+func (m WorkspaceMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "002",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m WorkspaceMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Workspace{}, &WorkspaceMember{}, &Project{}, &Folder{}, &Document{}, &Permission{}); err != nil {
+		return err
+	}
+	// text_pattern_ops lets "path LIKE 'prefix%'" subtree lookups use the index
+	if err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_folders_path ON folders (path text_pattern_ops)").Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m WorkspaceMigrationProvider) Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&Permission{}, &Document{}, &Folder{}, &Project{}, &WorkspaceMember{}, &Workspace{}); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("003", &MetadataMigrationProvider{})
+}
+
+type MetadataField struct {
+	Base
+	AuditBase
+	WorkspaceId string `gorm:"size:36;not null;uniqueIndex:idx_metadata_fields_workspace_key"`
+	Key         string `gorm:"size:64;not null;uniqueIndex:idx_metadata_fields_workspace_key"`
+	Label       string `gorm:"size:256;not null;"`
+	Type        string `gorm:"size:20;not null;"`
+	Options     string `gorm:"type:jsonb;not null;default:'[]'"`
+	Required    bool   `gorm:"not null;default:false"`
+}
+
+type Tag struct {
+	Base
+	WorkspaceId string `gorm:"size:36;not null;uniqueIndex:idx_tags_workspace_name"`
+	Name        string `gorm:"size:64;not null;uniqueIndex:idx_tags_workspace_name"`
+}
+
+type DocumentTag struct {
+	DocumentId string `gorm:"size:36;primaryKey"`
+	TagId      string `gorm:"size:36;primaryKey;index"`
+	Created    int64  `gorm:"autoCreateTime:milli"`
+}
+
+type MetadataMigrationProvider struct{}
+
+func (m MetadataMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "003",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m MetadataMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&MetadataField{}, &Tag{}, &DocumentTag{}); err != nil {
+		return err
+	}
+	if err := tx.Exec("ALTER TABLE documents ADD COLUMN IF NOT EXISTS metadata jsonb NOT NULL DEFAULT '{}'").Error; err != nil {
+		return err
+	}
+	// jsonb_path_ops serves the "metadata @> ?" containment filters
+	if err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_documents_metadata ON documents USING GIN (metadata jsonb_path_ops)").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_tags_name ON tags (workspace_id, name text_pattern_ops)").Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m MetadataMigrationProvider) Rollback(tx *gorm.DB) error {
+	if err := tx.Exec("ALTER TABLE documents DROP COLUMN IF EXISTS metadata").Error; err != nil {
+		return err
+	}
+	if err := tx.Migrator().DropTable(&DocumentTag{}, &Tag{}, &MetadataField{}); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("004", &WorkflowMigrationProvider{})
+}
+
+type WorkflowTransition struct {
+	Base
+	AuditBase
+	WorkspaceId  string `gorm:"size:36;not null;uniqueIndex:idx_workflow_transitions_workspace_from_to"`
+	From         string `gorm:"size:20;not null;uniqueIndex:idx_workflow_transitions_workspace_from_to"`
+	To           string `gorm:"size:20;not null;uniqueIndex:idx_workflow_transitions_workspace_from_to"`
+	ApproverRole string `gorm:"size:20;"`
+	ApproverIds  string `gorm:"type:jsonb;not null;default:'[]'"`
+	Quorum       int    `gorm:"not null;default:0"`
+}
+
+type DocumentTransition struct {
+	Base
+	WorkspaceId  string `gorm:"size:36;not null;index"`
+	DocumentId   string `gorm:"size:36;not null;index"`
+	From         string `gorm:"size:20;not null;"`
+	To           string `gorm:"size:20;not null;"`
+	Status       string `gorm:"size:20;not null;index"`
+	ApproverRole string `gorm:"size:20;"`
+	ApproverIds  string `gorm:"type:jsonb;not null;default:'[]'"`
+	Quorum       int    `gorm:"not null;default:0"`
+	RequestedBy  string `gorm:"size:36;not null;"`
+	Comment      string `gorm:"size:2048;"`
+	CompletedAt  sql.NullTime
+}
+
+type TransitionApproval struct {
+	Base
+	TransitionId string `gorm:"size:36;not null;uniqueIndex:idx_transition_approvals_transition_account"`
+	AccountId    string `gorm:"size:36;not null;uniqueIndex:idx_transition_approvals_transition_account"`
+	Decision     string `gorm:"size:20;not null;"`
+	Comment      string `gorm:"size:2048;"`
+}
+
+type WorkflowMigrationProvider struct{}
+
+func (m WorkflowMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "004",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m WorkflowMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&WorkflowTransition{}, &DocumentTransition{}, &TransitionApproval{}); err != nil {
+		return err
+	}
+	if err := tx.Exec("ALTER TABLE documents ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'draft'").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_documents_status ON documents (workspace_id, status)").Error; err != nil {
+		return err
+	}
+	// at most one pending transition per document
+	if err := tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_document_transitions_pending ON document_transitions (document_id) WHERE status = 'pending' AND deleted_at IS NULL").Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m WorkflowMigrationProvider) Rollback(tx *gorm.DB) error {
+	if err := tx.Exec("ALTER TABLE documents DROP COLUMN IF EXISTS status").Error; err != nil {
+		return err
+	}
+	if err := tx.Migrator().DropTable(&TransitionApproval{}, &DocumentTransition{}, &WorkflowTransition{}); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("005", &ExpiryMigrationProvider{})
+}
+
+type DocumentReminder struct {
+	Base
+	DocumentId string    `gorm:"size:36;not null;uniqueIndex:idx_document_reminders_document_offset"`
+	OffsetDays int       `gorm:"not null;uniqueIndex:idx_document_reminders_document_offset"`
+	ExpiresAt  time.Time `gorm:"not null;uniqueIndex:idx_document_reminders_document_offset"`
+}
+
+type ExpiryMigrationProvider struct{}
+
+func (m ExpiryMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "005",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m ExpiryMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&DocumentReminder{}); err != nil {
+		return err
+	}
+	if err := tx.Exec("ALTER TABLE documents ADD COLUMN IF NOT EXISTS expires_at timestamptz").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("ALTER TABLE documents ADD COLUMN IF NOT EXISTS reminder_offsets jsonb NOT NULL DEFAULT '[]'").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_documents_expires_at ON documents (expires_at) WHERE expires_at IS NOT NULL AND deleted_at IS NULL").Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m ExpiryMigrationProvider) Rollback(tx *gorm.DB) error {
+	if err := tx.Exec("ALTER TABLE documents DROP COLUMN IF EXISTS reminder_offsets").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("ALTER TABLE documents DROP COLUMN IF EXISTS expires_at").Error; err != nil {
+		return err
+	}
+	if err := tx.Migrator().DropTable(&DocumentReminder{}); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("006", &DocumentRequestMigrationProvider{})
+}
+
+type DocumentVersion struct {
+	Base
+	WorkspaceId string `gorm:"size:36;not null;index"`
+	DocumentId  string `gorm:"size:36;not null;uniqueIndex:idx_document_versions_document_version"`
+	Version     int    `gorm:"not null;uniqueIndex:idx_document_versions_document_version"`
+	FileName    string `gorm:"size:256;not null;"`
+	ContentType string `gorm:"size:128;not null;"`
+	Size        int64  `gorm:"not null;"`
+	Checksum    string `gorm:"size:64;not null;"`
+	StorageKey  string `gorm:"size:256;not null;"`
+	CreatedBy   string `gorm:"size:36;"`
+}
+
+type DocumentRequest struct {
+	Base
+	AuditBase
+	WorkspaceId          string `gorm:"size:36;not null;index"`
+	ProjectId            string `gorm:"size:36;not null;"`
+	FolderId             string `gorm:"size:36;"`
+	Title                string `gorm:"size:256;not null;"`
+	Message              string `gorm:"size:2048;"`
+	RecipientEmail       string `gorm:"size:256;not null;"`
+	RecipientName        string `gorm:"size:256;"`
+	TokenHash            string `gorm:"size:64;not null;uniqueIndex"`
+	Status               string `gorm:"size:20;not null;index"`
+	DueAt                sql.NullTime
+	ReminderIntervalDays int `gorm:"not null;default:0"`
+	LastRemindedAt       sql.NullTime
+	CompletedAt          sql.NullTime
+}
+
+type DocumentRequestItem struct {
+	Base
+	RequestId       string `gorm:"size:36;not null;index"`
+	Name            string `gorm:"size:256;not null;"`
+	Description     string `gorm:"size:1024;"`
+	AllowedTypes    string `gorm:"type:jsonb;not null;default:'[]'"`
+	MaxSize         int64  `gorm:"not null;"`
+	Required        bool   `gorm:"not null;default:true"`
+	Status          string `gorm:"size:20;not null;"`
+	DocumentId      string `gorm:"size:36;"`
+	RejectionReason string `gorm:"size:1024;"`
+	ReceivedAt      sql.NullTime
+}
+
+type DocumentRequestMigrationProvider struct{}
+
+func (m DocumentRequestMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "006",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m DocumentRequestMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&DocumentVersion{}, &DocumentRequest{}, &DocumentRequestItem{}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m DocumentRequestMigrationProvider) Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&DocumentRequestItem{}, &DocumentRequest{}, &DocumentVersion{}); err != nil {
+		return err
+	}
+	return nil
+}
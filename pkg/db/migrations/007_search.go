@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("007", &SearchMigrationProvider{})
+}
+
+// documentsSearchUpdateV1 indexes the name, tags and description of documents
+const documentsSearchUpdateV1 = `CREATE OR REPLACE FUNCTION documents_search_update() RETURNS trigger AS $$
+DECLARE
+	lang regconfig := (SELECT language FROM search_settings WHERE id = 1);
+	tag_names text := (SELECT string_agg(tags.name, ' ') FROM document_tags JOIN tags ON tags.id = document_tags.tag_id WHERE document_tags.document_id = NEW.id);
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector(lang, coalesce(NEW.name, '')), 'A') ||
+		setweight(to_tsvector(lang, coalesce(tag_names, '')), 'B') ||
+		setweight(to_tsvector(lang, coalesce(NEW.description, '')), 'C');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql`
+
+// SearchMigrationProvider maintains documents.search_vector for the postgres
+// search engine. The text search configuration lives in search_settings so
+// the engine can change it without replacing the triggers.
+type SearchMigrationProvider struct {
+	language string
+}
+
+func (m SearchMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	m.language = cfg.SearchLanguage
+	return &gormigrate.Migration{
+		ID:       "007",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m SearchMigrationProvider) Migrate(tx *gorm.DB) error {
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS search_settings (id int PRIMARY KEY DEFAULT 1 CHECK (id = 1), language regconfig NOT NULL)",
+		"ALTER TABLE documents ADD COLUMN IF NOT EXISTS search_vector tsvector",
+		"CREATE INDEX IF NOT EXISTS idx_documents_search_vector ON documents USING GIN (search_vector)",
+		documentsSearchUpdateV1,
+		// clearing search_vector recomputes it, which is how tag changes and
+		// language changes refresh documents
+		`CREATE OR REPLACE TRIGGER documents_search_update BEFORE INSERT OR UPDATE OF name, description, search_vector ON documents
+	FOR EACH ROW EXECUTE FUNCTION documents_search_update()`,
+		`CREATE OR REPLACE FUNCTION document_tags_search_update() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		UPDATE documents SET search_vector = NULL WHERE id = OLD.document_id;
+	ELSE
+		UPDATE documents SET search_vector = NULL WHERE id = NEW.document_id;
+	END IF;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql`,
+		`CREATE OR REPLACE TRIGGER document_tags_search_update AFTER INSERT OR DELETE ON document_tags
+	FOR EACH ROW EXECUTE FUNCTION document_tags_search_update()`,
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	err := tx.Exec("INSERT INTO search_settings (id, language) VALUES (1, ?::regconfig) ON CONFLICT (id) DO NOTHING", m.language).Error
+	if err != nil {
+		return err
+	}
+	return tx.Exec("UPDATE documents SET search_vector = NULL").Error
+}
+
+func (m SearchMigrationProvider) Rollback(tx *gorm.DB) error {
+	statements := []string{
+		"DROP TRIGGER IF EXISTS document_tags_search_update ON document_tags",
+		"DROP FUNCTION IF EXISTS document_tags_search_update()",
+		"DROP TRIGGER IF EXISTS documents_search_update ON documents",
+		"DROP FUNCTION IF EXISTS documents_search_update()",
+		"ALTER TABLE documents DROP COLUMN IF EXISTS search_vector",
+		"DROP TABLE IF EXISTS search_settings",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("008", &ExtractionMigrationProvider{})
+}
+
+// documentsSearchUpdateV2 also indexes the text of the current version. The
+// text is cut to ExtractMaxText as a tsvector cannot exceed 1MB.
+const documentsSearchUpdateV2 = `CREATE OR REPLACE FUNCTION documents_search_update() RETURNS trigger AS $$
+DECLARE
+	lang regconfig := (SELECT language FROM search_settings WHERE id = 1);
+	tag_names text := (SELECT string_agg(tags.name, ' ') FROM document_tags JOIN tags ON tags.id = document_tags.tag_id WHERE document_tags.document_id = NEW.id);
+	content text := (SELECT left(text, %d) FROM document_versions WHERE document_id = NEW.id AND deleted_at IS NULL ORDER BY version DESC LIMIT 1);
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector(lang, coalesce(NEW.name, '')), 'A') ||
+		setweight(to_tsvector(lang, coalesce(tag_names, '')), 'B') ||
+		setweight(to_tsvector(lang, coalesce(NEW.description, '')), 'C') ||
+		setweight(to_tsvector(lang, coalesce(content, '')), 'D');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql`
+
+type ExtractionMigrationProvider struct {
+	maxText int
+}
+
+func (m ExtractionMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	m.maxText = cfg.ExtractMaxText
+	return &gormigrate.Migration{
+		ID:       "008",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m ExtractionMigrationProvider) Migrate(tx *gorm.DB) error {
+	statements := []string{
+		"ALTER TABLE document_versions ADD COLUMN IF NOT EXISTS text text NOT NULL DEFAULT ''",
+		"ALTER TABLE document_versions ADD COLUMN IF NOT EXISTS page_count int NOT NULL DEFAULT 0",
+		"ALTER TABLE document_versions ADD COLUMN IF NOT EXISTS extract_status varchar(20) NOT NULL DEFAULT 'pending'",
+		"ALTER TABLE document_versions ADD COLUMN IF NOT EXISTS extract_error varchar(1024) NOT NULL DEFAULT ''",
+		"ALTER TABLE document_versions ADD COLUMN IF NOT EXISTS extract_attempts int NOT NULL DEFAULT 0",
+		"ALTER TABLE document_versions ADD COLUMN IF NOT EXISTS extract_after timestamptz",
+		"CREATE INDEX IF NOT EXISTS idx_document_versions_extract ON document_versions (extract_after) WHERE extract_status = 'pending' AND deleted_at IS NULL",
+		"ALTER TABLE documents ADD COLUMN IF NOT EXISTS extract_status varchar(20) NOT NULL DEFAULT ''",
+		"UPDATE documents SET extract_status = 'pending' WHERE id IN (SELECT document_id FROM document_versions)",
+		fmt.Sprintf(documentsSearchUpdateV2, m.maxText),
+		`CREATE OR REPLACE FUNCTION document_versions_search_update() RETURNS trigger AS $$
+BEGIN
+	UPDATE documents SET search_vector = NULL WHERE id = NEW.document_id;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql`,
+		// a new version replaces the text of the previous one
+		`CREATE OR REPLACE TRIGGER document_versions_search_update AFTER INSERT OR UPDATE OF text ON document_versions
+	FOR EACH ROW EXECUTE FUNCTION document_versions_search_update()`,
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m ExtractionMigrationProvider) Rollback(tx *gorm.DB) error {
+	statements := []string{
+		"DROP TRIGGER IF EXISTS document_versions_search_update ON document_versions",
+		"DROP FUNCTION IF EXISTS document_versions_search_update()",
+		documentsSearchUpdateV1,
+		"UPDATE documents SET search_vector = NULL",
+		"ALTER TABLE documents DROP COLUMN IF EXISTS extract_status",
+		"DROP INDEX IF EXISTS idx_document_versions_extract",
+		"ALTER TABLE document_versions DROP COLUMN IF EXISTS extract_after",
+		"ALTER TABLE document_versions DROP COLUMN IF EXISTS extract_attempts",
+		"ALTER TABLE document_versions DROP COLUMN IF EXISTS extract_error",
+		"ALTER TABLE document_versions DROP COLUMN IF EXISTS extract_status",
+		"ALTER TABLE document_versions DROP COLUMN IF EXISTS page_count",
+		"ALTER TABLE document_versions DROP COLUMN IF EXISTS text",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("009", &OutboxMigrationProvider{})
+}
+
+type OutboxEvent struct {
+	Base
+	Type        string    `gorm:"size:64;not null;"`
+	Topic       string    `gorm:"size:64;not null;"`
+	WorkspaceId string    `gorm:"size:36;"`
+	Payload     string    `gorm:"type:jsonb;not null;"`
+	Attempts    int       `gorm:"not null;default:0"`
+	LastError   string    `gorm:"size:1024;"`
+	AvailableAt time.Time `gorm:"not null;"`
+	DeliveredAt sql.NullTime
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+type OutboxMigrationProvider struct{}
+
+func (m OutboxMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "009",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m OutboxMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&OutboxEvent{}); err != nil {
+		return err
+	}
+	statements := []string{
+		// the relay only reads undelivered events
+		"CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (available_at, created) WHERE delivered_at IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_outbox_delivered ON outbox (delivered_at) WHERE delivered_at IS NOT NULL",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m OutboxMigrationProvider) Rollback(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&OutboxEvent{})
+}
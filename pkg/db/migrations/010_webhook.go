@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("010", &WebhookMigrationProvider{})
+}
+
+type Webhook struct {
+	Base
+	AuditBase
+	WorkspaceId     string `gorm:"size:36;not null;index"`
+	Url             string `gorm:"size:2048;not null;"`
+	Description     string `gorm:"size:1024;"`
+	Events          string `gorm:"type:jsonb;not null;default:'[]'"`
+	EncryptedSecret string `gorm:"size:256;not null;"`
+	Enabled         bool   `gorm:"not null;default:true"`
+	FailureCount    int    `gorm:"not null;default:0"`
+	DisabledAt      sql.NullTime
+	DisabledReason  string `gorm:"size:256;"`
+}
+
+type WebhookDelivery struct {
+	Base
+	WebhookId     string `gorm:"size:36;not null;uniqueIndex:idx_webhook_deliveries_webhook_event"`
+	WorkspaceId   string `gorm:"size:36;not null;"`
+	EventId       string `gorm:"size:36;not null;uniqueIndex:idx_webhook_deliveries_webhook_event"`
+	EventType     string `gorm:"size:64;not null;"`
+	Payload       string `gorm:"type:text;not null;"`
+	Status        string `gorm:"size:20;not null;"`
+	Attempts      int    `gorm:"not null;default:0"`
+	ResponseCode  int    `gorm:"not null;default:0"`
+	NextAttemptAt sql.NullTime
+	DeliveredAt   sql.NullTime
+}
+
+type WebhookAttempt struct {
+	Base
+	DeliveryId   string `gorm:"size:36;not null;index"`
+	Attempt      int    `gorm:"not null;"`
+	ResponseCode int    `gorm:"not null;default:0"`
+	ResponseBody string `gorm:"size:1024;"`
+	Error        string `gorm:"size:1024;"`
+	Duration     int64  `gorm:"not null;default:0"`
+}
+
+type WebhookMigrationProvider struct{}
+
+func (m WebhookMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "010",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m WebhookMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Webhook{}, &WebhookDelivery{}, &WebhookAttempt{}); err != nil {
+		return err
+	}
+	// deliveries are listed per webhook, newest first
+	if err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_created ON webhook_deliveries (webhook_id, created DESC)").Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m WebhookMigrationProvider) Rollback(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&WebhookAttempt{}, &WebhookDelivery{}, &Webhook{})
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("011", &NotificationMigrationProvider{})
+}
+
+type Notification struct {
+	Base
+	AccountId    string `gorm:"size:36;not null;"`
+	WorkspaceId  string `gorm:"size:36;"`
+	Type         string `gorm:"size:64;not null;"`
+	Title        string `gorm:"size:512;not null;"`
+	Body         string `gorm:"size:2048;"`
+	ResourceType string `gorm:"size:20;"`
+	ResourceId   string `gorm:"size:36;"`
+	ReadAt       sql.NullTime
+}
+
+type NotificationPreference struct {
+	AccountId string `gorm:"size:36;primaryKey"`
+	Type      string `gorm:"size:64;primaryKey"`
+	InApp     bool   `gorm:"not null;default:true"`
+	Email     bool   `gorm:"not null;default:false"`
+	Updated   int64  `gorm:"autoUpdateTime:milli"`
+}
+
+type NotificationMigrationProvider struct{}
+
+func (m NotificationMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "011",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m NotificationMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Notification{}, &NotificationPreference{}); err != nil {
+		return err
+	}
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_notifications_account_created ON notifications (account_id, created DESC)",
+		// unread counts only touch unread rows
+		"CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications (account_id) WHERE read_at IS NULL AND deleted_at IS NULL",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m NotificationMigrationProvider) Rollback(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&NotificationPreference{}, &Notification{})
+}
@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("012", &MailMigrationProvider{})
+}
+
+type NotificationDigest struct {
+	AccountId string    `gorm:"size:36;primaryKey"`
+	SentAt    time.Time `gorm:"not null;"`
+}
+
+type MailMigrationProvider struct{}
+
+func (m MailMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "012",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m MailMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&NotificationDigest{}); err != nil {
+		return err
+	}
+	statements := []string{
+		"ALTER TABLE accounts ADD COLUMN IF NOT EXISTS locale varchar(16) NOT NULL DEFAULT ''",
+		"ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS digest boolean NOT NULL DEFAULT false",
+		// the digest run looks for the accounts with a digest preference
+		"CREATE INDEX IF NOT EXISTS idx_notification_preferences_digest ON notification_preferences (account_id) WHERE digest",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m MailMigrationProvider) Rollback(tx *gorm.DB) error {
+	statements := []string{
+		"ALTER TABLE notification_preferences DROP COLUMN IF EXISTS digest",
+		"ALTER TABLE accounts DROP COLUMN IF EXISTS locale",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Migrator().DropTable(&NotificationDigest{})
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("013", &CommentMigrationProvider{})
+}
+
+type Comment struct {
+	Base
+	WorkspaceId string `gorm:"size:36;not null;"`
+	DocumentId  string `gorm:"size:36;not null;"`
+	ParentId    string `gorm:"size:36;not null;default:'';index"`
+	AccountId   string `gorm:"size:36;not null;"`
+	Body        string `gorm:"type:text;not null;"`
+	Mentions    string `gorm:"type:jsonb;not null;default:'[]'"`
+	VersionId   string `gorm:"size:36;"`
+	Page        int    `gorm:"not null;default:0"`
+	RangeStart  int    `gorm:"not null;default:0"`
+	RangeEnd    int    `gorm:"not null;default:0"`
+	Quote       string `gorm:"type:text;"`
+	EditedAt    sql.NullTime
+	ResolvedAt  sql.NullTime
+	ResolvedBy  string `gorm:"size:36;"`
+}
+
+type CommentEdit struct {
+	Base
+	CommentId string `gorm:"size:36;not null;index"`
+	Body      string `gorm:"type:text;not null;"`
+	EditedBy  string `gorm:"size:36;not null;"`
+}
+
+type CommentMigrationProvider struct{}
+
+func (m CommentMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "013",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m CommentMigrationProvider) Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Comment{}, &CommentEdit{}); err != nil {
+		return err
+	}
+	// threads of a document are listed oldest first
+	return tx.Exec("CREATE INDEX IF NOT EXISTS idx_comments_document_threads ON comments (document_id, created) WHERE parent_id = '' AND deleted_at IS NULL").Error
+}
+
+func (m CommentMigrationProvider) Rollback(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&CommentEdit{}, &Comment{})
+}
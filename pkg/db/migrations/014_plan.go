@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("014", &PlanMigrationProvider{})
+}
+
+type PlanMigrationProvider struct{}
+
+func (m PlanMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "014",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m PlanMigrationProvider) Migrate(tx *gorm.DB) error {
+	// the rate limit policies of the plan apply to the account, none by default
+	return tx.Exec("ALTER TABLE accounts ADD COLUMN IF NOT EXISTS plan varchar(32) NOT NULL DEFAULT ''").Error
+}
+
+func (m PlanMigrationProvider) Rollback(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE accounts DROP COLUMN IF EXISTS plan").Error
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("015", &OutboxOrderMigrationProvider{})
+}
+
+type OutboxOrderMigrationProvider struct{}
+
+func (m OutboxOrderMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "015",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m OutboxOrderMigrationProvider) Migrate(tx *gorm.DB) error {
+	// the relay reads the undelivered events of a workspace in order
+	return tx.Exec("CREATE INDEX IF NOT EXISTS idx_outbox_workspace_pending ON outbox (workspace_id, created, id) WHERE delivered_at IS NULL").Error
+}
+
+func (m OutboxOrderMigrationProvider) Rollback(tx *gorm.DB) error {
+	return tx.Exec("DROP INDEX IF EXISTS idx_outbox_workspace_pending").Error
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("016", &RequestTokenMigrationProvider{})
+}
+
+type RequestTokenMigrationProvider struct{}
+
+func (m RequestTokenMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "016",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m RequestTokenMigrationProvider) Migrate(tx *gorm.DB) error {
+	// reminders carry the upload link, requests created before have none
+	return tx.Exec("ALTER TABLE document_requests ADD COLUMN IF NOT EXISTS encrypted_token text NOT NULL DEFAULT ''").Error
+}
+
+func (m RequestTokenMigrationProvider) Rollback(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE document_requests DROP COLUMN IF EXISTS encrypted_token").Error
+}
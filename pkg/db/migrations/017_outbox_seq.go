@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("017", &OutboxSeqMigrationProvider{})
+}
+
+type OutboxSeqMigrationProvider struct{}
+
+func (m OutboxSeqMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "017",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m OutboxSeqMigrationProvider) Migrate(tx *gorm.DB) error {
+	// created can tie within a transaction and ids are random, the relay
+	// orders the events of a workspace by seq instead
+	if err := tx.Exec("ALTER TABLE outbox ADD COLUMN IF NOT EXISTS seq bigserial").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DROP INDEX IF EXISTS idx_outbox_workspace_pending").Error; err != nil {
+		return err
+	}
+	return tx.Exec("CREATE INDEX IF NOT EXISTS idx_outbox_workspace_seq ON outbox (workspace_id, seq) WHERE delivered_at IS NULL").Error
+}
+
+func (m OutboxSeqMigrationProvider) Rollback(tx *gorm.DB) error {
+	if err := tx.Exec("DROP INDEX IF EXISTS idx_outbox_workspace_seq").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_outbox_workspace_pending ON outbox (workspace_id, created, id) WHERE delivered_at IS NULL").Error; err != nil {
+		return err
+	}
+	return tx.Exec("ALTER TABLE outbox DROP COLUMN IF EXISTS seq").Error
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/zerogate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+func init() {
+	MigrationRegister("018", &WebhookRedeliveryMigrationProvider{})
+}
+
+type WebhookRedeliveryMigrationProvider struct{}
+
+func (m WebhookRedeliveryMigrationProvider) GetMigration(cfg *config.Config) *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID:       "018",
+		Migrate:  m.Migrate,
+		Rollback: m.Rollback,
+	}
+}
+
+func (m WebhookRedeliveryMigrationProvider) Migrate(tx *gorm.DB) error {
+	// attempts keep counting across redeliveries, the retries of a
+	// redelivery count from the attempts made before it
+	return tx.Exec("ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS redelivered_after integer NOT NULL DEFAULT 0").Error
+}
+
+func (m WebhookRedeliveryMigrationProvider) Rollback(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS redelivered_after").Error
+}
@@ -0,0 +1,177 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+)
+
+const (
+	BusKafka  = "kafka"
+	BusMemory = "memory"
+
+	TopicDocuments = "documents"
+	TopicAccounts  = "accounts"
+)
+
+// Event is the envelope of everything published on the bus. Data is the JSON
+// encoded payload of its Type, SubjectId the id of the document or account
+// the event is about.
+type Event struct {
+	Id          string          `json:"id"`
+	Type        string          `json:"type"`
+	Topic       string          `json:"topic"`
+	WorkspaceId string          `json:"workspace_id,omitempty"`
+	SubjectId   string          `json:"subject_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Key orders the events of a workspace, events outside of a workspace are
+// ordered by their subject
+func (e *Event) Key() string {
+	if e.WorkspaceId != "" {
+		return e.WorkspaceId
+	}
+	return e.SubjectId
+}
+
+// Handler handles a consumed event, an error redelivers it
+type Handler func(ctx context.Context, event *Event) error
+
+// Bus publishes events and delivers them to the subscribed groups. Every group
+// gets each event of its topics once.
+type Bus interface {
+	Publish(ctx context.Context, events ...*Event) error
+	// Subscribe calls handler with the events of topics for group until ctx
+	// is done
+	Subscribe(ctx context.Context, group string, handler Handler, topics ...string) error
+}
+
+// NewBus returns the bus configured by cfg.EventBus
+func NewBus(cfg *config.Config) (Bus, error) {
+	switch cfg.EventBus {
+	case BusKafka:
+		return NewKafka(cfg.KafkaBrokers, cfg.KafkaTopicPrefix), nil
+	case BusMemory:
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown event bus %q", cfg.EventBus)
+}
+
+// Type names a kind of event, the topic it is published on and its payload
+type Type[T any] struct {
+	Name  string
+	Topic string
+}
+
+func NewType[T any](name, topic string) Type[T] {
+	return Type[T]{Name: name, Topic: topic}
+}
+
+// New returns an event of type t about subjectId
+func (t Type[T]) New(workspaceId, subjectId string, data T) (*Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		Id:          crypto.GenerateId("evt", models.IdSize),
+		Type:        t.Name,
+		Topic:       t.Topic,
+		WorkspaceId: workspaceId,
+		SubjectId:   subjectId,
+		OccurredAt:  time.Now(),
+		Data:        payload,
+	}, nil
+}
+
+// Decode returns the payload of an event of type t
+func (t Type[T]) Decode(event *Event) (T, error) {
+	var data T
+	if event.Type != t.Name {
+		return data, fmt.Errorf("event %s is a %s, not a %s", event.Id, event.Type, t.Name)
+	}
+	err := json.Unmarshal(event.Data, &data)
+	return data, err
+}
+
+// DocumentPayload carries the document after the change and the account that
+// made it, empty for changes made by the system
+type DocumentPayload struct {
+	Document  *models.Document `json:"document"`
+	AccountId string           `json:"account_id,omitempty"`
+}
+
+type DocumentStatusPayload struct {
+	Document  *models.Document      `json:"document"`
+	From      models.DocumentStatus `json:"from"`
+	To        models.DocumentStatus `json:"to"`
+	AccountId string                `json:"account_id,omitempty"`
+}
+
+type DocumentVersionPayload struct {
+	Version *models.DocumentVersion `json:"version"`
+}
+
+// TransitionPayload carries a transition after the change, the decision that
+// changed it if any and the account that acted
+type TransitionPayload struct {
+	Transition *models.DocumentTransition `json:"transition"`
+	Approval   *models.TransitionApproval `json:"approval,omitempty"`
+	AccountId  string                     `json:"account_id,omitempty"`
+}
+
+// CommentPayload carries a comment after the change, the accounts newly
+// mentioned by it and the account that acted
+type CommentPayload struct {
+	Comment   *models.Comment `json:"comment"`
+	Mentioned []string        `json:"mentioned,omitempty"`
+	AccountId string          `json:"account_id,omitempty"`
+}
+
+type AccountPayload struct {
+	Account *models.Account `json:"account"`
+}
+
+var (
+	DocumentCreated       = NewType[DocumentPayload]("document.created", TopicDocuments)
+	DocumentUpdated       = NewType[DocumentPayload]("document.updated", TopicDocuments)
+	DocumentMoved         = NewType[DocumentPayload]("document.moved", TopicDocuments)
+	DocumentDeleted       = NewType[DocumentPayload]("document.deleted", TopicDocuments)
+	DocumentStatusChanged = NewType[DocumentStatusPayload]("document.status_changed", TopicDocuments)
+	DocumentVersionAdded  = NewType[DocumentVersionPayload]("document.version_added", TopicDocuments)
+	TransitionRequested   = NewType[TransitionPayload]("document.transition_requested", TopicDocuments)
+	TransitionDecided     = NewType[TransitionPayload]("document.transition_decided", TopicDocuments)
+	CommentCreated        = NewType[CommentPayload]("document.comment_created", TopicDocuments)
+	CommentUpdated        = NewType[CommentPayload]("document.comment_updated", TopicDocuments)
+	CommentResolved       = NewType[CommentPayload]("document.comment_resolved", TopicDocuments)
+	CommentReopened       = NewType[CommentPayload]("document.comment_reopened", TopicDocuments)
+	CommentDeleted        = NewType[CommentPayload]("document.comment_deleted", TopicDocuments)
+	AccountCreated        = NewType[AccountPayload]("account.created", TopicAccounts)
+	AccountUpdated        = NewType[AccountPayload]("account.updated", TopicAccounts)
+)
+
+// Names lists the names of all event types
+var Names = []string{
+	DocumentCreated.Name,
+	DocumentUpdated.Name,
+	DocumentMoved.Name,
+	DocumentDeleted.Name,
+	DocumentStatusChanged.Name,
+	DocumentVersionAdded.Name,
+	TransitionRequested.Name,
+	TransitionDecided.Name,
+	CommentCreated.Name,
+	CommentUpdated.Name,
+	CommentResolved.Name,
+	CommentReopened.Name,
+	CommentDeleted.Name,
+	AccountCreated.Name,
+	AccountUpdated.Name,
+}
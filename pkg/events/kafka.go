@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// KafkaBatchTimeout bounds how long Publish waits for more messages to
+	// batch with
+	KafkaBatchTimeout = 10 * time.Millisecond
+	// KafkaHandlerAttempts is how often a failing handler is called for an
+	// event before it is skipped
+	KafkaHandlerAttempts = 5
+	kafkaRetryDelay      = time.Second
+)
+
+// Kafka publishes events to the topic prefix + Event.Topic. Events are keyed
+// by Event.Key so the events of a workspace land on one partition in order.
+type Kafka struct {
+	brokers []string
+	prefix  string
+	writer  *kafka.Writer
+}
+
+func NewKafka(brokers []string, prefix string) *Kafka {
+	return &Kafka{
+		brokers: brokers,
+		prefix:  prefix,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			BatchTimeout:           KafkaBatchTimeout,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+// Publish returns once all events are acknowledged by the brokers
+func (k *Kafka) Publish(ctx context.Context, events ...*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	messages := make([]kafka.Message, 0, len(events))
+	for _, e := range events {
+		value, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, kafka.Message{
+			Topic: k.prefix + e.Topic,
+			Key:   []byte(e.Key()),
+			Value: value,
+			Headers: []kafka.Header{
+				{Key: "type", Value: []byte(e.Type)},
+			},
+		})
+	}
+	return k.writer.WriteMessages(ctx, messages...)
+}
+
+// Subscribe commits an event once handler succeeded. A handler that keeps
+// failing holds up its partition for KafkaHandlerAttempts tries, then the
+// event is logged and skipped.
+func (k *Kafka) Subscribe(ctx context.Context, group string, handler Handler, topics ...string) error {
+	groupTopics := make([]string, 0, len(topics))
+	for _, t := range topics {
+		groupTopics = append(groupTopics, k.prefix+t)
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     k.brokers,
+		GroupID:     group,
+		GroupTopics: groupTopics,
+	})
+	defer reader.Close()
+	for {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		event := &Event{}
+		if err := json.Unmarshal(message.Value, event); err != nil {
+			logger.Errorf("Kafka skipping malformed event at %s/%d/%d:%s", message.Topic, message.Partition, message.Offset, err.Error())
+		} else if err := k.handle(ctx, handler, event); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Errorf("Kafka skipping event %s of type %s for group %s:%s", event.Id, event.Type, group, err.Error())
+		}
+		if err := reader.CommitMessages(ctx, message); err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+}
+
+func (k *Kafka) handle(ctx context.Context, handler Handler, event *Event) error {
+	var err error
+	for attempt := 1; attempt <= KafkaHandlerAttempts; attempt++ {
+		if err = handler(ctx, event); err == nil {
+			return nil
+		}
+		if attempt == KafkaHandlerAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(kafkaRetryDelay << (attempt - 1)):
+		}
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+)
+
+// MemoryKeep is the number of published events Memory keeps
+const MemoryKeep = 1000
+
+// Memory is an in-process Bus for tests and single instance setups. Publish
+// calls the handlers before it returns and keeps the latest events for
+// Published.
+type Memory struct {
+	mu        sync.Mutex
+	published []*Event
+	groups    map[string][]*memorySubscriber
+}
+
+type memorySubscriber struct {
+	handler Handler
+	topics  []string
+}
+
+func NewMemory() *Memory {
+	return &Memory{groups: map[string][]*memorySubscriber{}}
+}
+
+// Publish delivers every event to the first subscriber of each group that
+// listens to its topic. Handler errors are logged, events are not redelivered.
+func (m *Memory) Publish(ctx context.Context, events ...*Event) error {
+	m.mu.Lock()
+	m.published = append(m.published, events...)
+	if extra := len(m.published) - MemoryKeep; extra > 0 {
+		m.published = slices.Delete(m.published, 0, extra)
+	}
+	var deliveries []func()
+	for _, e := range events {
+		for _, subscribers := range m.groups {
+			for _, s := range subscribers {
+				if !slices.Contains(s.topics, e.Topic) {
+					continue
+				}
+				handler, event := s.handler, e
+				deliveries = append(deliveries, func() {
+					if err := handler(ctx, event); err != nil {
+						logger.Errorf("Memory bus handler failed on event %s of type %s:%s", event.Id, event.Type, err.Error())
+					}
+				})
+				break
+			}
+		}
+	}
+	m.mu.Unlock()
+	for _, deliver := range deliveries {
+		deliver()
+	}
+	return nil
+}
+
+func (m *Memory) Subscribe(ctx context.Context, group string, handler Handler, topics ...string) error {
+	s := &memorySubscriber{handler: handler, topics: topics}
+	m.mu.Lock()
+	m.groups[group] = append(m.groups[group], s)
+	m.mu.Unlock()
+	<-ctx.Done()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.groups[group] = slices.DeleteFunc(m.groups[group], func(other *memorySubscriber) bool {
+		return other == s
+	})
+	if len(m.groups[group]) == 0 {
+		delete(m.groups, group)
+	}
+	return nil
+}
+
+// Published returns the latest published events, oldest first
+func (m *Memory) Published() []*Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.published)
+}
+
+// Reset forgets the published events
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = nil
+}
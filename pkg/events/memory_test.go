@@ -0,0 +1,151 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// subscribe subscribes handler until the test ends and waits for the
+// subscription
+func subscribe(t *testing.T, m *Memory, group string, handler Handler, topics ...string) context.CancelFunc {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.mu.Lock()
+	before := len(m.groups[group])
+	m.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Subscribe(ctx, group, handler, topics...)
+	}()
+	for {
+		m.mu.Lock()
+		n := len(m.groups[group])
+		m.mu.Unlock()
+		if n > before {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop := func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) handle(ctx context.Context, event *Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event.Type)
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+func newEvent(t *testing.T, typ Type[AccountPayload]) *Event {
+	t.Helper()
+	event, err := typ.New("", "acc_1", AccountPayload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestMemoryDeliversOncePerGroup(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	var search, webhooks1, webhooks2, documents recorder
+	subscribe(t, m, "search", search.handle, TopicAccounts)
+	subscribe(t, m, "webhooks", webhooks1.handle, TopicAccounts, TopicDocuments)
+	subscribe(t, m, "webhooks", webhooks2.handle, TopicAccounts)
+	subscribe(t, m, "documents", documents.handle, TopicDocuments)
+
+	if err := m.Publish(ctx, newEvent(t, AccountCreated), newEvent(t, AccountUpdated)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		r    *recorder
+		want int
+	}{
+		{"search", &search, 2},
+		{"webhooks", &webhooks1, 2},
+		{"second webhooks subscriber", &webhooks2, 0},
+		{"other topic", &documents, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.r.count(); got != tt.want {
+			t.Errorf("%s: got %d events, want %d", tt.name, got, tt.want)
+		}
+	}
+	if search.events[0] != AccountCreated.Name || search.events[1] != AccountUpdated.Name {
+		t.Errorf("events out of order: %v", search.events)
+	}
+}
+
+func TestMemoryUnsubscribes(t *testing.T) {
+	m := NewMemory()
+	var first, second recorder
+	stop := subscribe(t, m, "group", first.handle, TopicAccounts)
+	subscribe(t, m, "group", second.handle, TopicAccounts)
+	stop()
+	m.Publish(context.Background(), newEvent(t, AccountUpdated))
+	if first.count() != 0 || second.count() != 1 {
+		t.Errorf("got %d and %d events, want 0 and 1", first.count(), second.count())
+	}
+	stopAll := subscribe(t, m, "other", first.handle, TopicAccounts)
+	stopAll()
+	m.mu.Lock()
+	_, ok := m.groups["other"]
+	m.mu.Unlock()
+	if ok {
+		t.Error("empty group is kept")
+	}
+}
+
+func TestMemoryPublished(t *testing.T) {
+	m := NewMemory()
+	for i := 0; i < MemoryKeep+10; i++ {
+		m.Publish(context.Background(), newEvent(t, AccountUpdated))
+	}
+	if n := len(m.Published()); n != MemoryKeep {
+		t.Errorf("kept %d events, want %d", n, MemoryKeep)
+	}
+	m.Reset()
+	if n := len(m.Published()); n != 0 {
+		t.Errorf("kept %d events after reset", n)
+	}
+}
+
+func TestTypeDecode(t *testing.T) {
+	event, err := AccountUpdated.New("ws_1", "acc_1", AccountPayload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Topic != TopicAccounts || event.Key() != "ws_1" {
+		t.Errorf("got topic %s and key %s", event.Topic, event.Key())
+	}
+	if _, err := AccountUpdated.Decode(event); err != nil {
+		t.Error(err)
+	}
+	if _, err := AccountCreated.Decode(event); err == nil {
+		t.Error("decoded an event of another type")
+	}
+	event.WorkspaceId = ""
+	if event.Key() != "acc_1" {
+		t.Errorf("got key %s, want the subject", event.Key())
+	}
+}
@@ -0,0 +1,208 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+var (
+	// ErrUnsupported is returned for files no extractor handles
+	ErrUnsupported = errors.New("extract: unsupported file type")
+	// ErrTooLarge is returned for files above the size limit
+	ErrTooLarge = errors.New("extract: file too large")
+)
+
+// PageBreak separates the pages of paginated formats in Result.Text
+const PageBreak = "\f"
+
+// Result is the text of a file. Pages is 0 for formats without pages.
+type Result struct {
+	Text  string
+	Pages int
+}
+
+// Extractor reads the text of one kind of file. Extractors stop once the
+// text holds limit bytes.
+type Extractor interface {
+	Extract(ctx context.Context, data []byte, limit int) (*Result, error)
+}
+
+// ExtractorFunc adapts a function to an Extractor
+type ExtractorFunc func(ctx context.Context, data []byte, limit int) (*Result, error)
+
+func (f ExtractorFunc) Extract(ctx context.Context, data []byte, limit int) (*Result, error) {
+	return f(ctx, data, limit)
+}
+
+// Extractors picks the extractor of a file by its content type, or by its
+// extension for types content sniffing cannot tell apart
+type Extractors struct {
+	maxSize int64
+	maxText int
+	timeout time.Duration
+	byType  map[string]Extractor
+}
+
+// NewExtractors returns Extractors with the built-in extractors registered
+func NewExtractors(cfg *config.Config) *Extractors {
+	e := &Extractors{
+		maxSize: cfg.ExtractMaxSize,
+		maxText: cfg.ExtractMaxText,
+		timeout: cfg.ExtractTimeout,
+		byType:  map[string]Extractor{},
+	}
+	e.Register(ExtractorFunc(extractText), "text/plain", "text/csv", ".txt", ".csv")
+	e.Register(ExtractorFunc(extractMarkdown), "text/markdown", ".md", ".markdown")
+	e.Register(ExtractorFunc(extractHTML), "text/html", "application/xhtml+xml", ".html", ".htm")
+	e.Register(ExtractorFunc(extractPDF), "application/pdf", ".pdf")
+	e.Register(ExtractorFunc(extractDOCX), "application/vnd.openxmlformats-officedocument.wordprocessingml.document", ".docx")
+	e.Register(ExtractorFunc(extractXLSX), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx")
+	e.Register(ExtractorFunc(extractODT), "application/vnd.oasis.opendocument.text", ".odt")
+	return e
+}
+
+// Register makes extractor handle the given content types and ".ext" file
+// extensions, replacing the extractor registered before
+func (e *Extractors) Register(extractor Extractor, types ...string) {
+	for _, t := range types {
+		e.byType[strings.ToLower(t)] = extractor
+	}
+}
+
+// Find returns the extractor for the file or nil. The extension wins over a
+// generic content type, sniffing reports Markdown as text/plain and office
+// files as zip archives.
+func (e *Extractors) Find(fileName, contentType string) Extractor {
+	if ext := strings.ToLower(path.Ext(fileName)); ext != "" {
+		if extractor, ok := e.byType[ext]; ok {
+			return extractor
+		}
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	return e.byType[mediaType]
+}
+
+// Extract reads the file and returns its text, cut to the text limit
+func (e *Extractors) Extract(ctx context.Context, fileName, contentType string, r io.Reader) (*Result, error) {
+	extractor := e.Find(fileName, contentType)
+	if extractor == nil {
+		return nil, ErrUnsupported
+	}
+	data, err := io.ReadAll(io.LimitReader(r, e.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > e.maxSize {
+		return nil, ErrTooLarge
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	type outcome struct {
+		result *Result
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			// malformed files must not take the worker down
+			if p := recover(); p != nil {
+				done <- outcome{err: fmt.Errorf("extract: %s: %v", fileName, p)}
+			}
+		}()
+		result, err := extractor.Extract(ctx, data, e.maxText)
+		done <- outcome{result, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case o := <-done:
+		if o.err != nil {
+			return nil, o.err
+		}
+		o.result.Text = normalize(o.result.Text, e.maxText)
+		return o.result, nil
+	}
+}
+
+// normalize makes the text valid UTF-8 without NUL bytes, which postgres
+// rejects, collapses blank runs, keeps page breaks and cuts the text to limit
+// bytes without splitting a character
+func normalize(text string, limit int) string {
+	text = strings.ReplaceAll(strings.ToValidUTF8(text, ""), "\x00", "")
+	var b strings.Builder
+	for i, page := range strings.Split(text, PageBreak) {
+		if i > 0 {
+			b.WriteString(PageBreak)
+		}
+		blank := 0
+		for _, line := range strings.Split(page, "\n") {
+			line = strings.Join(strings.Fields(line), " ")
+			if line == "" {
+				blank++
+				continue
+			}
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), PageBreak) {
+				if blank > 0 {
+					b.WriteString("\n\n")
+				} else {
+					b.WriteString("\n")
+				}
+			}
+			blank = 0
+			b.WriteString(line)
+		}
+	}
+	return truncate(b.String(), limit)
+}
+
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	text = text[:limit]
+	// drop the bytes of a character cut in half
+	for len(text) > 0 {
+		r, size := utf8.DecodeLastRuneInString(text)
+		if r != utf8.RuneError || size > 1 {
+			break
+		}
+		text = text[:len(text)-1]
+	}
+	return text
+}
+
+// textBuilder collects text up to a limit so extractors can stop early
+type textBuilder struct {
+	strings.Builder
+	limit int
+}
+
+func newTextBuilder(limit int) *textBuilder {
+	return &textBuilder{limit: limit}
+}
+
+// Full reports whether the limit was reached
+func (b *textBuilder) Full() bool {
+	return b.Len() >= b.limit
+}
+
+// newline ends the current line unless the text is empty or already ends one
+func (b *textBuilder) newline() {
+	s := b.String()
+	if s != "" && !strings.HasSuffix(s, "\n") && !strings.HasSuffix(s, PageBreak) {
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,68 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlSkipped elements hold no readable text
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+}
+
+// htmlBlocks end a line of text
+var htmlBlocks = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Br: true, atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Footer: true, atom.Form: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Td: true, atom.Th: true, atom.Title: true, atom.Tr: true, atom.Ul: true,
+}
+
+// extractHTML reads the text of an HTML page without its scripts and styles
+func extractHTML(ctx context.Context, data []byte, limit int) (*Result, error) {
+	b := newTextBuilder(limit)
+	z := html.NewTokenizer(bytes.NewReader(data))
+	skipped := 0
+	for !b.Full() {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return &Result{Text: b.String()}, nil
+			}
+			return nil, z.Err()
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if htmlSkipped[a] && tt != html.SelfClosingTagToken {
+				if tt == html.StartTagToken {
+					skipped++
+				} else if skipped > 0 {
+					skipped--
+				}
+			}
+			if htmlBlocks[a] {
+				b.newline()
+			}
+		case html.TextToken:
+			if skipped == 0 {
+				b.Write(z.Text())
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return &Result{Text: b.String()}, nil
+}
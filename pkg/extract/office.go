@@ -0,0 +1,381 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// zipEntryMaxSize bounds the bytes decompressed from one archive entry, a
+// small upload can hide a huge entry
+const zipEntryMaxSize = 64 << 20
+
+// errStop ends walkXML early without an error
+var errStop = errors.New("stop")
+
+func openZip(data []byte) (*zip.Reader, error) {
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+func zipFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// walkXML calls fn with every token of the XML entry until fn returns an
+// error. A missing entry is not an error.
+func walkXML(ctx context.Context, f *zip.File, fn func(xml.Token) error) error {
+	if f == nil {
+		return nil
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	d := xml.NewDecoder(io.LimitReader(r, zipEntryMaxSize))
+	d.Strict = false
+	for i := 0; ; i++ {
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		t, err := d.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(t); err == errStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func xmlAttr(e xml.StartElement, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// extractDOCX reads the body of a Word document. The page count is the one
+// Word saved in the document properties.
+func extractDOCX(ctx context.Context, data []byte, limit int) (*Result, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	document := zipFile(zr, "word/document.xml")
+	if document == nil {
+		return nil, ErrUnsupported
+	}
+	b := newTextBuilder(limit)
+	inText := false
+	err = walkXML(ctx, document, func(t xml.Token) error {
+		switch t := t.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteString("\t")
+			case "br", "cr":
+				b.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+		if b.Full() {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	pages := 0
+	err = walkXML(ctx, zipFile(zr, "docProps/app.xml"), func(t xml.Token) error {
+		if e, ok := t.(xml.StartElement); ok && e.Name.Local == "Pages" {
+			pages = -1
+		} else if c, ok := t.(xml.CharData); ok && pages == -1 {
+			pages, _ = strconv.Atoi(strings.TrimSpace(string(c)))
+			return errStop
+		}
+		return nil
+	})
+	if err != nil || pages < 0 {
+		pages = 0
+	}
+	return &Result{Text: b.String(), Pages: pages}, nil
+}
+
+// extractXLSX reads the cells of every sheet of a workbook, one row per line.
+// Each sheet counts as a page and starts with its name.
+func extractXLSX(ctx context.Context, data []byte, limit int) (*Result, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := xlsxSharedStrings(ctx, zr)
+	if err != nil {
+		return nil, err
+	}
+	sheets, err := xlsxSheets(ctx, zr)
+	if err != nil {
+		return nil, err
+	}
+	if len(sheets) == 0 {
+		return nil, ErrUnsupported
+	}
+
+	b := newTextBuilder(limit)
+	for i, sheet := range sheets {
+		if i > 0 {
+			b.WriteString(PageBreak)
+		}
+		b.WriteString(sheet.name)
+		b.WriteString("\n")
+		var cellType, value string
+		inValue, inInline, cells := false, false, 0
+		err = walkXML(ctx, zipFile(zr, sheet.file), func(t xml.Token) error {
+			switch t := t.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "c":
+					cellType, value = xmlAttr(t, "t"), ""
+				case "v":
+					inValue = true
+				case "is":
+					inInline = true
+				case "t":
+					inValue = inInline
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "v", "t":
+					inValue = false
+				case "is":
+					inInline = false
+				case "c":
+					if cells > 0 {
+						b.WriteString("\t")
+					}
+					b.WriteString(xlsxCellText(cellType, value, shared))
+					cells++
+				case "row":
+					b.WriteString("\n")
+					cells = 0
+				}
+			case xml.CharData:
+				if inValue {
+					value += string(t)
+				}
+			}
+			if b.Full() {
+				return errStop
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if b.Full() {
+			break
+		}
+	}
+	return &Result{Text: b.String(), Pages: len(sheets)}, nil
+}
+
+func xlsxCellText(cellType, value string, shared []string) string {
+	switch cellType {
+	case "s":
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return value
+}
+
+// xlsxSharedStrings reads the string table cells refer to by index. Phonetic
+// runs are left out.
+func xlsxSharedStrings(ctx context.Context, zr *zip.Reader) ([]string, error) {
+	shared := []string{}
+	var current strings.Builder
+	inText, phonetic := false, false
+	err := walkXML(ctx, zipFile(zr, "xl/sharedStrings.xml"), func(t xml.Token) error {
+		switch t := t.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				current.Reset()
+			case "t":
+				inText = !phonetic
+			case "rPh":
+				phonetic = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				shared = append(shared, current.String())
+			case "t":
+				inText = false
+			case "rPh":
+				phonetic = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+		return nil
+	})
+	return shared, err
+}
+
+type xlsxSheet struct {
+	name string
+	file string
+}
+
+// xlsxSheets lists the sheets in workbook order with the archive entry of
+// each
+func xlsxSheets(ctx context.Context, zr *zip.Reader) ([]xlsxSheet, error) {
+	targets := map[string]string{}
+	err := walkXML(ctx, zipFile(zr, "xl/_rels/workbook.xml.rels"), func(t xml.Token) error {
+		if e, ok := t.(xml.StartElement); ok && e.Name.Local == "Relationship" {
+			target := xmlAttr(e, "Target")
+			if strings.HasPrefix(target, "/") {
+				target = strings.TrimPrefix(target, "/")
+			} else {
+				target = path.Join("xl", target)
+			}
+			targets[xmlAttr(e, "Id")] = target
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sheets := []xlsxSheet{}
+	err = walkXML(ctx, zipFile(zr, "xl/workbook.xml"), func(t xml.Token) error {
+		if e, ok := t.(xml.StartElement); ok && e.Name.Local == "sheet" {
+			if file, ok := targets[xmlAttr(e, "id")]; ok {
+				sheets = append(sheets, xlsxSheet{name: xmlAttr(e, "name"), file: file})
+			}
+		}
+		return nil
+	})
+	if err != nil || len(sheets) > 0 {
+		return sheets, err
+	}
+	// workbooks without relationships, read the worksheets in name order
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "xl/worksheets/") && strings.HasSuffix(f.Name, ".xml") {
+			name := strings.TrimSuffix(path.Base(f.Name), ".xml")
+			sheets = append(sheets, xlsxSheet{name: name, file: f.Name})
+		}
+	}
+	sort.Slice(sheets, func(i, j int) bool { return sheets[i].file < sheets[j].file })
+	return sheets, nil
+}
+
+// extractODT reads the body of an OpenDocument text. The page count is the
+// one saved in the document statistics.
+func extractODT(ctx context.Context, data []byte, limit int) (*Result, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	content := zipFile(zr, "content.xml")
+	if content == nil {
+		return nil, ErrUnsupported
+	}
+	b := newTextBuilder(limit)
+	inBody, annotation := false, 0
+	err = walkXML(ctx, content, func(t xml.Token) error {
+		switch t := t.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "body":
+				inBody = true
+			case "annotation":
+				annotation++
+			case "s":
+				n, err := strconv.Atoi(xmlAttr(t, "c"))
+				if err != nil || n < 1 {
+					n = 1
+				}
+				b.WriteString(strings.Repeat(" ", min(n, 100)))
+			case "tab":
+				b.WriteString("\t")
+			case "line-break":
+				b.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "body":
+				inBody = false
+			case "annotation":
+				annotation--
+			case "p", "h":
+				b.WriteString("\n")
+			}
+		case xml.CharData:
+			if inBody && annotation == 0 {
+				b.Write(t)
+			}
+		}
+		if b.Full() {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	pages := 0
+	err = walkXML(ctx, zipFile(zr, "meta.xml"), func(t xml.Token) error {
+		if e, ok := t.(xml.StartElement); ok && e.Name.Local == "document-statistic" {
+			pages, _ = strconv.Atoi(xmlAttr(e, "page-count"))
+			return errStop
+		}
+		return nil
+	})
+	if err != nil {
+		pages = 0
+	}
+	return &Result{Text: b.String(), Pages: pages}, nil
+}
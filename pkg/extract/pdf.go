@@ -0,0 +1,1007 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+)
+
+// The PDF reader below is only as complete as text extraction needs: it finds
+// objects by scanning the file instead of reading the cross reference table,
+// which also copes with damaged files, walks the page tree and decodes the
+// text showing operators of each page with the ToUnicode map of the font.
+
+const (
+	// pdfStreamMaxSize bounds the bytes decompressed from one stream
+	pdfStreamMaxSize = 64 << 20
+	// pdfDecodedMaxSize bounds the bytes decompressed from all the streams
+	// of a file
+	pdfDecodedMaxSize = 256 << 20
+	// pdfMaxDepth bounds nesting of page trees and form XObjects
+	pdfMaxDepth = 32
+	// pdfMaxNesting bounds nesting of arrays and dictionaries
+	pdfMaxNesting = 64
+	// pdfMaxOperators bounds the content stream operators run for a file and
+	// pdfMaxForms the form XObjects, forms calling forms fan out
+	pdfMaxOperators = 10_000_000
+	pdfMaxForms     = 10_000
+	// pdfCheckEvery is how many operators run between checks of the context
+	pdfCheckEvery = 4096
+)
+
+var (
+	errPDFEncrypted = errors.New("extract: encrypted pdf")
+	errPDFNoPages   = errors.New("extract: pdf without pages")
+	errPDFNesting   = errors.New("extract: pdf objects nested too deep")
+	errPDFTooLarge  = errors.New("extract: pdf content too large")
+)
+
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfString  []byte
+	pdfArray   []interface{}
+	pdfDict    map[pdfName]interface{}
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		raw  []byte
+	}
+)
+
+// pdfLexer reads PDF objects and content stream operators
+type pdfLexer struct {
+	data  []byte
+	pos   int
+	depth int // of the arrays and dictionaries being read
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		} else if isPDFSpace(c) {
+			l.pos++
+		} else {
+			return
+		}
+	}
+}
+
+// regular reads a run of regular characters
+func (l *pdfLexer) regular() []byte {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return l.data[start:l.pos]
+}
+
+// next returns the next object, or io.EOF. Dictionaries and arrays are read
+// whole, the end delimiters are returned as keywords.
+func (l *pdfLexer) next() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		return pdfName(pdfUnescapeName(l.regular())), nil
+	case c == '(':
+		return l.literalString(), nil
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return l.dict()
+	case c == '<':
+		return l.hexString(), nil
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return pdfKeyword(">>"), nil
+	case c == '[':
+		l.pos++
+		return l.array()
+	case c == ']' || c == '{' || c == '}' || c == ')' || c == '>':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	}
+	token := l.regular()
+	if len(token) == 0 {
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	}
+	if n, err := strconv.ParseFloat(string(token), 64); err == nil {
+		return n, nil
+	}
+	switch string(token) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return pdfKeyword(token), nil
+}
+
+// object reads an object where references "n g R" are allowed
+func (l *pdfLexer) object() (interface{}, error) {
+	v, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	num, ok := v.(float64)
+	if !ok {
+		return v, nil
+	}
+	// look ahead for a reference
+	save := l.pos
+	if gen, err := l.next(); err == nil {
+		if g, ok := gen.(float64); ok {
+			if r, err := l.next(); err == nil && r == pdfKeyword("R") {
+				return pdfRef{int(num), int(g)}, nil
+			}
+		}
+	}
+	l.pos = save
+	return num, nil
+}
+
+func (l *pdfLexer) dict() (pdfDict, error) {
+	if l.depth >= pdfMaxNesting {
+		return nil, errPDFNesting
+	}
+	l.depth++
+	defer func() { l.depth-- }()
+	d := pdfDict{}
+	for {
+		k, err := l.next()
+		if err != nil {
+			return d, err
+		}
+		if k == pdfKeyword(">>") {
+			return d, nil
+		}
+		name, ok := k.(pdfName)
+		if !ok {
+			continue
+		}
+		v, err := l.object()
+		if err != nil {
+			return d, err
+		}
+		if v == pdfKeyword(">>") {
+			return d, nil
+		}
+		d[name] = v
+	}
+}
+
+func (l *pdfLexer) array() (pdfArray, error) {
+	if l.depth >= pdfMaxNesting {
+		return nil, errPDFNesting
+	}
+	l.depth++
+	defer func() { l.depth-- }()
+	a := pdfArray{}
+	for {
+		v, err := l.object()
+		if err != nil {
+			return a, err
+		}
+		if v == pdfKeyword("]") {
+			return a, nil
+		}
+		a = append(a, v)
+	}
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	l.pos++ // (
+	var s []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return s
+			}
+			c = l.data[l.pos]
+			l.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					n := int(c - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(n)
+				}
+			}
+		}
+		s = append(s, c)
+	}
+	return s
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	s, _ := hex.DecodeString(string(digits))
+	return s
+}
+
+func pdfUnescapeName(b []byte) string {
+	if bytes.IndexByte(b, '#') < 0 {
+		return string(b)
+	}
+	var out []byte
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) {
+			if v, err := strconv.ParseUint(string(b[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
+
+// pdfFile indexes the objects of a document
+type pdfFile struct {
+	data    []byte
+	objects map[int]interface{}
+	streams map[*pdfStream][]byte // decoded stream data
+	fonts   map[pdfRef]*pdfFont
+	// decoded is what is left of pdfDecodedMaxSize, tooLarge is set once
+	// a stream did not fit
+	decoded  int64
+	tooLarge bool
+}
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+func parsePDF(ctx context.Context, data []byte) (*pdfFile, error) {
+	f := &pdfFile{data: data, objects: map[int]interface{}{}, streams: map[*pdfStream][]byte{}, fonts: map[pdfRef]*pdfFont{}, decoded: pdfDecodedMaxSize}
+	for i, loc := range pdfObjectHeader.FindAllSubmatchIndex(data, -1) {
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		num, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		// later objects win, they come from incremental updates
+		f.objects[num] = f.parseObject(loc[1])
+	}
+	// objects compressed into object streams never replace direct objects
+	for _, obj := range f.objects {
+		s, ok := obj.(*pdfStream)
+		if !ok || s.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		f.readObjectStream(s)
+	}
+	if f.encrypted() {
+		return nil, errPDFEncrypted
+	}
+	return f, nil
+}
+
+// encrypted looks for an Encrypt entry in the trailers and cross reference
+// streams
+func (f *pdfFile) encrypted() bool {
+	if !bytes.Contains(f.data, []byte("/Encrypt")) {
+		return false
+	}
+	for _, obj := range f.objects {
+		if s, ok := obj.(*pdfStream); ok && s.dict["Type"] == pdfName("XRef") && s.dict["Encrypt"] != nil {
+			return true
+		}
+	}
+	for offset := 0; ; {
+		i := bytes.Index(f.data[offset:], []byte("trailer"))
+		if i < 0 {
+			return false
+		}
+		offset += i + len("trailer")
+		if d, ok := f.parseObject(offset).(pdfDict); ok && d["Encrypt"] != nil {
+			return true
+		}
+	}
+}
+
+// parseObject reads the object starting at offset, with its stream
+func (f *pdfFile) parseObject(offset int) interface{} {
+	l := &pdfLexer{data: f.data, pos: offset}
+	obj, err := l.object()
+	if err != nil {
+		return nil
+	}
+	d, ok := obj.(pdfDict)
+	if !ok {
+		return obj
+	}
+	l.skipSpace()
+	if !bytes.HasPrefix(f.data[l.pos:], []byte("stream")) {
+		return d
+	}
+	start := l.pos + len("stream")
+	if start < len(f.data) && f.data[start] == '\r' {
+		start++
+	}
+	if start < len(f.data) && f.data[start] == '\n' {
+		start++
+	}
+	end := -1
+	if n, ok := d["Length"].(float64); ok {
+		if e := start + int(n); e >= start && e <= len(f.data) && bytes.Contains(f.data[e:min(e+32, len(f.data))], []byte("endstream")) {
+			end = e
+		}
+	}
+	if end < 0 {
+		// indirect or wrong length, the stream ends before endstream
+		i := bytes.Index(f.data[start:], []byte("endstream"))
+		if i < 0 {
+			return d
+		}
+		end = start + i
+		for end > start && (f.data[end-1] == '\n' || f.data[end-1] == '\r') {
+			end--
+		}
+	}
+	return &pdfStream{dict: d, raw: f.data[start:end]}
+}
+
+func (f *pdfFile) readObjectStream(s *pdfStream) {
+	data := f.decode(s)
+	n, _ := f.resolve(s.dict["N"]).(float64)
+	first, _ := f.resolve(s.dict["First"]).(float64)
+	if data == nil || int(first) > len(data) {
+		return
+	}
+	l := &pdfLexer{data: data[:int(first)]}
+	for i := 0; i < int(n); i++ {
+		num, err1 := l.next()
+		off, err2 := l.next()
+		if err1 != nil || err2 != nil {
+			return
+		}
+		objNum, ok1 := num.(float64)
+		objOff, ok2 := off.(float64)
+		if !ok1 || !ok2 {
+			return
+		}
+		if _, ok := f.objects[int(objNum)]; ok {
+			continue
+		}
+		pos := int(first) + int(objOff)
+		if pos < 0 || pos >= len(data) {
+			continue
+		}
+		obj, err := (&pdfLexer{data: data, pos: pos}).object()
+		if err == nil {
+			f.objects[int(objNum)] = obj
+		}
+	}
+}
+
+func (f *pdfFile) resolve(v interface{}) interface{} {
+	for i := 0; i < pdfMaxDepth; i++ {
+		r, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = f.objects[r.num]
+	}
+	return nil
+}
+
+func (f *pdfFile) dict(v interface{}) pdfDict {
+	switch v := f.resolve(v).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+// decode returns the data of the stream with its filters applied, or nil when
+// a filter is not supported
+func (f *pdfFile) decode(s *pdfStream) []byte {
+	if data, ok := f.streams[s]; ok {
+		return data
+	}
+	data := s.raw
+	var filters []interface{}
+	switch v := f.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []interface{}{v}
+	case pdfArray:
+		filters = v
+	}
+	for _, filter := range filters {
+		var err error
+		switch f.resolve(filter) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data, err = pdfInflate(data, min(pdfStreamMaxSize, f.decoded+1))
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			data, err = pdfASCIIHex(data)
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data, err = pdfASCII85(data)
+		default:
+			data, err = nil, ErrUnsupported
+		}
+		if err != nil {
+			data = nil
+			break
+		}
+	}
+	if int64(len(data)) > f.decoded {
+		f.tooLarge = true
+		data = nil
+	}
+	f.decoded -= int64(len(data))
+	f.streams[s] = data
+	return data
+}
+
+// pdfInflate keeps what could be inflated from truncated streams
+func pdfInflate(data []byte, limit int64) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	out, err := io.ReadAll(io.LimitReader(r, limit))
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func pdfASCIIHex(data []byte) ([]byte, error) {
+	if i := bytes.IndexByte(data, '>'); i >= 0 {
+		data = data[:i]
+	}
+	// data is part of the file, appending to it would overwrite what follows
+	wrapped := make([]byte, 0, len(data)+2)
+	wrapped = append(append(append(wrapped, '<'), data...), '>')
+	return (&pdfLexer{data: wrapped}).hexString(), nil
+}
+
+func pdfASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// pages returns the page dictionaries in order with their inherited
+// resources
+func (f *pdfFile) pages() []pdfPage {
+	var catalog pdfDict
+	nums := make([]int, 0, len(f.objects))
+	for num := range f.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		if d, ok := f.objects[num].(pdfDict); ok && d["Type"] == pdfName("Catalog") {
+			catalog = d
+		}
+	}
+	pages := []pdfPage{}
+	if catalog != nil {
+		f.walkPages(catalog["Pages"], nil, map[int]bool{}, 0, &pages)
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+	// no usable page tree, take the page objects in object order
+	for _, num := range nums {
+		if d := f.dict(f.objects[num]); d != nil && d["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{dict: d, resources: f.dict(d["Resources"])})
+		}
+	}
+	return pages
+}
+
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+func (f *pdfFile) walkPages(node interface{}, resources pdfDict, seen map[int]bool, depth int, pages *[]pdfPage) {
+	if r, ok := node.(pdfRef); ok {
+		if seen[r.num] {
+			return
+		}
+		seen[r.num] = true
+	}
+	d := f.dict(node)
+	if d == nil || depth > pdfMaxDepth {
+		return
+	}
+	if res := f.dict(d["Resources"]); res != nil {
+		resources = res
+	}
+	if d["Type"] == pdfName("Page") || d["Kids"] == nil {
+		*pages = append(*pages, pdfPage{dict: d, resources: resources})
+		return
+	}
+	kids, _ := f.resolve(d["Kids"]).(pdfArray)
+	for _, kid := range kids {
+		f.walkPages(kid, resources, seen, depth+1, pages)
+	}
+}
+
+// contents returns the content streams of the page joined
+func (f *pdfFile) contents(page pdfDict) []byte {
+	var parts [][]byte
+	switch v := f.resolve(page["Contents"]).(type) {
+	case *pdfStream:
+		parts = append(parts, f.decode(v))
+	case pdfArray:
+		for _, c := range v {
+			if s, ok := f.resolve(c).(*pdfStream); ok {
+				parts = append(parts, f.decode(s))
+			}
+		}
+	}
+	return bytes.Join(parts, []byte("\n"))
+}
+
+// extractPDF reads the text of every page, pages are separated by PageBreak
+func extractPDF(ctx context.Context, data []byte, limit int) (*Result, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF")) {
+		return nil, ErrUnsupported
+	}
+	f, err := parsePDF(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	pages := f.pages()
+	if len(pages) == 0 {
+		return nil, errPDFNoPages
+	}
+	b := newTextBuilder(limit)
+	r := &pdfTextReader{ctx: ctx, file: f, out: b}
+	for i, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteString(PageBreak)
+		}
+		if !b.Full() {
+			r.y, r.lineY, r.shown, r.font = 0, 0, false, nil
+			if err := r.run(f.contents(page.dict), page.resources, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if f.tooLarge {
+		return nil, errPDFTooLarge
+	}
+	return &Result{Text: b.String(), Pages: len(pages)}, nil
+}
+
+// pdfFont decodes the strings shown with a font
+type pdfFont struct {
+	cmap     map[string][]rune
+	codeLens []int // code lengths of the cmap, longest first
+	twoByte  bool  // composite font without a cmap
+}
+
+func (f *pdfFile) font(d pdfDict) *pdfFont {
+	font := &pdfFont{twoByte: d["Subtype"] == pdfName("Type0")}
+	if s, ok := f.resolve(d["ToUnicode"]).(*pdfStream); ok {
+		font.cmap, font.codeLens = parseCMap(f.decode(s))
+	}
+	return font
+}
+
+func (font *pdfFont) decode(s []byte) string {
+	if len(font.cmap) > 0 {
+		var out []rune
+		for i := 0; i < len(s); {
+			matched := false
+			for _, n := range font.codeLens {
+				if i+n <= len(s) {
+					if r, ok := font.cmap[string(s[i:i+n])]; ok {
+						out = append(out, r...)
+						i += n
+						matched = true
+						break
+					}
+				}
+			}
+			if !matched {
+				if font.twoByte {
+					i += 2
+				} else {
+					out = append(out, pdfDocRune(s[i]))
+					i++
+				}
+			}
+		}
+		return string(out)
+	}
+	if font.twoByte {
+		// without a map glyph ids cannot be turned into text
+		return ""
+	}
+	out := make([]rune, 0, len(s))
+	for _, c := range s {
+		out = append(out, pdfDocRune(c))
+	}
+	return string(out)
+}
+
+// pdfWinAnsi maps the 0x80-0x9f range of WinAnsiEncoding, the usual encoding
+// of simple fonts; other bytes match Latin-1
+var pdfWinAnsi = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ', 0x8e: 'Ž', 0x91: '‘',
+	0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜',
+	0x99: '™', 0x9a: 'š', 0x9b: '›', 0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
+
+func pdfDocRune(c byte) rune {
+	if r, ok := pdfWinAnsi[c]; ok {
+		return r
+	}
+	return rune(c)
+}
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode map
+func parseCMap(data []byte) (map[string][]rune, []int) {
+	cmap := map[string][]rune{}
+	lens := map[int]bool{}
+	l := &pdfLexer{data: data}
+	var operands []interface{}
+	mode := ""
+	for {
+		v, err := l.object()
+		if err != nil {
+			break
+		}
+		k, ok := v.(pdfKeyword)
+		if !ok {
+			operands = append(operands, v)
+			continue
+		}
+		switch k {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			mode = string(k)
+			operands = operands[:0]
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				if lo, ok := operands[i].(pdfString); ok && len(lo) > 0 {
+					lens[len(lo)] = true
+				}
+			}
+			mode = ""
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					cmap[string(src)] = utf16BE(dst)
+					lens[len(src)] = true
+				}
+			}
+			mode = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 || len(lo) > 4 {
+					continue
+				}
+				lens[len(lo)] = true
+				start, end := pdfCode(lo), pdfCode(hi)
+				for code := start; code <= end && code-start < 0x10000; code++ {
+					src := pdfCodeBytes(code, len(lo))
+					switch dst := operands[i+2].(type) {
+					case pdfString:
+						runes := utf16BE(dst)
+						if len(runes) > 0 {
+							runes[len(runes)-1] += rune(code - start)
+						}
+						cmap[string(src)] = runes
+					case pdfArray:
+						if idx := int(code - start); idx < len(dst) {
+							if s, ok := dst[idx].(pdfString); ok {
+								cmap[string(src)] = utf16BE(s)
+							}
+						}
+					}
+				}
+			}
+			mode = ""
+		}
+		if mode == "" {
+			operands = operands[:0]
+		}
+	}
+	codeLens := make([]int, 0, len(lens))
+	for n := range lens {
+		codeLens = append(codeLens, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(codeLens)))
+	return cmap, codeLens
+}
+
+func pdfCode(b []byte) uint32 {
+	var code uint32
+	for _, c := range b {
+		code = code<<8 | uint32(c)
+	}
+	return code
+}
+
+func pdfCodeBytes(code uint32, n int) []byte {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(code)
+		code >>= 8
+	}
+	return b
+}
+
+func utf16BE(b []byte) []rune {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return utf16.Decode(units)
+}
+
+// pdfTextReader runs the text operators of a content stream
+type pdfTextReader struct {
+	ctx  context.Context
+	file *pdfFile
+	out  *textBuilder
+	font *pdfFont
+
+	y, lineY float64 // baseline of the text matrix and of the last shown text
+	shown    bool    // text was shown on the current page
+
+	operators, forms int // run so far for the file
+}
+
+// run shows the text of a content stream. It fails when the context is done
+// or the file runs too many operators, malformed content just ends the run.
+func (r *pdfTextReader) run(content []byte, resources pdfDict, depth int) error {
+	if depth > pdfMaxDepth {
+		return nil
+	}
+	l := &pdfLexer{data: content}
+	var operands []interface{}
+	for !r.out.Full() {
+		v, err := l.next()
+		if err == errPDFNesting {
+			return err
+		}
+		if err != nil {
+			return nil
+		}
+		op, ok := v.(pdfKeyword)
+		if !ok {
+			operands = append(operands, v)
+			continue
+		}
+		r.operators++
+		if r.operators > pdfMaxOperators {
+			return errPDFTooLarge
+		}
+		if r.operators%pdfCheckEvery == 0 {
+			if err := r.ctx.Err(); err != nil {
+				return err
+			}
+		}
+		switch op {
+		case "BT":
+			r.y = 0
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[0].(pdfName); ok {
+					r.font = r.lookupFont(resources, name)
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, _ := operands[0].(float64)
+				ty, _ := operands[1].(float64)
+				r.y += ty
+				if ty == 0 && tx > 0 {
+					r.space()
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				r.y, _ = operands[5].(float64)
+			}
+		case "T*":
+			r.y--
+		case "Tj":
+			r.show(operands)
+		case "'", "\"":
+			r.y--
+			r.show(operands)
+		case "TJ":
+			if len(operands) > 0 {
+				items, _ := operands[len(operands)-1].(pdfArray)
+				for _, item := range items {
+					switch item := item.(type) {
+					case pdfString:
+						r.show([]interface{}{item})
+					case float64:
+						// a large negative adjustment is a word gap
+						if item < -200 {
+							r.space()
+						}
+					}
+				}
+			}
+		case "Do":
+			if len(operands) > 0 {
+				if name, ok := operands[0].(pdfName); ok {
+					if err := r.form(resources, name, depth); err != nil {
+						return err
+					}
+				}
+			}
+		case "BI":
+			// inline image data is binary, skip to its end
+			if i := bytes.Index(content[l.pos:], []byte("EI")); i >= 0 {
+				l.pos += i + 2
+			} else {
+				return nil
+			}
+		}
+		operands = operands[:0]
+	}
+	return nil
+}
+
+func (r *pdfTextReader) show(operands []interface{}) {
+	if len(operands) == 0 {
+		return
+	}
+	s, ok := operands[len(operands)-1].(pdfString)
+	if !ok {
+		return
+	}
+	font := r.font
+	if font == nil {
+		font = &pdfFont{}
+	}
+	text := font.decode(s)
+	if text == "" {
+		return
+	}
+	if r.shown && r.y != r.lineY {
+		r.out.newline()
+	}
+	r.shown = true
+	r.lineY = r.y
+	r.out.WriteString(text)
+}
+
+func (r *pdfTextReader) space() {
+	s := r.out.String()
+	if s != "" && s[len(s)-1] != ' ' && s[len(s)-1] != '\n' {
+		r.out.WriteString(" ")
+	}
+}
+
+func (r *pdfTextReader) lookupFont(resources pdfDict, name pdfName) *pdfFont {
+	fonts := r.file.dict(resources["Font"])
+	if fonts == nil {
+		return nil
+	}
+	ref, isRef := fonts[name].(pdfRef)
+	if font, ok := r.file.fonts[ref]; isRef && ok {
+		return font
+	}
+	var font *pdfFont
+	if d := r.file.dict(fonts[name]); d != nil {
+		font = r.file.font(d)
+	}
+	if isRef {
+		r.file.fonts[ref] = font
+	}
+	return font
+}
+
+// form runs the content of a form XObject, which may show text
+func (r *pdfTextReader) form(resources pdfDict, name pdfName, depth int) error {
+	xobjects := r.file.dict(resources["XObject"])
+	if xobjects == nil {
+		return nil
+	}
+	s, ok := r.file.resolve(xobjects[name]).(*pdfStream)
+	if !ok || s.dict["Subtype"] != pdfName("Form") {
+		return nil
+	}
+	r.forms++
+	if r.forms > pdfMaxForms {
+		return errPDFTooLarge
+	}
+	formResources := r.file.dict(s.dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+	font := r.font
+	err := r.run(r.file.decode(s), formResources, depth+1)
+	r.font = font
+	return err
+}
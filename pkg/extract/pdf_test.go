@@ -0,0 +1,109 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildPDF returns a file with a single page of content, objects are the
+// extra objects numbered from 4
+func buildPDF(content string, resources string, objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	b.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	fmt.Fprintf(&b, "3 0 obj << /Type /Page /Parent 2 0 R /Resources %s /Contents 4 0 R >> endobj\n", resources)
+	fmt.Fprintf(&b, "4 0 obj << /Length %d >> stream\n%s\nendstream endobj\n", len(content), content)
+	for i, obj := range objects {
+		fmt.Fprintf(&b, "%d 0 obj %s endobj\n", i+5, obj)
+	}
+	b.WriteString("%%EOF\n")
+	return b.Bytes()
+}
+
+func TestExtractPDF(t *testing.T) {
+	data := buildPDF("BT (Hello) Tj 0 -12 Td (world) Tj ET", "<< >>")
+	result, err := extractPDF(context.Background(), data, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text != "Hello\nworld" || result.Pages != 1 {
+		t.Errorf("got %q, %d pages", result.Text, result.Pages)
+	}
+}
+
+func TestPDFLexerNesting(t *testing.T) {
+	tests := []struct {
+		data string
+		err  error
+	}{
+		{strings.Repeat("[", pdfMaxNesting) + strings.Repeat("]", pdfMaxNesting), nil},
+		{strings.Repeat("[", pdfMaxNesting+1) + strings.Repeat("]", pdfMaxNesting+1), errPDFNesting},
+		{strings.Repeat("<< /A ", pdfMaxNesting+1) + strings.Repeat(">>", pdfMaxNesting+1), errPDFNesting},
+		// deep enough to overflow the stack without a limit
+		{strings.Repeat("[", 10_000_000), errPDFNesting},
+	}
+	for i, tt := range tests {
+		if _, err := (&pdfLexer{data: []byte(tt.data)}).object(); err != tt.err {
+			t.Errorf("%d: expected %v, got %v", i, tt.err, err)
+		}
+	}
+}
+
+func TestExtractPDFNestedContent(t *testing.T) {
+	data := buildPDF(strings.Repeat("[", 100_000)+" TJ", "<< >>")
+	if _, err := extractPDF(context.Background(), data, 1024); err != errPDFNesting {
+		t.Errorf("expected errPDFNesting, got %v", err)
+	}
+}
+
+func TestExtractPDFFormFanOut(t *testing.T) {
+	// the form draws itself twice, 2^pdfMaxDepth calls without a limit
+	form := "<< /Type /XObject /Subtype /Form /Length 15 >> stream\n/X Do /X Do BT\nendstream"
+	data := buildPDF("/X Do", "<< /XObject << /X 5 0 R >> >>", form)
+	start := time.Now()
+	_, err := extractPDF(context.Background(), data, 1024)
+	if err != errPDFTooLarge {
+		t.Errorf("expected errPDFTooLarge, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s", elapsed)
+	}
+}
+
+func TestPDFTextReaderContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := &pdfTextReader{ctx: ctx, file: &pdfFile{}, out: newTextBuilder(1024)}
+	content := []byte(strings.Repeat("q Q ", pdfCheckEvery))
+	if err := r.run(content, nil, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestPDFDecodedMaxSize(t *testing.T) {
+	f := &pdfFile{streams: map[*pdfStream][]byte{}, decoded: 4}
+	s := &pdfStream{dict: pdfDict{"Filter": pdfName("AHx")}, raw: []byte("4142434445>")}
+	if data := f.decode(s); data != nil || !f.tooLarge {
+		t.Errorf("expected the stream to be dropped, got %q", data)
+	}
+}
+
+func TestPDFASCIIHexKeepsInput(t *testing.T) {
+	buf := []byte("414243 endstream")
+	out, err := pdfASCIIHex(buf[:6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "ABC" {
+		t.Errorf("got %q", out)
+	}
+	if string(buf) != "414243 endstream" {
+		t.Errorf("input overwritten: %q", buf)
+	}
+}
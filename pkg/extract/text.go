@@ -0,0 +1,75 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+// extractText reads plain text in UTF-8, or UTF-16 when it starts with a byte
+// order mark
+func extractText(ctx context.Context, data []byte, limit int) (*Result, error) {
+	return &Result{Text: truncate(decodeText(data), limit)}, nil
+}
+
+func decodeText(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		return string(data[3:])
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return decodeUTF16(data[2:], true)
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return decodeUTF16(data[2:], false)
+	}
+	return string(data)
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+var (
+	markdownFence    = regexp.MustCompile("^\\s*(```|~~~)")
+	markdownRule     = regexp.MustCompile(`^\s*([-*_]\s*){3,}$`)
+	markdownPrefix   = regexp.MustCompile(`^\s*(#{1,6}\s+|>\s*|[-*+]\s+(\[[ xX]\]\s+)?|\d+[.)]\s+)+`)
+	markdownImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownRefLink  = regexp.MustCompile(`\[([^\]]*)\]\[[^\]]*\]`)
+	markdownLinkDef  = regexp.MustCompile(`^\s*\[[^\]]+\]:\s+\S+`)
+	markdownEmphasis = regexp.MustCompile("(\\*{1,3}|_{2,3}|~~|`+)")
+	markdownTable    = regexp.MustCompile(`^\s*\|?(\s*:?-+:?\s*\|)+\s*:?-*:?\s*$`)
+)
+
+// extractMarkdown reads Markdown as text, keeping link and image texts and
+// code but dropping the markup
+func extractMarkdown(ctx context.Context, data []byte, limit int) (*Result, error) {
+	b := newTextBuilder(limit)
+	for _, line := range strings.Split(decodeText(data), "\n") {
+		if b.Full() {
+			break
+		}
+		if markdownFence.MatchString(line) || markdownRule.MatchString(line) ||
+			markdownLinkDef.MatchString(line) || markdownTable.MatchString(line) {
+			continue
+		}
+		line = markdownPrefix.ReplaceAllString(line, "")
+		line = markdownImage.ReplaceAllString(line, "$1")
+		line = markdownLink.ReplaceAllString(line, "$1")
+		line = markdownRefLink.ReplaceAllString(line, "$1")
+		line = markdownEmphasis.ReplaceAllString(line, "")
+		line = strings.ReplaceAll(line, "|", " ")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return &Result{Text: b.String()}, nil
+}
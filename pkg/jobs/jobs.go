@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+)
+
+var (
+	ErrUnknownType = errors.New("no handler registered for job type")
+	ErrTimedOut    = errors.New("job exceeded its visibility timeout")
+	ErrJobLost     = errors.New("job was redelivered to another worker")
+)
+
+// Job is a unit of background work. Payload is the JSON encoded payload of
+// its Type, Attempts counts the failed runs so far.
+type Job struct {
+	Id         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"attempts"`
+	LastError  string          `json:"last_error,omitempty"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	RunAt      time.Time       `json:"run_at"`
+
+	// receipt identifies the delivery of the job to the queue
+	receipt string
+}
+
+// Queue stores jobs until a worker runs them. A fetched job is invisible to
+// other workers until it is acked, retried or dead lettered, or until the
+// worker stops extending it for the visibility timeout.
+type Queue interface {
+	// Enqueue adds a job that runs once RunAt has passed
+	Enqueue(ctx context.Context, job *Job) error
+	// Fetch returns up to count jobs for consumer, waiting a moment when
+	// there are none
+	Fetch(ctx context.Context, consumer string, count int) ([]*Job, error)
+	// Extend restarts the visibility timeout of a fetched job, it fails with
+	// ErrJobLost once the job timed out and was fetched again
+	Extend(ctx context.Context, consumer string, job *Job) error
+	// Ack removes a finished job
+	Ack(ctx context.Context, job *Job) error
+	// Retry counts a failed attempt and runs the job again at at
+	Retry(ctx context.Context, job *Job, at time.Time) error
+	// Dead moves a job that will not be retried to the dead letter queue
+	Dead(ctx context.Context, job *Job) error
+}
+
+// NewQueue returns a RedisQueue on a redis cache and a MemoryQueue otherwise
+func NewQueue(c cache.Cache, cfg *config.Config) (Queue, error) {
+	if r, ok := c.(*cache.Redis); ok {
+		return NewRedisQueue(r, cfg)
+	}
+	return NewMemoryQueue(cfg), nil
+}
+
+// Type names a kind of job and the payload it carries. Declare it once and use
+// it both to enqueue and to register the handler.
+type Type[T any] struct {
+	Name string
+}
+
+func NewType[T any](name string) Type[T] {
+	return Type[T]{Name: name}
+}
+
+// EnqueueOption configures an enqueued job
+type EnqueueOption func(*Job)
+
+// WithDelay runs the job after delay
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = time.Now().Add(delay)
+	}
+}
+
+// WithRunAt runs the job at t
+func WithRunAt(t time.Time) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = t
+	}
+}
+
+// Enqueue adds a job of type t with payload to queue
+func (t Type[T]) Enqueue(ctx context.Context, queue Queue, payload T, options ...EnqueueOption) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job := &Job{
+		Id:         crypto.GenerateId("job", models.IdSize),
+		Type:       t.Name,
+		Payload:    data,
+		EnqueuedAt: now,
+		RunAt:      now,
+	}
+	for _, o := range options {
+		o(job)
+	}
+	return job, queue.Enqueue(ctx, job)
+}
+
+// permanentError fails a job without retrying it
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err so the job is dead lettered instead of retried, for
+// errors that another attempt will not fix
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,181 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+const (
+	// MemoryDeadMaxLen caps the dead letter queue, the oldest jobs are dropped
+	MemoryDeadMaxLen = 1000
+	// memoryFetchWait is how long Fetch waits for new jobs
+	memoryFetchWait = time.Second
+)
+
+// MemoryQueue is a Queue kept in process, for running without redis. Jobs are
+// lost when the process stops and only the workers of the process see them.
+type MemoryQueue struct {
+	mu         sync.Mutex
+	ready      []*Job
+	delayed    []*Job
+	fetched    map[string]*memoryDelivery
+	dead       []*Job
+	deliveries int64
+	visibility time.Duration
+	// notify wakes a waiting Fetch when a job is ready
+	notify chan struct{}
+}
+
+// memoryDelivery is a fetched job, it is delivered again once deadline passed
+type memoryDelivery struct {
+	job      *Job
+	deadline time.Time
+}
+
+func NewMemoryQueue(cfg *config.Config) *MemoryQueue {
+	return &MemoryQueue{
+		fetched:    map[string]*memoryDelivery{},
+		visibility: cfg.JobVisibilityTimeout,
+		notify:     make(chan struct{}, 1),
+	}
+}
+
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.add(copyJob(job))
+	return nil
+}
+
+// add queues job by its RunAt, q.mu must be held
+func (q *MemoryQueue) add(job *Job) {
+	if job.RunAt.After(time.Now()) {
+		i := sort.Search(len(q.delayed), func(i int) bool { return q.delayed[i].RunAt.After(job.RunAt) })
+		q.delayed = append(q.delayed, nil)
+		copy(q.delayed[i+1:], q.delayed[i:])
+		q.delayed[i] = job
+		return
+	}
+	q.ready = append(q.ready, job)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Fetch promotes due delayed jobs, then takes back jobs whose visibility
+// timeout passed before the new ones
+func (q *MemoryQueue) Fetch(ctx context.Context, consumer string, count int) ([]*Job, error) {
+	timer := time.NewTimer(memoryFetchWait)
+	defer timer.Stop()
+	for {
+		if jobs := q.take(count); len(jobs) > 0 {
+			return jobs, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-timer.C:
+			return nil, nil
+		case <-q.notify:
+		}
+	}
+}
+
+func (q *MemoryQueue) take(count int) []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	due := 0
+	for due < len(q.delayed) && !q.delayed[due].RunAt.After(now) {
+		due++
+	}
+	q.ready = append(q.ready, q.delayed[:due]...)
+	q.delayed = q.delayed[due:]
+
+	jobs := make([]*Job, 0, count)
+	for receipt, d := range q.fetched {
+		if len(jobs) == count {
+			break
+		}
+		if now.Before(d.deadline) {
+			continue
+		}
+		delete(q.fetched, receipt)
+		// the timed out delivery counts as an attempt
+		d.job.Attempts++
+		d.job.LastError = ErrTimedOut.Error()
+		jobs = append(jobs, q.deliver(d.job, now))
+	}
+	for len(jobs) < count && len(q.ready) > 0 {
+		job := q.ready[0]
+		q.ready[0] = nil
+		q.ready = q.ready[1:]
+		jobs = append(jobs, q.deliver(job, now))
+	}
+	return jobs
+}
+
+// deliver records job as fetched and returns the copy handed to the worker,
+// q.mu must be held
+func (q *MemoryQueue) deliver(job *Job, now time.Time) *Job {
+	q.deliveries++
+	job.receipt = strconv.FormatInt(q.deliveries, 10)
+	q.fetched[job.receipt] = &memoryDelivery{job: job, deadline: now.Add(q.visibility)}
+	// the worker acks, extends and retries the delivery by its receipt
+	delivered := copyJob(job)
+	delivered.receipt = job.receipt
+	return delivered
+}
+
+// Extend restarts the visibility timeout of job
+func (q *MemoryQueue) Extend(ctx context.Context, consumer string, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	d, ok := q.fetched[job.receipt]
+	if !ok {
+		return ErrJobLost
+	}
+	d.deadline = time.Now().Add(q.visibility)
+	return nil
+}
+
+func (q *MemoryQueue) Ack(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.fetched, job.receipt)
+	return nil
+}
+
+func (q *MemoryQueue) Retry(ctx context.Context, job *Job, at time.Time) error {
+	job.Attempts++
+	job.RunAt = at
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.fetched, job.receipt)
+	q.add(copyJob(job))
+	return nil
+}
+
+func (q *MemoryQueue) Dead(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.fetched, job.receipt)
+	q.dead = append(q.dead, copyJob(job))
+	if len(q.dead) > MemoryDeadMaxLen {
+		q.dead = q.dead[len(q.dead)-MemoryDeadMaxLen:]
+	}
+	return nil
+}
+
+// copyJob keeps the queued job apart from the one a worker updates
+func copyJob(job *Job) *Job {
+	c := *job
+	c.receipt = ""
+	return &c
+}
@@ -0,0 +1,119 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+const testVisibility = 50 * time.Millisecond
+
+// fetchNow fetches without waiting for new jobs
+func fetchNow(t *testing.T, q *MemoryQueue) []*Job {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	jobs, err := q.Fetch(ctx, "test", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobs
+}
+
+func TestMemoryQueueVisibility(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// after runs on the fetched job, the queue is fetched again wait
+		// later
+		after     func(q *MemoryQueue, job *Job)
+		wait      time.Duration
+		redeliver bool
+	}{
+		{"timed out", func(q *MemoryQueue, job *Job) {}, testVisibility * 3 / 2, true},
+		{"acked", func(q *MemoryQueue, job *Job) { q.Ack(ctx, job) }, testVisibility * 3 / 2, false},
+		{"dead", func(q *MemoryQueue, job *Job) { q.Dead(ctx, job) }, testVisibility * 3 / 2, false},
+		{"extended", func(q *MemoryQueue, job *Job) {
+			// the job stays invisible past its first timeout
+			time.Sleep(testVisibility * 3 / 5)
+			q.Extend(ctx, "test", job)
+		}, testVisibility * 3 / 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewMemoryQueue(&config.Config{JobVisibilityTimeout: testVisibility})
+			if err := q.Enqueue(ctx, &Job{Id: "1", Type: "test"}); err != nil {
+				t.Fatal(err)
+			}
+			fetched := fetchNow(t, q)
+			if len(fetched) != 1 {
+				t.Fatalf("fetched %d jobs", len(fetched))
+			}
+			if again := fetchNow(t, q); len(again) != 0 {
+				t.Fatal("fetched job is visible")
+			}
+			tt.after(q, fetched[0])
+			time.Sleep(tt.wait)
+			again := fetchNow(t, q)
+			if tt.redeliver != (len(again) == 1) {
+				t.Fatalf("redelivered %d jobs", len(again))
+			}
+			if tt.redeliver && (again[0].Attempts != 1 || again[0].LastError != ErrTimedOut.Error()) {
+				t.Errorf("redelivered job %+v", again[0])
+			}
+		})
+	}
+}
+
+func TestMemoryQueueStaleAck(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(&config.Config{JobVisibilityTimeout: testVisibility})
+	q.Enqueue(ctx, &Job{Id: "1", Type: "test"})
+	first := fetchNow(t, q)
+	time.Sleep(testVisibility + 5*time.Millisecond)
+	second := fetchNow(t, q)
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatalf("fetched %d then %d jobs", len(first), len(second))
+	}
+	// the worker that lost the job must not ack the new delivery
+	q.Ack(ctx, first[0])
+	time.Sleep(testVisibility + 5*time.Millisecond)
+	if third := fetchNow(t, q); len(third) != 1 || third[0].Attempts != 2 {
+		t.Fatalf("expected a third delivery, got %+v", third)
+	}
+}
+
+func TestMemoryQueueLostExtend(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(&config.Config{JobVisibilityTimeout: testVisibility})
+	q.Enqueue(ctx, &Job{Id: "1", Type: "test"})
+	first := fetchNow(t, q)
+	time.Sleep(testVisibility + 5*time.Millisecond)
+	second := fetchNow(t, q)
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatalf("fetched %d then %d jobs", len(first), len(second))
+	}
+	if err := q.Extend(ctx, "first", first[0]); !errors.Is(err, ErrJobLost) {
+		t.Errorf("extending the lost delivery: %v", err)
+	}
+	if err := q.Extend(ctx, "second", second[0]); err != nil {
+		t.Errorf("extending the current delivery: %v", err)
+	}
+}
+
+func TestMemoryQueueDelayed(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(&config.Config{JobVisibilityTimeout: time.Minute})
+	q.Enqueue(ctx, &Job{Id: "later", RunAt: time.Now().Add(20 * time.Millisecond)})
+	q.Enqueue(ctx, &Job{Id: "now"})
+	if jobs := fetchNow(t, q); len(jobs) != 1 || jobs[0].Id != "now" {
+		t.Fatalf("got %+v", jobs)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if jobs := fetchNow(t, q); len(jobs) != 1 || jobs[0].Id != "later" {
+		t.Fatalf("got %+v", jobs)
+	}
+}
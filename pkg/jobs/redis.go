@@ -0,0 +1,234 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+)
+
+const (
+	// the keys share the {jobs} hash tag, the promote script and the
+	// transactions moving jobs between them need one cluster slot
+	RedisStream  = "{jobs}::stream"
+	RedisDelayed = "{jobs}::delayed"
+	RedisDead    = "{jobs}::dead"
+	RedisGroup   = "workers"
+	// RedisDeadMaxLen caps the dead letter stream, the oldest jobs are dropped
+	RedisDeadMaxLen = 10000
+	// redisFetchBlock is how long Fetch waits for new jobs
+	redisFetchBlock = time.Second
+	redisPromoteMax = 100
+	redisJobField   = "job"
+)
+
+// promoteScript moves delayed jobs that are due to the stream
+var promoteScript = redis.NewScript(`
+local jobs = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, job in ipairs(jobs) do
+	redis.call("XADD", KEYS[2], "*", "job", job)
+	redis.call("ZREM", KEYS[1], job)
+end
+return #jobs
+`)
+
+// extendScript claims the pending message ARGV[2] for consumer ARGV[3]
+// again, resetting its idle time, when that consumer still owns it
+var extendScript = redis.NewScript(`
+local pending = redis.call("XPENDING", KEYS[1], ARGV[1], ARGV[2], ARGV[2], 1)
+if #pending == 0 or pending[1][2] ~= ARGV[3] then
+	return 0
+end
+redis.call("XCLAIM", KEYS[1], ARGV[1], ARGV[3], 0, ARGV[2], "JUSTID")
+return 1
+`)
+
+// RedisQueue is a Queue on a Redis stream read by the consumer group of all
+// workers. Delayed and retried jobs wait in a sorted set until they are due,
+// jobs that are not extended within the visibility timeout are claimed by
+// another worker.
+type RedisQueue struct {
+	client     redis.UniversalClient
+	visibility time.Duration
+}
+
+func NewRedisQueue(cache *cache.Redis, cfg *config.Config) (*RedisQueue, error) {
+	q := &RedisQueue{client: cache.GetClient(), visibility: cfg.JobVisibilityTimeout}
+	err := q.client.XGroupCreateMkStream(context.Background(), RedisStream, RedisGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if job.RunAt.After(time.Now()) {
+		return q.client.ZAdd(ctx, RedisDelayed, &redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: data}).Err()
+	}
+	return q.client.XAdd(ctx, &redis.XAddArgs{Stream: RedisStream, Values: []interface{}{redisJobField, data}}).Err()
+}
+
+// Fetch promotes due delayed jobs, then takes over jobs of workers that
+// stopped extending them before reading new ones
+func (q *RedisQueue) Fetch(ctx context.Context, consumer string, count int) ([]*Job, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	err := promoteScript.Run(ctx, q.client, []string{RedisDelayed, RedisStream}, now, redisPromoteMax).Err()
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := q.reclaim(ctx, consumer, count)
+	if err != nil || len(jobs) > 0 {
+		return jobs, err
+	}
+	streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    RedisGroup,
+		Consumer: consumer,
+		Streams:  []string{RedisStream, ">"},
+		Count:    int64(count),
+		Block:    redisFetchBlock,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range streams {
+		for _, m := range s.Messages {
+			if job := q.decode(ctx, m); job != nil {
+				jobs = append(jobs, job)
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// reclaim claims jobs idle for longer than the visibility timeout. A job
+// delivered more than once failed its earlier runs, they count as attempts.
+func (q *RedisQueue) reclaim(ctx context.Context, consumer string, count int) ([]*Job, error) {
+	pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: RedisStream,
+		Group:  RedisGroup,
+		Idle:   q.visibility,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(count),
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+	deliveries := make(map[string]int64, len(pending))
+	ids := make([]string, 0, len(pending))
+	for _, p := range pending {
+		deliveries[p.ID] = p.RetryCount
+		ids = append(ids, p.ID)
+	}
+	// the idle time is checked again, only one worker wins a job
+	messages, err := q.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   RedisStream,
+		Group:    RedisGroup,
+		Consumer: consumer,
+		MinIdle:  q.visibility,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(messages))
+	for _, m := range messages {
+		job := q.decode(ctx, m)
+		if job == nil {
+			continue
+		}
+		job.Attempts += int(deliveries[m.ID])
+		job.LastError = ErrTimedOut.Error()
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// decode returns the job of a message, malformed messages are dropped
+func (q *RedisQueue) decode(ctx context.Context, m redis.XMessage) *Job {
+	job := &Job{}
+	data, _ := m.Values[redisJobField].(string)
+	if err := json.Unmarshal([]byte(data), job); err != nil {
+		logger.Errorf("RedisQueue dropping malformed message %s:%v", m.ID, err)
+		q.client.XAck(ctx, RedisStream, RedisGroup, m.ID)
+		q.client.XDel(ctx, RedisStream, m.ID)
+		return nil
+	}
+	job.receipt = m.ID
+	return job
+}
+
+// Extend claims the job again, which resets its idle time without counting
+// a delivery. A job another worker claimed after it timed out is not taken
+// back.
+func (q *RedisQueue) Extend(ctx context.Context, consumer string, job *Job) error {
+	claimed, err := extendScript.Run(ctx, q.client, []string{RedisStream}, RedisGroup, job.receipt, consumer).Int()
+	if err != nil {
+		return err
+	}
+	if claimed == 0 {
+		return ErrJobLost
+	}
+	return nil
+}
+
+func (q *RedisQueue) Ack(ctx context.Context, job *Job) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		q.remove(ctx, pipe, job)
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) Retry(ctx context.Context, job *Job, at time.Time) error {
+	job.Attempts++
+	job.RunAt = at
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, RedisDelayed, &redis.Z{Score: float64(at.UnixMilli()), Member: data})
+		q.remove(ctx, pipe, job)
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) Dead(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: RedisDead,
+			MaxLen: RedisDeadMaxLen,
+			Approx: true,
+			Values: []interface{}{redisJobField, data},
+		})
+		q.remove(ctx, pipe, job)
+		return nil
+	})
+	return err
+}
+
+// remove acks the delivery and deletes it from the stream, which otherwise
+// keeps every job
+func (q *RedisQueue) remove(ctx context.Context, pipe redis.Pipeliner, job *Job) {
+	pipe.XAck(ctx, RedisStream, RedisGroup, job.receipt)
+	pipe.XDel(ctx, RedisStream, job.receipt)
+}
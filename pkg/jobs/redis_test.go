@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+)
+
+// TestRedisQueueLostExtend runs against the redis at
+// TRACKDOCS_TEST_CACHE_SOURCE
+func TestRedisQueueLostExtend(t *testing.T) {
+	source := os.Getenv("TRACKDOCS_TEST_CACHE_SOURCE")
+	if source == "" {
+		t.Skip("TRACKDOCS_TEST_CACHE_SOURCE is not set")
+	}
+	cfg := &config.Config{CacheSource: source, CacheTimeout: time.Second, JobVisibilityTimeout: 50 * time.Millisecond}
+	c, err := cache.NewRedis(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := NewRedisQueue(c, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	id := crypto.GenerateId("job", 16)
+	if err := q.Enqueue(ctx, &Job{Id: id, Type: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	// find returns the test job among the fetched ones and acks the others
+	find := func(consumer string) *Job {
+		var found *Job
+		for i := 0; i < 10 && found == nil; i++ {
+			jobs, err := q.Fetch(ctx, consumer, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, job := range jobs {
+				if job.Id == id {
+					found = job
+				} else {
+					q.Ack(ctx, job)
+				}
+			}
+		}
+		if found == nil {
+			t.Fatalf("%s did not fetch the job", consumer)
+		}
+		return found
+	}
+	first := find("first")
+	time.Sleep(cfg.JobVisibilityTimeout * 2)
+	second := find("second")
+	defer q.Ack(ctx, second)
+
+	if err := q.Extend(ctx, "first", first); !errors.Is(err, ErrJobLost) {
+		t.Errorf("extending the lost job: %v", err)
+	}
+	if err := q.Extend(ctx, "second", second); err != nil {
+		t.Errorf("extending the current job: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+)
+
+const (
+	RetryBaseDelay = 10 * time.Second
+	RetryMaxDelay  = time.Hour
+	// fetchErrorDelay is the pause after the queue failed to fetch jobs
+	fetchErrorDelay = 5 * time.Second
+)
+
+// HandlerOption configures the handler of a job type
+type HandlerOption func(*handler)
+
+// WithMaxAttempts dead letters a job after attempts failed runs
+func WithMaxAttempts(attempts int) HandlerOption {
+	return func(h *handler) {
+		h.maxAttempts = attempts
+	}
+}
+
+// WithTimeout cancels a run after timeout
+func WithTimeout(timeout time.Duration) HandlerOption {
+	return func(h *handler) {
+		h.timeout = timeout
+	}
+}
+
+type handler struct {
+	run         func(ctx context.Context, payload json.RawMessage) error
+	maxAttempts int
+	timeout     time.Duration
+}
+
+// Worker runs the jobs of a queue with the handlers registered for their type
+type Worker struct {
+	cfg      *config.Config
+	queue    Queue
+	consumer string
+	handlers map[string]*handler
+}
+
+func NewWorker(cfg *config.Config, queue Queue) *Worker {
+	host, _ := os.Hostname()
+	return &Worker{
+		cfg:      cfg,
+		queue:    queue,
+		consumer: crypto.GenerateId(host, 4),
+		handlers: map[string]*handler{},
+	}
+}
+
+// Register runs fn for the jobs of type t. A job whose payload does not decode
+// is dead lettered right away.
+func Register[T any](w *Worker, t Type[T], fn func(ctx context.Context, payload T) error, options ...HandlerOption) {
+	h := &handler{
+		run: func(ctx context.Context, data json.RawMessage) error {
+			var payload T
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return Permanent(err)
+			}
+			return fn(ctx, payload)
+		},
+		maxAttempts: w.cfg.JobMaxAttempts,
+		timeout:     w.cfg.JobTimeout,
+	}
+	for _, o := range options {
+		o(h)
+	}
+	w.handlers[t.Name] = h
+}
+
+// Run fetches and runs jobs until ctx is done, then waits for the running
+// ones to finish
+func (w *Worker) Run(ctx context.Context) {
+	logger.Infof("Worker %s running %d job types with concurrency %d", w.consumer, len(w.handlers), w.cfg.JobConcurrency)
+	slots := make(chan struct{}, w.cfg.JobConcurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		// wait for a free slot, then fetch as many jobs as there are
+		select {
+		case <-ctx.Done():
+			return
+		case slots <- struct{}{}:
+		}
+		free := 1
+	fill:
+		for free < cap(slots) {
+			select {
+			case slots <- struct{}{}:
+				free++
+			default:
+				break fill
+			}
+		}
+		jobs, err := w.queue.Fetch(ctx, w.consumer, free)
+		if err != nil && ctx.Err() == nil {
+			logger.Errorf("Worker error while fetching jobs:%s", err.Error())
+			sleep(ctx, fetchErrorDelay)
+		}
+		for i := len(jobs); i < free; i++ {
+			<-slots
+		}
+		for _, job := range jobs {
+			wg.Add(1)
+			go func(job *Job) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				// running jobs finish after ctx is done, their handlers have
+				// timeouts
+				w.process(context.WithoutCancel(ctx), job)
+			}(job)
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job *Job) {
+	h := w.handlers[job.Type]
+	maxAttempts := w.cfg.JobMaxAttempts
+	if h != nil {
+		maxAttempts = h.maxAttempts
+	}
+	var err error
+	switch {
+	case job.Attempts >= maxAttempts:
+		// timed out on its last attempt
+		err = Permanent(ErrTimedOut)
+	case h == nil:
+		// another worker may know the type, during a deploy
+		err = ErrUnknownType
+	default:
+		err = w.run(ctx, h, job)
+	}
+	if err == nil {
+		if err := w.queue.Ack(ctx, job); err != nil {
+			logger.Errorf("Worker error while acking job %s:%s", job.Id, err.Error())
+		}
+		return
+	}
+
+	job.LastError = err.Error()
+	if IsPermanent(err) || job.Attempts+1 >= maxAttempts {
+		logger.Errorf("Worker job %s of type %s failed after %d attempts:%s", job.Id, job.Type, job.Attempts+1, err.Error())
+		err = w.queue.Dead(ctx, job)
+	} else {
+		logger.Errorf("Worker job %s of type %s failed, attempt %d:%s", job.Id, job.Type, job.Attempts+1, err.Error())
+		err = w.queue.Retry(ctx, job, time.Now().Add(Backoff(job.Attempts+1)))
+	}
+	if err != nil {
+		logger.Errorf("Worker error while failing job %s:%s", job.Id, err.Error())
+	}
+}
+
+// run calls the handler and keeps the job invisible to other workers while it
+// runs
+func (w *Worker) run(ctx context.Context, h *handler, job *Job) (err error) {
+	runCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(w.cfg.JobVisibilityTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := w.queue.Extend(ctx, w.consumer, job)
+				if errors.Is(err, ErrJobLost) {
+					// another worker runs it now, extending is pointless
+					logger.Errorf("Worker job %s of type %s timed out and was redelivered", job.Id, job.Type)
+					return
+				}
+				if err != nil {
+					logger.Errorf("Worker error while extending job %s:%s", job.Id, err.Error())
+				}
+			}
+		}
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h.run(runCtx, job.Payload)
+}
+
+// Backoff doubles the delay before every retry, from RetryBaseDelay up to
+// RetryMaxDelay, with up to a fifth of jitter so failed jobs spread out
+func Backoff(attempt int) time.Duration {
+	delay := RetryMaxDelay
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt <= 16 {
+		delay = min(RetryBaseDelay<<(attempt-1), RetryMaxDelay)
+	}
+	return delay + rand.N(delay/5+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
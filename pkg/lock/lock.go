@@ -9,11 +9,11 @@ import (
 )
 
 type RedisLock struct {
-	cache *cache.Cache
+	cache cache.Cache
 	cfg   *config.Config
 }
 
-func NewRedisLock(cache *cache.Cache, cfg *config.Config) (*RedisLock, error) {
+func NewRedisLock(cache cache.Cache, cfg *config.Config) (*RedisLock, error) {
 	return &RedisLock{cache: cache, cfg: cfg}, nil
 }
 
@@ -3,6 +3,7 @@ package lock
 // Refer https://redis.io/docs/reference/patterns/distributed-locks/
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -11,16 +12,6 @@ import (
 	"github.com/praveenmsp23/trackdocs/pkg/crypto"
 )
 
-const (
-	unlockScript = `
-		if redis.call("get",KEYS[1]) == ARGV[1] then
-		    return redis.call("del",KEYS[1])
-		else
-		    return 0
-		end
-	`
-)
-
 var ErrUnLockFailed = errors.New("lock unlock failed")
 
 // A Mutex is a distributed mutual exclusion lock.
@@ -31,7 +22,7 @@ type Mutex struct {
 	retryDelay time.Duration
 
 	expiry time.Duration
-	cache  *cache.Cache
+	cache  cache.Cache
 	cfg    *config.Config
 
 	value string
@@ -45,8 +36,13 @@ func (m *Mutex) Name() string {
 // Lock attempts to put a lock on the key for a mutex expiry duration.
 // If the lock was successfully acquired, true will be returned.
 func (m *Mutex) Lock() (bool, error) {
+	return m.LockContext(context.Background())
+}
+
+// LockContext is Lock that stops retrying when ctx is done
+func (m *Mutex) LockContext(ctx context.Context) (bool, error) {
 	for i := 0; i < m.retryCount; i++ {
-		success, err := m.acquire()
+		success, err := m.acquire(ctx)
 		if success || err != nil {
 			return success, err
 		}
@@ -54,7 +50,11 @@ func (m *Mutex) Lock() (bool, error) {
 			return false, nil
 		}
 		// Wait a random delay before to retry
-		time.Sleep(crypto.GenerateRandomDuration(m.retryDelay))
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(crypto.GenerateRandomDuration(m.retryDelay)):
+		}
 	}
 	return false, nil
 }
@@ -63,27 +63,39 @@ func (m *Mutex) Lock() (bool, error) {
 // If the lock cannot be removed, either because the key has already expired or
 // because the value was incorrect, an error will be returned.
 func (m *Mutex) Unlock() error {
-	return m.release(m.name)
+	return m.UnlockContext(context.Background())
+}
+
+// UnlockContext is Unlock bounded by ctx
+func (m *Mutex) UnlockContext(ctx context.Context) error {
+	return m.release(ctx, m.name)
+}
+
+// Extend resets the expiry of a held lock to the mutex expiry duration.
+// false is returned when the lock expired or is held by someone else.
+func (m *Mutex) Extend() (bool, error) {
+	return m.ExtendContext(context.Background())
 }
 
-func (m *Mutex) acquire() (bool, error) {
-	reply, err := m.cache.SetNX(m.name, m.value, m.expiry)
+// ExtendContext is Extend bounded by ctx
+func (m *Mutex) ExtendContext(ctx context.Context) (bool, error) {
+	return m.cache.ExpireIfEqual(ctx, m.name, m.value, m.expiry)
+}
+
+func (m *Mutex) acquire(ctx context.Context) (bool, error) {
+	reply, err := m.cache.SetNXContext(ctx, m.name, m.value, m.expiry)
 	if err != nil {
 		return false, err
 	}
 	return reply, nil
 }
 
-func (m *Mutex) release(lockName string) error {
-	res := m.cache.Eval(unlockScript, []string{lockName}, m.value)
-	if res.Err() != nil {
-		return res.Err()
-	}
-	result, err := res.Int()
+func (m *Mutex) release(ctx context.Context, lockName string) error {
+	ok, err := m.cache.DelIfEqual(ctx, lockName, m.value)
 	if err != nil {
 		return err
 	}
-	if result == 0 {
+	if !ok {
 		return ErrUnLockFailed
 	}
 	return nil
@@ -0,0 +1,136 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+const (
+	TransportSMTP = "smtp"
+	TransportFile = "file"
+	TransportLog  = "log"
+)
+
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// Message is a rendered email with a plain text and an optional HTML body
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+}
+
+// Sender delivers messages
+type Sender interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// NewSender returns the sender configured by cfg.MailTransport
+func NewSender(cfg *config.Config) (Sender, error) {
+	switch cfg.MailTransport {
+	case TransportSMTP:
+		return NewSMTP(cfg)
+	case TransportFile:
+		return NewFile(cfg)
+	case TransportLog:
+		return &Log{}, nil
+	}
+	return nil, fmt.Errorf("unknown mail transport %q", cfg.MailTransport)
+}
+
+// Encode returns the message as a MIME email from from, multipart when it
+// has an HTML body
+func Encode(from string, message *Message) ([]byte, error) {
+	if _, err := mail.ParseAddress(message.To); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, message.To)
+	}
+	var b bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+	}
+	header("From", from)
+	header("To", message.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageId(from))
+	header("MIME-Version", "1.0")
+	if message.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		if err := writeQuoted(&b, message.Text); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuoted(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	b.WriteString("\r\n")
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
+
+func writeQuoted(w interface{ Write([]byte) (int, error) }, content string) error {
+	q := quotedprintable.NewWriter(w)
+	if _, err := q.Write([]byte(strings.ReplaceAll(content, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return q.Close()
+}
+
+// messageId returns a unique Message-ID in the domain of from
+func messageId(from string) string {
+	domain := "localhost"
+	if address, err := mail.ParseAddress(from); err == nil {
+		if _, d, ok := strings.Cut(address.Address, "@"); ok {
+			domain = d
+		}
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain)
+}
+
+// IsPermanent reports whether sending failed for a reason retrying does not
+// fix, like an unknown recipient
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrInvalidAddress) {
+		return true
+	}
+	var protocolErr *textproto.Error
+	return errors.As(err, &protocolErr) && protocolErr.Code >= 500
+}
@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestEncodeText(t *testing.T) {
+	data, err := Encode("TrackDocs <noreply@example.com>", &Message{
+		To:      "ana@example.com",
+		Subject: "Héllo",
+		Text:    "first line\nsecond line, long enough to be wrapped by quoted printable encoding = done",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("content type %q", got)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "first line\r\nsecond line, long enough to be wrapped by quoted printable encoding = done"
+	if string(body) != want {
+		t.Errorf("body %q, want %q", body, want)
+	}
+}
+
+func TestEncodeHTML(t *testing.T) {
+	data, err := Encode("noreply@example.com", &Message{To: "ana@example.com", Subject: "Hi", Text: "text", HTML: "<p>html</p>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Header.Get("Content-Type"); !strings.HasPrefix(got, "multipart/alternative; boundary=") {
+		t.Errorf("content type %q", got)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	if !bytes.Contains(body, []byte("<p>html</p>")) || !bytes.Contains(body, []byte("text")) {
+		t.Errorf("body %q", body)
+	}
+}
+
+func TestEncodeInvalidAddress(t *testing.T) {
+	if _, err := Encode("noreply@example.com", &Message{To: "not an address"}); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("expected ErrInvalidAddress, got %v", err)
+	}
+}
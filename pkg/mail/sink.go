@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+)
+
+// File writes every message as an .eml file to a directory instead of
+// sending it, for local development
+type File struct {
+	dir  string
+	from string
+}
+
+func NewFile(cfg *config.Config) (*File, error) {
+	if err := os.MkdirAll(cfg.MailDir, 0o750); err != nil {
+		return nil, err
+	}
+	return &File{dir: cfg.MailDir, from: cfg.MailFrom}, nil
+}
+
+func (f *File) Send(ctx context.Context, message *Message) error {
+	data, err := Encode(f.from, message)
+	if err != nil {
+		return err
+	}
+	recipient := strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, message.To)
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), recipient)
+	return os.WriteFile(filepath.Join(f.dir, name), data, 0o640)
+}
+
+// Log writes the text body of every message to the log instead of sending it
+type Log struct{}
+
+func (l *Log) Send(ctx context.Context, message *Message) error {
+	logger.Infof("email to %s: %s\n%s", message.To, message.Subject, message.Text)
+	return nil
+}
@@ -0,0 +1,95 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+// SMTP sends messages through an SMTP relay, upgrading the connection with
+// STARTTLS when the server offers it
+type SMTP struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+	timeout  time.Duration
+}
+
+func NewSMTP(cfg *config.Config) (*SMTP, error) {
+	if _, err := mail.ParseAddress(cfg.MailFrom); err != nil {
+		return nil, err
+	}
+	return &SMTP{
+		addr:     net.JoinHostPort(cfg.SmtpHost, strconv.Itoa(cfg.SmtpPort)),
+		host:     cfg.SmtpHost,
+		from:     cfg.MailFrom,
+		username: cfg.SmtpUsername,
+		password: cfg.SmtpPassword,
+		timeout:  cfg.MailTimeout,
+	}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, message *Message) error {
+	data, err := Encode(s.from, message)
+	if err != nil {
+		return err
+	}
+	from, _ := mail.ParseAddress(s.from)
+	to, _ := mail.ParseAddress(message.To)
+
+	dialer := &net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
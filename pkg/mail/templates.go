@@ -0,0 +1,172 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates locales
+var files embed.FS
+
+// Templates render messages from the templates in templates/, one text and
+// one HTML file per name. Text templates define a "subject" template as
+// well, HTML templates fill the "content" of layout.html. Both look up
+// their strings with t, from the catalog in locales/ of the locale the
+// message is rendered in.
+type Templates struct {
+	defaultLocale string
+	catalogs      map[string]map[string]string
+	text          map[string]*texttemplate.Template
+	html          map[string]*htmltemplate.Template
+}
+
+// NewTemplates parses the embedded templates, messages in locales without a
+// catalog are rendered in defaultLocale
+func NewTemplates(defaultLocale string) (*Templates, error) {
+	t := &Templates{
+		defaultLocale: defaultLocale,
+		catalogs:      map[string]map[string]string{},
+		text:          map[string]*texttemplate.Template{},
+		html:          map[string]*htmltemplate.Template{},
+	}
+	catalogs, err := fs.Glob(files, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range catalogs {
+		data, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		catalog := map[string]string{}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("locale %s: %w", name, err)
+		}
+		t.catalogs[strings.TrimSuffix(path.Base(name), ".json")] = catalog
+	}
+	if _, ok := t.catalogs[defaultLocale]; !ok {
+		return nil, fmt.Errorf("no catalog for the default locale %q", defaultLocale)
+	}
+
+	// t is bound to the locale when rendering
+	funcs := map[string]any{"t": func(key string, args ...any) string { return key }}
+	texts, err := fs.Glob(files, "templates/*.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range texts {
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+		text, err := texttemplate.New(path.Base(file)).Funcs(funcs).ParseFS(files, file)
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFS(files, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, err
+		}
+		t.text[name] = text
+		t.html[name] = html
+	}
+	return t, nil
+}
+
+// HasLocale reports whether messages can be rendered in locale or its
+// language
+func HasLocale(locale string) bool {
+	locale = normalizeLocale(locale)
+	if _, err := fs.Stat(files, "locales/"+locale+".json"); err == nil {
+		return true
+	}
+	language, _, ok := strings.Cut(locale, "-")
+	if !ok {
+		return false
+	}
+	_, err := fs.Stat(files, "locales/"+language+".json")
+	return err == nil
+}
+
+// Supports reports whether messages can be rendered in locale
+func (t *Templates) Supports(locale string) bool {
+	_, ok := t.catalogs[locale]
+	return ok
+}
+
+// Render returns the message name rendered with data in locale, falling
+// back to the language of locale and then the default locale. To is left
+// empty.
+func (t *Templates) Render(locale, name string, data any) (*Message, error) {
+	text, ok := t.text[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown mail template %q", name)
+	}
+	funcs := map[string]any{"t": t.translate(t.resolve(locale))}
+	text, err := text.Clone()
+	if err != nil {
+		return nil, err
+	}
+	html, err := t.html[name].Clone()
+	if err != nil {
+		return nil, err
+	}
+	text.Funcs(funcs)
+	html.Funcs(funcs)
+
+	message := &Message{}
+	var b bytes.Buffer
+	if err := text.ExecuteTemplate(&b, "subject", data); err != nil {
+		return nil, err
+	}
+	message.Subject = strings.TrimSpace(b.String())
+	b.Reset()
+	if err := text.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	message.Text = strings.TrimSpace(b.String()) + "\n"
+	b.Reset()
+	if err := html.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	message.HTML = b.String()
+	return message, nil
+}
+
+// resolve returns the supported locale closest to locale
+func (t *Templates) resolve(locale string) string {
+	locale = normalizeLocale(locale)
+	if t.Supports(locale) {
+		return locale
+	}
+	if language, _, ok := strings.Cut(locale, "-"); ok && t.Supports(language) {
+		return language
+	}
+	return t.defaultLocale
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(locale), "_", "-")
+}
+
+// translate returns the t function of locale, which formats the string of
+// key with args and falls back to the default locale for missing keys
+func (t *Templates) translate(locale string) func(key string, args ...any) string {
+	return func(key string, args ...any) string {
+		format, ok := t.catalogs[locale][key]
+		if !ok {
+			format, ok = t.catalogs[t.defaultLocale][key]
+		}
+		if !ok {
+			return key
+		}
+		if len(args) == 0 {
+			return format
+		}
+		return fmt.Sprintf(format, args...)
+	}
+}
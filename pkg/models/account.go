@@ -20,6 +20,8 @@ type Account struct {
 	Name        string       `json:"name"`
 	Email       string       `json:"email"`
 	Status      UserStatus   `json:"status"`
+	Locale      string       `json:"locale"` // of the emails, empty for the default
+	Plan        string       `json:"plan"`   // selects the rate limits, empty for the default
 	LastLoginAt sql.NullTime `json:"last_login_at"`
 }
 
@@ -51,6 +53,7 @@ func (a *Account) Update(db *gorm.DB) (*Account, error) {
 			"name":          a.Name,
 			"last_login_at": a.LastLoginAt,
 			"status":        a.Status,
+			"locale":        a.Locale,
 		},
 	)
 	if db.Error != nil {
@@ -49,13 +49,74 @@ func NewSqlNullTime(t time.Time) sql.NullTime {
 type Jsonb map[string]interface{}
 
 func (j Jsonb) Value() (driver.Value, error) {
+	if j == nil {
+		return "{}", nil
+	}
 	valueString, err := json.Marshal(j)
 	return string(valueString), err
 }
 
 func (j *Jsonb) Scan(value interface{}) error {
-	if err := json.Unmarshal(value.([]byte), &j); err != nil {
+	if err := json.Unmarshal(scanBytes(value), &j); err != nil {
+		return err
+	}
+	return nil
+}
+
+// StringList is a list of strings stored as a json array
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	valueString, err := json.Marshal(l)
+	return string(valueString), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	if err := json.Unmarshal(scanBytes(value), &l); err != nil {
 		return err
 	}
 	return nil
 }
+
+// Contains reports whether s is in the list
+func (l StringList) Contains(s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IntList is a list of integers stored as a json array
+type IntList []int
+
+func (l IntList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	valueString, err := json.Marshal(l)
+	return string(valueString), err
+}
+
+func (l *IntList) Scan(value interface{}) error {
+	if err := json.Unmarshal(scanBytes(value), &l); err != nil {
+		return err
+	}
+	return nil
+}
+
+// scanBytes returns the raw bytes of a json column, which drivers hand out
+// either as []byte or as string
+func scanBytes(value interface{}) []byte {
+	switch v := value.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+	return []byte("null")
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+// Comment is a message in a discussion of a document. Comments without a
+// ParentId start a thread, replies point to the comment starting their
+// thread. A thread may be anchored to a page and a range of the extracted
+// text of one version, and is resolved as a whole.
+type Comment struct {
+	Base
+	WorkspaceId string     `json:"workspace_id"`
+	DocumentId  string     `json:"document_id"`
+	ParentId    string     `json:"parent_id,omitempty"`
+	AccountId   string     `json:"account_id"`
+	Body        string     `json:"body"`
+	Mentions    StringList `json:"mentions"`
+	// VersionId is set for anchored threads. Page counts from 1, RangeStart
+	// and RangeEnd are character offsets into the text of the version and
+	// Quote is the text between them when the comment was made.
+	VersionId  string       `json:"version_id,omitempty"`
+	Page       int          `json:"page,omitempty"`
+	RangeStart int          `json:"range_start,omitempty"`
+	RangeEnd   int          `json:"range_end,omitempty"`
+	Quote      string       `json:"quote,omitempty"`
+	EditedAt   sql.NullTime `json:"edited_at"`
+	ResolvedAt sql.NullTime `json:"resolved_at"`
+	ResolvedBy string       `json:"resolved_by,omitempty"`
+	Replies    []*Comment   `gorm:"foreignKey:ParentId" json:"replies,omitempty"`
+}
+
+func NewComment(document *Document, parentId, accountId, body string, mentions []string) *Comment {
+	return &Comment{
+		WorkspaceId: document.WorkspaceId,
+		DocumentId:  document.Id,
+		ParentId:    parentId,
+		AccountId:   accountId,
+		Body:        body,
+		Mentions:    mentions,
+	}
+}
+
+func (c *Comment) BeforeCreate(tx *gorm.DB) (err error) {
+	c.Id = crypto.GenerateId("cmt", IdSize)
+	return nil
+}
+
+func (c *Comment) Create(db *gorm.DB) (*Comment, error) {
+	err := db.Create(&c).Error
+	if err != nil {
+		return &Comment{}, err
+	}
+	return c, nil
+}
+
+// Update saves the body and mentions of an edited comment
+func (c *Comment) Update(db *gorm.DB) (*Comment, error) {
+	c.EditedAt = NewSqlNullTime(time.Now())
+	err := db.Model(&Comment{}).Where("id = ?", c.Id).UpdateColumns(
+		map[string]interface{}{
+			"body":      c.Body,
+			"mentions":  c.Mentions,
+			"edited_at": c.EditedAt,
+		},
+	).Error
+	if err != nil {
+		return &Comment{}, err
+	}
+	return c, nil
+}
+
+// Resolve resolves the thread started by the comment, or reopens it when
+// accountId is empty
+func (c *Comment) Resolve(db *gorm.DB, accountId string) (*Comment, error) {
+	c.ResolvedBy = accountId
+	c.ResolvedAt = sql.NullTime{}
+	if accountId != "" {
+		c.ResolvedAt = NewSqlNullTime(time.Now())
+	}
+	err := db.Model(&Comment{}).Where("id = ?", c.Id).UpdateColumns(
+		map[string]interface{}{
+			"resolved_at": c.ResolvedAt,
+			"resolved_by": c.ResolvedBy,
+		},
+	).Error
+	if err != nil {
+		return &Comment{}, err
+	}
+	return c, nil
+}
+
+// IsThread reports whether the comment starts a thread
+func (c *Comment) IsThread() bool {
+	return c.ParentId == ""
+}
+
+// CommentEdit keeps the body a comment had before an edit
+type CommentEdit struct {
+	Base
+	CommentId string `json:"comment_id"`
+	Body      string `json:"body"`
+	EditedBy  string `json:"edited_by"`
+}
+
+func NewCommentEdit(comment *Comment, editedBy string) *CommentEdit {
+	return &CommentEdit{CommentId: comment.Id, Body: comment.Body, EditedBy: editedBy}
+}
+
+func (e *CommentEdit) BeforeCreate(tx *gorm.DB) (err error) {
+	e.Id = crypto.GenerateId("cme", IdSize)
+	return nil
+}
+
+func (e *CommentEdit) Create(db *gorm.DB) (*CommentEdit, error) {
+	err := db.Create(&e).Error
+	if err != nil {
+		return &CommentEdit{}, err
+	}
+	return e, nil
+}
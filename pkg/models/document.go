@@ -0,0 +1,140 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+// Document is a tracked document. FolderId is empty for documents that sit at
+// the project root.
+type Document struct {
+	Base
+	AuditBase
+	WorkspaceId string `json:"workspace_id"`
+	ProjectId   string `json:"project_id"`
+	FolderId    string `json:"folder_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	OwnerId     string `json:"owner_id"`
+	Metadata    Jsonb  `json:"metadata"`
+	// Status only changes through the workflow store, see Document.Update
+	Status DocumentStatus `json:"status"`
+	// ReminderOffsets are the number of days before ExpiresAt at which the
+	// owner is reminded
+	ExpiresAt       sql.NullTime `json:"expires_at"`
+	ReminderOffsets IntList      `json:"reminder_offsets"`
+	// ExtractStatus is the one of the current version, empty without versions
+	ExtractStatus ExtractStatus `json:"extract_status"`
+
+	Tags []string `gorm:"-" json:"tags"`
+}
+
+func NewDocument(project *Project, folder *Folder, name, description, createdBy string) *Document {
+	d := &Document{
+		WorkspaceId:     project.WorkspaceId,
+		ProjectId:       project.Id,
+		Name:            name,
+		Description:     description,
+		OwnerId:         createdBy,
+		Metadata:        Jsonb{},
+		Status:          DocumentStatusDraft,
+		ReminderOffsets: IntList{},
+		AuditBase:       AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy},
+	}
+	if folder != nil {
+		d.FolderId = folder.Id
+	}
+	return d
+}
+
+// Copy returns an unsaved copy of the document placed in project and folder
+func (d *Document) Copy(project *Project, folder *Folder, createdBy string) *Document {
+	c := *d
+	c.Base = Base{}
+	c.WorkspaceId = project.WorkspaceId
+	c.ProjectId = project.Id
+	// versions are not copied
+	c.ExtractStatus = ""
+	c.FolderId = ""
+	if folder != nil {
+		c.FolderId = folder.Id
+	}
+	c.OwnerId = createdBy
+	c.Status = DocumentStatusDraft
+	c.AuditBase = AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy}
+	return &c
+}
+
+// DefaultReminderOffsets are used when an expiry date is set without offsets
+var DefaultReminderOffsets = IntList{30, 7, 1}
+
+// SetExpiry sets or, with a nil expiresAt, clears the expiry of the document
+func (d *Document) SetExpiry(expiresAt *time.Time, offsets []int) {
+	if expiresAt == nil {
+		d.ExpiresAt = sql.NullTime{}
+		d.ReminderOffsets = IntList{}
+		return
+	}
+	d.ExpiresAt = NewSqlNullTime(*expiresAt)
+	if offsets == nil {
+		offsets = DefaultReminderOffsets
+	}
+	seen := make(map[int]bool, len(offsets))
+	d.ReminderOffsets = make(IntList, 0, len(offsets))
+	for _, o := range offsets {
+		if !seen[o] {
+			seen[o] = true
+			d.ReminderOffsets = append(d.ReminderOffsets, o)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(d.ReminderOffsets)))
+}
+
+func (d *Document) BeforeCreate(tx *gorm.DB) (err error) {
+	d.Id = crypto.GenerateId("doc", IdSize)
+	return nil
+}
+
+func (d *Document) Create(db *gorm.DB) (*Document, error) {
+	err := db.Create(&d).Error
+	if err != nil {
+		return &Document{}, err
+	}
+	return d, nil
+}
+
+func (d *Document) Update(db *gorm.DB) (*Document, error) {
+	db = db.Model(&Document{}).Where("id = ?", d.Id).UpdateColumns(
+		map[string]interface{}{
+			"name":             d.Name,
+			"description":      d.Description,
+			"project_id":       d.ProjectId,
+			"folder_id":        d.FolderId,
+			"owner_id":         d.OwnerId,
+			"metadata":         d.Metadata,
+			"expires_at":       d.ExpiresAt,
+			"reminder_offsets": d.ReminderOffsets,
+			"modified_by":      d.ModifiedBy,
+		},
+	)
+	if db.Error != nil {
+		return &Document{}, db.Error
+	}
+	err := db.Model(&Document{}).Where("id = ?", d.Id).Take(&d).Error
+	if err != nil {
+		return &Document{}, err
+	}
+	return d, nil
+}
+
+func (d *Document) Delete(db *gorm.DB) (int64, error) {
+	db = db.Model(&Document{}).Where("id = ?", d.Id).Delete(&Document{})
+	if db.Error != nil {
+		return 0, db.Error
+	}
+	return db.RowsAffected, nil
+}
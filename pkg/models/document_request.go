@@ -0,0 +1,195 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+type DocumentRequestStatus string
+
+const (
+	DocumentRequestStatusOpen      DocumentRequestStatus = "open"
+	DocumentRequestStatusCompleted DocumentRequestStatus = "completed"
+	DocumentRequestStatusCancelled DocumentRequestStatus = "cancelled"
+)
+
+// DocumentRequest asks an external party to upload a checklist of documents
+// through a public link. The link token is looked up by its hash and kept
+// encrypted for the reminder emails.
+type DocumentRequest struct {
+	Base
+	AuditBase
+	WorkspaceId          string                 `json:"workspace_id"`
+	ProjectId            string                 `json:"project_id"`
+	FolderId             string                 `json:"folder_id"`
+	Title                string                 `json:"title"`
+	Message              string                 `json:"message"`
+	RecipientEmail       string                 `json:"recipient_email"`
+	RecipientName        string                 `json:"recipient_name"`
+	TokenHash            string                 `json:"-"`
+	EncryptedToken       string                 `json:"-"`
+	Status               DocumentRequestStatus  `json:"status"`
+	DueAt                sql.NullTime           `json:"due_at"`
+	ReminderIntervalDays int                    `json:"reminder_interval_days"`
+	LastRemindedAt       sql.NullTime           `json:"last_reminded_at"`
+	CompletedAt          sql.NullTime           `json:"completed_at"`
+	Items                []*DocumentRequestItem `gorm:"foreignKey:RequestId" json:"items,omitempty"`
+
+	// Link is the public upload link, only returned right after creation
+	Link string `gorm:"-" json:"link,omitempty"`
+}
+
+// NewDocumentRequest returns the request together with the plain link token,
+// which the caller encrypts into EncryptedToken
+func NewDocumentRequest(project *Project, folder *Folder, title, message, email, name string, createdBy string) (*DocumentRequest, string) {
+	token := crypto.GenerateId("dqt", TokenSize)
+	r := &DocumentRequest{
+		WorkspaceId:    project.WorkspaceId,
+		ProjectId:      project.Id,
+		Title:          title,
+		Message:        message,
+		RecipientEmail: strings.ToLower(strings.TrimSpace(email)),
+		RecipientName:  name,
+		TokenHash:      HashRequestToken(token),
+		Status:         DocumentRequestStatusOpen,
+		AuditBase:      AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy},
+	}
+	if folder != nil {
+		r.FolderId = folder.Id
+	}
+	return r, token
+}
+
+// HashRequestToken returns the value stored for a link token
+func HashRequestToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsComplete reports whether every required item was received
+func (r *DocumentRequest) IsComplete() bool {
+	for _, item := range r.Items {
+		if item.Required && item.Status != DocumentRequestItemStatusReceived {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *DocumentRequest) BeforeCreate(tx *gorm.DB) (err error) {
+	r.Id = crypto.GenerateId("dqr", IdSize)
+	return nil
+}
+
+func (r *DocumentRequest) Create(db *gorm.DB) (*DocumentRequest, error) {
+	err := db.Create(&r).Error
+	if err != nil {
+		return &DocumentRequest{}, err
+	}
+	return r, nil
+}
+
+// SetStatus moves the request to status, recording the completion time
+func (r *DocumentRequest) SetStatus(db *gorm.DB, status DocumentRequestStatus, accountId string) (*DocumentRequest, error) {
+	r.Status = status
+	r.CompletedAt = sql.NullTime{}
+	if status == DocumentRequestStatusCompleted {
+		r.CompletedAt = NewSqlNullTime(time.Now())
+	}
+	if accountId != "" {
+		r.ModifiedBy = accountId
+	}
+	err := db.Model(&DocumentRequest{}).Where("id = ?", r.Id).UpdateColumns(
+		map[string]interface{}{
+			"status":       r.Status,
+			"completed_at": r.CompletedAt,
+			"modified_by":  r.ModifiedBy,
+		},
+	).Error
+	if err != nil {
+		return &DocumentRequest{}, err
+	}
+	return r, nil
+}
+
+type DocumentRequestItemStatus string
+
+const (
+	DocumentRequestItemStatusPending  DocumentRequestItemStatus = "pending"
+	DocumentRequestItemStatusReceived DocumentRequestItemStatus = "received"
+	DocumentRequestItemStatusRejected DocumentRequestItemStatus = "rejected"
+)
+
+// DocumentRequestItem is a single entry of the checklist. AllowedTypes holds
+// file extensions like ".pdf" or mime types like "image/*"; an empty list
+// accepts any file. Received files become DocumentId.
+type DocumentRequestItem struct {
+	Base
+	RequestId       string                    `json:"request_id"`
+	Name            string                    `json:"name"`
+	Description     string                    `json:"description"`
+	AllowedTypes    StringList                `json:"allowed_types"`
+	MaxSize         int64                     `json:"max_size"`
+	Required        bool                      `json:"required"`
+	Status          DocumentRequestItemStatus `json:"status"`
+	DocumentId      string                    `json:"document_id"`
+	RejectionReason string                    `json:"rejection_reason"`
+	ReceivedAt      sql.NullTime              `json:"received_at"`
+}
+
+// Accepts reports whether a file with the given name and detected content type
+// is allowed for the item
+func (i *DocumentRequestItem) Accepts(fileName, contentType string) bool {
+	if len(i.AllowedTypes) == 0 {
+		return true
+	}
+	ext := strings.ToLower(path.Ext(fileName))
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	for _, allowed := range i.AllowedTypes {
+		allowed = strings.ToLower(allowed)
+		switch {
+		case strings.HasPrefix(allowed, "."):
+			if ext == allowed {
+				return true
+			}
+		case strings.HasSuffix(allowed, "/*"):
+			if strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+		case allowed == mediaType:
+			return true
+		}
+	}
+	return false
+}
+
+func (i *DocumentRequestItem) BeforeCreate(tx *gorm.DB) (err error) {
+	i.Id = crypto.GenerateId("dqi", IdSize)
+	return nil
+}
+
+func (i *DocumentRequestItem) Update(db *gorm.DB) (*DocumentRequestItem, error) {
+	err := db.Model(&DocumentRequestItem{}).Where("id = ?", i.Id).UpdateColumns(
+		map[string]interface{}{
+			"status":           i.Status,
+			"document_id":      i.DocumentId,
+			"rejection_reason": i.RejectionReason,
+			"received_at":      i.ReceivedAt,
+		},
+	).Error
+	if err != nil {
+		return &DocumentRequestItem{}, err
+	}
+	return i, nil
+}
@@ -6,7 +6,8 @@ type AccountCreateRequest struct {
 }
 
 type AccountUpdateRequest struct {
-	Name string `json:"name" binding:"required,max=254"`
+	Name   string `json:"name" binding:"required,max=254"`
+	Locale string `json:"locale" binding:"max=16"`
 }
 
 type AccountOAuth2Request struct {
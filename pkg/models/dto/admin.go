@@ -0,0 +1,9 @@
+package dto
+
+// CacheInvalidateRequest deletes the cache entries of a workspace or the
+// keys starting with a prefix, which must start with one of the cache
+// prefixes of the stores
+type CacheInvalidateRequest struct {
+	WorkspaceId string `json:"workspace_id" binding:"required_without=Prefix"`
+	Prefix      string `json:"prefix" binding:"required_without=WorkspaceId,omitempty,min=3"`
+}
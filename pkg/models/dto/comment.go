@@ -0,0 +1,24 @@
+package dto
+
+// CommentAnchorRequest anchors a thread to a version of the document, to a
+// page and/or a range of characters of the extracted text
+type CommentAnchorRequest struct {
+	VersionId  string `json:"version_id" binding:"required,max=36"`
+	Page       int    `json:"page" binding:"min=0"`
+	RangeStart int    `json:"range_start" binding:"min=0"`
+	RangeEnd   int    `json:"range_end" binding:"min=0"`
+}
+
+// CommentCreateRequest starts a thread, or replies to one with ParentId.
+// Mentions are the ids of the workspace members to notify.
+type CommentCreateRequest struct {
+	Body     string                `json:"body" binding:"required,min=1,max=10000"`
+	ParentId string                `json:"parent_id" binding:"max=36"`
+	Mentions []string              `json:"mentions" binding:"max=50,dive,min=1,max=36"`
+	Anchor   *CommentAnchorRequest `json:"anchor"`
+}
+
+type CommentUpdateRequest struct {
+	Body     string   `json:"body" binding:"required,min=1,max=10000"`
+	Mentions []string `json:"mentions" binding:"max=50,dive,min=1,max=36"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+type DocumentCreateRequest struct {
+	ProjectId       string                 `json:"project_id" binding:"required,max=36"`
+	FolderId        string                 `json:"folder_id" binding:"max=36"`
+	Name            string                 `json:"name" binding:"required,min=1,max=254"`
+	Description     string                 `json:"description" binding:"max=1024"`
+	Metadata        map[string]interface{} `json:"metadata"`
+	Tags            []string               `json:"tags" binding:"omitempty,max=50,dive,min=1,max=64"`
+	ExpiresAt       *time.Time             `json:"expires_at"`
+	ReminderOffsets []int                  `json:"reminder_offsets" binding:"omitempty,max=10,dive,min=0,max=365"`
+}
+
+// DocumentUpdateRequest updates a document. Metadata and Tags replace the
+// current values when present and are left untouched when omitted.
+type DocumentUpdateRequest struct {
+	Name        string                 `json:"name" binding:"required,min=1,max=254"`
+	Description string                 `json:"description" binding:"max=1024"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Tags        []string               `json:"tags" binding:"omitempty,max=50,dive,min=1,max=64"`
+}
+
+// DocumentMoveRequest moves or copies a document. An empty FolderId targets
+// the root of ProjectId.
+type DocumentMoveRequest struct {
+	ProjectId string `json:"project_id" binding:"required,max=36"`
+	FolderId  string `json:"folder_id" binding:"max=36"`
+}
+
+type DocumentTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=50,dive,min=1,max=64"`
+}
+
+// DocumentExpiryRequest sets the expiry of a document. A null ExpiresAt clears
+// it, omitted ReminderOffsets fall back to the defaults. Offsets are days
+// before the expiry.
+type DocumentExpiryRequest struct {
+	ExpiresAt       *time.Time `json:"expires_at"`
+	ReminderOffsets []int      `json:"reminder_offsets" binding:"omitempty,max=10,dive,min=0,max=365"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+type DocumentRequestItemRequest struct {
+	Name         string   `json:"name" binding:"required,min=1,max=254"`
+	Description  string   `json:"description" binding:"max=1024"`
+	AllowedTypes []string `json:"allowed_types" binding:"max=20,dive,min=2,max=128"`
+	MaxSize      int64    `json:"max_size" binding:"min=0"`
+	Required     *bool    `json:"required"`
+}
+
+// DocumentRequestCreateRequest sends a checklist to RecipientEmail. Received
+// files are stored as documents in ProjectId, below FolderId when given.
+// ReminderIntervalDays of zero disables the automatic reminders.
+type DocumentRequestCreateRequest struct {
+	ProjectId            string                       `json:"project_id" binding:"required,max=36"`
+	FolderId             string                       `json:"folder_id" binding:"max=36"`
+	Title                string                       `json:"title" binding:"required,min=1,max=254"`
+	Message              string                       `json:"message" binding:"max=2048"`
+	RecipientEmail       string                       `json:"recipient_email" binding:"required,email,max=254"`
+	RecipientName        string                       `json:"recipient_name" binding:"max=254"`
+	DueAt                *time.Time                   `json:"due_at"`
+	ReminderIntervalDays int                          `json:"reminder_interval_days" binding:"min=0,max=90"`
+	Items                []DocumentRequestItemRequest `json:"items" binding:"required,min=1,max=50,dive"`
+}
+
+type DocumentRequestItemRejectRequest struct {
+	Reason string `json:"reason" binding:"required,min=1,max=1024"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+type FolderCreateRequest struct {
+	ProjectId string `json:"project_id" binding:"required,max=36"`
+	ParentId  string `json:"parent_id" binding:"max=36"`
+	Name      string `json:"name" binding:"required,min=1,max=254"`
+}
+
+type FolderRenameRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=254"`
+}
+
+// FolderMoveRequest moves or copies a folder. An empty ParentId targets the
+// root of ProjectId.
+type FolderMoveRequest struct {
+	ProjectId string `json:"project_id" binding:"required,max=36"`
+	ParentId  string `json:"parent_id" binding:"max=36"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+type MetadataFieldCreateRequest struct {
+	Key      string   `json:"key" binding:"required,min=1,max=64"`
+	Label    string   `json:"label" binding:"required,min=1,max=254"`
+	Type     string   `json:"type" binding:"required,oneof=string number date enum"`
+	Options  []string `json:"options" binding:"max=100,dive,min=1,max=254"`
+	Required bool     `json:"required"`
+}
+
+type MetadataFieldUpdateRequest struct {
+	Label    string   `json:"label" binding:"required,min=1,max=254"`
+	Options  []string `json:"options" binding:"max=100,dive,min=1,max=254"`
+	Required bool     `json:"required"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+type NotificationPreferenceRequest struct {
+	Type   string `json:"type" binding:"required,max=64"`
+	InApp  bool   `json:"in_app"`
+	Email  bool   `json:"email"`
+	Digest bool   `json:"digest"`
+}
+
+type NotificationPreferencesUpdateRequest struct {
+	Preferences []NotificationPreferenceRequest `json:"preferences" binding:"required,max=50,dive"`
+}
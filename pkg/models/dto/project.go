@@ -0,0 +1,11 @@
+package dto
+
+type ProjectCreateRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=254"`
+	Description string `json:"description" binding:"max=1024"`
+}
+
+type ProjectUpdateRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=254"`
+	Description string `json:"description" binding:"max=1024"`
+}
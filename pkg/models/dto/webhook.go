@@ -0,0 +1,14 @@
+package dto
+
+type WebhookCreateRequest struct {
+	Url         string   `json:"url" binding:"required,max=2048,url"`
+	Description string   `json:"description" binding:"max=1024"`
+	Events      []string `json:"events" binding:"required,min=1,max=50,dive,min=1,max=64"`
+}
+
+type WebhookUpdateRequest struct {
+	Url         string   `json:"url" binding:"required,max=2048,url"`
+	Description string   `json:"description" binding:"max=1024"`
+	Events      []string `json:"events" binding:"required,min=1,max=50,dive,min=1,max=64"`
+	Enabled     bool     `json:"enabled"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+// WorkflowTransitionRequest describes one allowed transition. Quorum above zero
+// makes the transition require approval, by the named ApproverIds when given
+// and otherwise by accounts holding ApproverRole on the document.
+type WorkflowTransitionRequest struct {
+	From         string   `json:"from" binding:"required,oneof=draft in_review approved published archived"`
+	To           string   `json:"to" binding:"required,oneof=draft in_review approved published archived"`
+	ApproverRole string   `json:"approver_role" binding:"omitempty,oneof=owner admin editor viewer"`
+	ApproverIds  []string `json:"approver_ids" binding:"max=50,dive,min=1,max=36"`
+	Quorum       int      `json:"quorum" binding:"min=0,max=50"`
+}
+
+// WorkflowUpdateRequest replaces the whole workflow of a workspace. An empty
+// list of transitions restores the default workflow.
+type WorkflowUpdateRequest struct {
+	Transitions []WorkflowTransitionRequest `json:"transitions" binding:"max=50,dive"`
+}
+
+type TransitionCreateRequest struct {
+	To      string `json:"to" binding:"required,oneof=draft in_review approved published archived"`
+	Comment string `json:"comment" binding:"max=2048"`
+}
+
+type TransitionApproveRequest struct {
+	Comment string `json:"comment" binding:"max=2048"`
+}
+
+// TransitionRejectRequest rejects a pending transition, the comment telling
+// the requester what to change is mandatory
+type TransitionRejectRequest struct {
+	Comment string `json:"comment" binding:"required,min=1,max=2048"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+type WorkspaceCreateRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=254"`
+}
+
+type WorkspaceUpdateRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=254"`
+}
+
+type WorkspaceMemberAddRequest struct {
+	Email string `json:"email" binding:"required,min=2,max=254,email"`
+	Role  string `json:"role" binding:"required,oneof=owner admin editor viewer"`
+}
+
+type WorkspaceMemberUpdateRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin editor viewer"`
+}
+
+type PermissionGrantRequest struct {
+	ResourceType string `json:"resource_type" binding:"required,oneof=project folder document"`
+	ResourceId   string `json:"resource_id" binding:"required,max=36"`
+	AccountId    string `json:"account_id" binding:"required,max=36"`
+	Role         string `json:"role" binding:"required,oneof=admin editor viewer"`
+}
@@ -10,29 +10,124 @@ var (
 	ErrInternalServer = errors.New("internal server error. please try again later")
 
 	//NotFound
-	ErrAccountNotFound = errors.New("account not found")
+	ErrAccountNotFound         = errors.New("account not found")
+	ErrWorkspaceNotFound       = errors.New("workspace not found")
+	ErrWorkspaceMemberNotFound = errors.New("workspace member not found")
+	ErrProjectNotFound         = errors.New("project not found")
+	ErrFolderNotFound          = errors.New("folder not found")
+	ErrDocumentNotFound        = errors.New("document not found")
+	ErrPermissionNotFound      = errors.New("permission not found")
+	ErrMetadataFieldNotFound   = errors.New("metadata field not found")
+	ErrTransitionNotFound      = errors.New("transition not found")
+	ErrVersionNotFound         = errors.New("document version not found")
+	ErrDocumentRequestNotFound = errors.New("document request not found")
+	ErrRequestItemNotFound     = errors.New("document request item not found")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrNotificationNotFound    = errors.New("notification not found")
+	ErrCommentNotFound         = errors.New("comment not found")
 
 	//BadRequest
-	ErrAccountExists = errors.New("account already exists")
-	ErrBadRequest    = errors.New("bad request")
+	ErrAccountExists        = errors.New("account already exists")
+	ErrBadRequest           = errors.New("bad request")
+	ErrMemberExists         = errors.New("account is already a member of the workspace")
+	ErrInvalidRole          = errors.New("invalid role")
+	ErrFolderCycle          = errors.New("folder cannot be moved into itself or one of its sub folders")
+	ErrLastOwner            = errors.New("workspace must have at least one owner")
+	ErrInvalidMetadata      = errors.New("invalid metadata")
+	ErrMetadataFieldExists  = errors.New("metadata field already exists")
+	ErrInvalidFilter        = errors.New("invalid filter")
+	ErrInvalidWorkflow      = errors.New("invalid workflow")
+	ErrTransitionNotAllowed = errors.New("transition is not allowed from the current document status")
+	ErrTransitionPending    = errors.New("document already has a pending transition")
+	ErrTransitionClosed     = errors.New("transition is no longer pending")
+	ErrAlreadyDecided       = errors.New("you already decided on this transition")
+	ErrStatusChanged        = errors.New("document status changed, please reload and try again")
+	ErrDocumentLocked       = errors.New("document can only be edited in draft status")
+	ErrRequestClosed        = errors.New("document request is no longer open")
+	ErrItemReceived         = errors.New("document was already received for this item")
+	ErrItemChanged          = errors.New("document request item changed, please try again")
+	ErrFileTypeNotAllowed   = errors.New("file type is not allowed")
+	ErrFileTooLarge         = errors.New("file is too large")
+	ErrLengthRequired       = errors.New("content length is required")
+	ErrFileMissing          = errors.New("file is missing")
+	ErrReindexRunning       = errors.New("a reindex is already running")
+	ErrInvalidWebhook       = errors.New("invalid webhook")
+	ErrWebhookDisabled      = errors.New("webhook is disabled")
+	ErrDeliveryPending      = errors.New("webhook delivery is still pending")
+	ErrInvalidNotification  = errors.New("invalid notification type")
+	ErrInvalidPreference    = errors.New("invalid notification preference")
+	ErrInvalidLocale        = errors.New("unsupported locale")
+	ErrInvalidComment       = errors.New("invalid comment")
+	ErrInvalidCachePrefix   = errors.New("unknown cache prefix")
 
 	//Unauthorized
 	ErrTokenExpired       = errors.New("token expired")
 	ErrUnauthorized       = errors.New("unauthorized")
 	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	//Forbidden
+	ErrForbidden = errors.New("you do not have permission to perform this action")
 )
 
 var customErrors = map[error]int{
 	ErrInternalServer: http.StatusInternalServerError,
 
-	ErrAccountNotFound: http.StatusNotFound,
+	ErrAccountNotFound:         http.StatusNotFound,
+	ErrWorkspaceNotFound:       http.StatusNotFound,
+	ErrWorkspaceMemberNotFound: http.StatusNotFound,
+	ErrProjectNotFound:         http.StatusNotFound,
+	ErrFolderNotFound:          http.StatusNotFound,
+	ErrDocumentNotFound:        http.StatusNotFound,
+	ErrPermissionNotFound:      http.StatusNotFound,
+	ErrMetadataFieldNotFound:   http.StatusNotFound,
+	ErrTransitionNotFound:      http.StatusNotFound,
+	ErrVersionNotFound:         http.StatusNotFound,
+	ErrDocumentRequestNotFound: http.StatusNotFound,
+	ErrRequestItemNotFound:     http.StatusNotFound,
+	ErrWebhookNotFound:         http.StatusNotFound,
+	ErrWebhookDeliveryNotFound: http.StatusNotFound,
+	ErrNotificationNotFound:    http.StatusNotFound,
+	ErrCommentNotFound:         http.StatusNotFound,
 
-	ErrAccountExists: http.StatusBadRequest,
-	ErrBadRequest:    http.StatusBadRequest,
+	ErrAccountExists:        http.StatusBadRequest,
+	ErrBadRequest:           http.StatusBadRequest,
+	ErrMemberExists:         http.StatusBadRequest,
+	ErrInvalidRole:          http.StatusBadRequest,
+	ErrFolderCycle:          http.StatusBadRequest,
+	ErrLastOwner:            http.StatusBadRequest,
+	ErrInvalidMetadata:      http.StatusBadRequest,
+	ErrMetadataFieldExists:  http.StatusBadRequest,
+	ErrInvalidFilter:        http.StatusBadRequest,
+	ErrInvalidWorkflow:      http.StatusBadRequest,
+	ErrTransitionNotAllowed: http.StatusBadRequest,
+	ErrTransitionPending:    http.StatusBadRequest,
+	ErrTransitionClosed:     http.StatusBadRequest,
+	ErrAlreadyDecided:       http.StatusBadRequest,
+	ErrStatusChanged:        http.StatusConflict,
+	ErrDocumentLocked:       http.StatusBadRequest,
+	ErrRequestClosed:        http.StatusBadRequest,
+	ErrItemReceived:         http.StatusBadRequest,
+	ErrItemChanged:          http.StatusConflict,
+	ErrFileTypeNotAllowed:   http.StatusBadRequest,
+	ErrFileTooLarge:         http.StatusRequestEntityTooLarge,
+	ErrLengthRequired:       http.StatusLengthRequired,
+	ErrFileMissing:          http.StatusBadRequest,
+	ErrReindexRunning:       http.StatusConflict,
+	ErrInvalidWebhook:       http.StatusBadRequest,
+	ErrWebhookDisabled:      http.StatusBadRequest,
+	ErrDeliveryPending:      http.StatusBadRequest,
+	ErrInvalidNotification:  http.StatusBadRequest,
+	ErrInvalidPreference:    http.StatusBadRequest,
+	ErrInvalidLocale:        http.StatusBadRequest,
+	ErrInvalidComment:       http.StatusBadRequest,
+	ErrInvalidCachePrefix:   http.StatusBadRequest,
 
 	ErrTokenExpired:       http.StatusUnauthorized,
 	ErrUnauthorized:       http.StatusUnauthorized,
 	ErrInvalidCredentials: http.StatusUnauthorized,
+
+	ErrForbidden: http.StatusForbidden,
 }
 
 func IsErrorCustom(err error) bool {
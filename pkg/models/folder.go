@@ -0,0 +1,99 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+// PathSeparator separates folder ids in a materialized Folder.Path
+const PathSeparator = "/"
+
+// Folder is a node of the folder tree of a project. Path holds the ids of all
+// ancestors and the folder itself, e.g. "/fld_a/fld_b/", which lets a whole
+// subtree be selected with a single prefix match.
+type Folder struct {
+	Base
+	AuditBase
+	WorkspaceId string `json:"workspace_id"`
+	ProjectId   string `json:"project_id"`
+	ParentId    string `json:"parent_id"`
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Depth       int    `json:"depth"`
+}
+
+// NewFolder creates a folder under parent, or at the project root when parent is nil
+func NewFolder(project *Project, parent *Folder, name, createdBy string) *Folder {
+	f := &Folder{
+		WorkspaceId: project.WorkspaceId,
+		ProjectId:   project.Id,
+		Name:        name,
+		Path:        PathSeparator,
+		AuditBase:   AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy},
+	}
+	if parent != nil {
+		f.ParentId = parent.Id
+		f.Path = parent.Path
+		f.Depth = parent.Depth + 1
+	}
+	return f
+}
+
+// BeforeCreate assigns the id and appends it to the parent path set by NewFolder
+func (f *Folder) BeforeCreate(tx *gorm.DB) (err error) {
+	f.Id = crypto.GenerateId("fld", IdSize)
+	if f.Path == "" {
+		f.Path = PathSeparator
+	}
+	f.Path = f.Path + f.Id + PathSeparator
+	return nil
+}
+
+// AncestorIds returns the ids of the folder ancestors ordered from the root,
+// excluding the folder itself
+func (f *Folder) AncestorIds() []string {
+	ids := strings.Split(strings.Trim(f.Path, PathSeparator), PathSeparator)
+	if len(ids) == 0 {
+		return ids
+	}
+	return ids[:len(ids)-1]
+}
+
+// IsAncestorOf reports whether f is other or one of its ancestors
+func (f *Folder) IsAncestorOf(other *Folder) bool {
+	return strings.HasPrefix(other.Path, f.Path)
+}
+
+func (f *Folder) Create(db *gorm.DB) (*Folder, error) {
+	err := db.Create(&f).Error
+	if err != nil {
+		return &Folder{}, err
+	}
+	return f, nil
+}
+
+func (f *Folder) Update(db *gorm.DB) (*Folder, error) {
+	db = db.Model(&Folder{}).Where("id = ?", f.Id).UpdateColumns(
+		map[string]interface{}{
+			"name":        f.Name,
+			"modified_by": f.ModifiedBy,
+		},
+	)
+	if db.Error != nil {
+		return &Folder{}, db.Error
+	}
+	err := db.Model(&Folder{}).Where("id = ?", f.Id).Take(&f).Error
+	if err != nil {
+		return &Folder{}, err
+	}
+	return f, nil
+}
+
+// Breadcrumb is a single entry of the navigation trail of a folder or document
+type Breadcrumb struct {
+	Type ResourceType `json:"type"`
+	Id   string       `json:"id"`
+	Name string       `json:"name"`
+}
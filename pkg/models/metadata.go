@@ -0,0 +1,170 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+type MetadataFieldType string
+
+const (
+	MetadataFieldTypeString MetadataFieldType = "string"
+	MetadataFieldTypeNumber MetadataFieldType = "number"
+	MetadataFieldTypeDate   MetadataFieldType = "date"
+	MetadataFieldTypeEnum   MetadataFieldType = "enum"
+)
+
+// MetadataDateLayout is the layout date metadata values are stored in. Keeping
+// dates in this form makes them sort and compare correctly as text.
+const MetadataDateLayout = "2006-01-02"
+
+// MetadataField is a custom metadata field defined by a workspace. Document
+// metadata is validated against the fields of its workspace on every write.
+type MetadataField struct {
+	Base
+	AuditBase
+	WorkspaceId string            `json:"workspace_id"`
+	Key         string            `json:"key"`
+	Label       string            `json:"label"`
+	Type        MetadataFieldType `json:"type"`
+	Options     StringList        `json:"options"`
+	Required    bool              `json:"required"`
+}
+
+func NewMetadataField(workspaceId, key, label string, fieldType MetadataFieldType, options StringList, required bool, createdBy string) *MetadataField {
+	return &MetadataField{
+		WorkspaceId: workspaceId,
+		Key:         key,
+		Label:       label,
+		Type:        fieldType,
+		Options:     options,
+		Required:    required,
+		AuditBase:   AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy},
+	}
+}
+
+func (f *MetadataField) BeforeCreate(tx *gorm.DB) (err error) {
+	f.Id = crypto.GenerateId("mdf", IdSize)
+	return nil
+}
+
+func (f *MetadataField) Create(db *gorm.DB) (*MetadataField, error) {
+	err := db.Create(&f).Error
+	if err != nil {
+		return &MetadataField{}, err
+	}
+	return f, nil
+}
+
+func (f *MetadataField) Update(db *gorm.DB) (*MetadataField, error) {
+	db = db.Model(&MetadataField{}).Where("id = ?", f.Id).UpdateColumns(
+		map[string]interface{}{
+			"label":       f.Label,
+			"options":     f.Options,
+			"required":    f.Required,
+			"modified_by": f.ModifiedBy,
+		},
+	)
+	if db.Error != nil {
+		return &MetadataField{}, db.Error
+	}
+	err := db.Model(&MetadataField{}).Where("id = ?", f.Id).Take(&f).Error
+	if err != nil {
+		return &MetadataField{}, err
+	}
+	return f, nil
+}
+
+func (f *MetadataField) Delete(db *gorm.DB) (int64, error) {
+	db = db.Unscoped().Model(&MetadataField{}).Where("id = ?", f.Id).Delete(&MetadataField{})
+	if db.Error != nil {
+		return 0, db.Error
+	}
+	return db.RowsAffected, nil
+}
+
+// Normalize checks value against the field type and returns it in the form it
+// is stored in: strings, float64 numbers, dates as MetadataDateLayout and enum
+// values as one of the field options.
+func (f *MetadataField) Normalize(value interface{}) (interface{}, error) {
+	switch f.Type {
+	case MetadataFieldTypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case MetadataFieldTypeNumber:
+		switch v := value.(type) {
+		case float64:
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				return v, nil
+			}
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case json.Number:
+			if n, err := v.Float64(); err == nil {
+				return n, nil
+			}
+		case string:
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				return n, nil
+			}
+		}
+	case MetadataFieldTypeDate:
+		if s, ok := value.(string); ok {
+			for _, layout := range []string{MetadataDateLayout, time.RFC3339} {
+				if t, err := time.Parse(layout, s); err == nil {
+					return t.Format(MetadataDateLayout), nil
+				}
+			}
+		}
+	case MetadataFieldTypeEnum:
+		if s, ok := value.(string); ok {
+			for _, option := range f.Options {
+				if option == s {
+					return s, nil
+				}
+			}
+			return nil, fmt.Errorf("%w: %s must be one of %v", ErrInvalidMetadata, f.Key, f.Options)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s must be a %s", ErrInvalidMetadata, f.Key, f.Type)
+}
+
+// ValidateMetadata validates values against the workspace fields and returns
+// the normalized metadata. Unknown keys are rejected and required fields must
+// be present.
+func ValidateMetadata(fields []*MetadataField, values Jsonb) (Jsonb, error) {
+	byKey := make(map[string]*MetadataField, len(fields))
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+	out := Jsonb{}
+	for key, value := range values {
+		f, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %s", ErrInvalidMetadata, key)
+		}
+		if value == nil || value == "" {
+			continue
+		}
+		v, err := f.Normalize(value)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+	for _, f := range fields {
+		if _, ok := out[f.Key]; f.Required && !ok {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidMetadata, f.Key)
+		}
+	}
+	return out, nil
+}
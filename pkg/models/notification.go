@@ -0,0 +1,97 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+type NotificationType string
+
+const (
+	NotificationDocumentExpiring      NotificationType = "document.expiring"
+	NotificationDocumentStatusChanged NotificationType = "document.status_changed"
+	NotificationRequestCompleted      NotificationType = "document_request.completed"
+	NotificationTransitionRequested   NotificationType = "transition.requested"
+	NotificationTransitionDecided     NotificationType = "transition.decided"
+	NotificationCommentMentioned      NotificationType = "comment.mentioned"
+	NotificationCommentReplied        NotificationType = "comment.replied"
+)
+
+// NotificationChannels says where notifications of a type are delivered.
+// Digest batches the unread in-app notifications into a daily email.
+type NotificationChannels struct {
+	InApp  bool `json:"in_app"`
+	Email  bool `json:"email"`
+	Digest bool `json:"digest"`
+}
+
+// NotificationDefaults lists every notification type with the channels used
+// until an account sets its own preference
+var NotificationDefaults = map[NotificationType]NotificationChannels{
+	NotificationDocumentExpiring:      {InApp: true, Email: true},
+	NotificationDocumentStatusChanged: {InApp: true},
+	NotificationRequestCompleted:      {InApp: true, Email: true},
+	NotificationTransitionRequested:   {InApp: true, Email: true},
+	NotificationTransitionDecided:     {InApp: true},
+	NotificationCommentMentioned:      {InApp: true, Email: true},
+	NotificationCommentReplied:        {InApp: true},
+}
+
+// IsValid reports whether t is a known notification type
+func (t NotificationType) IsValid() bool {
+	_, ok := NotificationDefaults[t]
+	return ok
+}
+
+// Notification is a message for a single account about a resource, shown in
+// the notification center until it is read
+type Notification struct {
+	Base
+	AccountId    string           `json:"account_id"`
+	WorkspaceId  string           `json:"workspace_id"`
+	Type         NotificationType `json:"type"`
+	Title        string           `json:"title"`
+	Body         string           `json:"body"`
+	ResourceType ResourceType     `json:"resource_type"`
+	ResourceId   string           `json:"resource_id"`
+	ReadAt       sql.NullTime     `json:"read_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	n.Id = crypto.GenerateId("ntf", IdSize)
+	return nil
+}
+
+func (n *Notification) Create(db *gorm.DB) (*Notification, error) {
+	err := db.Create(&n).Error
+	if err != nil {
+		return &Notification{}, err
+	}
+	return n, nil
+}
+
+// NotificationPreference overrides the default channels of a notification
+// type for an account
+type NotificationPreference struct {
+	AccountId string           `gorm:"primaryKey" json:"-"`
+	Type      NotificationType `gorm:"primaryKey" json:"type"`
+	NotificationChannels
+	Updated int64 `gorm:"autoUpdateTime:milli" json:"updated"`
+}
+
+func NewNotificationPreference(accountId string, notificationType NotificationType, channels NotificationChannels) *NotificationPreference {
+	return &NotificationPreference{
+		AccountId:            accountId,
+		Type:                 notificationType,
+		NotificationChannels: channels,
+	}
+}
+
+// NotificationDigest records when the last digest was sent to an account
+type NotificationDigest struct {
+	AccountId string    `gorm:"primaryKey" json:"-"`
+	SentAt    time.Time `json:"sent_at"`
+}
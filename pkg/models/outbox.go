@@ -0,0 +1,48 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is an event written in the transaction of the change it
+// describes. The relay publishes it to the event bus once the transaction is
+// committed. Payload is the JSON encoded event, Id its id. Seq is assigned by
+// the database and orders the events as they were recorded.
+type OutboxEvent struct {
+	Base
+	Seq         int64        `gorm:"->" json:"-"`
+	Type        string       `json:"type"`
+	Topic       string       `json:"topic"`
+	WorkspaceId string       `json:"workspace_id"`
+	Payload     string       `json:"-"`
+	Attempts    int          `json:"attempts"`
+	LastError   string       `json:"last_error"`
+	AvailableAt time.Time    `json:"available_at"`
+	DeliveredAt sql.NullTime `json:"delivered_at"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+func NewOutboxEvent(id, eventType, topic, workspaceId, payload string) *OutboxEvent {
+	return &OutboxEvent{
+		Base:        Base{Id: id},
+		Type:        eventType,
+		Topic:       topic,
+		WorkspaceId: workspaceId,
+		Payload:     payload,
+		AvailableAt: time.Now(),
+	}
+}
+
+func (o *OutboxEvent) Create(db *gorm.DB) (*OutboxEvent, error) {
+	err := db.Create(&o).Error
+	if err != nil {
+		return &OutboxEvent{}, err
+	}
+	return o, nil
+}
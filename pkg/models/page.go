@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -64,6 +65,29 @@ func NewPageFromContext(c *TrackDocsContext) *Page {
 	}
 }
 
+// TakeFilter removes the filter for key and returns its value, so callers can
+// apply filters that are not plain column equality themselves
+func (p *Page) TakeFilter(key string) (interface{}, bool) {
+	value, ok := p.Filter[key]
+	if ok {
+		delete(p.Filter, key)
+	}
+	return value, ok
+}
+
+// TakeFilterPrefix removes all filters whose key starts with prefix and returns
+// them keyed by the rest of the key
+func (p *Page) TakeFilterPrefix(prefix string) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, v := range p.Filter {
+		if strings.HasPrefix(k, prefix) {
+			out[strings.TrimPrefix(k, prefix)] = v
+			delete(p.Filter, k)
+		}
+	}
+	return out
+}
+
 func (p *Page) Paginate(db *gorm.DB) *gorm.DB {
 	offset := (p.CurrentPage - 1) * p.PageSize
 	if len(p.Sort) > 0 {
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+type ResourceType string
+
+const (
+	ResourceTypeWorkspace ResourceType = "workspace"
+	ResourceTypeProject   ResourceType = "project"
+	ResourceTypeFolder    ResourceType = "folder"
+	ResourceTypeDocument  ResourceType = "document"
+)
+
+// Permission grants an account a role on a project, folder or document. Grants
+// are inherited down the tree (project -> folder -> sub folder -> document)
+// and the grant closest to the resource wins.
+type Permission struct {
+	Base
+	AuditBase
+	WorkspaceId  string        `json:"workspace_id"`
+	ResourceType ResourceType  `json:"resource_type"`
+	ResourceId   string        `json:"resource_id"`
+	AccountId    string        `json:"account_id"`
+	Role         WorkspaceRole `json:"role"`
+}
+
+func NewPermission(workspaceId string, resourceType ResourceType, resourceId, accountId string, role WorkspaceRole, createdBy string) *Permission {
+	return &Permission{
+		WorkspaceId:  workspaceId,
+		ResourceType: resourceType,
+		ResourceId:   resourceId,
+		AccountId:    accountId,
+		Role:         role,
+		AuditBase:    AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy},
+	}
+}
+
+func (p *Permission) BeforeCreate(tx *gorm.DB) (err error) {
+	p.Id = crypto.GenerateId("prm", IdSize)
+	return nil
+}
+
+func (p *Permission) Create(db *gorm.DB) (*Permission, error) {
+	err := db.Create(&p).Error
+	if err != nil {
+		return &Permission{}, err
+	}
+	return p, nil
+}
+
+func (p *Permission) Delete(db *gorm.DB) (int64, error) {
+	db = db.Unscoped().Model(&Permission{}).Where("id = ?", p.Id).Delete(&Permission{})
+	if db.Error != nil {
+		return 0, db.Error
+	}
+	return db.RowsAffected, nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+type Project struct {
+	Base
+	AuditBase
+	WorkspaceId string `json:"workspace_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func NewProject(workspaceId, name, description, createdBy string) *Project {
+	return &Project{
+		WorkspaceId: workspaceId,
+		Name:        name,
+		Description: description,
+		AuditBase:   AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy},
+	}
+}
+
+func (p *Project) BeforeCreate(tx *gorm.DB) (err error) {
+	p.Id = crypto.GenerateId("prj", IdSize)
+	return nil
+}
+
+func (p *Project) Create(db *gorm.DB) (*Project, error) {
+	err := db.Create(&p).Error
+	if err != nil {
+		return &Project{}, err
+	}
+	return p, nil
+}
+
+func (p *Project) Update(db *gorm.DB) (*Project, error) {
+	db = db.Model(&Project{}).Where("id = ?", p.Id).UpdateColumns(
+		map[string]interface{}{
+			"name":        p.Name,
+			"description": p.Description,
+			"modified_by": p.ModifiedBy,
+		},
+	)
+	if db.Error != nil {
+		return &Project{}, db.Error
+	}
+	err := db.Model(&Project{}).Where("id = ?", p.Id).Take(&p).Error
+	if err != nil {
+		return &Project{}, err
+	}
+	return p, nil
+}
+
+func (p *Project) Delete(db *gorm.DB) (int64, error) {
+	db = db.Model(&Project{}).Where("id = ?", p.Id).Delete(&Project{})
+	if db.Error != nil {
+		return 0, db.Error
+	}
+	return db.RowsAffected, nil
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+// DocumentReminder records that the owner was reminded of an upcoming expiry.
+// It is keyed on the expiry date as well, so renewing a document re-arms its
+// reminders.
+type DocumentReminder struct {
+	Base
+	DocumentId string    `json:"document_id"`
+	OffsetDays int       `json:"offset_days"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (r *DocumentReminder) BeforeCreate(tx *gorm.DB) (err error) {
+	r.Id = crypto.GenerateId("rmd", IdSize)
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+// Tag is a free-form label shared by the documents of a workspace
+type Tag struct {
+	Base
+	WorkspaceId string `json:"workspace_id"`
+	Name        string `json:"name"`
+	UsageCount  int64  `gorm:"->;-:migration" json:"usage_count"`
+}
+
+// DocumentTag links a document to a tag
+type DocumentTag struct {
+	DocumentId string `gorm:"primaryKey" json:"document_id"`
+	TagId      string `gorm:"primaryKey" json:"tag_id"`
+	Created    int64  `gorm:"autoCreateTime:milli" json:"created"`
+}
+
+// NormalizeTag trims and lowercases a tag name so "Invoice " and "invoice"
+// are the same tag
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func NewTag(workspaceId, name string) *Tag {
+	return &Tag{
+		WorkspaceId: workspaceId,
+		Name:        NormalizeTag(name),
+	}
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) (err error) {
+	t.Id = crypto.GenerateId("tag", IdSize)
+	return nil
+}
+
+func (t *Tag) Create(db *gorm.DB) (*Tag, error) {
+	err := db.Create(&t).Error
+	if err != nil {
+		return &Tag{}, err
+	}
+	return t, nil
+}
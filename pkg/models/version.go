@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql"
+	"path"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+// ExtractStatus tells whether the text of a version was extracted
+type ExtractStatus string
+
+const (
+	ExtractStatusPending ExtractStatus = "pending"
+	ExtractStatusDone    ExtractStatus = "done"
+	ExtractStatusFailed  ExtractStatus = "failed"
+	// ExtractStatusSkipped versions have an unsupported type or are too large
+	ExtractStatusSkipped ExtractStatus = "skipped"
+)
+
+// DocumentVersion is an uploaded file of a document. Versions are numbered
+// from 1 and the highest number is the current file of the document.
+type DocumentVersion struct {
+	Base
+	WorkspaceId string `json:"workspace_id"`
+	DocumentId  string `json:"document_id"`
+	Version     int    `json:"version"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	StorageKey  string `json:"-"`
+	CreatedBy   string `json:"created_by"`
+	// Text is extracted after the upload, pages are separated by form feeds
+	Text            string        `json:"-"`
+	PageCount       int           `json:"page_count"`
+	ExtractStatus   ExtractStatus `json:"extract_status"`
+	ExtractError    string        `json:"extract_error,omitempty"`
+	ExtractAttempts int           `json:"-"`
+	// ExtractAfter delays the next attempt, it is also the lease of the
+	// attempt in progress
+	ExtractAfter sql.NullTime `json:"-"`
+}
+
+func NewDocumentVersion(document *Document, fileName, contentType, createdBy string) *DocumentVersion {
+	v := &DocumentVersion{
+		WorkspaceId:   document.WorkspaceId,
+		DocumentId:    document.Id,
+		FileName:      path.Base(fileName),
+		ContentType:   contentType,
+		CreatedBy:     createdBy,
+		ExtractStatus: ExtractStatusPending,
+	}
+	v.Id = crypto.GenerateId("ver", IdSize)
+	v.StorageKey = path.Join(document.WorkspaceId, document.Id, v.Id)
+	return v
+}
+
+// BeforeCreate keeps the id assigned by NewDocumentVersion, the file is
+// stored under it before the row is created
+func (v *DocumentVersion) BeforeCreate(tx *gorm.DB) (err error) {
+	if v.Id == "" {
+		v.Id = crypto.GenerateId("ver", IdSize)
+	}
+	return nil
+}
+
+func (v *DocumentVersion) Create(db *gorm.DB) (*DocumentVersion, error) {
+	err := db.Create(&v).Error
+	if err != nil {
+		return &DocumentVersion{}, err
+	}
+	return v, nil
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+// Webhook posts the events of its workspace listed in Events to Url. Payloads
+// are signed with the secret, which is stored encrypted and only returned
+// when the webhook is created. FailureCount counts the failed attempts since
+// the last successful one, the webhook is disabled once it grows too large.
+type Webhook struct {
+	Base
+	AuditBase
+	WorkspaceId     string       `json:"workspace_id"`
+	Url             string       `json:"url"`
+	Description     string       `json:"description"`
+	Events          StringList   `json:"events"`
+	EncryptedSecret string       `json:"-"`
+	Enabled         bool         `json:"enabled"`
+	FailureCount    int          `json:"failure_count"`
+	DisabledAt      sql.NullTime `json:"disabled_at"`
+	DisabledReason  string       `json:"disabled_reason"`
+
+	// Secret signs the payloads, only known right after creation
+	Secret string `gorm:"-" json:"secret,omitempty"`
+}
+
+func NewWebhook(workspaceId, url, description string, events StringList, encryptedSecret, createdBy string) *Webhook {
+	return &Webhook{
+		WorkspaceId:     workspaceId,
+		Url:             url,
+		Description:     description,
+		Events:          events,
+		EncryptedSecret: encryptedSecret,
+		Enabled:         true,
+		AuditBase:       AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy},
+	}
+}
+
+// Subscribes reports whether the webhook receives events of eventType
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Webhook) BeforeCreate(tx *gorm.DB) (err error) {
+	w.Id = crypto.GenerateId("whk", IdSize)
+	return nil
+}
+
+func (w *Webhook) Create(db *gorm.DB) (*Webhook, error) {
+	err := db.Create(&w).Error
+	if err != nil {
+		return &Webhook{}, err
+	}
+	return w, nil
+}
+
+func (w *Webhook) Update(db *gorm.DB) (*Webhook, error) {
+	err := db.Model(&Webhook{}).Where("id = ?", w.Id).UpdateColumns(
+		map[string]interface{}{
+			"url":             w.Url,
+			"description":     w.Description,
+			"events":          w.Events,
+			"enabled":         w.Enabled,
+			"failure_count":   w.FailureCount,
+			"disabled_at":     w.DisabledAt,
+			"disabled_reason": w.DisabledReason,
+			"modified_by":     w.ModifiedBy,
+		},
+	).Error
+	if err != nil {
+		return &Webhook{}, err
+	}
+	return w, nil
+}
+
+func (w *Webhook) Delete(db *gorm.DB) (int64, error) {
+	db = db.Model(&Webhook{}).Where("id = ?", w.Id).Delete(&Webhook{})
+	if db.Error != nil {
+		return 0, db.Error
+	}
+	return db.RowsAffected, nil
+}
+
+// WebhookDelivery is an event sent to a webhook. Payload is the exact body
+// posted, so redeliveries carry the same signature input.
+type WebhookDelivery struct {
+	Base
+	WebhookId     string                `json:"webhook_id"`
+	WorkspaceId   string                `json:"workspace_id"`
+	EventId       string                `json:"event_id"`
+	EventType     string                `json:"event_type"`
+	Payload       string                `json:"-"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	ResponseCode  int                   `json:"response_code"`
+	NextAttemptAt sql.NullTime          `json:"next_attempt_at"`
+	DeliveredAt   sql.NullTime          `json:"delivered_at"`
+	Log           []*WebhookAttempt     `gorm:"foreignKey:DeliveryId" json:"log,omitempty"`
+	// RedeliveredAfter is the number of attempts made before the last
+	// redelivery, its retries count from there
+	RedeliveredAfter int `json:"redelivered_after"`
+}
+
+func NewWebhookDelivery(webhook *Webhook, eventId, eventType, payload string) *WebhookDelivery {
+	return &WebhookDelivery{
+		WebhookId:     webhook.Id,
+		WorkspaceId:   webhook.WorkspaceId,
+		EventId:       eventId,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        WebhookDeliveryStatusPending,
+		NextAttemptAt: NewSqlNullTime(time.Now()),
+	}
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	d.Id = crypto.GenerateId("whd", IdSize)
+	return nil
+}
+
+func (d *WebhookDelivery) Update(db *gorm.DB) (*WebhookDelivery, error) {
+	err := db.Model(&WebhookDelivery{}).Where("id = ?", d.Id).UpdateColumns(
+		map[string]interface{}{
+			"status":          d.Status,
+			"attempts":        d.Attempts,
+			"response_code":   d.ResponseCode,
+			"next_attempt_at": d.NextAttemptAt,
+			"delivered_at":    d.DeliveredAt,
+		},
+	).Error
+	if err != nil {
+		return &WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+// WebhookAttempt logs a single request of a delivery. ResponseCode is 0 when
+// no response was received, Error then says why.
+type WebhookAttempt struct {
+	Base
+	DeliveryId   string `json:"delivery_id"`
+	Attempt      int    `json:"attempt"`
+	ResponseCode int    `json:"response_code"`
+	ResponseBody string `json:"response_body"`
+	Error        string `json:"error"`
+	Duration     int64  `json:"duration"` // milliseconds
+}
+
+func (a *WebhookAttempt) BeforeCreate(tx *gorm.DB) (err error) {
+	a.Id = crypto.GenerateId("wha", IdSize)
+	return nil
+}
+
+func (a *WebhookAttempt) Create(db *gorm.DB) (*WebhookAttempt, error) {
+	err := db.Create(&a).Error
+	if err != nil {
+		return &WebhookAttempt{}, err
+	}
+	return a, nil
+}
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+type DocumentStatus string
+
+const (
+	DocumentStatusDraft     DocumentStatus = "draft"
+	DocumentStatusInReview  DocumentStatus = "in_review"
+	DocumentStatusApproved  DocumentStatus = "approved"
+	DocumentStatusPublished DocumentStatus = "published"
+	DocumentStatusArchived  DocumentStatus = "archived"
+)
+
+var documentStatuses = map[DocumentStatus]bool{
+	DocumentStatusDraft:     true,
+	DocumentStatusInReview:  true,
+	DocumentStatusApproved:  true,
+	DocumentStatusPublished: true,
+	DocumentStatusArchived:  true,
+}
+
+// IsValid reports whether s is one of the known document states
+func (s DocumentStatus) IsValid() bool {
+	return documentStatuses[s]
+}
+
+// IsEditable reports whether documents in this state may be changed. Once a
+// document leaves draft it has to be moved back to draft before editing.
+func (s DocumentStatus) IsEditable() bool {
+	return s == DocumentStatusDraft || s == ""
+}
+
+// WorkflowTransition is an allowed move between two document states of a
+// workspace. A transition needs approval when Quorum is above zero: either
+// Quorum of the named ApproverIds, or Quorum accounts holding ApproverRole on
+// the document when no approvers are named.
+type WorkflowTransition struct {
+	Base
+	AuditBase
+	WorkspaceId  string         `json:"workspace_id"`
+	From         DocumentStatus `json:"from"`
+	To           DocumentStatus `json:"to"`
+	ApproverRole WorkspaceRole  `json:"approver_role"`
+	ApproverIds  StringList     `json:"approver_ids"`
+	Quorum       int            `json:"quorum"`
+}
+
+// DefaultWorkflow is used by workspaces that did not configure their own
+// transitions
+func DefaultWorkflow(workspaceId string) []*WorkflowTransition {
+	return []*WorkflowTransition{
+		{WorkspaceId: workspaceId, From: DocumentStatusDraft, To: DocumentStatusInReview},
+		{WorkspaceId: workspaceId, From: DocumentStatusInReview, To: DocumentStatusDraft},
+		{WorkspaceId: workspaceId, From: DocumentStatusInReview, To: DocumentStatusApproved, ApproverRole: WorkspaceRoleAdmin, Quorum: 1},
+		{WorkspaceId: workspaceId, From: DocumentStatusApproved, To: DocumentStatusPublished},
+		{WorkspaceId: workspaceId, From: DocumentStatusApproved, To: DocumentStatusDraft},
+		{WorkspaceId: workspaceId, From: DocumentStatusPublished, To: DocumentStatusArchived},
+		{WorkspaceId: workspaceId, From: DocumentStatusPublished, To: DocumentStatusDraft},
+		{WorkspaceId: workspaceId, From: DocumentStatusArchived, To: DocumentStatusDraft},
+	}
+}
+
+// NeedsApproval reports whether the transition has to be approved before it
+// is applied
+func (t *WorkflowTransition) NeedsApproval() bool {
+	return t.Quorum > 0
+}
+
+func (t *WorkflowTransition) BeforeCreate(tx *gorm.DB) (err error) {
+	t.Id = crypto.GenerateId("wft", IdSize)
+	return nil
+}
+
+type TransitionStatus string
+
+const (
+	TransitionStatusPending   TransitionStatus = "pending"
+	TransitionStatusCompleted TransitionStatus = "completed"
+	TransitionStatusRejected  TransitionStatus = "rejected"
+	TransitionStatusCancelled TransitionStatus = "cancelled"
+)
+
+// DocumentTransition records a requested or applied change of a document
+// state. Together these rows form the transition history of the document.
+type DocumentTransition struct {
+	Base
+	WorkspaceId  string                `json:"workspace_id"`
+	DocumentId   string                `json:"document_id"`
+	From         DocumentStatus        `json:"from"`
+	To           DocumentStatus        `json:"to"`
+	Status       TransitionStatus      `json:"status"`
+	ApproverRole WorkspaceRole         `json:"approver_role"`
+	ApproverIds  StringList            `json:"approver_ids"`
+	Quorum       int                   `json:"quorum"`
+	RequestedBy  string                `json:"requested_by"`
+	Comment      string                `json:"comment"`
+	CompletedAt  sql.NullTime          `json:"completed_at"`
+	Approvals    []*TransitionApproval `gorm:"foreignKey:TransitionId" json:"approvals,omitempty"`
+}
+
+func NewDocumentTransition(document *Document, transition *WorkflowTransition, requestedBy, comment string) *DocumentTransition {
+	return &DocumentTransition{
+		WorkspaceId:  document.WorkspaceId,
+		DocumentId:   document.Id,
+		From:         transition.From,
+		To:           transition.To,
+		Status:       TransitionStatusPending,
+		ApproverRole: transition.ApproverRole,
+		ApproverIds:  transition.ApproverIds,
+		Quorum:       transition.Quorum,
+		RequestedBy:  requestedBy,
+		Comment:      comment,
+	}
+}
+
+func (t *DocumentTransition) BeforeCreate(tx *gorm.DB) (err error) {
+	t.Id = crypto.GenerateId("dtr", IdSize)
+	return nil
+}
+
+func (t *DocumentTransition) Create(db *gorm.DB) (*DocumentTransition, error) {
+	err := db.Create(&t).Error
+	if err != nil {
+		return &DocumentTransition{}, err
+	}
+	return t, nil
+}
+
+// Close ends a pending transition with the given status
+func (t *DocumentTransition) Close(db *gorm.DB, status TransitionStatus) (*DocumentTransition, error) {
+	t.Status = status
+	t.CompletedAt = NewSqlNullTime(time.Now())
+	err := db.Model(&DocumentTransition{}).Where("id = ?", t.Id).UpdateColumns(
+		map[string]interface{}{
+			"status":       t.Status,
+			"completed_at": t.CompletedAt,
+		},
+	).Error
+	if err != nil {
+		return &DocumentTransition{}, err
+	}
+	return t, nil
+}
+
+// IsNamedApprover reports whether the account is one of the named approvers
+func (t *DocumentTransition) IsNamedApprover(accountId string) bool {
+	for _, id := range t.ApproverIds {
+		if id == accountId {
+			return true
+		}
+	}
+	return false
+}
+
+type ApprovalDecision string
+
+const (
+	ApprovalDecisionApprove ApprovalDecision = "approve"
+	ApprovalDecisionReject  ApprovalDecision = "reject"
+)
+
+// TransitionApproval is the decision of a single approver on a pending
+// transition
+type TransitionApproval struct {
+	Base
+	TransitionId string           `json:"transition_id"`
+	AccountId    string           `json:"account_id"`
+	Decision     ApprovalDecision `json:"decision"`
+	Comment      string           `json:"comment"`
+}
+
+func NewTransitionApproval(transitionId, accountId string, decision ApprovalDecision, comment string) *TransitionApproval {
+	return &TransitionApproval{
+		TransitionId: transitionId,
+		AccountId:    accountId,
+		Decision:     decision,
+		Comment:      comment,
+	}
+}
+
+func (a *TransitionApproval) BeforeCreate(tx *gorm.DB) (err error) {
+	a.Id = crypto.GenerateId("apr", IdSize)
+	return nil
+}
+
+func (a *TransitionApproval) Create(db *gorm.DB) (*TransitionApproval, error) {
+	err := db.Create(&a).Error
+	if err != nil {
+		return &TransitionApproval{}, err
+	}
+	return a, nil
+}
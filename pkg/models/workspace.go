@@ -0,0 +1,119 @@
+package models
+
+import (
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner  WorkspaceRole = "owner"
+	WorkspaceRoleAdmin  WorkspaceRole = "admin"
+	WorkspaceRoleEditor WorkspaceRole = "editor"
+	WorkspaceRoleViewer WorkspaceRole = "viewer"
+)
+
+var workspaceRoleRank = map[WorkspaceRole]int{
+	WorkspaceRoleViewer: 1,
+	WorkspaceRoleEditor: 2,
+	WorkspaceRoleAdmin:  3,
+	WorkspaceRoleOwner:  4,
+}
+
+// IsValid reports whether r is one of the known workspace roles
+func (r WorkspaceRole) IsValid() bool {
+	_, ok := workspaceRoleRank[r]
+	return ok
+}
+
+// Includes reports whether r grants at least the privileges of required
+func (r WorkspaceRole) Includes(required WorkspaceRole) bool {
+	rank, ok := workspaceRoleRank[r]
+	if !ok {
+		return false
+	}
+	return rank >= workspaceRoleRank[required]
+}
+
+type Workspace struct {
+	Base
+	AuditBase
+	Name string `json:"name"`
+}
+
+func NewWorkspace(name, createdBy string) *Workspace {
+	return &Workspace{
+		Name:      name,
+		AuditBase: AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy},
+	}
+}
+
+func (w *Workspace) BeforeCreate(tx *gorm.DB) (err error) {
+	w.Id = crypto.GenerateId("wsp", IdSize)
+	return nil
+}
+
+func (w *Workspace) Create(db *gorm.DB) (*Workspace, error) {
+	err := db.Create(&w).Error
+	if err != nil {
+		return &Workspace{}, err
+	}
+	return w, nil
+}
+
+func (w *Workspace) Update(db *gorm.DB) (*Workspace, error) {
+	db = db.Model(&Workspace{}).Where("id = ?", w.Id).UpdateColumns(
+		map[string]interface{}{
+			"name":        w.Name,
+			"modified_by": w.ModifiedBy,
+		},
+	)
+	if db.Error != nil {
+		return &Workspace{}, db.Error
+	}
+	err := db.Model(&Workspace{}).Where("id = ?", w.Id).Take(&w).Error
+	if err != nil {
+		return &Workspace{}, err
+	}
+	return w, nil
+}
+
+type WorkspaceMember struct {
+	Base
+	AuditBase
+	WorkspaceId string        `json:"workspace_id"`
+	AccountId   string        `json:"account_id"`
+	Role        WorkspaceRole `json:"role"`
+}
+
+func NewWorkspaceMember(workspaceId, accountId string, role WorkspaceRole, createdBy string) *WorkspaceMember {
+	return &WorkspaceMember{
+		WorkspaceId: workspaceId,
+		AccountId:   accountId,
+		Role:        role,
+		AuditBase:   AuditBase{CreatedBy: createdBy, ModifiedBy: createdBy},
+	}
+}
+
+func (m *WorkspaceMember) BeforeCreate(tx *gorm.DB) (err error) {
+	m.Id = crypto.GenerateId("wsm", IdSize)
+	return nil
+}
+
+func (m *WorkspaceMember) Create(db *gorm.DB) (*WorkspaceMember, error) {
+	err := db.Create(&m).Error
+	if err != nil {
+		return &WorkspaceMember{}, err
+	}
+	return m, nil
+}
+
+// Delete removes the membership permanently so the account can be invited again
+func (m *WorkspaceMember) Delete(db *gorm.DB) (int64, error) {
+	db = db.Unscoped().Model(&WorkspaceMember{}).Where("id = ?", m.Id).Delete(&WorkspaceMember{})
+	if db.Error != nil {
+		return 0, db.Error
+	}
+	return db.RowsAffected, nil
+}
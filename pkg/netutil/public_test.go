@@ -0,0 +1,104 @@
+package netutil
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublic(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("IsPublic(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestIsLocalName(t *testing.T) {
+	tests := []struct {
+		host  string
+		local bool
+	}{
+		{"localhost", true},
+		{"LOCALHOST.", true},
+		{"api.localhost", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"example.com", false},
+		{"8.8.8.8", false},
+	}
+	for _, tt := range tests {
+		if got := IsLocalName(tt.host); got != tt.local {
+			t.Errorf("IsLocalName(%s) = %v, want %v", tt.host, got, tt.local)
+		}
+	}
+}
+
+func TestPublicClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	client := NewPublicClient(time.Second, nil)
+	resp, err := client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the connection to a loopback address to be refused")
+	}
+	if !errors.Is(err, ErrNotPublic) {
+		t.Fatalf("expected ErrNotPublic, got %v", err)
+	}
+}
+
+func TestPublicClientChecksRedirects(t *testing.T) {
+	client := NewPublicClient(time.Second, func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	})
+	tests := []struct {
+		url     string
+		refused bool
+	}{
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://127.0.0.1:6379/", true},
+		{"http://[::1]/", true},
+		{"http://10.0.0.5:7700/", true},
+		{"http://8.8.8.8/", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		err := client.CheckRedirect(req, nil)
+		if tt.refused && !errors.Is(err, ErrNotPublic) {
+			t.Errorf("redirect to %s: expected ErrNotPublic, got %v", tt.url, err)
+		}
+		if !tt.refused && err != http.ErrUseLastResponse {
+			t.Errorf("redirect to %s: expected ErrUseLastResponse, got %v", tt.url, err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+// KeyKind is what a policy counts requests by
+type KeyKind string
+
+const (
+	KeyAccount   KeyKind = "account"
+	KeyApiKey    KeyKind = "apikey" // the access token of the request
+	KeyIP        KeyKind = "ip"
+	KeyWorkspace KeyKind = "workspace"
+	KeyEmail     KeyKind = "email" // a field of the JSON or form body
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// DefaultPolicies apply when cfg.RateLimitPolicies is empty, 100 requests a
+// minute per account, 30 a minute per ip on the public portal and 60 on ping,
+// the only routes open without an account.
+var DefaultPolicies = []*Policy{
+	{Name: "account", Routes: []string{"/api/*"}, Key: KeyAccount, Rate: 100, Period: Duration(time.Minute)},
+	{Name: "portal", Routes: []string{"/api/portal/*"}, Key: KeyIP, Rate: 30, Period: Duration(time.Minute)},
+	{Name: "ping", Routes: []string{"/api/ping"}, Key: KeyIP, Rate: 60, Period: Duration(time.Minute)},
+}
+
+// Policy limits the requests of the routes it matches. Every matching policy
+// applies, each counts on its own.
+type Policy struct {
+	Name string `json:"name"`
+	// Routes are gin route patterns with an optional method, like
+	// "POST /api/documents/:id/versions". A trailing /* matches every route
+	// below.
+	Routes []string `json:"routes"`
+	Key    KeyKind  `json:"key"`
+	// Param names the route parameter holding the workspace id of workspace
+	// keyed policies, which is required as :id is a resource id on most
+	// routes, and the body field of email keyed ones, email by default
+	Param  string   `json:"param,omitempty"`
+	Rate   int      `json:"rate"`
+	Burst  int      `json:"burst,omitempty"` // Rate when zero
+	Period Duration `json:"period"`
+	// Cost is what a request takes from the limit, 1 when zero. With
+	// CostBytes a request costs one per started CostBytes of its body.
+	Cost      int   `json:"cost,omitempty"`
+	CostBytes int64 `json:"cost_bytes,omitempty"`
+	// AtMost lets a request through while anything is left of the limit,
+	// taking up to its cost, so requests costing more than the burst still
+	// pass once the limit recovered
+	AtMost bool `json:"at_most,omitempty"`
+	// Plans override the limit for accounts on a plan
+	Plans map[string]*Override `json:"plans,omitempty"`
+}
+
+// Override replaces the non zero fields of the limit of a policy
+type Override struct {
+	Rate   int      `json:"rate,omitempty"`
+	Burst  int      `json:"burst,omitempty"`
+	Period Duration `json:"period,omitempty"`
+}
+
+// Duration reads durations like "1m" from JSON
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Limit returns the limit of the policy for accounts on plan
+func (p *Policy) Limit(plan string) cache.Limit {
+	limit := cache.Limit{Rate: p.Rate, Burst: p.Burst, Period: time.Duration(p.Period)}
+	if o, ok := p.Plans[plan]; ok {
+		if o.Rate > 0 {
+			limit.Rate = o.Rate
+			// the burst follows the rate unless it is set
+			limit.Burst = 0
+		}
+		if o.Burst > 0 {
+			limit.Burst = o.Burst
+		}
+		if o.Period > 0 {
+			limit.Period = time.Duration(o.Period)
+		}
+	}
+	if limit.Burst == 0 {
+		limit.Burst = limit.Rate
+	}
+	return limit
+}
+
+// CostOf returns the cost of a request with a body of length bytes. Requests
+// of unknown length are refused before with CostBytes.
+func (p *Policy) CostOf(length int64) int {
+	if p.CostBytes > 0 && length > 0 {
+		return int((length + p.CostBytes - 1) / p.CostBytes)
+	}
+	if p.Cost > 0 {
+		return p.Cost
+	}
+	return 1
+}
+
+// Matches reports whether the policy applies to the route pattern of a
+// request
+func (p *Policy) Matches(method, route string) bool {
+	for _, r := range p.Routes {
+		pattern := r
+		if m, path, ok := strings.Cut(r, " "); ok {
+			if !strings.EqualFold(m, method) {
+				continue
+			}
+			pattern = path
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if route == prefix || strings.HasPrefix(route, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if route == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) validate() error {
+	switch p.Key {
+	case KeyAccount, KeyApiKey, KeyIP, KeyWorkspace, KeyEmail:
+	default:
+		return fmt.Errorf("policy %q: unknown key %q", p.Name, p.Key)
+	}
+	if p.Key == KeyWorkspace && p.Param == "" {
+		return fmt.Errorf("policy %q: workspace keyed policies need the param holding the workspace id", p.Name)
+	}
+	// names appear unquoted in keys and quoted in headers
+	if !validName.MatchString(p.Name) || len(p.Routes) == 0 {
+		return fmt.Errorf("policy %q: a name of letters, digits, _ . - and routes are required", p.Name)
+	}
+	for plan, o := range p.Plans {
+		if o == nil {
+			return fmt.Errorf("policy %q: empty override of plan %q", p.Name, plan)
+		}
+	}
+	for _, plan := range append([]string{""}, planNames(p.Plans)...) {
+		limit := p.Limit(plan)
+		if limit.Rate <= 0 || limit.Period <= 0 || limit.Burst < 0 {
+			return fmt.Errorf("policy %q: rate and period must be positive", p.Name)
+		}
+	}
+	return nil
+}
+
+func planNames(plans map[string]*Override) []string {
+	names := make([]string, 0, len(plans))
+	for name := range plans {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Policies are the rate limit policies of the API
+type Policies struct {
+	policies []*Policy
+}
+
+// NewPolicies reads the policies from the JSON file cfg.RateLimitPolicies,
+// an array of Policy, or uses DefaultPolicies
+func NewPolicies(cfg *config.Config) (*Policies, error) {
+	if cfg.RateLimitPolicies == "" {
+		return &Policies{policies: DefaultPolicies}, nil
+	}
+	data, err := os.ReadFile(cfg.RateLimitPolicies)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read rate limit policies")
+	}
+	var policies []*Policy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, errors.Wrap(err, "failed to parse rate limit policies")
+	}
+	names := map[string]bool{}
+	for _, p := range policies {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("policy %q is defined twice", p.Name)
+		}
+		names[p.Name] = true
+	}
+	return &Policies{policies: policies}, nil
+}
+
+// Match returns the policies of a route pattern
+func (ps *Policies) Match(method, route string) []*Policy {
+	var matched []*Policy
+	for _, p := range ps.policies {
+		if p.Matches(method, route) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+)
+
+func TestPolicyMatches(t *testing.T) {
+	p := &Policy{Routes: []string{"/api/portal/*", "POST /api/documents/:id/versions", "/api/ping"}}
+	tests := []struct {
+		method string
+		route  string
+		want   bool
+	}{
+		{"GET", "/api/portal", true},
+		{"POST", "/api/portal/:token/upload", true},
+		{"GET", "/api/portals", false},
+		{"POST", "/api/documents/:id/versions", true},
+		{"post", "/api/documents/:id/versions", true},
+		{"GET", "/api/documents/:id/versions", false},
+		{"GET", "/api/ping", true},
+		{"GET", "/api/ping/more", false},
+		{"GET", "", false},
+	}
+	for _, tt := range tests {
+		if got := p.Matches(tt.method, tt.route); got != tt.want {
+			t.Errorf("%s %s: got %v, want %v", tt.method, tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyLimit(t *testing.T) {
+	p := &Policy{Rate: 10, Period: Duration(time.Minute), Plans: map[string]*Override{
+		"pro":    {Rate: 100},
+		"burst":  {Burst: 50},
+		"hourly": {Rate: 100, Burst: 20, Period: Duration(time.Hour)},
+	}}
+	tests := []struct {
+		plan string
+		want cache.Limit
+	}{
+		{"", cache.Limit{Rate: 10, Burst: 10, Period: time.Minute}},
+		{"unknown", cache.Limit{Rate: 10, Burst: 10, Period: time.Minute}},
+		{"pro", cache.Limit{Rate: 100, Burst: 100, Period: time.Minute}},
+		{"burst", cache.Limit{Rate: 10, Burst: 50, Period: time.Minute}},
+		{"hourly", cache.Limit{Rate: 100, Burst: 20, Period: time.Hour}},
+	}
+	for _, tt := range tests {
+		if got := p.Limit(tt.plan); got != tt.want {
+			t.Errorf("plan %q: got %+v, want %+v", tt.plan, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyCostOf(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		length int64
+		want   int
+	}{
+		{Policy{}, 100, 1},
+		{Policy{Cost: 5}, 100, 5},
+		{Policy{CostBytes: 1024}, 1, 1},
+		{Policy{CostBytes: 1024}, 1024, 1},
+		{Policy{CostBytes: 1024}, 1025, 2},
+		{Policy{CostBytes: 1024, Cost: 3}, 0, 3},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.CostOf(tt.length); got != tt.want {
+			t.Errorf("%+v of %d bytes: got %d, want %d", tt.policy, tt.length, got, tt.want)
+		}
+	}
+}
+
+func TestNewPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies string
+		err      string
+	}{
+		{"valid", `[
+			{"name": "account", "routes": ["/api/*"], "key": "account", "rate": 100, "period": "1m", "plans": {"pro": {"rate": 1000}}},
+			{"name": "workspace", "routes": ["/api/workspaces/*"], "key": "workspace", "param": "id", "rate": 10, "period": "1s"}
+		]`, ""},
+		{"unknown key", `[{"name": "a", "routes": ["/api/*"], "key": "user", "rate": 1, "period": "1m"}]`, "unknown key"},
+		{"workspace without param", `[{"name": "a", "routes": ["/api/*"], "key": "workspace", "rate": 1, "period": "1m"}]`, "param"},
+		{"bad name", `[{"name": "a b", "routes": ["/api/*"], "key": "ip", "rate": 1, "period": "1m"}]`, "name"},
+		{"no routes", `[{"name": "a", "key": "ip", "rate": 1, "period": "1m"}]`, "routes"},
+		{"no rate", `[{"name": "a", "routes": ["/api/*"], "key": "ip", "period": "1m"}]`, "positive"},
+		{"bad plan", `[{"name": "a", "routes": ["/api/*"], "key": "ip", "rate": 1, "period": "1m", "plans": {"pro": null}}]`, "plan"},
+		{"bad period", `[{"name": "a", "routes": ["/api/*"], "key": "ip", "rate": 1, "period": "soon"}]`, "parse"},
+		{"twice", `[
+			{"name": "a", "routes": ["/api/*"], "key": "ip", "rate": 1, "period": "1m"},
+			{"name": "a", "routes": ["/api/ping"], "key": "ip", "rate": 1, "period": "1m"}
+		]`, "twice"},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "policies.json")
+		if err := os.WriteFile(file, []byte(tt.policies), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewPolicies(&config.Config{RateLimitPolicies: file})
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: got %v, want an error about %s", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestDefaultPolicies(t *testing.T) {
+	for _, p := range DefaultPolicies {
+		if err := p.validate(); err != nil {
+			t.Error(err)
+		}
+	}
+	ps, err := NewPolicies(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := func(policies []*Policy) string {
+		var s []string
+		for _, p := range policies {
+			s = append(s, p.Name)
+		}
+		return strings.Join(s, ",")
+	}
+	if got := names(ps.Match("GET", "/api/ping")); got != "account,ping" {
+		t.Errorf("ping matched %s", got)
+	}
+	if got := names(ps.Match("GET", "/api/documents/:id")); got != "account" {
+		t.Errorf("documents matched %s", got)
+	}
+}
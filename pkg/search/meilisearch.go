@@ -0,0 +1,285 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Meilisearch talks to the Meilisearch HTTP API. Any server speaking the same
+// API, like a stand-in in tests, can be used by pointing host at it.
+type Meilisearch struct {
+	host      string
+	masterKey string
+	index     string
+	client    *http.Client
+
+	lock      sync.Mutex // protects searchKey
+	searchKey *meilisearchKey
+}
+
+type meilisearchKey struct {
+	Uid     string   `json:"uid"`
+	Key     string   `json:"key"`
+	Name    string   `json:"name"`
+	Actions []string `json:"actions"`
+	Indexes []string `json:"indexes"`
+}
+
+type meilisearchError struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+// NewMeilisearch returns an Indexer for the index on host. A nil client uses
+// a client with a 10 second timeout.
+func NewMeilisearch(host, masterKey, index string, client *http.Client) *Meilisearch {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Meilisearch{host: strings.TrimSuffix(host, "/"), masterKey: masterKey, index: index, client: client}
+}
+
+// Setup creates the index and its settings. Both are asynchronous tasks in
+// Meilisearch; creating an existing index fails its task but not the request.
+func (m *Meilisearch) Setup(ctx context.Context) error {
+	err := m.request(ctx, http.MethodPost, "/indexes", map[string]string{"uid": m.index, "primaryKey": "id"}, nil)
+	if err != nil {
+		return err
+	}
+	settings := map[string]interface{}{
+		"searchableAttributes": []string{"name", "tags", "description", "content"},
+		"filterableAttributes": []string{"workspace_id", "project_id", "folder_id", "tags", "status", "owner_id"},
+		"sortableAttributes":   []string{"updated", "name"},
+	}
+	return m.request(ctx, http.MethodPatch, m.indexPath("/settings"), settings, nil)
+}
+
+func (m *Meilisearch) Index(ctx context.Context, docs ...*Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	// the highlight markers must only come from Meilisearch
+	stripped := make([]*Document, 0, len(docs))
+	for _, d := range docs {
+		c := *d
+		c.Name = markerRemover.Replace(c.Name)
+		c.Description = markerRemover.Replace(c.Description)
+		c.Content = markerRemover.Replace(c.Content)
+		c.Tags = make([]string, 0, len(d.Tags))
+		for _, t := range d.Tags {
+			c.Tags = append(c.Tags, markerRemover.Replace(t))
+		}
+		stripped = append(stripped, &c)
+	}
+	return m.request(ctx, http.MethodPost, m.indexPath("/documents?primaryKey=id"), stripped, nil)
+}
+
+func (m *Meilisearch) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return m.request(ctx, http.MethodPost, m.indexPath("/documents/delete-batch"), ids, nil)
+}
+
+func (m *Meilisearch) Search(ctx context.Context, q *Query) (*Result, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"q":                     q.Query,
+		"filter":                meilisearchFilter(q),
+		"offset":                q.Offset,
+		"limit":                 q.Limit,
+		"attributesToHighlight": []string{"name", "description", "tags", "content"},
+		"attributesToCrop":      []string{"content"},
+		"cropLength":            30,
+		"highlightPreTag":       highlightStart,
+		"highlightPostTag":      highlightEnd,
+	}
+	if len(q.Facets) > 0 {
+		body["facets"] = q.Facets
+	}
+	var res struct {
+		Hits []struct {
+			Document
+			Formatted map[string]interface{} `json:"_formatted"`
+		} `json:"hits"`
+		EstimatedTotalHits int64                       `json:"estimatedTotalHits"`
+		FacetDistribution  map[string]map[string]int64 `json:"facetDistribution"`
+	}
+	err := m.request(ctx, http.MethodPost, m.indexPath("/search"), body, &res)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Hits: make([]*Hit, 0, len(res.Hits)), Total: res.EstimatedTotalHits, Facets: res.FacetDistribution}
+	if result.Facets == nil {
+		result.Facets = map[string]map[string]int64{}
+	}
+	for i := range res.Hits {
+		doc := res.Hits[i].Document
+		// the full text stays in the index, hits carry the cropped highlight
+		doc.Content = ""
+		result.Hits = append(result.Hits, &Hit{Document: &doc, Highlights: meilisearchHighlights(res.Hits[i].Formatted)})
+	}
+	return result, nil
+}
+
+// TenantToken returns a token clients can search the index with directly.
+// Meilisearch applies the workspace filter to every search made with it.
+func (m *Meilisearch) TenantToken(ctx context.Context, workspaceIds []string, expiresAt time.Time) (string, error) {
+	key, err := m.findSearchKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	claims, err := json.Marshal(map[string]interface{}{
+		"apiKeyUid": key.Uid,
+		"exp":       expiresAt.Unix(),
+		"searchRules": map[string]interface{}{
+			m.index: map[string]string{"filter": "workspace_id IN " + meilisearchList(workspaceIds)},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(key.Key))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// findSearchKey looks up an API key that can only search and covers the
+// index. Tenant tokens are signed with it and grant whatever it allows, so
+// keys with more actions, like the default admin key, are never used.
+func (m *Meilisearch) findSearchKey(ctx context.Context) (*meilisearchKey, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.searchKey != nil {
+		return m.searchKey, nil
+	}
+	var res struct {
+		Results []*meilisearchKey `json:"results"`
+	}
+	err := m.request(ctx, http.MethodGet, "/keys?limit=100", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range res.Results {
+		if len(k.Actions) == 1 && k.Actions[0] == "search" && contains(k.Indexes, m.index, "*") {
+			m.searchKey = k
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("meilisearch: no search-only API key for index %s", m.index)
+}
+
+func (m *Meilisearch) indexPath(path string) string {
+	return "/indexes/" + url.PathEscape(m.index) + path
+}
+
+func (m *Meilisearch) request(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, m.host+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.masterKey)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		var e meilisearchError
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("meilisearch: %s %s: %d %s %s", method, path, resp.StatusCode, e.Code, e.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func meilisearchFilter(q *Query) []interface{} {
+	filter := []interface{}{"workspace_id IN " + meilisearchList(q.WorkspaceIds)}
+	if len(q.ProjectIds) > 0 {
+		filter = append(filter, "project_id IN "+meilisearchList(q.ProjectIds))
+	}
+	if len(q.Status) > 0 {
+		filter = append(filter, "status IN "+meilisearchList(q.Status))
+	}
+	for _, t := range q.Tags {
+		filter = append(filter, "tags = "+meilisearchQuote(t))
+	}
+	return filter
+}
+
+func meilisearchList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, meilisearchQuote(v))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+var meilisearchEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func meilisearchQuote(value string) string {
+	return `"` + meilisearchEscaper.Replace(value) + `"`
+}
+
+// meilisearchHighlights keeps the formatted fields that contain a match
+func meilisearchHighlights(formatted map[string]interface{}) map[string]string {
+	highlights := map[string]string{}
+	for field, value := range formatted {
+		switch v := value.(type) {
+		case string:
+			if h, ok := highlight(v); ok {
+				highlights[field] = h
+			}
+		case []interface{}:
+			matched := []string{}
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					if h, ok := highlight(s); ok {
+						matched = append(matched, h)
+					}
+				}
+			}
+			if len(matched) > 0 {
+				highlights[field] = strings.Join(matched, ", ")
+			}
+		}
+	}
+	return highlights
+}
+
+func contains(values []string, wanted ...string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package search
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// meilisearchStandIn answers like Meilisearch with the responses set per
+// method and path and records the requests it got
+type meilisearchStandIn struct {
+	t         *testing.T
+	mu        sync.Mutex
+	requests  []meilisearchRequest
+	responses map[string]string
+}
+
+type meilisearchRequest struct {
+	route string
+	auth  string
+	body  string
+}
+
+func newMeilisearchStandIn(t *testing.T, responses map[string]string) (*Meilisearch, *meilisearchStandIn) {
+	s := &meilisearchStandIn{t: t, responses: responses}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return NewMeilisearch(server.URL+"/", "master", "docs", server.Client()), s
+}
+
+func (s *meilisearchStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	route := r.Method + " " + r.URL.RequestURI()
+	s.mu.Lock()
+	s.requests = append(s.requests, meilisearchRequest{route: route, auth: r.Header.Get("Authorization"), body: string(body)})
+	s.mu.Unlock()
+	if response, ok := s.responses[route]; ok {
+		// responses may start with a status code
+		if code, rest, ok := strings.Cut(response, " "); ok {
+			if status, err := strconv.Atoi(code); err == nil {
+				w.WriteHeader(status)
+				response = rest
+			}
+		}
+		io.WriteString(w, response)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	io.WriteString(w, `{"taskUid": 1}`)
+}
+
+func (s *meilisearchStandIn) routes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	routes := []string{}
+	for _, r := range s.requests {
+		routes = append(routes, r.route)
+	}
+	return routes
+}
+
+func (s *meilisearchStandIn) body(i int) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(s.requests[i].body), &body); err != nil {
+		s.t.Fatal(err)
+	}
+	return body
+}
+
+func TestMeilisearchRequests(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		run    func(m *Meilisearch) error
+		routes []string
+	}{
+		{"setup", func(m *Meilisearch) error { return m.Setup(ctx) }, []string{"POST /indexes", "PATCH /indexes/docs/settings"}},
+		{"index", func(m *Meilisearch) error { return m.Index(ctx, &Document{Id: "doc_1"}) }, []string{"POST /indexes/docs/documents?primaryKey=id"}},
+		{"index nothing", func(m *Meilisearch) error { return m.Index(ctx) }, []string{}},
+		{"delete", func(m *Meilisearch) error { return m.Delete(ctx, "doc_1", "doc_2") }, []string{"POST /indexes/docs/documents/delete-batch"}},
+		{"delete nothing", func(m *Meilisearch) error { return m.Delete(ctx) }, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, s := newMeilisearchStandIn(t, nil)
+			if err := tt.run(m); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(s.routes(), ", "); got != strings.Join(tt.routes, ", ") {
+				t.Errorf("requests %s, want %s", got, strings.Join(tt.routes, ", "))
+			}
+			for _, r := range s.requests {
+				if r.auth != "Bearer master" {
+					t.Errorf("%s authorized with %q", r.route, r.auth)
+				}
+			}
+		})
+	}
+}
+
+func TestMeilisearchSearch(t *testing.T) {
+	m, s := newMeilisearchStandIn(t, map[string]string{
+		"POST /indexes/docs/search": `{
+			"hits": [{
+				"id": "doc_1", "name": "Contract <img src=x>", "content": "the full text", "tags": ["legal"],
+				"_formatted": {"name": "\u0002Contract\u0003 <img src=x>", "content": "…the full…", "tags": ["\u0002legal\u0003", "other"]}
+			}],
+			"estimatedTotalHits": 1
+		}`,
+	})
+	result, err := m.Search(context.Background(), &Query{
+		Query:        "contract",
+		WorkspaceIds: []string{"ws_1", `ws"2`},
+		Status:       []string{"draft"},
+		Tags:         []string{"Legal"},
+		Limit:        1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := s.body(0)
+	filter, _ := json.Marshal(body["filter"])
+	if want := `["workspace_id IN [\"ws_1\", \"ws\\\"2\"]","status IN [\"draft\"]","tags = \"legal\""]`; string(filter) != want {
+		t.Errorf("filter %s, want %s", filter, want)
+	}
+	if body["highlightPreTag"] != highlightStart || body["highlightPostTag"] != highlightEnd {
+		t.Errorf("highlight tags %q %q", body["highlightPreTag"], body["highlightPostTag"])
+	}
+	if body["limit"] != float64(MaxLimit) {
+		t.Errorf("limit %v", body["limit"])
+	}
+	if result.Total != 1 || len(result.Hits) != 1 || result.Facets == nil {
+		t.Fatalf("got %+v", result)
+	}
+	hit := result.Hits[0]
+	if hit.Content != "" {
+		t.Errorf("hit carries the content %q", hit.Content)
+	}
+	want := map[string]string{"name": "<mark>Contract</mark> &lt;img src=x&gt;", "tags": "<mark>legal</mark>"}
+	if len(hit.Highlights) != len(want) || hit.Highlights["name"] != want["name"] || hit.Highlights["tags"] != want["tags"] {
+		t.Errorf("highlights %v, want %v", hit.Highlights, want)
+	}
+}
+
+func TestMeilisearchIndexStripsMarkers(t *testing.T) {
+	m, s := newMeilisearchStandIn(t, nil)
+	doc := &Document{Id: "doc_1", Name: "a\x02b", Content: "c\x03d", Tags: []string{"e\x02"}}
+	if err := m.Index(context.Background(), doc); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	body := s.requests[0].body
+	s.mu.Unlock()
+	var docs []*Document
+	if err := json.Unmarshal([]byte(body), &docs); err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0].Name != "ab" || docs[0].Content != "cd" || docs[0].Tags[0] != "e" {
+		t.Errorf("indexed %+v", docs[0])
+	}
+	if doc.Name != "a\x02b" {
+		t.Error("the indexed document was changed")
+	}
+}
+
+func TestMeilisearchErrors(t *testing.T) {
+	m, _ := newMeilisearchStandIn(t, map[string]string{
+		"POST /indexes/docs/search": `400 {"message": "Attribute foo is not filterable", "code": "invalid_search_filter"}`,
+	})
+	_, err := m.Search(context.Background(), &Query{WorkspaceIds: []string{"ws_1"}})
+	if err == nil || !strings.Contains(err.Error(), "400 invalid_search_filter") {
+		t.Errorf("got %v", err)
+	}
+	if _, err := m.Search(context.Background(), &Query{WorkspaceIds: []string{"ws_1"}, Facets: []string{"owner"}}); err == nil {
+		t.Error("searched an unknown facet")
+	}
+}
+
+func TestMeilisearchTenantToken(t *testing.T) {
+	m, s := newMeilisearchStandIn(t, map[string]string{
+		"GET /keys?limit=100": `{"results": [
+			{"uid": "admin", "key": "admin-key", "actions": ["*"], "indexes": ["*"]},
+			{"uid": "chat", "key": "chat-key", "actions": ["search", "chatCompletions"], "indexes": ["docs"]},
+			{"uid": "other", "key": "other-key", "actions": ["search"], "indexes": ["other"]},
+			{"uid": "search", "key": "search-key", "actions": ["search"], "indexes": ["docs"]}
+		]}`,
+	})
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	for i := 0; i < 2; i++ {
+		token, err := m.TenantToken(context.Background(), []string{"ws_1"}, expires)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			t.Fatalf("token %s", token)
+		}
+		mac := hmac.New(sha256.New, []byte("search-key"))
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		if base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) != parts[2] {
+			t.Error("token is not signed with the search key")
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims struct {
+			ApiKeyUid   string                       `json:"apiKeyUid"`
+			Exp         int64                        `json:"exp"`
+			SearchRules map[string]map[string]string `json:"searchRules"`
+		}
+		if err := json.Unmarshal(payload, &claims); err != nil {
+			t.Fatal(err)
+		}
+		if claims.ApiKeyUid != "search" || claims.Exp != expires.Unix() || claims.SearchRules["docs"]["filter"] != `workspace_id IN ["ws_1"]` {
+			t.Errorf("claims %+v", claims)
+		}
+	}
+	// the key is looked up once
+	if routes := s.routes(); len(routes) != 1 {
+		t.Errorf("requests %v", routes)
+	}
+}
+
+func TestMeilisearchTenantTokenWithoutSearchKey(t *testing.T) {
+	m, _ := newMeilisearchStandIn(t, map[string]string{
+		"GET /keys?limit=100": `{"results": [
+			{"uid": "admin", "key": "admin-key", "actions": ["*"], "indexes": ["*"]},
+			{"uid": "search", "key": "search-key", "actions": ["search", "documents.get"], "indexes": ["docs"]}
+		]}`,
+	})
+	if _, err := m.TenantToken(context.Background(), []string{"ws_1"}, time.Now().Add(time.Hour)); err == nil {
+		t.Error("signed a token with a key that can do more than search")
+	}
+}
@@ -0,0 +1,233 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Postgres searches the documents table directly. Triggers keep
+// documents.search_vector up to date, so Index and Delete have nothing to do.
+type Postgres struct {
+	db       *gorm.DB
+	language string
+}
+
+type postgresHit struct {
+	Id                   string
+	WorkspaceId          string
+	ProjectId            string
+	FolderId             string
+	Name                 string
+	Description          string
+	Tags                 models.StringList
+	Status               string
+	OwnerId              string
+	Updated              int64
+	NameHighlight        string
+	DescriptionHighlight string
+	ContentHighlight     string
+}
+
+type postgresFacet struct {
+	Value string
+	Count int64
+}
+
+const postgresColumns = "documents.id, documents.workspace_id, documents.project_id, documents.folder_id, " +
+	"documents.name, documents.description, documents.status, documents.owner_id, documents.updated, " +
+	"COALESCE((SELECT json_agg(tags.name ORDER BY tags.name) FROM document_tags JOIN tags ON tags.id = document_tags.tag_id " +
+	"WHERE document_tags.document_id = documents.id), '[]') AS tags"
+
+// postgresContent selects the text of the current version, as far as it is
+// indexed
+const postgresContent = "SELECT left(document_versions.text, 262144) FROM document_versions " +
+	"WHERE document_versions.document_id = documents.id AND document_versions.deleted_at IS NULL " +
+	"ORDER BY document_versions.version DESC LIMIT 1"
+
+// postgresHeadline marks the matches with the control characters of
+// highlightStart and highlightEnd, which postgresText removes from the text
+const postgresHeadline = `StartSel="` + highlightStart + `", StopSel="` + highlightEnd + `"`
+
+// postgresText removes the highlight markers from the text expression
+func postgresText(expr string) string {
+	return "translate(" + expr + ", chr(2) || chr(3), '')"
+}
+
+// NewPostgres returns an Indexer using the text search configuration language
+func NewPostgres(db *gorm.DB, language string) *Postgres {
+	return &Postgres{db: db, language: language}
+}
+
+// Setup applies the configured language and refreshes the vectors when it
+// changed
+func (p *Postgres) Setup(ctx context.Context) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Exec("UPDATE search_settings SET language = ?::regconfig WHERE id = 1 AND language <> ?::regconfig", p.language, p.language)
+		if db.Error != nil || db.RowsAffected == 0 {
+			return db.Error
+		}
+		return tx.Exec("UPDATE documents SET search_vector = NULL").Error
+	})
+}
+
+// Index recomputes the search vectors of docs. The triggers keep them current,
+// this only matters when the vectors were built by an older configuration.
+func (p *Postgres) Index(ctx context.Context, docs ...*Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, d.Id)
+	}
+	return p.db.WithContext(ctx).Exec("UPDATE documents SET search_vector = NULL WHERE id IN ?", ids).Error
+}
+
+func (p *Postgres) Delete(ctx context.Context, ids ...string) error {
+	return nil
+}
+
+func (p *Postgres) Search(ctx context.Context, q *Query) (*Result, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+	db := p.db.WithContext(ctx)
+	tsquery := postgresQuery(q.Query)
+
+	var total int64
+	err := p.filter(db, q, tsquery).Count(&total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query := p.filter(db, q, tsquery)
+	if tsquery == "" {
+		query = query.Select(postgresColumns).Order("documents.updated DESC")
+	} else {
+		query = query.Select(postgresColumns+", "+
+			"ts_headline(?::regconfig, "+postgresText("documents.name")+", to_tsquery(?::regconfig, ?), ?) AS name_highlight, "+
+			"ts_headline(?::regconfig, "+postgresText("documents.description")+", to_tsquery(?::regconfig, ?), ?) AS description_highlight, "+
+			"ts_headline(?::regconfig, "+postgresText("("+postgresContent+")")+", to_tsquery(?::regconfig, ?), ?) AS content_highlight",
+			p.language, p.language, tsquery, postgresHeadline+", HighlightAll=true",
+			p.language, p.language, tsquery, postgresHeadline+", MaxWords=30, MinWords=10",
+			p.language, p.language, tsquery, postgresHeadline+", MaxWords=30, MinWords=10, MaxFragments=2",
+		).Order(clause.Expr{
+			SQL:  "ts_rank_cd(documents.search_vector, to_tsquery(?::regconfig, ?)) DESC, documents.updated DESC",
+			Vars: []interface{}{p.language, tsquery},
+		})
+	}
+	rows := []*postgresHit{}
+	err = query.Offset(q.Offset).Limit(q.Limit).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Hits: make([]*Hit, 0, len(rows)), Total: total, Facets: map[string]map[string]int64{}}
+	for _, r := range rows {
+		hit := &Hit{
+			Document: &Document{
+				Id:          r.Id,
+				WorkspaceId: r.WorkspaceId,
+				ProjectId:   r.ProjectId,
+				FolderId:    r.FolderId,
+				Name:        r.Name,
+				Description: r.Description,
+				Tags:        r.Tags,
+				Status:      r.Status,
+				OwnerId:     r.OwnerId,
+				Updated:     r.Updated,
+			},
+			Highlights: map[string]string{},
+		}
+		if h, ok := highlight(r.NameHighlight); ok {
+			hit.Highlights["name"] = h
+		}
+		if h, ok := highlight(r.DescriptionHighlight); ok {
+			hit.Highlights["description"] = h
+		}
+		if h, ok := highlight(r.ContentHighlight); ok {
+			hit.Highlights["content"] = h
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+
+	for _, facet := range q.Facets {
+		counts, err := p.facet(db, q, tsquery, facet)
+		if err != nil {
+			return nil, err
+		}
+		result.Facets[facet] = counts
+	}
+	return result, nil
+}
+
+// filter selects the live documents matching the query and its filters
+func (p *Postgres) filter(db *gorm.DB, q *Query, tsquery string) *gorm.DB {
+	query := db.Table("documents").Where("documents.deleted_at IS NULL AND documents.workspace_id IN ?", q.WorkspaceIds)
+	if len(q.ProjectIds) > 0 {
+		query = query.Where("documents.project_id IN ?", q.ProjectIds)
+	}
+	if len(q.Status) > 0 {
+		query = query.Where("documents.status IN ?", q.Status)
+	}
+	if len(q.Tags) > 0 {
+		tagged := db.Table("document_tags").
+			Select("document_tags.document_id").
+			Joins("JOIN tags ON tags.id = document_tags.tag_id").
+			Where("tags.workspace_id IN ? AND tags.name IN ?", q.WorkspaceIds, q.Tags).
+			Group("document_tags.document_id").
+			Having("COUNT(DISTINCT tags.name) = ?", len(q.Tags))
+		query = query.Where("documents.id IN (?)", tagged)
+	}
+	if tsquery != "" {
+		query = query.Where("documents.search_vector @@ to_tsquery(?::regconfig, ?)", p.language, tsquery)
+	}
+	return query
+}
+
+func (p *Postgres) facet(db *gorm.DB, q *Query, tsquery, facet string) (map[string]int64, error) {
+	query := p.filter(db, q, tsquery)
+	switch facet {
+	case "tags":
+		query = query.Joins("JOIN document_tags ON document_tags.document_id = documents.id").
+			Joins("JOIN tags ON tags.id = document_tags.tag_id").
+			Select("tags.name AS value, COUNT(*) AS count").Group("tags.name")
+	default:
+		// facet names are validated by Query.Normalize and match the columns
+		query = query.Select("documents." + facet + " AS value, COUNT(*) AS count").Group("documents." + facet)
+	}
+	rows := []*postgresFacet{}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counts[r.Value] = r.Count
+	}
+	return counts, nil
+}
+
+// postgresQuery turns free text into a tsquery matching documents containing
+// every word, the last one as a prefix so results show up while typing
+func postgresQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	terms := make([]string, 0, len(words))
+	for i, w := range words {
+		term := "'" + strings.ToLower(w) + "'"
+		if i == len(words)-1 {
+			term += ":*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " & ")
+}
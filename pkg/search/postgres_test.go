@@ -0,0 +1,43 @@
+package search
+
+import (
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// TestPostgresHeadline runs ts_headline with the highlight options on the
+// postgres at TRACKDOCS_TEST_DATASOURCE
+func TestPostgresHeadline(t *testing.T) {
+	source := os.Getenv("TRACKDOCS_TEST_DATASOURCE")
+	if source == "" {
+		t.Skip("TRACKDOCS_TEST_DATASOURCE is not set")
+	}
+	db, err := gorm.Open(postgres.Open(source), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"the contract", "the <mark>contract</mark>"},
+		{"<b>contract</b>", "&lt;b&gt;<mark>contract</mark>&lt;/b&gt;"},
+		// markers in the text do not turn into tags
+		{"\x02x\x03 contract", "x <mark>contract</mark>"},
+	}
+	for _, tt := range tests {
+		var headline string
+		err := db.Raw("SELECT ts_headline('simple', "+postgresText("?")+", to_tsquery('simple', 'contract'), ?)",
+			tt.text, postgresHeadline+", HighlightAll=true").Scan(&headline).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := highlight(headline)
+		if !ok || got != tt.want {
+			t.Errorf("headline of %q = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
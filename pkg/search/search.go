@@ -0,0 +1,179 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"gorm.io/gorm"
+)
+
+const (
+	EngineMeilisearch = "meilisearch"
+	EnginePostgres    = "postgres"
+
+	// DefaultLimit and MaxLimit bound the hits returned by a single query
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Facets that can be requested with a Query
+var Facets = []string{"tags", "status", "project_id"}
+
+var ErrUnknownFacet = errors.New("unknown facet")
+
+// Document is the searchable representation of a models.Document
+type Document struct {
+	Id          string   `json:"id"`
+	WorkspaceId string   `json:"workspace_id"`
+	ProjectId   string   `json:"project_id"`
+	FolderId    string   `json:"folder_id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Content     string   `json:"content"`
+	Tags        []string `json:"tags"`
+	Status      string   `json:"status"`
+	OwnerId     string   `json:"owner_id"`
+	Updated     int64    `json:"updated"`
+}
+
+// NewDocument returns the searchable representation of the document, which
+// needs its tags loaded
+func NewDocument(d *models.Document) *Document {
+	tags := d.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return &Document{
+		Id:          d.Id,
+		WorkspaceId: d.WorkspaceId,
+		ProjectId:   d.ProjectId,
+		FolderId:    d.FolderId,
+		Name:        d.Name,
+		Description: d.Description,
+		Tags:        tags,
+		Status:      string(d.Status),
+		OwnerId:     d.OwnerId,
+		Updated:     d.Updated,
+	}
+}
+
+// Query searches the documents of WorkspaceIds, which must not be empty.
+// Tags match documents carrying all of them, Status and ProjectIds any of
+// them.
+type Query struct {
+	Query        string
+	WorkspaceIds []string
+	ProjectIds   []string
+	Tags         []string
+	Status       []string
+	Facets       []string
+	Offset       int
+	Limit        int
+}
+
+// Normalize applies the default limit, normalizes the tags and validates the
+// facets
+func (q *Query) Normalize() error {
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	for i, t := range q.Tags {
+		q.Tags[i] = models.NormalizeTag(t)
+	}
+	for _, f := range q.Facets {
+		if !isFacet(f) {
+			return fmt.Errorf("%w: %s", ErrUnknownFacet, f)
+		}
+	}
+	return nil
+}
+
+func isFacet(name string) bool {
+	for _, f := range Facets {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Hit is a matching document. Highlights holds the matched fields as HTML,
+// escaped and with the matches wrapped in HighlightPreTag and
+// HighlightPostTag.
+type Hit struct {
+	*Document
+	Highlights map[string]string `json:"highlights"`
+}
+
+type Result struct {
+	Hits   []*Hit                      `json:"hits"`
+	Total  int64                       `json:"total"`
+	Facets map[string]map[string]int64 `json:"facets"`
+}
+
+const (
+	HighlightPreTag  = "<mark>"
+	HighlightPostTag = "</mark>"
+)
+
+// highlightStart and highlightEnd mark the matches in the results of the
+// engines. They are control characters, which are removed from the indexed
+// text, so the text can be HTML escaped before the tags are added.
+const (
+	highlightStart = "\x02"
+	highlightEnd   = "\x03"
+)
+
+var (
+	markerRemover = strings.NewReplacer(highlightStart, "", highlightEnd, "")
+	markerTagger  = strings.NewReplacer(highlightStart, HighlightPreTag, highlightEnd, HighlightPostTag)
+)
+
+// highlight escapes the text of a match and wraps the marked matches in
+// HighlightPreTag and HighlightPostTag. Text without a match returns false.
+func highlight(text string) (string, bool) {
+	if !strings.Contains(text, highlightStart) {
+		return "", false
+	}
+	return markerTagger.Replace(html.EscapeString(text)), true
+}
+
+// Indexer keeps documents searchable
+type Indexer interface {
+	// Setup creates the index and applies its settings
+	Setup(ctx context.Context) error
+	// Index adds or replaces documents
+	Index(ctx context.Context, docs ...*Document) error
+	// Delete removes documents by id
+	Delete(ctx context.Context, ids ...string) error
+	Search(ctx context.Context, q *Query) (*Result, error)
+}
+
+// TenantTokenIssuer is implemented by engines that let clients search
+// directly with a token restricted to some workspaces
+type TenantTokenIssuer interface {
+	TenantToken(ctx context.Context, workspaceIds []string, expiresAt time.Time) (string, error)
+}
+
+// NewIndexer returns the search engine configured with SearchEngine
+func NewIndexer(cfg *config.Config, db *gorm.DB) (Indexer, error) {
+	switch cfg.SearchEngine {
+	case EnginePostgres:
+		return NewPostgres(db, cfg.SearchLanguage), nil
+	case EngineMeilisearch:
+		return NewMeilisearch(cfg.MeilisearchHost, cfg.MeilisearchMasterKey, cfg.SearchIndex, nil), nil
+	}
+	return nil, fmt.Errorf("search: unknown engine %q", cfg.SearchEngine)
+}
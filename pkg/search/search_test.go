@@ -0,0 +1,23 @@
+package search
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		matched bool
+	}{
+		{"\x02Contract\x03 draft", "<mark>Contract</mark> draft", true},
+		{"<img src=x onerror=\"alert(1)\"> \x02match\x03", "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>match</mark>", true},
+		{"a & \x02b\x03", "a &amp; <mark>b</mark>", true},
+		{"<mark>no match</mark>", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, matched := highlight(tt.text)
+		if matched != tt.matched || (matched && got != tt.want) {
+			t.Errorf("highlight(%q) = %q, %v, want %q, %v", tt.text, got, matched, tt.want, tt.matched)
+		}
+	}
+}
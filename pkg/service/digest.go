@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const (
+	DigestLock      = "lock::notification_digests"
+	DigestInterval  = 24 * time.Hour
+	DigestBatchSize = 100
+	// DigestMaxNotifications is the most notifications listed in one digest
+	DigestMaxNotifications = 50
+)
+
+// Digests emails accounts that opted in a daily summary of their unread
+// notifications
+type Digests struct {
+	cfg       *config.Config
+	repo      *store.Store
+	redisLock *lock.RedisLock
+	mailer    Mailer
+}
+
+func NewDigests(cfg *config.Config, repo *store.Store, redisLock *lock.RedisLock, mailer Mailer) *Digests {
+	return &Digests{cfg: cfg, repo: repo, redisLock: redisLock, mailer: mailer}
+}
+
+// Run sends the due digests every SchedulerInterval until ctx is done
+func (d *Digests) Run(ctx context.Context) {
+	runScheduled(ctx, d.redisLock, DigestLock, d.cfg.SchedulerInterval, func() {
+		sent, err := d.SendDue(ctx, time.Now())
+		if err != nil {
+			logger.Errorf("Digests error while sending digests:%s", err.Error())
+		}
+		if sent > 0 {
+			logger.Infof("Digests sent %d digests", sent)
+		}
+	})
+}
+
+// SendDue sends a digest to every account whose last one is DigestInterval
+// old and returns how many were sent. Accounts without new notifications
+// are skipped until the next interval.
+func (d *Digests) SendDue(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for {
+		accountIds, err := d.repo.NotificationStore.DigestsDue(ctx, now.Add(-DigestInterval), DigestBatchSize)
+		if err != nil {
+			return sent, err
+		}
+		for _, accountId := range accountIds {
+			ok, err := d.send(ctx, accountId, now)
+			if err != nil {
+				logger.Errorf("Digests error while sending the digest of %s:%s", accountId, err.Error())
+			}
+			if ok {
+				sent++
+			}
+			// failed digests wait for the next interval too, or the batch
+			// would keep returning them
+			if err := d.repo.NotificationStore.MarkDigestSent(ctx, accountId, now); err != nil {
+				return sent, err
+			}
+		}
+		if len(accountIds) < DigestBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+func (d *Digests) send(ctx context.Context, accountId string, now time.Time) (bool, error) {
+	notifications, err := d.repo.NotificationStore.DigestNotifications(ctx, accountId, now.Add(-DigestInterval), DigestMaxNotifications)
+	if err != nil || len(notifications) == 0 {
+		return false, err
+	}
+	account, err := d.repo.AccountStore.FindAccountById(ctx, accountId)
+	if err != nil {
+		return false, err
+	}
+	err = d.mailer.Send(ctx, account.Email, account.Locale, MailDigest, &notificationMail{
+		Name:          account.Name,
+		Notifications: notifications,
+	})
+	return err == nil, err
+}
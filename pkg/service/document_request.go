@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const (
+	RequestReminderLock      = "lock::document_request_reminders"
+	RequestReminderBatchSize = 100
+)
+
+// DocumentRequests collects documents from external parties through public
+// upload links
+type DocumentRequests struct {
+	cfg       *config.Config
+	repo      *store.Store
+	redisLock *lock.RedisLock
+	uploads   *Uploads
+	mailer    Mailer
+	notifier  Notifier
+}
+
+func NewDocumentRequests(cfg *config.Config, repo *store.Store, redisLock *lock.RedisLock, uploads *Uploads, mailer Mailer, notifier Notifier) *DocumentRequests {
+	return &DocumentRequests{cfg: cfg, repo: repo, redisLock: redisLock, uploads: uploads, mailer: mailer, notifier: notifier}
+}
+
+// CreateFromRequest creates the request and emails the upload link to the
+// recipient. The link is returned once, in DocumentRequest.Link.
+func (s *DocumentRequests) CreateFromRequest(ctx context.Context, workspaceId, accountId string, req *dto.DocumentRequestCreateRequest) (*models.DocumentRequest, error) {
+	request, token, err := s.repo.RequestStore.NewRequestFromRequest(ctx, workspaceId, accountId, req)
+	if err != nil {
+		return request, err
+	}
+	request.Link = s.link(token)
+	s.send(ctx, request, MailRequestInvitation, &requestMail{
+		Recipient: recipient(request),
+		Title:     request.Title,
+		Message:   request.Message,
+		Items:     checklist(request),
+		Link:      request.Link,
+	})
+	return request, nil
+}
+
+// Remind emails the recipient the upload link and the items still missing
+func (s *DocumentRequests) Remind(ctx context.Context, request *models.DocumentRequest) error {
+	if request.Status != models.DocumentRequestStatusOpen {
+		return models.ErrRequestClosed
+	}
+	s.send(ctx, request, MailRequestReminder, &requestMail{
+		Recipient: recipient(request),
+		Title:     request.Title,
+		Items:     checklist(request),
+		Link:      s.requestLink(request),
+	})
+	return nil
+}
+
+// Upload stores a file uploaded through the public link for one item
+func (s *DocumentRequests) Upload(ctx context.Context, token, itemId, fileName string, r io.Reader) (*models.DocumentRequestItem, error) {
+	request, err := s.repo.RequestStore.FindOpenRequestByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	item := store.RequestItem(request, itemId)
+	if item == nil {
+		return nil, models.ErrRequestItemNotFound
+	}
+	if item.Status == models.DocumentRequestItemStatusReceived {
+		return nil, models.ErrItemReceived
+	}
+
+	document, err := s.itemDocument(ctx, request, item, fileName)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.uploads.Put(document, fileName, "", r, item.MaxSize, item.Accepts)
+	if err != nil {
+		return nil, err
+	}
+	completed, err := s.repo.RequestStore.ReceiveItem(ctx, request, item, document, version)
+	if err != nil {
+		s.uploads.Discard(version)
+		return nil, err
+	}
+	if completed {
+		err = s.notifier.Notify(ctx, request.CreatedBy, &models.Notification{
+			WorkspaceId:  request.WorkspaceId,
+			Type:         models.NotificationRequestCompleted,
+			Title:        fmt.Sprintf("%s received every document of %s", recipient(request), request.Title),
+			ResourceType: models.ResourceTypeProject,
+			ResourceId:   request.ProjectId,
+		})
+		if err != nil {
+			logger.Errorf("DocumentRequests error while notifying %s:%s", request.CreatedBy, err.Error())
+		}
+	}
+	return item, nil
+}
+
+// itemDocument returns the document a file of the item is stored in: the one
+// of an earlier, rejected upload or a new unsaved document owned by the
+// requester
+func (s *DocumentRequests) itemDocument(ctx context.Context, request *models.DocumentRequest, item *models.DocumentRequestItem, fileName string) (*models.Document, error) {
+	if item.DocumentId != "" {
+		return s.repo.DocumentStore.FindDocumentById(ctx, item.DocumentId)
+	}
+	project, folder, err := s.repo.FolderStore.FindTarget(ctx, request.ProjectId, request.FolderId)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s - %s", item.Name, fileName)
+	description := fmt.Sprintf("Uploaded by %s for %s", recipient(request), request.Title)
+	return models.NewDocument(project, folder, name, description, request.CreatedBy), nil
+}
+
+// RejectItem rejects a received item and asks the recipient to upload it again
+func (s *DocumentRequests) RejectItem(ctx context.Context, requestId, itemId, accountId string, req *dto.DocumentRequestItemRejectRequest) (*models.DocumentRequest, error) {
+	request, item, err := s.repo.RequestStore.RejectItem(ctx, requestId, itemId, accountId, req.Reason)
+	if err != nil {
+		return request, err
+	}
+	s.send(ctx, request, MailRequestRejected, &requestMail{
+		Recipient: recipient(request),
+		Title:     request.Title,
+		Item:      item.Name,
+		Reason:    req.Reason,
+		Link:      s.requestLink(request),
+	})
+	return request, nil
+}
+
+// Run sends the automatic reminders until ctx is done
+func (s *DocumentRequests) Run(ctx context.Context) {
+	runScheduled(ctx, s.redisLock, RequestReminderLock, s.cfg.SchedulerInterval, func() {
+		err := s.SendDueReminders(ctx, time.Now())
+		if err != nil {
+			logger.Errorf("DocumentRequests error while sending reminders:%s", err.Error())
+		}
+	})
+}
+
+// SendDueReminders reminds the recipients of open requests whose reminder
+// interval has passed
+func (s *DocumentRequests) SendDueReminders(ctx context.Context, now time.Time) error {
+	for {
+		requests, err := s.repo.RequestStore.DueReminders(ctx, now, RequestReminderBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, request := range requests {
+			first, err := s.repo.RequestStore.MarkReminded(ctx, request, now)
+			if err != nil {
+				return err
+			}
+			if first {
+				_ = s.Remind(ctx, request)
+			}
+		}
+		if len(requests) < RequestReminderBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *DocumentRequests) link(token string) string {
+	return strings.TrimSuffix(s.cfg.PortalUrl, "/") + "/" + token
+}
+
+// requestLink returns the upload link of a stored request, empty when the
+// token is not available and the recipient has to use the first email
+func (s *DocumentRequests) requestLink(request *models.DocumentRequest) string {
+	token, err := s.repo.RequestStore.Token(request)
+	if err != nil {
+		logger.Errorf("DocumentRequests error while decrypting the token of %s:%s", request.Id, err.Error())
+		return ""
+	}
+	if token == "" {
+		return ""
+	}
+	return s.link(token)
+}
+
+// send queues an email to the recipient, who has no account and gets the
+// default locale
+func (s *DocumentRequests) send(ctx context.Context, request *models.DocumentRequest, template string, data *requestMail) {
+	err := s.mailer.Send(ctx, request.RecipientEmail, "", template, data)
+	if err != nil {
+		logger.Errorf("DocumentRequests error while emailing %s:%s", request.RecipientEmail, err.Error())
+	}
+}
+
+// requestMail is the data of the document request emails
+type requestMail struct {
+	Recipient string
+	Title     string
+	Message   string
+	Items     []*requestMailItem
+	Link      string
+	Item      string
+	Reason    string
+}
+
+type requestMailItem struct {
+	Name            string
+	Optional        bool
+	RejectionReason string
+}
+
+// checklist lists the items the recipient still has to upload
+func checklist(request *models.DocumentRequest) []*requestMailItem {
+	items := []*requestMailItem{}
+	for _, item := range request.Items {
+		if item.Status == models.DocumentRequestItemStatusReceived {
+			continue
+		}
+		i := &requestMailItem{Name: item.Name, Optional: !item.Required}
+		if item.Status == models.DocumentRequestItemStatusRejected {
+			i.RejectionReason = item.RejectionReason
+		}
+		items = append(items, i)
+	}
+	return items
+}
+
+func recipient(request *models.DocumentRequest) string {
+	if request.RecipientName != "" {
+		return request.RecipientName
+	}
+	return request.RecipientEmail
+}
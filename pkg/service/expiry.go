@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const (
+	ExpiryReminderLock      = "lock::expiry_reminders"
+	ExpiryReminderBatchSize = 500
+)
+
+// ExpiryReminder periodically notifies document owners about documents
+// nearing their expiry date
+type ExpiryReminder struct {
+	cfg       *config.Config
+	repo      *store.Store
+	redisLock *lock.RedisLock
+	notifier  Notifier
+}
+
+func NewExpiryReminder(cfg *config.Config, repo *store.Store, redisLock *lock.RedisLock, notifier Notifier) *ExpiryReminder {
+	return &ExpiryReminder{cfg: cfg, repo: repo, redisLock: redisLock, notifier: notifier}
+}
+
+// Run checks for due reminders every SchedulerInterval until ctx is done
+func (e *ExpiryReminder) Run(ctx context.Context) {
+	runScheduled(ctx, e.redisLock, ExpiryReminderLock, e.cfg.SchedulerInterval, func() {
+		sent, err := e.SendDueReminders(ctx, time.Now())
+		if err != nil {
+			logger.Errorf("ExpiryReminder error while sending reminders:%s", err.Error())
+		}
+		if sent > 0 {
+			logger.Infof("ExpiryReminder sent %d reminders", sent)
+		}
+	})
+}
+
+// SendDueReminders notifies the owners of every document with a due reminder
+// and returns the number of notifications sent. When several offsets of a
+// document are due at once only one notification is sent.
+func (e *ExpiryReminder) SendDueReminders(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for {
+		due, err := e.repo.ReminderStore.DueReminders(ctx, now, ExpiryReminderBatchSize)
+		if err != nil {
+			return sent, err
+		}
+		byDocument := make(map[string][]*store.DueReminder)
+		order := []string{}
+		for _, r := range due {
+			if _, ok := byDocument[r.DocumentId]; !ok {
+				order = append(order, r.DocumentId)
+			}
+			byDocument[r.DocumentId] = append(byDocument[r.DocumentId], r)
+		}
+		for _, documentId := range order {
+			ok, err := e.remind(ctx, documentId, byDocument[documentId], now)
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+		if len(due) < ExpiryReminderBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+func (e *ExpiryReminder) remind(ctx context.Context, documentId string, reminders []*store.DueReminder, now time.Time) (bool, error) {
+	first, err := e.repo.ReminderStore.MarkSent(ctx, reminders)
+	if err != nil || !first {
+		return false, err
+	}
+	document, err := e.repo.DocumentStore.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return false, err
+	}
+	title := fmt.Sprintf("%s expires on %s", document.Name, document.ExpiresAt.Time.Format(models.MetadataDateLayout))
+	if !document.ExpiresAt.Time.After(now) {
+		title = fmt.Sprintf("%s expired on %s", document.Name, document.ExpiresAt.Time.Format(models.MetadataDateLayout))
+	}
+	err = e.notifier.Notify(ctx, document.OwnerId, &models.Notification{
+		WorkspaceId:  document.WorkspaceId,
+		Type:         models.NotificationDocumentExpiring,
+		Title:        title,
+		Body:         "Renew the document and update its expiry date to stop these reminders.",
+		ResourceType: models.ResourceTypeDocument,
+		ResourceId:   document.Id,
+	})
+	if err != nil {
+		// the reminder is recorded already, a failed notification is not retried
+		logger.Errorf("ExpiryReminder error while notifying %s:%s", document.OwnerId, err.Error())
+	}
+	return true, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/extract"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const (
+	ExtractionBatchSize    = 10
+	ExtractionPollInterval = time.Minute
+	// ExtractionMaxBackoff caps the delay between two attempts
+	ExtractionMaxBackoff = time.Hour
+	extractErrorSize     = 1024
+)
+
+// Extraction extracts the text of uploaded versions in the background. Every
+// instance runs a worker, versions are leased so each is handled by one.
+type Extraction struct {
+	cfg        *config.Config
+	repo       *store.Store
+	uploads    *Uploads
+	extractors *extract.Extractors
+	wake       chan struct{}
+}
+
+func NewExtraction(cfg *config.Config, repo *store.Store, uploads *Uploads, extractors *extract.Extractors) *Extraction {
+	s := &Extraction{cfg: cfg, repo: repo, uploads: uploads, extractors: extractors, wake: make(chan struct{}, 1)}
+	// new versions change their document, look for work right away instead
+	// of waiting for the next poll
+	repo.OnDocumentsChanged(func(...string) {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	})
+	return s
+}
+
+// Run extracts pending versions until ctx is done
+func (s *Extraction) Run(ctx context.Context) {
+	ticker := time.NewTicker(ExtractionPollInterval)
+	defer ticker.Stop()
+	for {
+		for s.runBatch(ctx) == ExtractionBatchSize {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// runBatch extracts a batch of pending versions and returns its size
+func (s *Extraction) runBatch(ctx context.Context) int {
+	lease := 2*s.cfg.ExtractTimeout + time.Minute
+	versions, err := s.repo.VersionStore.ClaimExtractions(ctx, time.Now(), lease, ExtractionBatchSize)
+	if err != nil {
+		logger.Errorf("Extraction error while claiming versions:%s", err.Error())
+		return 0
+	}
+	for _, version := range versions {
+		if ctx.Err() != nil {
+			// the lease runs out and another worker takes over
+			return 0
+		}
+		s.extract(ctx, version)
+	}
+	return len(versions)
+}
+
+func (s *Extraction) extract(ctx context.Context, version *models.DocumentVersion) {
+	var result *extract.Result
+	r, err := s.uploads.Open(version)
+	if err == nil {
+		result, err = s.extractors.Extract(ctx, version.FileName, version.ContentType, r)
+		r.Close()
+	}
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	version.ExtractError = ""
+	version.ExtractAfter = models.NewSqlNullTime(time.Now())
+	switch {
+	case err == nil:
+		version.ExtractStatus = models.ExtractStatusDone
+		version.Text = result.Text
+		version.PageCount = result.Pages
+	case errors.Is(err, extract.ErrUnsupported), errors.Is(err, extract.ErrTooLarge):
+		version.ExtractStatus = models.ExtractStatusSkipped
+		version.ExtractError = err.Error()
+	case version.ExtractAttempts >= s.cfg.ExtractMaxAttempts:
+		version.ExtractStatus = models.ExtractStatusFailed
+		version.ExtractError = err.Error()
+	default:
+		version.ExtractError = err.Error()
+		version.ExtractAfter = models.NewSqlNullTime(time.Now().Add(extractionBackoff(version.ExtractAttempts)))
+	}
+	if len(version.ExtractError) > extractErrorSize {
+		version.ExtractError = strings.ToValidUTF8(version.ExtractError[:extractErrorSize], "")
+	}
+	if err != nil {
+		logger.Errorf("Extraction error while extracting %s, attempt %d:%s", version.Id, version.ExtractAttempts, err.Error())
+	}
+	if err := s.repo.VersionStore.FinishExtraction(ctx, version); err != nil {
+		logger.Errorf("Extraction error while saving %s:%s", version.Id, err.Error())
+	}
+}
+
+// extractionBackoff doubles the delay after every attempt, from a minute
+func extractionBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 7 {
+		return ExtractionMaxBackoff
+	}
+	return min(time.Minute<<(attempts-1), ExtractionMaxBackoff)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
+	"github.com/praveenmsp23/trackdocs/pkg/mail"
+)
+
+// Mail templates, see pkg/mail/templates
+const (
+	MailNotification      = "notification"
+	MailDigest            = "digest"
+	MailRequestInvitation = "request_invitation"
+	MailRequestReminder   = "request_reminder"
+	MailRequestRejected   = "request_rejected"
+)
+
+// MailJob sends a rendered email
+var MailJob = jobs.NewType[mail.Message]("mail.send")
+
+// Mailer sends emails rendered from a template in the locale of the
+// recipient, the default locale when empty
+type Mailer interface {
+	Send(ctx context.Context, to, locale, template string, data any) error
+}
+
+// Mail renders emails when they are sent and queues them, the worker
+// delivers them with retries through the configured mail.Sender
+type Mail struct {
+	cfg       *config.Config
+	templates *mail.Templates
+	sender    mail.Sender
+	queue     jobs.Queue
+}
+
+func NewMail(cfg *config.Config, queue jobs.Queue) (*Mail, error) {
+	templates, err := mail.NewTemplates(cfg.MailLocale)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := mail.NewSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Mail{cfg: cfg, templates: templates, sender: sender, queue: queue}, nil
+}
+
+func (m *Mail) Send(ctx context.Context, to, locale, template string, data any) error {
+	message, err := m.templates.Render(locale, template, data)
+	if err != nil {
+		return err
+	}
+	message.To = to
+	_, err = MailJob.Enqueue(ctx, m.queue, *message)
+	return err
+}
+
+// Register runs MailJob on w
+func (m *Mail) Register(w *jobs.Worker) {
+	jobs.Register(w, MailJob, m.deliver, jobs.WithMaxAttempts(m.cfg.MailMaxAttempts), jobs.WithTimeout(2*m.cfg.MailTimeout))
+}
+
+func (m *Mail) deliver(ctx context.Context, message mail.Message) error {
+	err := m.sender.Send(ctx, &message)
+	if mail.IsPermanent(err) {
+		return jobs.Permanent(err)
+	}
+	return err
+}
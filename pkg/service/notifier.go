@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const (
+	NotificationGroup = "notifications"
+	// NotificationExcerptSize is the most bytes of a comment quoted in a
+	// notification
+	NotificationExcerptSize = 500
+	// notificationSubscribeDelay is the pause before subscribing again after
+	// the bus failed
+	notificationSubscribeDelay = 5 * time.Second
+)
+
+// Notifier delivers notifications to accounts
+type Notifier interface {
+	Notify(ctx context.Context, accountId string, notification *models.Notification) error
+}
+
+// Notifications delivers notifications to the notification center and by
+// email, as the preferences of the account say. It also turns document
+// events into notifications for the accounts involved. Notifications in the
+// notification center are streamed to the connected clients of the account.
+type Notifications struct {
+	repo   *store.Store
+	mailer Mailer
+	bus    events.Bus
+	stream *Stream
+}
+
+func NewNotifications(repo *store.Store, mailer Mailer, bus events.Bus, stream *Stream) *Notifications {
+	return &Notifications{repo: repo, mailer: mailer, bus: bus, stream: stream}
+}
+
+func (n *Notifications) Notify(ctx context.Context, accountId string, notification *models.Notification) error {
+	channels, err := n.repo.NotificationStore.Channels(ctx, accountId, notification.Type)
+	if err != nil {
+		return err
+	}
+	if channels.InApp {
+		notification.AccountId = accountId
+		if _, err := n.repo.NotificationStore.NewNotification(ctx, notification); err != nil {
+			return err
+		}
+		n.send(ctx, accountId, notification)
+	}
+	if channels.Email {
+		account, err := n.repo.AccountStore.FindAccountById(ctx, accountId)
+		if err != nil {
+			return err
+		}
+		err = n.mailer.Send(ctx, account.Email, account.Locale, MailNotification, &notificationMail{
+			Name:         account.Name,
+			Notification: notification,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notificationMail is the data of the notification and digest emails
+type notificationMail struct {
+	Name          string
+	Notification  *models.Notification
+	Notifications []*models.Notification
+}
+
+// send streams the new notification, clients that miss it still find it in
+// the notification center
+func (n *Notifications) send(ctx context.Context, accountId string, notification *models.Notification) {
+	event, err := NotificationCreated.New(notification.WorkspaceId, notification.Id, NotificationPayload{Notification: notification})
+	if err == nil {
+		err = n.stream.Send(ctx, accountId, event)
+	}
+	if err != nil {
+		logger.Errorf("Notifications error while streaming %s:%s", notification.Id, err.Error())
+	}
+}
+
+// Run notifies about the events on the bus until ctx is done
+func (n *Notifications) Run(ctx context.Context) {
+	for {
+		err := n.bus.Subscribe(ctx, NotificationGroup, n.handle, events.TopicDocuments)
+		if err != nil {
+			logger.Errorf("Notifications error while consuming events:%s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(notificationSubscribeDelay):
+		}
+	}
+}
+
+// handle notifies everyone an event concerns except the account that caused
+// it. Events are handled at least once, so a notification may repeat.
+func (n *Notifications) handle(ctx context.Context, event *events.Event) error {
+	switch event.Type {
+	case events.DocumentStatusChanged.Name:
+		data, err := events.DocumentStatusChanged.Decode(event)
+		if err != nil {
+			return err
+		}
+		document := data.Document
+		return n.notifyAll(ctx, []string{document.OwnerId}, data.AccountId, &models.Notification{
+			WorkspaceId:  document.WorkspaceId,
+			Type:         models.NotificationDocumentStatusChanged,
+			Title:        fmt.Sprintf("%s moved from %s to %s", document.Name, data.From, data.To),
+			ResourceType: models.ResourceTypeDocument,
+			ResourceId:   document.Id,
+		})
+	case events.TransitionRequested.Name:
+		data, err := events.TransitionRequested.Decode(event)
+		if err != nil {
+			return err
+		}
+		transition := data.Transition
+		document, err := n.repo.DocumentStore.FindDocumentById(ctx, transition.DocumentId)
+		if errors.Is(err, models.ErrDocumentNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		approvers, err := n.repo.WorkflowStore.Approvers(ctx, transition)
+		if err != nil {
+			return err
+		}
+		return n.notifyAll(ctx, approvers, data.AccountId, &models.Notification{
+			WorkspaceId:  transition.WorkspaceId,
+			Type:         models.NotificationTransitionRequested,
+			Title:        fmt.Sprintf("%s needs your approval to move to %s", document.Name, transition.To),
+			Body:         transition.Comment,
+			ResourceType: models.ResourceTypeDocument,
+			ResourceId:   document.Id,
+		})
+	case events.TransitionDecided.Name:
+		data, err := events.TransitionDecided.Decode(event)
+		if err != nil {
+			return err
+		}
+		transition := data.Transition
+		document, err := n.repo.DocumentStore.FindDocumentById(ctx, transition.DocumentId)
+		if errors.Is(err, models.ErrDocumentNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		title := fmt.Sprintf("Moving %s to %s received an approval", document.Name, transition.To)
+		switch transition.Status {
+		case models.TransitionStatusCompleted:
+			title = fmt.Sprintf("Moving %s to %s was approved", document.Name, transition.To)
+		case models.TransitionStatusRejected:
+			title = fmt.Sprintf("Moving %s to %s was rejected", document.Name, transition.To)
+		}
+		body := ""
+		if data.Approval != nil {
+			body = data.Approval.Comment
+		}
+		return n.notifyAll(ctx, []string{transition.RequestedBy}, data.AccountId, &models.Notification{
+			WorkspaceId:  transition.WorkspaceId,
+			Type:         models.NotificationTransitionDecided,
+			Title:        title,
+			Body:         body,
+			ResourceType: models.ResourceTypeDocument,
+			ResourceId:   document.Id,
+		})
+	case events.CommentCreated.Name:
+		data, err := events.CommentCreated.Decode(event)
+		if err != nil {
+			return err
+		}
+		return n.notifyComment(ctx, true, data)
+	case events.CommentUpdated.Name:
+		data, err := events.CommentUpdated.Decode(event)
+		if err != nil {
+			return err
+		}
+		return n.notifyComment(ctx, false, data)
+	}
+	return nil
+}
+
+// notifyComment notifies the accounts mentioned by a comment and, for new
+// replies, the other participants of the thread
+func (n *Notifications) notifyComment(ctx context.Context, created bool, data events.CommentPayload) error {
+	comment := data.Comment
+	document, err := n.repo.DocumentStore.FindDocumentById(ctx, comment.DocumentId)
+	if errors.Is(err, models.ErrDocumentNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	author, err := n.repo.AccountStore.FindAccountById(ctx, comment.AccountId)
+	if err != nil {
+		return err
+	}
+	body := excerpt(comment.Body, NotificationExcerptSize)
+	err = n.notifyAll(ctx, data.Mentioned, data.AccountId, &models.Notification{
+		WorkspaceId:  comment.WorkspaceId,
+		Type:         models.NotificationCommentMentioned,
+		Title:        fmt.Sprintf("%s mentioned you on %s", author.Name, document.Name),
+		Body:         body,
+		ResourceType: models.ResourceTypeDocument,
+		ResourceId:   document.Id,
+	})
+	if err != nil || !created || comment.IsThread() {
+		return err
+	}
+	participants, err := n.repo.CommentStore.Participants(ctx, comment.ParentId)
+	if err != nil {
+		return err
+	}
+	// the mentioned accounts were notified already
+	others := []string{}
+	for _, accountId := range participants {
+		if !comment.Mentions.Contains(accountId) {
+			others = append(others, accountId)
+		}
+	}
+	return n.notifyAll(ctx, others, data.AccountId, &models.Notification{
+		WorkspaceId:  comment.WorkspaceId,
+		Type:         models.NotificationCommentReplied,
+		Title:        fmt.Sprintf("%s replied to a discussion on %s", author.Name, document.Name),
+		Body:         body,
+		ResourceType: models.ResourceTypeDocument,
+		ResourceId:   document.Id,
+	})
+}
+
+// excerpt cuts text to at most size bytes without splitting a character
+func excerpt(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	text = text[:size]
+	for len(text) > 0 {
+		r, width := utf8.DecodeLastRuneInString(text)
+		if r != utf8.RuneError || width > 1 {
+			break
+		}
+		text = text[:len(text)-1]
+	}
+	return text + "…"
+}
+
+// notifyAll sends a copy of notification to every account but actorId.
+// Failures are logged, retrying the event would repeat the notifications
+// that were sent.
+func (n *Notifications) notifyAll(ctx context.Context, accountIds []string, actorId string, notification *models.Notification) error {
+	for _, accountId := range accountIds {
+		if accountId == "" || accountId == actorId {
+			continue
+		}
+		copied := *notification
+		if err := n.Notify(ctx, accountId, &copied); err != nil {
+			logger.Errorf("Notifications error while notifying %s of %s:%s", accountId, notification.Type, err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const (
+	OutboxBatchSize      = 100
+	OutboxPollInterval   = time.Second
+	OutboxPublishTimeout = 30 * time.Second
+	// OutboxRetention is how long delivered events are kept
+	OutboxRetention     = 7 * 24 * time.Hour
+	OutboxPurgeInterval = time.Hour
+	OutboxPurgeLock     = "lock::outbox_purge"
+)
+
+// OutboxRelay publishes the events the store recorded in the outbox on the
+// bus. Every instance runs a relay, workspaces are claimed so the events of a
+// workspace are published by one of them, in order.
+type OutboxRelay struct {
+	repo      *store.Store
+	redisLock *lock.RedisLock
+	bus       events.Bus
+}
+
+func NewOutboxRelay(repo *store.Store, redisLock *lock.RedisLock, bus events.Bus) *OutboxRelay {
+	return &OutboxRelay{repo: repo, redisLock: redisLock, bus: bus}
+}
+
+// Run relays recorded events until ctx is done
+func (r *OutboxRelay) Run(ctx context.Context) {
+	go runScheduled(ctx, r.redisLock, OutboxPurgeLock, OutboxPurgeInterval, func() {
+		purged, err := r.repo.OutboxStore.Purge(ctx, time.Now().Add(-OutboxRetention))
+		if err != nil {
+			logger.Errorf("OutboxRelay error while purging delivered events:%s", err.Error())
+		}
+		if purged > 0 {
+			logger.Infof("OutboxRelay purged %d delivered events", purged)
+		}
+	})
+
+	ticker := time.NewTicker(OutboxPollInterval)
+	defer ticker.Stop()
+	for {
+		for r.runBatch(ctx) == OutboxBatchSize {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runBatch relays a batch of events and returns its size
+func (r *OutboxRelay) runBatch(ctx context.Context) int {
+	relayed, err := r.repo.OutboxStore.Relay(ctx, OutboxBatchSize, func(batch []*events.Event) error {
+		ctx, cancel := context.WithTimeout(ctx, OutboxPublishTimeout)
+		defer cancel()
+		return r.bus.Publish(ctx, batch...)
+	}, jobs.Backoff)
+	if err != nil {
+		logger.Errorf("OutboxRelay error while relaying events:%s", err.Error())
+		return 0
+	}
+	return relayed
+}
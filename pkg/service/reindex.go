@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const (
+	ReindexBatchSize = SearchSyncBatchSize
+	// ReindexLockExpiry is extended after every batch, a crashed reindex
+	// releases the lock after it
+	ReindexLockExpiry = 2 * time.Minute
+	ReindexLock       = "lock::reindex"
+	// ReindexTimeout cancels a long reindex, the retry resumes it
+	ReindexTimeout     = time.Hour
+	reindexProgressKey = "reindex::progress"
+)
+
+var ErrReindexLockLost = errors.New("reindex lost its lock")
+
+// ReindexJob runs a reindex on a worker
+var ReindexJob = jobs.NewType[ReindexPayload]("search.reindex")
+
+type ReindexPayload struct{}
+
+// ReindexProgress is checkpointed after every batch. A reindex that did not
+// finish resumes after LastId.
+type ReindexProgress struct {
+	LastId     string     `json:"last_id"`
+	Indexed    int64      `json:"indexed"`
+	Total      int64      `json:"total"`
+	StartedAt  time.Time  `json:"started_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Error      string     `json:"error"`
+	Running    bool       `json:"running"`
+}
+
+// Reindex pushes all documents to the search backend, for a new backend or
+// one that got out of sync. One reindex runs at a time across all instances.
+type Reindex struct {
+	repo      *store.Store
+	redisLock *lock.RedisLock
+	cache     cache.Cache
+	sync      *SearchSync
+	queue     jobs.Queue
+}
+
+func NewReindex(repo *store.Store, redisLock *lock.RedisLock, cache cache.Cache, sync *SearchSync, queue jobs.Queue) *Reindex {
+	return &Reindex{repo: repo, redisLock: redisLock, cache: cache, sync: sync, queue: queue}
+}
+
+// Start enqueues a reindex for a worker, see Run
+func (s *Reindex) Start(ctx context.Context, restart bool) error {
+	running, err := s.cache.ExistsContext(ctx, ReindexLock)
+	if err != nil {
+		return err
+	}
+	if running {
+		return models.ErrReindexRunning
+	}
+	if restart {
+		// the job always resumes, so a retry does not start over
+		if err := s.cache.DelContext(ctx, reindexProgressKey); err != nil {
+			return err
+		}
+	}
+	_, err = ReindexJob.Enqueue(ctx, s.queue, ReindexPayload{})
+	return err
+}
+
+// Register runs ReindexJob on w
+func (s *Reindex) Register(w *jobs.Worker) {
+	jobs.Register(w, ReindexJob, func(ctx context.Context, _ ReindexPayload) error {
+		_, err := s.Run(ctx, false)
+		if errors.Is(err, models.ErrReindexRunning) {
+			return nil
+		}
+		return err
+	}, jobs.WithTimeout(ReindexTimeout))
+}
+
+// Run reindexes all documents and returns the final progress. It resumes an
+// unfinished reindex unless restart is set.
+func (s *Reindex) Run(ctx context.Context, restart bool) (*ReindexProgress, error) {
+	mutex, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(ctx, mutex, restart)
+}
+
+// Progress returns the progress of the running or last reindex, nil when
+// there was none
+func (s *Reindex) Progress(ctx context.Context) (*ReindexProgress, error) {
+	progress := &ReindexProgress{}
+	err := s.cache.GetContext(ctx, reindexProgressKey, progress)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	progress.Running, err = s.cache.ExistsContext(ctx, ReindexLock)
+	return progress, err
+}
+
+func (s *Reindex) lock(ctx context.Context) (*lock.Mutex, error) {
+	mutex := s.redisLock.NewMutex(ReindexLock, lock.WithExpiry(ReindexLockExpiry), lock.WithRetryCount(1))
+	ok, err := mutex.LockContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, models.ErrReindexRunning
+	}
+	return mutex, nil
+}
+
+func (s *Reindex) run(ctx context.Context, mutex *lock.Mutex, restart bool) (*ReindexProgress, error) {
+	defer func() {
+		// ctx may be done already, the lock and the progress are saved anyway
+		if err := mutex.UnlockContext(context.WithoutCancel(ctx)); err != nil {
+			logger.Errorf("Reindex error while unlocking:%s", err.Error())
+		}
+	}()
+	if err := s.sync.Setup(ctx); err != nil {
+		return nil, err
+	}
+	progress, err := s.start(ctx, restart)
+	if err != nil {
+		return nil, err
+	}
+	if progress.LastId != "" {
+		logger.Infof("Reindex resuming after %s, %d of %d documents indexed", progress.LastId, progress.Indexed, progress.Total)
+	}
+	err = s.walk(ctx, mutex, progress)
+	if err != nil {
+		progress.Error = err.Error()
+	} else {
+		finishedAt := time.Now()
+		progress.FinishedAt = &finishedAt
+		logger.Infof("Reindex finished, %d documents indexed", progress.Indexed)
+	}
+	if err := s.checkpoint(context.WithoutCancel(ctx), progress); err != nil {
+		logger.Errorf("Reindex error while saving progress:%s", err.Error())
+	}
+	return progress, err
+}
+
+// start loads the checkpoint of an unfinished reindex or starts over
+func (s *Reindex) start(ctx context.Context, restart bool) (*ReindexProgress, error) {
+	progress := &ReindexProgress{}
+	if !restart {
+		err := s.cache.GetContext(ctx, reindexProgressKey, progress)
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		if err == nil && progress.FinishedAt == nil {
+			progress.Error = ""
+			return progress, nil
+		}
+	}
+	total, err := s.repo.DocumentStore.CountDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	progress = &ReindexProgress{Total: total, StartedAt: now, UpdatedAt: now}
+	return progress, s.checkpoint(ctx, progress)
+}
+
+// walk indexes the documents after progress.LastId batch by batch in id order
+// and removes the deleted ones from the index, documents created meanwhile are
+// indexed by SearchSync
+func (s *Reindex) walk(ctx context.Context, mutex *lock.Mutex, progress *ReindexProgress) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ids, err := s.repo.DocumentStore.ListDocumentIdsAfter(ctx, progress.LastId, ReindexBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := s.sync.Sync(ctx, ids...); err != nil {
+			return err
+		}
+		progress.LastId = ids[len(ids)-1]
+		progress.Indexed += int64(len(ids))
+		progress.UpdatedAt = time.Now()
+		if err := s.checkpoint(ctx, progress); err != nil {
+			return err
+		}
+		ok, err := mutex.ExtendContext(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrReindexLockLost
+		}
+	}
+}
+
+func (s *Reindex) checkpoint(ctx context.Context, progress *ReindexProgress) error {
+	return s.cache.SetContext(ctx, reindexProgressKey, progress)
+}
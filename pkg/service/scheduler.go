@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+)
+
+// runScheduled calls fn every interval until ctx is done, on one replica only.
+// Each round the replica that acquires the lock of the round, the interval the
+// tick falls in, runs fn. The lock is not released so the other replicas skip
+// the round. A lock shared by all rounds would expire just as the next tick
+// fires and could still be held then, skipping a round.
+func runScheduled(ctx context.Context, redisLock *lock.RedisLock, name string, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			round := now.Truncate(interval).Unix()
+			mutex := redisLock.NewMutex(fmt.Sprintf("%s::%d", name, round), lock.WithExpiry(interval), lock.WithRetryCount(1))
+			leader, err := mutex.LockContext(ctx)
+			if err != nil {
+				logger.Errorf("runScheduled error while acquiring lock %s:%s", name, err.Error())
+				continue
+			}
+			if leader {
+				fn()
+			}
+		}
+	}
+}
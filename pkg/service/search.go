@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/search"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const (
+	SearchSyncBatchSize = 100
+	SearchSyncDelay     = time.Second
+	SearchSyncTimeout   = 30 * time.Second
+)
+
+// SearchSync keeps the search index in line with the documents. Changed
+// documents are collected for SearchSyncDelay and indexed in batches.
+type SearchSync struct {
+	repo    *store.Store
+	indexer search.Indexer
+	changes chan string
+}
+
+func NewSearchSync(repo *store.Store, indexer search.Indexer) *SearchSync {
+	s := &SearchSync{repo: repo, indexer: indexer, changes: make(chan string, 10*SearchSyncBatchSize)}
+	repo.OnDocumentsChanged(s.enqueue)
+	return s
+}
+
+// enqueue must not block the store, changes are dropped and logged when the
+// index falls behind
+func (s *SearchSync) enqueue(documentIds ...string) {
+	for _, id := range documentIds {
+		select {
+		case s.changes <- id:
+		default:
+			logger.Errorf("SearchSync queue full, dropping document %s", id)
+		}
+	}
+}
+
+// Run sets the index up and indexes changed documents until ctx is done
+func (s *SearchSync) Run(ctx context.Context) {
+	if err := s.Setup(ctx); err != nil {
+		logger.Errorf("SearchSync error while setting up the index:%s", err.Error())
+	}
+
+	pending := map[string]bool{}
+	timer := time.NewTimer(SearchSyncDelay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case id := <-s.changes:
+			if len(pending) == 0 {
+				timer.Reset(SearchSyncDelay)
+			}
+			pending[id] = true
+			if len(pending) < SearchSyncBatchSize {
+				continue
+			}
+			timer.Stop()
+		case <-timer.C:
+		}
+		ids := make([]string, 0, len(pending))
+		for id := range pending {
+			ids = append(ids, id)
+		}
+		pending = map[string]bool{}
+		if err := s.Sync(ctx, ids...); err != nil {
+			logger.Errorf("SearchSync error while indexing %d documents:%s", len(ids), err.Error())
+		}
+	}
+}
+
+// Setup creates or updates the index settings
+func (s *SearchSync) Setup(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, SearchSyncTimeout)
+	defer cancel()
+	return s.indexer.Setup(ctx)
+}
+
+// Sync indexes the documents among ids and removes the deleted ones from the
+// index
+func (s *SearchSync) Sync(ctx context.Context, documentIds ...string) error {
+	if len(documentIds) == 0 {
+		return nil
+	}
+	documents, err := s.repo.DocumentStore.FindDocumentsByIds(ctx, documentIds)
+	if err != nil {
+		return err
+	}
+	texts, err := s.repo.VersionStore.LatestTexts(ctx, documentIds)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]bool, len(documents))
+	docs := make([]*search.Document, 0, len(documents))
+	for _, d := range documents {
+		found[d.Id] = true
+		doc := search.NewDocument(d)
+		doc.Content = texts[d.Id]
+		docs = append(docs, doc)
+	}
+	deleted := []string{}
+	for _, id := range documentIds {
+		if !found[id] {
+			deleted = append(deleted, id)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, SearchSyncTimeout)
+	defer cancel()
+	if err := s.indexer.Index(ctx, docs...); err != nil {
+		return err
+	}
+	return s.indexer.Delete(ctx, deleted...)
+}
@@ -1,15 +1,101 @@
 package service
 
 import (
+	"context"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
 	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/extract"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/search"
+	"github.com/praveenmsp23/trackdocs/pkg/storage"
 	"github.com/praveenmsp23/trackdocs/pkg/store"
+	"github.com/praveenmsp23/trackdocs/pkg/stream"
 )
 
 // Service one stop for all the services
 type Service struct {
+	Jobs             jobs.Queue
+	Notifier         Notifier
+	Notifications    *Notifications
+	Mail             *Mail
+	Digests          *Digests
+	Uploads          *Uploads
+	ExpiryReminder   *ExpiryReminder
+	DocumentRequests *DocumentRequests
+	Indexer          search.Indexer
+	SearchSync       *SearchSync
+	Extraction       *Extraction
+	Reindex          *Reindex
+	OutboxRelay      *OutboxRelay
+	Webhooks         *Webhooks
+	Stream           *Stream
+
+	cfg   *config.Config
+	cache cache.Cache
+}
+
+// NewService create all the services, the background jobs only run once
+// Start is called
+func NewService(cfg *config.Config, repo *store.Store, redisLock *lock.RedisLock, cache cache.Cache, storage *storage.Storage, indexer search.Indexer, queue jobs.Queue, bus events.Bus) (*Service, error) {
+	mailer, err := NewMail(cfg, queue)
+	if err != nil {
+		return nil, err
+	}
+	eventStream := NewStream(repo, stream.NewHub(cache, cfg), bus)
+	notifications := NewNotifications(repo, mailer, bus, eventStream)
+	uploads := NewUploads(cfg, repo, storage)
+	searchSync := NewSearchSync(repo, indexer)
+	srv := &Service{
+		Jobs:             queue,
+		Notifier:         notifications,
+		Notifications:    notifications,
+		Mail:             mailer,
+		Digests:          NewDigests(cfg, repo, redisLock, mailer),
+		Uploads:          uploads,
+		ExpiryReminder:   NewExpiryReminder(cfg, repo, redisLock, notifications),
+		DocumentRequests: NewDocumentRequests(cfg, repo, redisLock, uploads, mailer, notifications),
+		Indexer:          indexer,
+		SearchSync:       searchSync,
+		Extraction:       NewExtraction(cfg, repo, uploads, extract.NewExtractors(cfg)),
+		Reindex:          NewReindex(repo, redisLock, cache, searchSync, queue),
+		OutboxRelay:      NewOutboxRelay(repo, redisLock, bus),
+		Webhooks:         NewWebhooks(cfg, repo, queue, bus),
+		Stream:           eventStream,
+		cfg:              cfg,
+		cache:            cache,
+	}
+	return srv, nil
+}
+
+// Start runs the background jobs until ctx is done
+func (s *Service) Start(ctx context.Context) {
+	if c, ok := s.cache.(*cache.Redis); ok {
+		// the local cache tier is used once it receives invalidations
+		go c.Listen(ctx)
+	}
+	go s.ExpiryReminder.Run(ctx)
+	go s.DocumentRequests.Run(ctx)
+	go s.SearchSync.Run(ctx)
+	go s.Extraction.Run(ctx)
+	go s.OutboxRelay.Run(ctx)
+	go s.Webhooks.Run(ctx)
+	go s.Notifications.Run(ctx)
+	go s.Stream.Run(ctx)
+	go s.Digests.Run(ctx)
+	if _, ok := s.Jobs.(*jobs.MemoryQueue); ok {
+		// no other process sees the jobs, run them here
+		w := jobs.NewWorker(s.cfg, s.Jobs)
+		s.RegisterJobs(w)
+		go w.Run(ctx)
+	}
 }
 
-// NewService create all the services
-func NewService(cfg *config.Config, repo *store.Store) (*Service, error) {
-	return &Service{}, nil
+// RegisterJobs registers the handlers of the background jobs on w
+func (s *Service) RegisterJobs(w *jobs.Worker) {
+	s.Reindex.Register(w)
+	s.Webhooks.Register(w)
+	s.Mail.Register(w)
 }
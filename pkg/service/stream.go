@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+	"github.com/praveenmsp23/trackdocs/pkg/stream"
+)
+
+const (
+	StreamGroup = "stream"
+	// StreamPermissionTTL is how long a connection trusts a permission check
+	// before it checks again
+	StreamPermissionTTL = 30 * time.Second
+	// streamSubscribeDelay is the pause before subscribing again after the
+	// bus failed
+	streamSubscribeDelay = 5 * time.Second
+)
+
+// NotificationPayload carries a notification created in the notification
+// center
+type NotificationPayload struct {
+	Notification *models.Notification `json:"notification"`
+}
+
+// NotificationCreated is only sent to the stream of the notified account, it
+// is not published on the bus
+var NotificationCreated = events.NewType[NotificationPayload]("notification.created", events.TopicAccounts)
+
+// Stream forwards the events on the bus to the clients connected to the
+// stream endpoint of every API replica
+type Stream struct {
+	repo *store.Store
+	hub  *stream.Hub
+	bus  events.Bus
+}
+
+func NewStream(repo *store.Store, hub *stream.Hub, bus events.Bus) *Stream {
+	return &Stream{repo: repo, hub: hub, bus: bus}
+}
+
+// Run forwards the events on the bus and delivers the messages of the hub
+// to the local subscribers until ctx is done
+func (s *Stream) Run(ctx context.Context) {
+	go s.hub.Run(ctx)
+	for {
+		err := s.bus.Subscribe(ctx, StreamGroup, s.handle, events.TopicDocuments, events.TopicAccounts)
+		if err != nil {
+			logger.Errorf("Stream error while consuming events:%s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(streamSubscribeDelay):
+		}
+	}
+}
+
+func (s *Stream) handle(ctx context.Context, event *events.Event) error {
+	return s.hub.Publish(ctx, &stream.Message{Event: event})
+}
+
+// Send streams an event to accountId only
+func (s *Stream) Send(ctx context.Context, accountId string, event *events.Event) error {
+	return s.hub.Publish(ctx, &stream.Message{AccountId: accountId, Event: event})
+}
+
+func (s *Stream) Subscribe() *stream.Subscription {
+	return s.hub.Subscribe()
+}
+
+// Replay returns the messages after lastId, see stream.Hub.Replay
+func (s *Stream) Replay(ctx context.Context, lastId string, limit int64) ([]*stream.Message, bool, error) {
+	if !stream.ValidId(lastId) {
+		return nil, false, nil
+	}
+	return s.hub.Replay(ctx, lastId, limit)
+}
+
+// Last returns the id of the latest message, see stream.Hub.Last
+func (s *Stream) Last(ctx context.Context) (string, error) {
+	return s.hub.Last(ctx)
+}
+
+// StreamFilter decides which messages a connection of an account receives.
+// It remembers its permission checks for StreamPermissionTTL, it is not safe
+// for concurrent use.
+type StreamFilter struct {
+	repo      *store.Store
+	accountId string
+	checked   map[string]streamPermission
+}
+
+type streamPermission struct {
+	allowed bool
+	expires time.Time
+}
+
+func (s *Stream) NewFilter(accountId string) *StreamFilter {
+	return &StreamFilter{repo: s.repo, accountId: accountId, checked: map[string]streamPermission{}}
+}
+
+// Allowed reports whether the account may see the message. Account events
+// only go to their account, document events to the accounts that can view
+// the document.
+func (f *StreamFilter) Allowed(ctx context.Context, message *stream.Message) bool {
+	if message.AccountId != "" {
+		return message.AccountId == f.accountId
+	}
+	event := message.Event
+	if event == nil {
+		return false
+	}
+	switch event.Topic {
+	case events.TopicAccounts:
+		return event.SubjectId == f.accountId
+	case events.TopicDocuments:
+		if event.Type == events.DocumentDeleted.Name {
+			// the document is gone, whoever can view where it was may know
+			data, err := events.DocumentDeleted.Decode(event)
+			if err != nil || data.Document == nil {
+				return false
+			}
+			if data.Document.FolderId != "" {
+				return f.can(ctx, models.ResourceTypeFolder, data.Document.FolderId)
+			}
+			return f.can(ctx, models.ResourceTypeProject, data.Document.ProjectId)
+		}
+		return f.can(ctx, models.ResourceTypeDocument, event.SubjectId)
+	}
+	return false
+}
+
+func (f *StreamFilter) can(ctx context.Context, resourceType models.ResourceType, resourceId string) bool {
+	if p, ok := f.checked[resourceId]; ok && time.Now().Before(p.expires) {
+		return p.allowed
+	}
+	_, err := f.repo.PermissionStore.Authorize(ctx, f.accountId, resourceType, resourceId, models.WorkspaceRoleViewer)
+	// forbidden and not found deny quietly, anything else is not remembered
+	if err != nil && !models.IsErrorCustom(err) {
+		logger.Errorf("Stream error while authorizing %s on %s:%s", f.accountId, resourceId, err.Error())
+		return false
+	}
+	f.checked[resourceId] = streamPermission{allowed: err == nil, expires: time.Now().Add(StreamPermissionTTL)}
+	return err == nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/db"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+	"github.com/praveenmsp23/trackdocs/pkg/stream"
+	"gorm.io/gorm"
+)
+
+// newTestStream returns a stream on an in-process hub, repo may be nil when
+// no document events are filtered
+func newTestStream(repo *store.Store) *Stream {
+	cfg := &config.Config{StreamBufferSize: 100}
+	return NewStream(repo, stream.NewHub(cache.NewMemory(cfg), cfg), events.NewMemory())
+}
+
+// receive reads the next n messages of the subscription
+func receive(t *testing.T, sub *stream.Subscription, n int) []*stream.Message {
+	t.Helper()
+	messages := make([]*stream.Message, 0, n)
+	for len(messages) < n {
+		select {
+		case m := <-sub.C:
+			messages = append(messages, m)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d messages, want %d", len(messages), n)
+		}
+	}
+	return messages
+}
+
+func TestStreamFilterAccountMessages(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStream(nil)
+	sub := s.Subscribe()
+	defer sub.Close()
+
+	updated := func(accountId string) *events.Event {
+		event, err := events.AccountUpdated.New("", accountId, events.AccountPayload{Account: &models.Account{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return event
+	}
+	notification, err := NotificationCreated.New("", "ntf", NotificationPayload{Notification: &models.Notification{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := &events.Event{Id: "evt", Type: events.DocumentDeleted.Name, Topic: events.TopicDocuments, SubjectId: "doc", Data: []byte(`"document"`)}
+	unknown := &events.Event{Id: "evt", Type: "other", Topic: "other", SubjectId: "ana"}
+	for _, event := range []*events.Event{updated("ana"), updated("bob"), broken, unknown} {
+		if err := s.handle(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Send(ctx, "ana", notification); err != nil {
+		t.Fatal(err)
+	}
+	messages := receive(t, sub, 5)
+
+	tests := []struct {
+		accountId string
+		want      []bool
+	}{
+		{"ana", []bool{true, false, false, false, true}},
+		{"bob", []bool{false, true, false, false, false}},
+	}
+	for _, tt := range tests {
+		filter := s.NewFilter(tt.accountId)
+		for i, m := range messages {
+			if got := filter.Allowed(ctx, m); got != tt.want[i] {
+				t.Errorf("%s sees %s %s: %v, want %v", tt.accountId, m.Event.Type, m.Event.SubjectId, got, tt.want[i])
+			}
+		}
+	}
+}
+
+// newTestRepo connects to the postgres at TRACKDOCS_TEST_DATASOURCE and
+// migrates it
+func newTestRepo(t *testing.T) (*store.Store, *gorm.DB) {
+	t.Helper()
+	source := os.Getenv("TRACKDOCS_TEST_DATASOURCE")
+	if source == "" {
+		t.Skip("TRACKDOCS_TEST_DATASOURCE is not set")
+	}
+	cfg := &config.Config{Datasource: source, CacheTimeout: time.Second}
+	c := cache.NewMemory(cfg)
+	redisLock, err := lock.NewRedisLock(c, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := db.NewDB(cfg, redisLock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, err := conn.DB()
+		if err == nil {
+			sqlDB.Close()
+		}
+	})
+	repo, err := store.NewStore(conn, c, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo, conn
+}
+
+func TestStreamFilterDocumentEvents(t *testing.T) {
+	repo, conn := newTestRepo(t)
+	ctx := context.Background()
+	account := func(name string) *models.Account {
+		email := fmt.Sprintf("%s-%d@example.com", name, time.Now().UnixNano())
+		a, err := models.NewAccount(name, email).Create(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	owner, outsider := account("owner"), account("outsider")
+	workspace, err := repo.WorkspaceStore.NewWorkspaceFromRequest(ctx, owner.Id, &dto.WorkspaceCreateRequest{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := repo.ProjectStore.NewProjectFromRequest(ctx, workspace.Id, owner.Id, &dto.ProjectCreateRequest{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder, err := repo.FolderStore.NewFolderFromRequest(ctx, owner.Id, &dto.FolderCreateRequest{ProjectId: project.Id, Name: "contracts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := repo.DocumentStore.NewDocumentFromRequest(ctx, owner.Id, &dto.DocumentCreateRequest{ProjectId: project.Id, FolderId: folder.Id, Name: "lease"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestStream(repo)
+	sub := s.Subscribe()
+	defer sub.Close()
+	updated, err := events.DocumentUpdated.New(workspace.Id, document.Id, events.DocumentPayload{Document: document})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the deleted document is no longer found, its folder decides
+	gone := *document
+	gone.Id = "doc-gone"
+	deleted, err := events.DocumentDeleted.New(workspace.Id, gone.Id, events.DocumentPayload{Document: &gone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range []*events.Event{updated, deleted} {
+		if err := s.handle(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	messages := receive(t, sub, 2)
+
+	for _, tt := range []struct {
+		account *models.Account
+		want    bool
+	}{{owner, true}, {outsider, false}} {
+		filter := s.NewFilter(tt.account.Id)
+		for _, m := range messages {
+			if got := filter.Allowed(ctx, m); got != tt.want {
+				t.Errorf("%s sees %s: %v, want %v", tt.account.Name, m.Event.Type, got, tt.want)
+			}
+		}
+	}
+}
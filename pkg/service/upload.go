@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/storage"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+// uploadSniffSize is the head of a file the content type is detected from,
+// office files need more than the 512 bytes of http.DetectContentType
+const uploadSniffSize = 3072
+
+// Uploads stores uploaded files and records them as document versions
+type Uploads struct {
+	cfg     *config.Config
+	repo    *store.Store
+	storage *storage.Storage
+}
+
+func NewUploads(cfg *config.Config, repo *store.Store, storage *storage.Storage) *Uploads {
+	return &Uploads{cfg: cfg, repo: repo, storage: storage}
+}
+
+// Put writes the file of a new version of the document to the storage and
+// returns the unsaved version. The content type is detected from the content.
+// accepts, when set, decides whether the file name and content type are
+// allowed.
+func (s *Uploads) Put(document *models.Document, fileName, createdBy string, r io.Reader, maxSize int64, accepts func(fileName, contentType string) bool) (*models.DocumentVersion, error) {
+	if maxSize <= 0 || maxSize > s.cfg.UploadMaxSize {
+		maxSize = s.cfg.UploadMaxSize
+	}
+	buffered := bufio.NewReader(io.LimitReader(r, maxSize+1))
+	head, err := buffered.Peek(uploadSniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	contentType := mimetype.Detect(head).String()
+	if accepts != nil && !accepts(fileName, contentType) {
+		return nil, models.ErrFileTypeNotAllowed
+	}
+
+	version := models.NewDocumentVersion(document, fileName, contentType, createdBy)
+	hash := sha256.New()
+	size, err := s.storage.Put(version.StorageKey, io.TeeReader(buffered, hash))
+	if err != nil {
+		return nil, err
+	}
+	if size > maxSize {
+		s.Discard(version)
+		return nil, models.ErrFileTooLarge
+	}
+	version.Size = size
+	version.Checksum = hex.EncodeToString(hash.Sum(nil))
+	return version, nil
+}
+
+// Discard removes the file of a version that could not be saved
+func (s *Uploads) Discard(version *models.DocumentVersion) {
+	err := s.storage.Delete(version.StorageKey)
+	if err != nil {
+		logger.Errorf("Uploads error while deleting %s:%s", version.StorageKey, err.Error())
+	}
+}
+
+// AddVersion uploads a new version of a document in draft status
+func (s *Uploads) AddVersion(ctx context.Context, documentId, accountId, fileName string, r io.Reader) (*models.DocumentVersion, error) {
+	document, err := s.repo.DocumentStore.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return nil, err
+	}
+	if !document.Status.IsEditable() {
+		return nil, models.ErrDocumentLocked
+	}
+	version, err := s.Put(document, fileName, accountId, r, s.cfg.UploadMaxSize, nil)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.repo.VersionStore.NewVersion(ctx, version)
+	if err != nil {
+		s.Discard(version)
+		return nil, err
+	}
+	return saved, nil
+}
+
+// Open returns the file of the version
+func (s *Uploads) Open(version *models.DocumentVersion) (io.ReadSeekCloser, error) {
+	return s.storage.Open(version.StorageKey)
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/jobs"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/netutil"
+	"github.com/praveenmsp23/trackdocs/pkg/store"
+)
+
+const (
+	WebhookGroup          = "webhooks"
+	WebhookUserAgent      = "TrackDocs-Webhooks/1.0"
+	WebhookEventHeader    = "X-TrackDocs-Event"
+	WebhookDeliveryHeader = "X-TrackDocs-Delivery"
+	WebhookTimeHeader     = "X-TrackDocs-Timestamp"
+	WebhookSignHeader     = "X-TrackDocs-Signature"
+	// webhookSubscribeDelay is the pause before subscribing again after the
+	// bus failed
+	webhookSubscribeDelay = 5 * time.Second
+)
+
+// WebhookDeliveryJob sends a delivery to its webhook
+var WebhookDeliveryJob = jobs.NewType[WebhookDeliveryPayload]("webhook.deliver")
+
+type WebhookDeliveryPayload struct {
+	WebhookId   string `json:"webhook_id"`
+	WorkspaceId string `json:"workspace_id"`
+	DeliveryId  string `json:"delivery_id"`
+}
+
+// Webhooks posts the events of a workspace to its webhooks. Every request
+// carries the unix time in WebhookTimeHeader and in WebhookSignHeader
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed
+// with the webhook secret. Failed attempts are retried with jobs.Backoff up
+// to WebhookMaxAttempts times, and again as often after a redelivery.
+type Webhooks struct {
+	cfg    *config.Config
+	repo   *store.Store
+	queue  jobs.Queue
+	bus    events.Bus
+	client *http.Client
+}
+
+func NewWebhooks(cfg *config.Config, repo *store.Store, queue jobs.Queue, bus events.Bus) *Webhooks {
+	return &Webhooks{
+		cfg:   cfg,
+		repo:  repo,
+		queue: queue,
+		bus:   bus,
+		// urls are chosen by users, connections to the private network,
+		// such as redis or the metadata service, are refused. A redirect is a
+		// failed attempt, receivers must answer at the configured url.
+		client: netutil.NewPublicClient(cfg.WebhookTimeout, func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}),
+	}
+}
+
+// Run creates deliveries for the events on the bus until ctx is done
+func (s *Webhooks) Run(ctx context.Context) {
+	for {
+		err := s.bus.Subscribe(ctx, WebhookGroup, s.dispatch, events.TopicDocuments, events.TopicAccounts)
+		if err != nil {
+			logger.Errorf("Webhooks error while consuming events:%s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(webhookSubscribeDelay):
+		}
+	}
+}
+
+// Register runs WebhookDeliveryJob on w
+func (s *Webhooks) Register(w *jobs.Worker) {
+	jobs.Register(w, WebhookDeliveryJob, s.deliver, jobs.WithTimeout(2*s.cfg.WebhookTimeout))
+}
+
+// dispatch creates a delivery for every webhook subscribed to the event.
+// Events outside of a workspace are not sent to webhooks.
+func (s *Webhooks) dispatch(ctx context.Context, event *events.Event) error {
+	if event.WorkspaceId == "" {
+		return nil
+	}
+	webhooks, err := s.repo.WebhookStore.Subscribers(ctx, event.WorkspaceId, event.Type)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	for _, webhook := range webhooks {
+		delivery, err := s.repo.WebhookStore.NewDelivery(ctx, webhook, event.Id, event.Type, string(payload))
+		if err != nil {
+			return err
+		}
+		// a delivery that was attempted is already in the queue
+		if delivery.Status != models.WebhookDeliveryStatusPending || delivery.Attempts > 0 {
+			continue
+		}
+		if err := s.enqueue(ctx, delivery, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redeliver sends a finished delivery of the webhook again
+func (s *Webhooks) Redeliver(ctx context.Context, webhook *models.Webhook, deliveryId string) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.WebhookStore.Redeliver(ctx, webhook, deliveryId)
+	if err != nil {
+		return delivery, err
+	}
+	return delivery, s.enqueue(ctx, delivery, time.Now())
+}
+
+func (s *Webhooks) enqueue(ctx context.Context, delivery *models.WebhookDelivery, at time.Time) error {
+	_, err := WebhookDeliveryJob.Enqueue(ctx, s.queue, WebhookDeliveryPayload{
+		WebhookId:   delivery.WebhookId,
+		WorkspaceId: delivery.WorkspaceId,
+		DeliveryId:  delivery.Id,
+	}, jobs.WithRunAt(at))
+	return err
+}
+
+// deliver makes one attempt. Only failures to record the attempt fail the
+// job, failed requests are scheduled as a new job after jobs.Backoff.
+func (s *Webhooks) deliver(ctx context.Context, payload WebhookDeliveryPayload) error {
+	webhook, err := s.repo.WebhookStore.FindWebhookById(ctx, payload.WorkspaceId, payload.WebhookId)
+	if errors.Is(err, models.ErrWebhookNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	delivery, err := s.repo.WebhookStore.FindDeliveryById(ctx, payload.WebhookId, payload.DeliveryId)
+	if errors.Is(err, models.ErrWebhookDeliveryNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if delivery.Status != models.WebhookDeliveryStatusPending {
+		return nil
+	}
+
+	attempt := &models.WebhookAttempt{Attempt: delivery.Attempts + 1}
+	if webhook.Enabled {
+		err = s.send(ctx, webhook, delivery, attempt)
+	} else {
+		err = models.ErrWebhookDisabled
+	}
+	succeeded := err == nil
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	retryAt := sql.NullTime{}
+	// a redelivery gets the retries of a new delivery
+	tries := attempt.Attempt - delivery.RedeliveredAfter
+	if !succeeded && webhook.Enabled && tries < s.cfg.WebhookMaxAttempts {
+		retryAt = models.NewSqlNullTime(time.Now().Add(jobs.Backoff(tries)))
+	}
+	disabled, err := s.repo.WebhookStore.RecordAttempt(ctx, delivery, attempt, succeeded, retryAt, s.cfg.WebhookDisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		logger.Infof("Webhooks disabled webhook %s of workspace %s after %d failed attempts", webhook.Id, webhook.WorkspaceId, s.cfg.WebhookDisableAfter)
+	}
+	if retryAt.Valid && !disabled {
+		return s.enqueue(ctx, delivery, retryAt.Time)
+	}
+	return nil
+}
+
+// send posts the delivery payload and fills in the response of the attempt.
+// Any status but 2xx fails the attempt.
+func (s *Webhooks) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	secret, err := s.repo.WebhookStore.Secret(webhook)
+	if err != nil {
+		return err
+	}
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", WebhookUserAgent)
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.Id)
+	req.Header.Set(WebhookTimeHeader, timestamp)
+	req.Header.Set(WebhookSignHeader, "sha256="+SignWebhook(secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	attempt.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, store.WebhookResponseSize))
+	attempt.ResponseCode = resp.StatusCode
+	attempt.ResponseBody = string(response)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>", receivers
+// compute the same to verify a request and reject old timestamps to prevent
+// replays
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
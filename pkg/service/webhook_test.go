@@ -0,0 +1,34 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/netutil"
+)
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("+PONG"))
+	}))
+	defer server.Close()
+
+	s := NewWebhooks(&config.Config{WebhookTimeout: time.Second}, nil, nil, nil)
+	resp, err := s.client.Post(server.URL, "application/json", nil)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the delivery to a loopback address to be refused")
+	}
+	if !errors.Is(err, netutil.ErrNotPublic) {
+		t.Fatalf("expected ErrNotPublic, got %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://169.254.169.254/latest/meta-data/", nil)
+	if err := s.client.CheckRedirect(req, nil); !errors.Is(err, netutil.ErrNotPublic) {
+		t.Fatalf("expected the redirect to the metadata service to be refused, got %v", err)
+	}
+}
@@ -1,26 +1,35 @@
 package store
 
 import (
+	"context"
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"time"
+
 	"github.com/praveenmsp23/trackdocs/pkg/cache"
 	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
 	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/mail"
 	"github.com/praveenmsp23/trackdocs/pkg/models"
 	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
 	"gorm.io/gorm"
 )
 
 type accountStore struct {
-	db    *gorm.DB
-	cfg   *config.Config
-	cache *cache.Cache
-	repo  *Store
+	db       *gorm.DB
+	cfg      *config.Config
+	cache    cache.Cache
+	accounts *cache.Typed[*models.Account]
+	repo     *Store
 }
 
 const MaxVerifyAttempts = 10
 
+// AccountNotFoundExpiry is how long unknown account ids are remembered
+const AccountNotFoundExpiry = time.Minute
+
 const (
 	AccountCachePrefix      = "account_v1::"
 	AccountEmailCachePrefix = "account_email_v1::"
@@ -34,65 +43,71 @@ func getAccountEmailCacheKey(email string) string {
 	return fmt.Sprintf("%s%s", AccountEmailCachePrefix, sha256.Sum256([]byte(email)))
 }
 
-func newAccountStore(conn *gorm.DB, cache *cache.Cache, cfg *config.Config) *accountStore {
-	return &accountStore{db: conn, cache: cache, cfg: cfg}
+func newAccountStore(conn *gorm.DB, c cache.Cache, cfg *config.Config) *accountStore {
+	accounts := cache.NewTyped[*models.Account](c, cache.WithNegativeCaching(models.ErrAccountNotFound, AccountNotFoundExpiry))
+	return &accountStore{db: conn, cache: c, accounts: accounts, cfg: cfg}
 }
 
-func (u *accountStore) NewAccountFromRequest(req *dto.AccountCreateRequest) (*models.Account, error) {
-	return u.NewAccount(req.Name, req.Email)
+func (u *accountStore) NewAccountFromRequest(ctx context.Context, req *dto.AccountCreateRequest) (*models.Account, error) {
+	return u.NewAccount(ctx, req.Name, req.Email)
 }
 
-func (u *accountStore) UpdateAccountFromRequest(accountId string, req *dto.AccountUpdateRequest) (*models.Account, error) {
-	return u.UpdateAccount(accountId, req.Name)
+func (u *accountStore) UpdateAccountFromRequest(ctx context.Context, accountId string, req *dto.AccountUpdateRequest) (*models.Account, error) {
+	if req.Locale != "" && !mail.HasLocale(req.Locale) {
+		return &models.Account{}, fmt.Errorf("%w: %s", models.ErrInvalidLocale, req.Locale)
+	}
+	return u.UpdateAccount(ctx, accountId, req.Name, req.Locale)
 }
 
-func (u *accountStore) FindAccountById(uid string) (*models.Account, error) {
-	var err error
-	account := &models.Account{}
-	err = u.cache.Get(getAccountCacheKey(uid), account)
-	if err == nil && account != nil && account.Id != "" {
-		return account, nil
-	}
-	err = u.db.Model(models.Account{}).Where("id = ?", uid).Take(&account).Error
+func (u *accountStore) FindAccountById(ctx context.Context, uid string) (*models.Account, error) {
+	account, err := u.accounts.GetOrLoad(ctx, getAccountCacheKey(uid), cache.DefaultExpiry*time.Second,
+		func(ctx context.Context) (*models.Account, error) {
+			account := &models.Account{}
+			err := u.db.WithContext(ctx).Model(models.Account{}).Where("id = ?", uid).Take(account).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, models.ErrAccountNotFound
+			}
+			return account, err
+		})
 	if err != nil {
 		return &models.Account{}, err
 	}
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return &models.Account{}, models.ErrAccountNotFound
-	}
-	err = u.cache.Set(getAccountCacheKey(uid), account)
-	if err != nil {
-		logger.Errorf("FindAccountById error while setting cache:%s for key %s", err.Error(), getAccountCacheKey(uid))
-	}
-	return account, err
+	return account, nil
 }
 
-func (u *accountStore) FindAccountByEmail(email string) (*models.Account, error) {
+func (u *accountStore) FindAccountByEmail(ctx context.Context, email string) (*models.Account, error) {
 	var err error
 	account := &models.Account{}
 	var id string
-	id, err = u.cache.GetString(getAccountEmailCacheKey(email))
+	id, err = u.cache.GetStringContext(ctx, getAccountEmailCacheKey(email))
 	if err == nil && id != "" {
-		return u.FindAccountById(id)
+		return u.FindAccountById(ctx, id)
 	}
-	err = u.db.Model(models.Account{}).Where("email = ?", email).Take(account).Error
+	err = u.db.WithContext(ctx).Model(models.Account{}).Where("email = ?", email).Take(account).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return &models.Account{}, models.ErrAccountNotFound
 	} else if err != nil {
 		return &models.Account{}, err
 	}
-	err = u.cache.SetString(getAccountEmailCacheKey(email), account.Id)
+	err = u.cache.SetStringContext(ctx, getAccountEmailCacheKey(email), account.Id)
 	if err != nil {
 		logger.Errorf("FindAccountByEmail error while setting cache:%s for key %s", err.Error(), getAccountEmailCacheKey(email))
 	}
 	return account, err
 }
 
-func (u *accountStore) NewAccount(name, email string) (*models.Account, error) {
-	account, err := u.FindAccountByEmail(email)
+func (u *accountStore) NewAccount(ctx context.Context, name, email string) (*models.Account, error) {
+	account, err := u.FindAccountByEmail(ctx, email)
 	if errors.Is(err, models.ErrAccountNotFound) {
 		t := models.NewAccount(name, email)
-		t, err := t.Create(u.db)
+		err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			t, err = t.Create(tx)
+			if err != nil {
+				return err
+			}
+			return recordEvent(tx, events.AccountCreated, "", t.Id, events.AccountPayload{Account: t})
+		})
 		if err != nil {
 			return t, err
 		}
@@ -103,29 +118,29 @@ func (u *accountStore) NewAccount(name, email string) (*models.Account, error) {
 	return account, models.ErrAccountExists
 }
 
-func (u *accountStore) UpdateAccount(accountId, name string) (*models.Account, error) {
-	account, err := u.FindAccountById(accountId)
+func (u *accountStore) UpdateAccount(ctx context.Context, accountId, name, locale string) (*models.Account, error) {
+	account, err := u.FindAccountById(ctx, accountId)
 	if err != nil {
 		return account, err
 	}
 	account.Name = name
-	account, err = account.Update(u.db)
-	if err != nil {
-		return account, err
-	}
-	err = u.cache.Del(getAccountCacheKey(accountId))
-	if err != nil {
-		logger.Errorf("UpdateAccount error while deleting cache:%s for key %s", err.Error(), getAccountCacheKey(accountId))
-	}
-	return account, nil
+	account.Locale = locale
+	return u.Update(ctx, account)
 }
 
-func (u *accountStore) Update(account *models.Account) (*models.Account, error) {
-	account, err := account.Update(u.db)
+func (u *accountStore) Update(ctx context.Context, account *models.Account) (*models.Account, error) {
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		account, err = account.Update(tx)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.AccountUpdated, "", account.Id, events.AccountPayload{Account: account})
+	})
 	if err != nil {
 		return account, err
 	}
-	err = u.cache.Del(getAccountCacheKey(account.Id))
+	err = u.accounts.Del(ctx, getAccountCacheKey(account.Id))
 	if err != nil {
 		logger.Errorf("Update account error while deleting cache:%s for key %s", err.Error(), getAccountCacheKey(account.Id))
 	}
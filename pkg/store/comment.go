@@ -0,0 +1,304 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+)
+
+// CommentQuoteSize is the most characters of anchored text kept with a
+// comment
+const CommentQuoteSize = 1000
+
+type commentStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newCommentStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *commentStore {
+	return &commentStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// NewCommentFromRequest starts a thread on the document or replies to one.
+// Replies to a reply join the thread of that reply, and only threads are
+// anchored.
+func (u *commentStore) NewCommentFromRequest(ctx context.Context, documentId, accountId string, req *dto.CommentCreateRequest) (*models.Comment, error) {
+	document, err := u.repo.DocumentStore.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return &models.Comment{}, err
+	}
+	mentions, err := u.validateMentions(ctx, document, req.Mentions)
+	if err != nil {
+		return &models.Comment{}, err
+	}
+	parentId := ""
+	if req.ParentId != "" {
+		parent, err := u.find(ctx, documentId, req.ParentId)
+		if err != nil {
+			return &models.Comment{}, err
+		}
+		if req.Anchor != nil {
+			return &models.Comment{}, fmt.Errorf("%w: replies cannot be anchored", models.ErrInvalidComment)
+		}
+		parentId = parent.Id
+		if !parent.IsThread() {
+			parentId = parent.ParentId
+		}
+	}
+	comment := models.NewComment(document, parentId, accountId, req.Body, mentions)
+	if req.Anchor != nil {
+		if err := u.anchor(ctx, comment, req.Anchor); err != nil {
+			return &models.Comment{}, err
+		}
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		comment, err = comment.Create(tx)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.CommentCreated, comment.WorkspaceId, comment.DocumentId,
+			events.CommentPayload{Comment: comment, Mentioned: mentions, AccountId: accountId})
+	})
+	if err != nil {
+		return &models.Comment{}, err
+	}
+	return comment, nil
+}
+
+// anchor places the comment on a page and/or a text range of a version of
+// its document
+func (u *commentStore) anchor(ctx context.Context, comment *models.Comment, req *dto.CommentAnchorRequest) error {
+	version, err := u.repo.VersionStore.FindVersionById(ctx, comment.DocumentId, req.VersionId)
+	if errors.Is(err, models.ErrVersionNotFound) {
+		return fmt.Errorf("%w: version %s not found", models.ErrInvalidComment, req.VersionId)
+	} else if err != nil {
+		return err
+	}
+	if req.Page > 0 && version.PageCount > 0 && req.Page > version.PageCount {
+		return fmt.Errorf("%w: the version has %d pages", models.ErrInvalidComment, version.PageCount)
+	}
+	if req.RangeStart != 0 || req.RangeEnd != 0 {
+		if version.ExtractStatus != models.ExtractStatusDone {
+			return fmt.Errorf("%w: the text of the version is not extracted", models.ErrInvalidComment)
+		}
+		text := []rune(version.Text)
+		if req.RangeStart >= req.RangeEnd || req.RangeEnd > len(text) {
+			return fmt.Errorf("%w: the range is outside of the text", models.ErrInvalidComment)
+		}
+		quote := text[req.RangeStart:req.RangeEnd]
+		if len(quote) > CommentQuoteSize {
+			quote = quote[:CommentQuoteSize]
+		}
+		comment.RangeStart = req.RangeStart
+		comment.RangeEnd = req.RangeEnd
+		comment.Quote = string(quote)
+	}
+	comment.VersionId = version.Id
+	comment.Page = req.Page
+	return nil
+}
+
+// validateMentions dedupes the mentioned accounts and checks that they can
+// view the document, so mentions do not leak it
+func (u *commentStore) validateMentions(ctx context.Context, document *models.Document, accountIds []string) (models.StringList, error) {
+	mentions := models.StringList{}
+	seen := map[string]bool{}
+	for _, id := range accountIds {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		_, err := u.repo.PermissionStore.Authorize(ctx, id, models.ResourceTypeDocument, document.Id, models.WorkspaceRoleViewer)
+		if errors.Is(err, models.ErrForbidden) {
+			return nil, fmt.Errorf("%w: %s cannot view the document", models.ErrInvalidComment, id)
+		} else if err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, id)
+	}
+	return mentions, nil
+}
+
+func (u *commentStore) find(ctx context.Context, documentId, commentId string) (*models.Comment, error) {
+	comment := &models.Comment{}
+	err := u.db.WithContext(ctx).Model(models.Comment{}).Where("document_id = ? AND id = ?", documentId, commentId).Take(comment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Comment{}, models.ErrCommentNotFound
+	} else if err != nil {
+		return &models.Comment{}, err
+	}
+	return comment, nil
+}
+
+// FindCommentById returns the comment, with its replies when it starts a
+// thread
+func (u *commentStore) FindCommentById(ctx context.Context, documentId, commentId string) (*models.Comment, error) {
+	comment := &models.Comment{}
+	err := u.db.WithContext(ctx).Model(models.Comment{}).Preload("Replies", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created")
+	}).Where("document_id = ? AND id = ?", documentId, commentId).Take(comment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Comment{}, models.ErrCommentNotFound
+	} else if err != nil {
+		return &models.Comment{}, err
+	}
+	return comment, nil
+}
+
+// ListThreads lists the threads of the document with their replies, oldest
+// first unless the page asks for another order. resolved filters on the
+// state of the threads when set, versionId on their anchor.
+func (u *commentStore) ListThreads(ctx context.Context, documentId string, resolved *bool, versionId string, page *models.Page) ([]*models.Comment, int64, error) {
+	comments := []*models.Comment{}
+	query := u.db.WithContext(ctx).Model(models.Comment{}).Preload("Replies", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created")
+	}).Where("document_id = ? AND parent_id = ''", documentId)
+	if resolved != nil && *resolved {
+		query = query.Where("resolved_at IS NOT NULL")
+	} else if resolved != nil {
+		query = query.Where("resolved_at IS NULL")
+	}
+	if versionId != "" {
+		query = query.Where("version_id = ?", versionId)
+	}
+	if len(page.Sort) == 0 {
+		query = query.Order("created")
+	}
+	total, err := paginate(query, page, &comments)
+	return comments, total, err
+}
+
+// UpdateCommentFromRequest edits a comment of the account, the previous body
+// is kept in its history
+func (u *commentStore) UpdateCommentFromRequest(ctx context.Context, documentId, commentId, accountId string, req *dto.CommentUpdateRequest) (*models.Comment, error) {
+	comment, err := u.find(ctx, documentId, commentId)
+	if err != nil {
+		return comment, err
+	}
+	if comment.AccountId != accountId {
+		return comment, models.ErrForbidden
+	}
+	document, err := u.repo.DocumentStore.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return comment, err
+	}
+	mentions, err := u.validateMentions(ctx, document, req.Mentions)
+	if err != nil {
+		return comment, err
+	}
+	mentioned := []string{}
+	for _, id := range mentions {
+		if !comment.Mentions.Contains(id) {
+			mentioned = append(mentioned, id)
+		}
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := models.NewCommentEdit(comment, accountId).Create(tx)
+		if err != nil {
+			return err
+		}
+		comment.Body = req.Body
+		comment.Mentions = mentions
+		comment, err = comment.Update(tx)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.CommentUpdated, comment.WorkspaceId, comment.DocumentId,
+			events.CommentPayload{Comment: comment, Mentioned: mentioned, AccountId: accountId})
+	})
+	if err != nil {
+		return &models.Comment{}, err
+	}
+	return comment, nil
+}
+
+// ListEdits returns the earlier bodies of the comment, newest first
+func (u *commentStore) ListEdits(ctx context.Context, documentId, commentId string) ([]*models.CommentEdit, error) {
+	comment, err := u.find(ctx, documentId, commentId)
+	if err != nil {
+		return nil, err
+	}
+	edits := []*models.CommentEdit{}
+	err = u.db.WithContext(ctx).Model(models.CommentEdit{}).Where("comment_id = ?", comment.Id).Order("created DESC").Find(&edits).Error
+	return edits, err
+}
+
+// Resolve resolves or reopens a thread. The author of the thread and the
+// editors of the document may do so.
+func (u *commentStore) Resolve(ctx context.Context, documentId, commentId, accountId string, resolved bool) (*models.Comment, error) {
+	comment, err := u.FindCommentById(ctx, documentId, commentId)
+	if err != nil {
+		return comment, err
+	}
+	if !comment.IsThread() {
+		return comment, fmt.Errorf("%w: replies cannot be resolved", models.ErrInvalidComment)
+	}
+	if comment.AccountId != accountId {
+		_, err = u.repo.PermissionStore.Authorize(ctx, accountId, models.ResourceTypeDocument, documentId, models.WorkspaceRoleEditor)
+		if err != nil {
+			return comment, err
+		}
+	}
+	if comment.ResolvedAt.Valid == resolved {
+		return comment, nil
+	}
+	eventType, resolvedBy := events.CommentReopened, ""
+	if resolved {
+		eventType, resolvedBy = events.CommentResolved, accountId
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		comment, err = comment.Resolve(tx, resolvedBy)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, eventType, comment.WorkspaceId, comment.DocumentId,
+			events.CommentPayload{Comment: comment, AccountId: accountId})
+	})
+	if err != nil {
+		return &models.Comment{}, err
+	}
+	return comment, nil
+}
+
+// DeleteComment deletes a comment, with its replies when it starts a thread.
+// The author and the admins of the document may do so.
+func (u *commentStore) DeleteComment(ctx context.Context, documentId, commentId, accountId string) error {
+	comment, err := u.find(ctx, documentId, commentId)
+	if err != nil {
+		return err
+	}
+	if comment.AccountId != accountId {
+		_, err = u.repo.PermissionStore.Authorize(ctx, accountId, models.ResourceTypeDocument, documentId, models.WorkspaceRoleAdmin)
+		if err != nil {
+			return err
+		}
+	}
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ? OR parent_id = ?", comment.Id, comment.Id).Delete(&models.Comment{}).Error
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.CommentDeleted, comment.WorkspaceId, comment.DocumentId,
+			events.CommentPayload{Comment: comment, AccountId: accountId})
+	})
+}
+
+// Participants returns the authors of the comments of a thread
+func (u *commentStore) Participants(ctx context.Context, threadId string) ([]string, error) {
+	accountIds := []string{}
+	err := u.db.WithContext(ctx).Model(models.Comment{}).Distinct("account_id").
+		Where("id = ? OR parent_id = ?", threadId, threadId).
+		Pluck("account_id", &accountIds).Error
+	return accountIds, err
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/db"
+	"github.com/praveenmsp23/trackdocs/pkg/lock"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+)
+
+// newTestStore connects to the postgres at TRACKDOCS_TEST_DATASOURCE and
+// migrates it. Tests create their own workspace, so they can share the
+// database.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	source := os.Getenv("TRACKDOCS_TEST_DATASOURCE")
+	if source == "" {
+		t.Skip("TRACKDOCS_TEST_DATASOURCE is not set")
+	}
+	cfg := &config.Config{Datasource: source, CacheTimeout: time.Second}
+	c := cache.NewMemory(cfg)
+	redisLock, err := lock.NewRedisLock(c, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := db.NewDB(cfg, redisLock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, err := conn.DB()
+		if err == nil {
+			sqlDB.Close()
+		}
+	})
+	repo, err := NewStore(conn, c, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// newTestAccount creates an account with a unique email
+func newTestAccount(t *testing.T, repo *Store, name string) *models.Account {
+	t.Helper()
+	email := fmt.Sprintf("%s-%d@example.com", name, time.Now().UnixNano())
+	account, err := models.NewAccount(name, email).Create(repo.AccountStore.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+// newTestProject creates a workspace owned by a new account and a project in it
+func newTestProject(t *testing.T, repo *Store) (*models.Account, *models.Project) {
+	t.Helper()
+	ctx := context.Background()
+	owner := newTestAccount(t, repo, "owner")
+	workspace, err := repo.WorkspaceStore.NewWorkspaceFromRequest(ctx, owner.Id, &dto.WorkspaceCreateRequest{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := repo.ProjectStore.NewProjectFromRequest(ctx, workspace.Id, owner.Id, &dto.ProjectCreateRequest{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return owner, project
+}
+
+// newTestMember creates an account and adds it to the workspace with role
+func newTestMember(t *testing.T, repo *Store, workspaceId, ownerId, name string, role models.WorkspaceRole) *models.Account {
+	t.Helper()
+	account := newTestAccount(t, repo, name)
+	_, err := repo.WorkspaceStore.AddMemberFromRequest(context.Background(), workspaceId, ownerId, &dto.WorkspaceMemberAddRequest{Email: account.Email, Role: string(role)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
@@ -0,0 +1,481 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+)
+
+type documentStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newDocumentStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *documentStore {
+	return &documentStore{db: conn, cache: cache, cfg: cfg}
+}
+
+func (u *documentStore) NewDocumentFromRequest(ctx context.Context, accountId string, req *dto.DocumentCreateRequest) (*models.Document, error) {
+	project, folder, err := u.repo.FolderStore.FindTarget(ctx, req.ProjectId, req.FolderId)
+	if err != nil {
+		return &models.Document{}, err
+	}
+	document := models.NewDocument(project, folder, req.Name, req.Description, accountId)
+	document.Metadata, err = u.repo.MetadataStore.Validate(ctx, project.WorkspaceId, req.Metadata)
+	if err != nil {
+		return &models.Document{}, err
+	}
+	if req.ExpiresAt != nil {
+		document.SetExpiry(req.ExpiresAt, req.ReminderOffsets)
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		document, err = document.Create(tx)
+		if err != nil {
+			return err
+		}
+		err = u.repo.TagStore.SetDocumentTags(tx, document, req.Tags)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.DocumentCreated, document.WorkspaceId, document.Id, events.DocumentPayload{Document: document, AccountId: accountId})
+	})
+	if err != nil {
+		return &models.Document{}, err
+	}
+	u.repo.documentsChanged(document.Id)
+	return document, nil
+}
+
+// GetDocument returns the document with its tags
+func (u *documentStore) GetDocument(ctx context.Context, documentId string) (*models.Document, error) {
+	document, err := u.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return document, err
+	}
+	err = u.repo.TagStore.LoadTags(ctx, document)
+	if err != nil {
+		return &models.Document{}, err
+	}
+	return document, nil
+}
+
+func (u *documentStore) FindDocumentById(ctx context.Context, documentId string) (*models.Document, error) {
+	document := &models.Document{}
+	err := u.db.WithContext(ctx).Model(models.Document{}).Where("id = ?", documentId).Take(document).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Document{}, models.ErrDocumentNotFound
+	} else if err != nil {
+		return &models.Document{}, err
+	}
+	return document, nil
+}
+
+// FindDocumentsByIds returns the documents that still exist among the ids,
+// with their tags
+func (u *documentStore) FindDocumentsByIds(ctx context.Context, documentIds []string) ([]*models.Document, error) {
+	documents := []*models.Document{}
+	err := u.db.WithContext(ctx).Model(models.Document{}).Where("id IN ?", documentIds).Find(&documents).Error
+	if err != nil {
+		return documents, err
+	}
+	err = u.repo.TagStore.LoadTags(ctx, documents...)
+	return documents, err
+}
+
+// ListDocumentIdsAfter returns up to limit document ids greater than afterId
+// in id order, an empty afterId starts at the first document. Deleted
+// documents are included so a reindex removes them from the index.
+func (u *documentStore) ListDocumentIdsAfter(ctx context.Context, afterId string, limit int) ([]string, error) {
+	query := u.db.WithContext(ctx).Unscoped().Order("id").Limit(limit)
+	if afterId != "" {
+		query = query.Where("id > ?", afterId)
+	}
+	return documentIds(query)
+}
+
+// CountDocuments counts the documents of all workspaces, deleted ones
+// included like in ListDocumentIdsAfter
+func (u *documentStore) CountDocuments(ctx context.Context) (int64, error) {
+	var count int64
+	err := u.db.WithContext(ctx).Unscoped().Model(&models.Document{}).Count(&count).Error
+	return count, err
+}
+
+// ListDocuments lists the documents of a folder, or of the project root when
+// folderId is empty. With recursive set, documents of all sub folders are
+// included as well.
+func (u *documentStore) ListDocuments(ctx context.Context, projectId, folderId string, recursive bool, page *models.Page) ([]*models.Document, int64, error) {
+	documents := []*models.Document{}
+	project, err := u.repo.ProjectStore.FindProjectById(ctx, projectId)
+	if err != nil {
+		return documents, 0, err
+	}
+	query := u.db.WithContext(ctx).Model(models.Document{}).Where("project_id = ?", project.Id)
+	switch {
+	case recursive && folderId != "":
+		folder, err := u.repo.FolderStore.FindFolderById(ctx, folderId)
+		if err != nil {
+			return documents, 0, err
+		}
+		subtree := u.db.WithContext(ctx).Model(models.Folder{}).Select("id").Where("path LIKE ?", likePrefix(folder.Path))
+		query = query.Where("folder_id IN (?)", subtree)
+	case !recursive:
+		query = query.Where("folder_id = ?", folderId)
+	}
+	return u.list(ctx, query, project.WorkspaceId, page)
+}
+
+// ListWorkspaceDocuments lists the documents of every project of the workspace
+func (u *documentStore) ListWorkspaceDocuments(ctx context.Context, workspaceId string, page *models.Page) ([]*models.Document, int64, error) {
+	query := u.db.WithContext(ctx).Model(models.Document{}).Where("workspace_id = ?", workspaceId)
+	return u.list(ctx, query, workspaceId, page)
+}
+
+func (u *documentStore) list(ctx context.Context, query *gorm.DB, workspaceId string, page *models.Page) ([]*models.Document, int64, error) {
+	documents := []*models.Document{}
+	query, err := u.applyFilters(ctx, query, workspaceId, page)
+	if err != nil {
+		return documents, 0, err
+	}
+	total, err := paginate(query, page, &documents)
+	if err != nil {
+		return documents, 0, err
+	}
+	err = u.repo.TagStore.LoadTags(ctx, documents...)
+	return documents, total, err
+}
+
+// applyFilters turns the "tags" and "metadata.<key>" filters of the page into
+// query conditions. The remaining filters stay plain column matches.
+//
+//	{"tags": ["invoice", "2024"]}             documents carrying all tags
+//	{"metadata.region": "emea"}               exact match
+//	{"metadata.region": ["emea", "apac"]}     any of the values
+//	{"metadata.amount": {"gte": 10, "lt": 99}} range on number and date fields
+func (u *documentStore) applyFilters(ctx context.Context, query *gorm.DB, workspaceId string, page *models.Page) (*gorm.DB, error) {
+	if value, ok := page.TakeFilter("tags"); ok {
+		names, err := filterStrings(value)
+		if err != nil {
+			return query, err
+		}
+		if names = normalizeTags(names); len(names) > 0 {
+			query = query.Where("id IN (?)", u.repo.TagStore.taggedWith(ctx, workspaceId, names))
+		}
+	}
+	filters := page.TakeFilterPrefix("metadata.")
+	if len(filters) == 0 {
+		return query, nil
+	}
+	fields, err := u.repo.MetadataStore.ListFields(ctx, workspaceId)
+	if err != nil {
+		return query, err
+	}
+	byKey := make(map[string]*models.MetadataField, len(fields))
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+	for key, value := range filters {
+		field, ok := byKey[key]
+		if !ok {
+			return query, fmt.Errorf("%w: unknown metadata field %s", models.ErrInvalidFilter, key)
+		}
+		query, err = applyMetadataFilter(u.db.WithContext(ctx), query, field, value)
+		if err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+var metadataRangeOperators = map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+
+func applyMetadataFilter(db, query *gorm.DB, field *models.MetadataField, value interface{}) (*gorm.DB, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		if len(v) == 0 {
+			return query, nil
+		}
+		cond := db.Session(&gorm.Session{NewDB: true})
+		for _, item := range v {
+			normalized, err := field.Normalize(item)
+			if err != nil {
+				return query, fmt.Errorf("%w: %s", models.ErrInvalidFilter, err.Error())
+			}
+			cond = cond.Or("metadata @> ?", models.Jsonb{field.Key: normalized})
+		}
+		return query.Where(cond), nil
+	case map[string]interface{}:
+		if field.Type != models.MetadataFieldTypeNumber && field.Type != models.MetadataFieldTypeDate {
+			return query, fmt.Errorf("%w: %s does not support ranges", models.ErrInvalidFilter, field.Key)
+		}
+		column := "metadata->>?"
+		if field.Type == models.MetadataFieldTypeNumber {
+			column = "(metadata->>?)::numeric"
+		}
+		for op, bound := range v {
+			operator, ok := metadataRangeOperators[op]
+			if !ok {
+				return query, fmt.Errorf("%w: unknown operator %s", models.ErrInvalidFilter, op)
+			}
+			normalized, err := field.Normalize(bound)
+			if err != nil {
+				return query, fmt.Errorf("%w: %s", models.ErrInvalidFilter, err.Error())
+			}
+			query = query.Where(column+" "+operator+" ?", field.Key, normalized)
+		}
+		return query, nil
+	default:
+		normalized, err := field.Normalize(v)
+		if err != nil {
+			return query, fmt.Errorf("%w: %s", models.ErrInvalidFilter, err.Error())
+		}
+		return query.Where("metadata @> ?", models.Jsonb{field.Key: normalized}), nil
+	}
+}
+
+func filterStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, models.ErrInvalidFilter
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	return nil, models.ErrInvalidFilter
+}
+
+func (u *documentStore) UpdateDocumentFromRequest(ctx context.Context, documentId, accountId string, req *dto.DocumentUpdateRequest) (*models.Document, error) {
+	document, err := u.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return document, err
+	}
+	if !document.Status.IsEditable() {
+		return document, models.ErrDocumentLocked
+	}
+	document.Name = req.Name
+	document.Description = req.Description
+	document.ModifiedBy = accountId
+	if req.Metadata != nil {
+		document.Metadata, err = u.repo.MetadataStore.Validate(ctx, document.WorkspaceId, req.Metadata)
+		if err != nil {
+			return document, err
+		}
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		document, err = document.Update(tx)
+		if err != nil {
+			return err
+		}
+		if req.Tags == nil {
+			err = u.repo.TagStore.LoadTags(ctx, document)
+		} else {
+			err = u.repo.TagStore.SetDocumentTags(tx, document, req.Tags)
+		}
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.DocumentUpdated, document.WorkspaceId, document.Id, events.DocumentPayload{Document: document, AccountId: accountId})
+	})
+	if err != nil {
+		return &models.Document{}, err
+	}
+	u.repo.documentsChanged(document.Id)
+	return document, nil
+}
+
+func (u *documentStore) SetTagsFromRequest(ctx context.Context, documentId string, req *dto.DocumentTagsRequest) (*models.Document, error) {
+	document, err := u.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return document, err
+	}
+	if !document.Status.IsEditable() {
+		return document, models.ErrDocumentLocked
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := u.repo.TagStore.SetDocumentTags(tx, document, req.Tags)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.DocumentUpdated, document.WorkspaceId, document.Id, events.DocumentPayload{Document: document})
+	})
+	if err != nil {
+		return &models.Document{}, err
+	}
+	u.repo.documentsChanged(document.Id)
+	return document, nil
+}
+
+// SetExpiryFromRequest sets or clears the expiry of the document. Renewing an
+// expiry does not change the content, so it is allowed in every status.
+func (u *documentStore) SetExpiryFromRequest(ctx context.Context, documentId, accountId string, req *dto.DocumentExpiryRequest) (*models.Document, error) {
+	document, err := u.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return document, err
+	}
+	document.SetExpiry(req.ExpiresAt, req.ReminderOffsets)
+	document.ModifiedBy = accountId
+	return u.update(ctx, document, events.DocumentUpdated)
+}
+
+// ListExpiringDocuments lists the documents of the workspace expiring before
+// the given time, including the already expired ones, soonest first. Archived
+// documents are left out.
+func (u *documentStore) ListExpiringDocuments(ctx context.Context, workspaceId string, before time.Time, page *models.Page) ([]*models.Document, int64, error) {
+	query := u.db.WithContext(ctx).Model(models.Document{}).
+		Where("workspace_id = ? AND expires_at IS NOT NULL AND expires_at <= ? AND status <> ?", workspaceId, before, models.DocumentStatusArchived)
+	if len(page.Sort) == 0 {
+		query = query.Order("expires_at")
+	}
+	return u.list(ctx, query, workspaceId, page)
+}
+
+func (u *documentStore) MoveDocumentFromRequest(ctx context.Context, documentId, accountId string, req *dto.DocumentMoveRequest) (*models.Document, error) {
+	document, err := u.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return document, err
+	}
+	project, folder, err := u.repo.FolderStore.FindTarget(ctx, req.ProjectId, req.FolderId)
+	if err != nil {
+		return document, err
+	}
+	if project.WorkspaceId != document.WorkspaceId {
+		return document, models.ErrBadRequest
+	}
+	document.ProjectId = project.Id
+	document.FolderId = ""
+	if folder != nil {
+		document.FolderId = folder.Id
+	}
+	document.ModifiedBy = accountId
+	return u.update(ctx, document, events.DocumentMoved)
+}
+
+// update saves the document with an event of eventType and tells the
+// listeners
+func (u *documentStore) update(ctx context.Context, document *models.Document, eventType events.Type[events.DocumentPayload]) (*models.Document, error) {
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		document, err = document.Update(tx)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, eventType, document.WorkspaceId, document.Id, events.DocumentPayload{Document: document, AccountId: document.ModifiedBy})
+	})
+	if err != nil {
+		return &models.Document{}, err
+	}
+	u.repo.documentsChanged(document.Id)
+	return document, nil
+}
+
+func (u *documentStore) CopyDocumentFromRequest(ctx context.Context, documentId, accountId string, req *dto.DocumentMoveRequest) (*models.Document, error) {
+	document, err := u.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return document, err
+	}
+	project, folder, err := u.repo.FolderStore.FindTarget(ctx, req.ProjectId, req.FolderId)
+	if err != nil {
+		return document, err
+	}
+	if project.WorkspaceId != document.WorkspaceId {
+		return document, models.ErrBadRequest
+	}
+	var copied *models.Document
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		copied, err = document.Copy(project, folder, accountId).Create(tx)
+		if err != nil {
+			return err
+		}
+		err = u.repo.TagStore.CopyDocumentTags(tx, document.Id, copied.Id)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.DocumentCreated, copied.WorkspaceId, copied.Id, events.DocumentPayload{Document: copied, AccountId: accountId})
+	})
+	if err != nil {
+		return &models.Document{}, err
+	}
+	u.repo.documentsChanged(copied.Id)
+	return copied, nil
+}
+
+// setStatus is the only way a document changes status. It is called by the
+// workflow store once a transition is allowed and approved, and fails with
+// ErrStatusChanged when the document is no longer in status from.
+func (u *documentStore) setStatus(tx *gorm.DB, document *models.Document, from, to models.DocumentStatus, accountId string) error {
+	db := tx.Model(&models.Document{}).Where("id = ? AND status = ?", document.Id, from).UpdateColumns(
+		map[string]interface{}{
+			"status":      to,
+			"modified_by": accountId,
+		},
+	)
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return models.ErrStatusChanged
+	}
+	document.Status = to
+	return nil
+}
+
+func (u *documentStore) DeleteDocument(ctx context.Context, documentId string) error {
+	document, err := u.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return err
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := document.Delete(tx)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.DocumentDeleted, document.WorkspaceId, document.Id, events.DocumentPayload{Document: document})
+	})
+	if err != nil {
+		return err
+	}
+	u.repo.documentsChanged(document.Id)
+	return nil
+}
+
+// Breadcrumbs returns the trail from the project down to the document itself
+func (u *documentStore) Breadcrumbs(ctx context.Context, document *models.Document) ([]models.Breadcrumb, error) {
+	var crumbs []models.Breadcrumb
+	if document.FolderId == "" {
+		project, err := u.repo.ProjectStore.FindProjectById(ctx, document.ProjectId)
+		if err != nil {
+			return nil, err
+		}
+		crumbs = []models.Breadcrumb{{Type: models.ResourceTypeProject, Id: project.Id, Name: project.Name}}
+	} else {
+		folder, err := u.repo.FolderStore.FindFolderById(ctx, document.FolderId)
+		if err != nil {
+			return nil, err
+		}
+		crumbs, err = u.repo.FolderStore.Breadcrumbs(ctx, folder)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return append(crumbs, models.Breadcrumb{Type: models.ResourceTypeDocument, Id: document.Id, Name: document.Name}), nil
+}
@@ -0,0 +1,283 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type documentRequestStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newDocumentRequestStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *documentRequestStore {
+	return &documentRequestStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// NewRequestFromRequest creates the request with its checklist and returns the
+// plain link token
+func (u *documentRequestStore) NewRequestFromRequest(ctx context.Context, workspaceId, accountId string, req *dto.DocumentRequestCreateRequest) (*models.DocumentRequest, string, error) {
+	project, folder, err := u.repo.FolderStore.FindTarget(ctx, req.ProjectId, req.FolderId)
+	if err != nil {
+		return &models.DocumentRequest{}, "", err
+	}
+	if project.WorkspaceId != workspaceId {
+		return &models.DocumentRequest{}, "", models.ErrProjectNotFound
+	}
+	request, token := models.NewDocumentRequest(project, folder, req.Title, req.Message, req.RecipientEmail, req.RecipientName, accountId)
+	request.EncryptedToken, err = crypto.Encrypt(encryptionKey(u.cfg), []byte(token))
+	if err != nil {
+		return &models.DocumentRequest{}, "", err
+	}
+	request.ReminderIntervalDays = req.ReminderIntervalDays
+	// the invitation counts as the first reminder
+	request.LastRemindedAt = models.NewSqlNullTime(time.Now())
+	if req.DueAt != nil {
+		request.DueAt = models.NewSqlNullTime(*req.DueAt)
+	}
+	for _, i := range req.Items {
+		maxSize := i.MaxSize
+		if maxSize == 0 || maxSize > u.cfg.UploadMaxSize {
+			maxSize = u.cfg.UploadMaxSize
+		}
+		required := true
+		if i.Required != nil {
+			required = *i.Required
+		}
+		request.Items = append(request.Items, &models.DocumentRequestItem{
+			Name:         i.Name,
+			Description:  i.Description,
+			AllowedTypes: i.AllowedTypes,
+			MaxSize:      maxSize,
+			Required:     required,
+			Status:       models.DocumentRequestItemStatusPending,
+		})
+	}
+	request, err = request.Create(u.db.WithContext(ctx))
+	if err != nil {
+		return request, "", err
+	}
+	return request, token, nil
+}
+
+// Token returns the plain link token of the request, requests created before
+// the token was stored return an empty token
+func (u *documentRequestStore) Token(request *models.DocumentRequest) (string, error) {
+	if request.EncryptedToken == "" {
+		return "", nil
+	}
+	token, err := crypto.Decrypt(encryptionKey(u.cfg), request.EncryptedToken)
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+func (u *documentRequestStore) FindRequestById(ctx context.Context, requestId string) (*models.DocumentRequest, error) {
+	return u.find(u.db.WithContext(ctx).Where("id = ?", requestId))
+}
+
+// FindRequestByToken resolves a public upload link
+func (u *documentRequestStore) FindRequestByToken(ctx context.Context, token string) (*models.DocumentRequest, error) {
+	return u.find(u.db.WithContext(ctx).Where("token_hash = ?", models.HashRequestToken(token)))
+}
+
+// FindOpenRequestByToken resolves a public upload link that still accepts
+// uploads
+func (u *documentRequestStore) FindOpenRequestByToken(ctx context.Context, token string) (*models.DocumentRequest, error) {
+	request, err := u.FindRequestByToken(ctx, token)
+	if err != nil {
+		return request, err
+	}
+	if request.Status != models.DocumentRequestStatusOpen {
+		return request, models.ErrRequestClosed
+	}
+	return request, nil
+}
+
+func (u *documentRequestStore) find(query *gorm.DB) (*models.DocumentRequest, error) {
+	request := &models.DocumentRequest{}
+	err := query.Model(models.DocumentRequest{}).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created, id") }).
+		Take(request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.DocumentRequest{}, models.ErrDocumentRequestNotFound
+	} else if err != nil {
+		return &models.DocumentRequest{}, err
+	}
+	return request, nil
+}
+
+func (u *documentRequestStore) ListRequests(ctx context.Context, workspaceId string, page *models.Page) ([]*models.DocumentRequest, int64, error) {
+	requests := []*models.DocumentRequest{}
+	query := u.db.WithContext(ctx).Model(models.DocumentRequest{}).Preload("Items").Where("workspace_id = ?", workspaceId)
+	if len(page.Sort) == 0 {
+		query = query.Order("created DESC")
+	}
+	total, err := paginate(query, page, &requests)
+	return requests, total, err
+}
+
+func (u *documentRequestStore) CancelRequest(ctx context.Context, requestId, accountId string) (*models.DocumentRequest, error) {
+	request, err := u.FindRequestById(ctx, requestId)
+	if err != nil {
+		return request, err
+	}
+	if request.Status == models.DocumentRequestStatusCancelled {
+		return request, models.ErrRequestClosed
+	}
+	return request.SetStatus(u.db.WithContext(ctx), models.DocumentRequestStatusCancelled, accountId)
+}
+
+// ReceiveItem stores an uploaded file for the item. The document is created
+// on the first upload and gets a new version when a rejected item is
+// uploaded again. The request row is locked and reloaded first, so
+// concurrent uploads see each other's items. Returns whether the upload
+// completed the request.
+func (u *documentRequestStore) ReceiveItem(ctx context.Context, request *models.DocumentRequest, item *models.DocumentRequestItem, document *models.Document, version *models.DocumentVersion) (bool, error) {
+	completed := false
+	created := document.Id == ""
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := u.find(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", request.Id))
+		if err != nil {
+			return err
+		}
+		if locked.Status != models.DocumentRequestStatusOpen {
+			return models.ErrRequestClosed
+		}
+		current := RequestItem(locked, item.Id)
+		if current == nil {
+			return models.ErrRequestItemNotFound
+		}
+		if current.Status == models.DocumentRequestItemStatusReceived {
+			return models.ErrItemReceived
+		}
+		// the file was stored for the document the item had when the upload
+		// started
+		if current.DocumentId != item.DocumentId {
+			return models.ErrItemChanged
+		}
+		if created {
+			document, err = document.Create(tx)
+			if err != nil {
+				return err
+			}
+			version.WorkspaceId = document.WorkspaceId
+			version.DocumentId = document.Id
+			err = recordEvent(tx, events.DocumentCreated, document.WorkspaceId, document.Id, events.DocumentPayload{Document: document})
+			if err != nil {
+				return err
+			}
+		}
+		_, err = u.repo.VersionStore.CreateVersion(tx, version)
+		if err != nil {
+			return err
+		}
+		err = recordEvent(tx, events.DocumentVersionAdded, version.WorkspaceId, version.DocumentId, events.DocumentVersionPayload{Version: version})
+		if err != nil {
+			return err
+		}
+		current.Status = models.DocumentRequestItemStatusReceived
+		current.DocumentId = document.Id
+		current.RejectionReason = ""
+		current.ReceivedAt = models.NewSqlNullTime(time.Now())
+		_, err = current.Update(tx)
+		if err != nil {
+			return err
+		}
+		*item = *current
+		*request = *locked
+		if !request.IsComplete() {
+			return nil
+		}
+		completed = true
+		_, err = request.SetStatus(tx, models.DocumentRequestStatusCompleted, "")
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	u.repo.documentsChanged(document.Id)
+	return completed, nil
+}
+
+// RejectItem asks the recipient to upload the item again. A completed request
+// is reopened.
+func (u *documentRequestStore) RejectItem(ctx context.Context, requestId, itemId, accountId, reason string) (*models.DocumentRequest, *models.DocumentRequestItem, error) {
+	request, err := u.FindRequestById(ctx, requestId)
+	if err != nil {
+		return request, nil, err
+	}
+	if request.Status == models.DocumentRequestStatusCancelled {
+		return request, nil, models.ErrRequestClosed
+	}
+	item := RequestItem(request, itemId)
+	if item == nil {
+		return request, nil, models.ErrRequestItemNotFound
+	}
+	if item.Status != models.DocumentRequestItemStatusReceived {
+		return request, item, models.ErrBadRequest
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		item.Status = models.DocumentRequestItemStatusRejected
+		item.RejectionReason = reason
+		_, err := item.Update(tx)
+		if err != nil || request.Status == models.DocumentRequestStatusOpen {
+			return err
+		}
+		_, err = request.SetStatus(tx, models.DocumentRequestStatusOpen, accountId)
+		return err
+	})
+	if err != nil {
+		return request, item, err
+	}
+	return request, item, nil
+}
+
+// DueReminders returns open requests whose reminder interval has passed since
+// the last reminder
+func (u *documentRequestStore) DueReminders(ctx context.Context, now time.Time, limit int) ([]*models.DocumentRequest, error) {
+	requests := []*models.DocumentRequest{}
+	err := u.db.WithContext(ctx).Model(models.DocumentRequest{}).Preload("Items").
+		Where("status = ? AND reminder_interval_days > 0", models.DocumentRequestStatusOpen).
+		Where("last_reminded_at + make_interval(days => reminder_interval_days) <= ?", now).
+		Order("last_reminded_at").
+		Limit(limit).
+		Find(&requests).Error
+	return requests, err
+}
+
+// MarkReminded records a reminder and reports whether this call recorded it,
+// so a reminder is only sent once even when two runs overlap
+func (u *documentRequestStore) MarkReminded(ctx context.Context, request *models.DocumentRequest, now time.Time) (bool, error) {
+	db := u.db.WithContext(ctx).Model(&models.DocumentRequest{}).
+		Where("id = ? AND last_reminded_at = ?", request.Id, request.LastRemindedAt).
+		UpdateColumn("last_reminded_at", now)
+	if db.Error != nil {
+		return false, db.Error
+	}
+	request.LastRemindedAt = models.NewSqlNullTime(now)
+	return db.RowsAffected > 0, nil
+}
+
+// RequestItem returns the item of the request with the given id or nil
+func RequestItem(request *models.DocumentRequest, itemId string) *models.DocumentRequestItem {
+	for _, item := range request.Items {
+		if item.Id == itemId {
+			return item
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+)
+
+// receive uploads a file for the item of a request loaded before any of the
+// concurrent uploads, like the portal handler does
+func receive(ctx context.Context, repo *Store, request *models.DocumentRequest, itemId string) (bool, error) {
+	item := RequestItem(request, itemId)
+	project, folder, err := repo.FolderStore.FindTarget(ctx, request.ProjectId, request.FolderId)
+	if err != nil {
+		return false, err
+	}
+	document := models.NewDocument(project, folder, item.Name, "", request.CreatedBy)
+	version := models.NewDocumentVersion(document, "file.pdf", "application/pdf", request.CreatedBy)
+	return repo.RequestStore.ReceiveItem(ctx, request, item, document, version)
+}
+
+func TestReceiveItemConcurrent(t *testing.T) {
+	repo := newTestStore(t)
+	ctx := context.Background()
+	owner, project := newTestProject(t, repo)
+	request, token, err := repo.RequestStore.NewRequestFromRequest(ctx, project.WorkspaceId, owner.Id, &dto.DocumentRequestCreateRequest{
+		ProjectId:      project.Id,
+		Title:          "onboarding",
+		RecipientEmail: "recipient@example.com",
+		Items:          []dto.DocumentRequestItemRequest{{Name: "passport"}, {Name: "address"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots := make([]*models.DocumentRequest, 3)
+	for i := range snapshots {
+		snapshots[i], err = repo.RequestStore.FindOpenRequestByToken(ctx, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	itemIds := []string{request.Items[0].Id, request.Items[1].Id, request.Items[0].Id}
+	completed := make([]bool, len(snapshots))
+	errs := make([]error, len(snapshots))
+	var wg sync.WaitGroup
+	for i := range snapshots {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			completed[i], errs[i] = receive(ctx, repo, snapshots[i], itemIds[i])
+		}(i)
+	}
+	wg.Wait()
+
+	// one of the two uploads for the first item loses
+	received, completions := 0, 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			received++
+			if completed[i] {
+				completions++
+			}
+		case !errors.Is(err, models.ErrItemReceived):
+			t.Fatalf("upload %d: %v", i, err)
+		}
+	}
+	if received != 2 {
+		t.Errorf("received %d uploads, want 2", received)
+	}
+	if completions != 1 {
+		t.Errorf("%d uploads completed the request, want 1", completions)
+	}
+	request, err = repo.RequestStore.FindRequestById(ctx, request.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Status != models.DocumentRequestStatusCompleted {
+		t.Errorf("status = %s, want completed", request.Status)
+	}
+}
+
+func TestRequestToken(t *testing.T) {
+	repo := newTestStore(t)
+	ctx := context.Background()
+	owner, project := newTestProject(t, repo)
+	request, token, err := repo.RequestStore.NewRequestFromRequest(ctx, project.WorkspaceId, owner.Id, &dto.DocumentRequestCreateRequest{
+		ProjectId:      project.Id,
+		Title:          "onboarding",
+		RecipientEmail: "recipient@example.com",
+		Items:          []dto.DocumentRequestItemRequest{{Name: "passport"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err = repo.RequestStore.FindRequestById(ctx, request.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.RequestStore.Token(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != token {
+		t.Errorf("Token() = %q, want %q", got, token)
+	}
+}
@@ -0,0 +1,281 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+)
+
+type folderStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePrefix returns a LIKE pattern matching every string starting with prefix
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+func newFolderStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *folderStore {
+	return &folderStore{db: conn, cache: cache, cfg: cfg}
+}
+
+func (u *folderStore) NewFolderFromRequest(ctx context.Context, accountId string, req *dto.FolderCreateRequest) (*models.Folder, error) {
+	project, parent, err := u.FindTarget(ctx, req.ProjectId, req.ParentId)
+	if err != nil {
+		return &models.Folder{}, err
+	}
+	return models.NewFolder(project, parent, req.Name, accountId).Create(u.db.WithContext(ctx))
+}
+
+func (u *folderStore) FindFolderById(ctx context.Context, folderId string) (*models.Folder, error) {
+	folder := &models.Folder{}
+	err := u.db.WithContext(ctx).Model(models.Folder{}).Where("id = ?", folderId).Take(folder).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Folder{}, models.ErrFolderNotFound
+	} else if err != nil {
+		return &models.Folder{}, err
+	}
+	return folder, nil
+}
+
+// ListFolders lists the direct sub folders of parentId, or the root folders of
+// the project when parentId is empty
+func (u *folderStore) ListFolders(ctx context.Context, projectId, parentId string, page *models.Page) ([]*models.Folder, int64, error) {
+	folders := []*models.Folder{}
+	query := u.db.WithContext(ctx).Model(models.Folder{}).Where("project_id = ? AND parent_id = ?", projectId, parentId)
+	total, err := paginate(query, page, &folders)
+	return folders, total, err
+}
+
+// Breadcrumbs returns the trail from the project down to the folder itself
+func (u *folderStore) Breadcrumbs(ctx context.Context, folder *models.Folder) ([]models.Breadcrumb, error) {
+	project, err := u.repo.ProjectStore.FindProjectById(ctx, folder.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	ids := append(folder.AncestorIds(), folder.Id)
+	folders := []*models.Folder{}
+	err = u.db.WithContext(ctx).Model(models.Folder{}).Where("id IN ?", ids).Find(&folders).Error
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]*models.Folder, len(folders))
+	for _, f := range folders {
+		byId[f.Id] = f
+	}
+	crumbs := []models.Breadcrumb{{Type: models.ResourceTypeProject, Id: project.Id, Name: project.Name}}
+	for _, id := range ids {
+		if f, ok := byId[id]; ok {
+			crumbs = append(crumbs, models.Breadcrumb{Type: models.ResourceTypeFolder, Id: f.Id, Name: f.Name})
+		}
+	}
+	return crumbs, nil
+}
+
+func (u *folderStore) RenameFolderFromRequest(ctx context.Context, folderId, accountId string, req *dto.FolderRenameRequest) (*models.Folder, error) {
+	folder, err := u.FindFolderById(ctx, folderId)
+	if err != nil {
+		return folder, err
+	}
+	folder.Name = req.Name
+	folder.ModifiedBy = accountId
+	return folder.Update(u.db.WithContext(ctx))
+}
+
+// MoveFolderFromRequest moves the folder with its whole subtree below a new
+// parent, possibly in another project of the same workspace
+func (u *folderStore) MoveFolderFromRequest(ctx context.Context, folderId, accountId string, req *dto.FolderMoveRequest) (*models.Folder, error) {
+	folder, err := u.FindFolderById(ctx, folderId)
+	if err != nil {
+		return folder, err
+	}
+	project, parent, err := u.FindTarget(ctx, req.ProjectId, req.ParentId)
+	if err != nil {
+		return folder, err
+	}
+	if project.WorkspaceId != folder.WorkspaceId {
+		return folder, models.ErrBadRequest
+	}
+	if parent != nil && folder.IsAncestorOf(parent) {
+		return folder, models.ErrFolderCycle
+	}
+
+	oldPath := folder.Path
+	newPath := models.PathSeparator + folder.Id + models.PathSeparator
+	newDepth := 0
+	parentId := ""
+	if parent != nil {
+		newPath = parent.Path + folder.Id + models.PathSeparator
+		newDepth = parent.Depth + 1
+		parentId = parent.Id
+	}
+	var movedIds []string
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Folder{}).Where("path LIKE ?", likePrefix(oldPath)).UpdateColumns(
+			map[string]interface{}{
+				"path":       gorm.Expr("? || substr(path, ?)", newPath, len(oldPath)+1),
+				"depth":      gorm.Expr("depth + ?", newDepth-folder.Depth),
+				"project_id": project.Id,
+			},
+		).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.Folder{}).Where("id = ?", folder.Id).UpdateColumns(
+			map[string]interface{}{
+				"parent_id":   parentId,
+				"modified_by": accountId,
+			},
+		).Error
+		if err != nil {
+			return err
+		}
+		if project.Id == folder.ProjectId {
+			return nil
+		}
+		subtree := tx.Model(&models.Folder{}).Select("id").Where("path LIKE ?", likePrefix(newPath))
+		movedIds, err = documentIds(tx.Where("folder_id IN (?)", subtree))
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Document{}).Where("folder_id IN (?)", subtree).
+			UpdateColumn("project_id", project.Id).Error
+	})
+	if err != nil {
+		return folder, err
+	}
+	u.repo.documentsChanged(movedIds...)
+	return u.FindFolderById(ctx, folder.Id)
+}
+
+// CopyFolderFromRequest copies the folder, its sub folders and their documents
+// below a new parent. The copy is owned by the account performing it.
+func (u *folderStore) CopyFolderFromRequest(ctx context.Context, folderId, accountId string, req *dto.FolderMoveRequest) (*models.Folder, error) {
+	folder, err := u.FindFolderById(ctx, folderId)
+	if err != nil {
+		return folder, err
+	}
+	project, parent, err := u.FindTarget(ctx, req.ProjectId, req.ParentId)
+	if err != nil {
+		return folder, err
+	}
+	if project.WorkspaceId != folder.WorkspaceId {
+		return folder, models.ErrBadRequest
+	}
+	if parent != nil && folder.IsAncestorOf(parent) {
+		return folder, models.ErrFolderCycle
+	}
+
+	var root *models.Folder
+	var copiedIds []string
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		folders := []*models.Folder{}
+		err := tx.Model(&models.Folder{}).Where("path LIKE ?", likePrefix(folder.Path)).Order("depth").Find(&folders).Error
+		if err != nil {
+			return err
+		}
+		copies := make(map[string]*models.Folder, len(folders))
+		for _, f := range folders {
+			target := parent
+			if f.Id != folder.Id {
+				target = copies[f.ParentId]
+			}
+			c, err := models.NewFolder(project, target, f.Name, accountId).Create(tx)
+			if err != nil {
+				return err
+			}
+			copies[f.Id] = c
+		}
+		root = copies[folder.Id]
+
+		ids := make([]string, 0, len(folders))
+		for _, f := range folders {
+			ids = append(ids, f.Id)
+		}
+		documents := []*models.Document{}
+		err = tx.Model(&models.Document{}).Where("folder_id IN ?", ids).Find(&documents).Error
+		if err != nil {
+			return err
+		}
+		for _, d := range documents {
+			c, err := d.Copy(project, copies[d.FolderId], accountId).Create(tx)
+			if err != nil {
+				return err
+			}
+			err = u.repo.TagStore.CopyDocumentTags(tx, d.Id, c.Id)
+			if err != nil {
+				return err
+			}
+			copiedIds = append(copiedIds, c.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return folder, err
+	}
+	u.repo.documentsChanged(copiedIds...)
+	return root, nil
+}
+
+// DeleteFolder soft deletes the folder with its sub folders and documents
+func (u *folderStore) DeleteFolder(ctx context.Context, folderId string) error {
+	folder, err := u.FindFolderById(ctx, folderId)
+	if err != nil {
+		return err
+	}
+	var deletedIds []string
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		subtree := tx.Model(&models.Folder{}).Select("id").Where("path LIKE ?", likePrefix(folder.Path))
+		deletedIds, err = documentIds(tx.Where("folder_id IN (?)", subtree))
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("folder_id IN (?)", subtree).Delete(&models.Document{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("path LIKE ?", likePrefix(folder.Path)).Delete(&models.Folder{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	u.repo.documentsChanged(deletedIds...)
+	return nil
+}
+
+// documentIds returns the ids of the documents matched by the conditions of query
+func documentIds(query *gorm.DB) ([]string, error) {
+	ids := []string{}
+	err := query.Model(&models.Document{}).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// FindTarget resolves the project and optional parent folder a folder or
+// document is created in, moved to or copied to
+func (u *folderStore) FindTarget(ctx context.Context, projectId, folderId string) (*models.Project, *models.Folder, error) {
+	project, err := u.repo.ProjectStore.FindProjectById(ctx, projectId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if folderId == "" {
+		return project, nil, nil
+	}
+	folder, err := u.FindFolderById(ctx, folderId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if folder.ProjectId != project.Id {
+		return nil, nil, models.ErrFolderNotFound
+	}
+	return project, folder, nil
+}
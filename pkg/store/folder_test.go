@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+)
+
+// newTestFolder creates a folder below parentId, or at the project root when
+// it is empty
+func newTestFolder(t *testing.T, repo *Store, accountId, projectId, parentId, name string) *models.Folder {
+	t.Helper()
+	folder, err := repo.FolderStore.NewFolderFromRequest(context.Background(), accountId, &dto.FolderCreateRequest{
+		ProjectId: projectId,
+		ParentId:  parentId,
+		Name:      name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return folder
+}
+
+// newTestDocument creates a document in folderId, or at the project root when
+// it is empty
+func newTestDocument(t *testing.T, repo *Store, accountId, projectId, folderId, name string) *models.Document {
+	t.Helper()
+	document, err := repo.DocumentStore.NewDocumentFromRequest(context.Background(), accountId, &dto.DocumentCreateRequest{
+		ProjectId: projectId,
+		FolderId:  folderId,
+		Name:      name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func findTestFolder(t *testing.T, repo *Store, folderId string) *models.Folder {
+	t.Helper()
+	folder, err := repo.FolderStore.FindFolderById(context.Background(), folderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return folder
+}
+
+func TestMoveFolder(t *testing.T) {
+	repo := newTestStore(t)
+	ctx := context.Background()
+	owner, project := newTestProject(t, repo)
+	other, err := repo.ProjectStore.NewProjectFromRequest(ctx, project.WorkspaceId, owner.Id, &dto.ProjectCreateRequest{Name: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newTestFolder(t, repo, owner.Id, project.Id, "", "a")
+	b := newTestFolder(t, repo, owner.Id, project.Id, a.Id, "b")
+	c := newTestFolder(t, repo, owner.Id, project.Id, b.Id, "c")
+	document := newTestDocument(t, repo, owner.Id, project.Id, c.Id, "contract")
+
+	// a folder cannot move below itself
+	_, err = repo.FolderStore.MoveFolderFromRequest(ctx, b.Id, owner.Id, &dto.FolderMoveRequest{ProjectId: project.Id, ParentId: c.Id})
+	if !errors.Is(err, models.ErrFolderCycle) {
+		t.Fatalf("moved below itself: %v", err)
+	}
+
+	moved, err := repo.FolderStore.MoveFolderFromRequest(ctx, b.Id, owner.Id, &dto.FolderMoveRequest{ProjectId: other.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.ParentId != "" || moved.Path != "/"+b.Id+"/" || moved.Depth != 0 || moved.ProjectId != other.Id {
+		t.Errorf("moved folder %+v", moved)
+	}
+	c = findTestFolder(t, repo, c.Id)
+	if c.ParentId != b.Id || c.Path != "/"+b.Id+"/"+c.Id+"/" || c.Depth != 1 || c.ProjectId != other.Id {
+		t.Errorf("sub folder %+v", c)
+	}
+	if a = findTestFolder(t, repo, a.Id); a.ProjectId != project.Id || a.Path != "/"+a.Id+"/" {
+		t.Errorf("old parent %+v", a)
+	}
+	document, err = repo.DocumentStore.FindDocumentById(ctx, document.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document.ProjectId != other.Id || document.FolderId != c.Id {
+		t.Errorf("document in project %s folder %s", document.ProjectId, document.FolderId)
+	}
+
+	// and back below a in the first project
+	_, err = repo.FolderStore.MoveFolderFromRequest(ctx, b.Id, owner.Id, &dto.FolderMoveRequest{ProjectId: project.Id, ParentId: a.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c = findTestFolder(t, repo, c.Id); c.Path != a.Path+b.Id+"/"+c.Id+"/" || c.Depth != 2 || c.ProjectId != project.Id {
+		t.Errorf("sub folder moved back %+v", c)
+	}
+}
+
+func TestCopyFolder(t *testing.T) {
+	repo := newTestStore(t)
+	ctx := context.Background()
+	owner, project := newTestProject(t, repo)
+	a := newTestFolder(t, repo, owner.Id, project.Id, "", "a")
+	b := newTestFolder(t, repo, owner.Id, project.Id, a.Id, "b")
+	newTestDocument(t, repo, owner.Id, project.Id, b.Id, "contract")
+	target := newTestFolder(t, repo, owner.Id, project.Id, "", "target")
+
+	_, err := repo.FolderStore.CopyFolderFromRequest(ctx, a.Id, owner.Id, &dto.FolderMoveRequest{ProjectId: project.Id, ParentId: b.Id})
+	if !errors.Is(err, models.ErrFolderCycle) {
+		t.Fatalf("copied below itself: %v", err)
+	}
+
+	copied, err := repo.FolderStore.CopyFolderFromRequest(ctx, a.Id, owner.Id, &dto.FolderMoveRequest{ProjectId: project.Id, ParentId: target.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.Id == a.Id || copied.ParentId != target.Id || copied.Path != target.Path+copied.Id+"/" || copied.Depth != 1 {
+		t.Errorf("copy %+v", copied)
+	}
+	folders, _, err := repo.FolderStore.ListFolders(ctx, project.Id, copied.Id, &models.Page{CurrentPage: 1, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(folders) != 1 || folders[0].Id == b.Id || folders[0].Name != "b" || folders[0].Path != copied.Path+folders[0].Id+"/" {
+		t.Fatalf("copied sub folders %+v", folders)
+	}
+	documents := []*models.Document{}
+	err = repo.DocumentStore.db.Where("folder_id IN ?", []string{b.Id, folders[0].Id}).Find(&documents).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 2 {
+		t.Errorf("%d documents, want the original and its copy", len(documents))
+	}
+	// the original is left alone
+	if b = findTestFolder(t, repo, b.Id); b.ParentId != a.Id || b.Path != a.Path+b.Id+"/" {
+		t.Errorf("original %+v", b)
+	}
+}
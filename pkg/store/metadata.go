@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+)
+
+type metadataStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+const (
+	MetadataFieldsCachePrefix = "metadata_fields_v1::"
+)
+
+var metadataKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func getMetadataFieldsCacheKey(workspaceId string) string {
+	return fmt.Sprintf("%s%s", MetadataFieldsCachePrefix, workspaceId)
+}
+
+func newMetadataStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *metadataStore {
+	return &metadataStore{db: conn, cache: cache, cfg: cfg}
+}
+
+func (u *metadataStore) NewFieldFromRequest(ctx context.Context, workspaceId, accountId string, req *dto.MetadataFieldCreateRequest) (*models.MetadataField, error) {
+	if !metadataKeyPattern.MatchString(req.Key) {
+		return &models.MetadataField{}, fmt.Errorf("%w: key must be lowercase letters, digits and underscores", models.ErrInvalidMetadata)
+	}
+	fieldType := models.MetadataFieldType(req.Type)
+	if fieldType == models.MetadataFieldTypeEnum && len(req.Options) == 0 {
+		return &models.MetadataField{}, fmt.Errorf("%w: enum fields need at least one option", models.ErrInvalidMetadata)
+	}
+	var count int64
+	err := u.db.WithContext(ctx).Model(models.MetadataField{}).Where("workspace_id = ? AND key = ?", workspaceId, req.Key).Count(&count).Error
+	if err != nil {
+		return &models.MetadataField{}, err
+	}
+	if count > 0 {
+		return &models.MetadataField{}, models.ErrMetadataFieldExists
+	}
+	field, err := models.NewMetadataField(workspaceId, req.Key, req.Label, fieldType, req.Options, req.Required, accountId).Create(u.db.WithContext(ctx))
+	if err != nil {
+		return field, err
+	}
+	u.invalidate(ctx, workspaceId)
+	return field, nil
+}
+
+// UpdateFieldFromRequest updates the label, options and required flag of a
+// field. Key and type are fixed once created as documents already hold values.
+func (u *metadataStore) UpdateFieldFromRequest(ctx context.Context, workspaceId, fieldId, accountId string, req *dto.MetadataFieldUpdateRequest) (*models.MetadataField, error) {
+	field, err := u.FindFieldById(ctx, workspaceId, fieldId)
+	if err != nil {
+		return field, err
+	}
+	if field.Type == models.MetadataFieldTypeEnum && len(req.Options) == 0 {
+		return field, fmt.Errorf("%w: enum fields need at least one option", models.ErrInvalidMetadata)
+	}
+	field.Label = req.Label
+	field.Options = req.Options
+	field.Required = req.Required
+	field.ModifiedBy = accountId
+	field, err = field.Update(u.db.WithContext(ctx))
+	if err != nil {
+		return field, err
+	}
+	u.invalidate(ctx, workspaceId)
+	return field, nil
+}
+
+// DeleteField removes the field and strips its value from every document of
+// the workspace
+func (u *metadataStore) DeleteField(ctx context.Context, workspaceId, fieldId string) error {
+	field, err := u.FindFieldById(ctx, workspaceId, fieldId)
+	if err != nil {
+		return err
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Document{}).Where("workspace_id = ?", workspaceId).
+			UpdateColumn("metadata", gorm.Expr("metadata - ?", field.Key)).Error
+		if err != nil {
+			return err
+		}
+		_, err = field.Delete(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	u.invalidate(ctx, workspaceId)
+	return nil
+}
+
+func (u *metadataStore) FindFieldById(ctx context.Context, workspaceId, fieldId string) (*models.MetadataField, error) {
+	field := &models.MetadataField{}
+	err := u.db.WithContext(ctx).Model(models.MetadataField{}).Where("workspace_id = ? AND id = ?", workspaceId, fieldId).Take(field).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.MetadataField{}, models.ErrMetadataFieldNotFound
+	} else if err != nil {
+		return &models.MetadataField{}, err
+	}
+	return field, nil
+}
+
+// ListFields returns every metadata field of the workspace
+func (u *metadataStore) ListFields(ctx context.Context, workspaceId string) ([]*models.MetadataField, error) {
+	var err error
+	fields := []*models.MetadataField{}
+	err = u.cache.GetContext(ctx, getMetadataFieldsCacheKey(workspaceId), &fields)
+	if err == nil {
+		return fields, nil
+	}
+	err = u.db.WithContext(ctx).Model(models.MetadataField{}).Where("workspace_id = ?", workspaceId).Order("key").Find(&fields).Error
+	if err != nil {
+		return fields, err
+	}
+	err = u.cache.SetContext(ctx, getMetadataFieldsCacheKey(workspaceId), fields)
+	if err == nil {
+		err = u.cache.Tag(ctx, getMetadataFieldsCacheKey(workspaceId), getWorkspaceCacheTag(workspaceId))
+	}
+	if err != nil {
+		logger.Errorf("ListFields error while setting cache:%s for key %s", err.Error(), getMetadataFieldsCacheKey(workspaceId))
+	}
+	return fields, nil
+}
+
+// Validate checks document metadata against the fields of the workspace and
+// returns it normalized
+func (u *metadataStore) Validate(ctx context.Context, workspaceId string, values map[string]interface{}) (models.Jsonb, error) {
+	fields, err := u.ListFields(ctx, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	return models.ValidateMetadata(fields, values)
+}
+
+func (u *metadataStore) invalidate(ctx context.Context, workspaceId string) {
+	err := u.cache.DelContext(ctx, getMetadataFieldsCacheKey(workspaceId))
+	if err != nil {
+		logger.Errorf("metadataStore error while deleting cache:%s for key %s", err.Error(), getMetadataFieldsCacheKey(workspaceId))
+	}
+}
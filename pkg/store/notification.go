@@ -0,0 +1,193 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type notificationStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newNotificationStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *notificationStore {
+	return &notificationStore{db: conn, cache: cache, cfg: cfg}
+}
+
+func (u *notificationStore) NewNotification(ctx context.Context, notification *models.Notification) (*models.Notification, error) {
+	return notification.Create(u.db.WithContext(ctx))
+}
+
+// ListNotifications returns the notifications of the account, newest first
+func (u *notificationStore) ListNotifications(ctx context.Context, accountId string, unreadOnly bool, page *models.Page) ([]*models.Notification, int64, error) {
+	notifications := []*models.Notification{}
+	query := u.db.WithContext(ctx).Model(models.Notification{}).Where("account_id = ?", accountId)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if len(page.Sort) == 0 {
+		query = query.Order("created DESC")
+	}
+	total, err := paginate(query, page, &notifications)
+	return notifications, total, err
+}
+
+func (u *notificationStore) UnreadCount(ctx context.Context, accountId string) (int64, error) {
+	var count int64
+	err := u.db.WithContext(ctx).Model(models.Notification{}).Where("account_id = ? AND read_at IS NULL", accountId).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks a notification of the account read, reading it again keeps
+// the first read time
+func (u *notificationStore) MarkRead(ctx context.Context, accountId, notificationId string) (*models.Notification, error) {
+	err := u.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("account_id = ? AND id = ? AND read_at IS NULL", accountId, notificationId).
+		UpdateColumn("read_at", models.NewSqlNullTime(time.Now())).Error
+	if err != nil {
+		return &models.Notification{}, err
+	}
+	notification := &models.Notification{}
+	err = u.db.WithContext(ctx).Model(models.Notification{}).Where("account_id = ? AND id = ?", accountId, notificationId).Take(notification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Notification{}, models.ErrNotificationNotFound
+	} else if err != nil {
+		return &models.Notification{}, err
+	}
+	return notification, nil
+}
+
+// MarkAllRead marks every unread notification of the account read and
+// returns how many there were
+func (u *notificationStore) MarkAllRead(ctx context.Context, accountId string) (int64, error) {
+	db := u.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("account_id = ? AND read_at IS NULL", accountId).
+		UpdateColumn("read_at", models.NewSqlNullTime(time.Now()))
+	return db.RowsAffected, db.Error
+}
+
+// Preferences returns the channels of every notification type for the
+// account, the defaults where it set none
+func (u *notificationStore) Preferences(ctx context.Context, accountId string) ([]*models.NotificationPreference, error) {
+	stored := []*models.NotificationPreference{}
+	err := u.db.WithContext(ctx).Model(models.NotificationPreference{}).Where("account_id = ?", accountId).Find(&stored).Error
+	if err != nil {
+		return nil, err
+	}
+	byType := make(map[models.NotificationType]*models.NotificationPreference, len(stored))
+	for _, p := range stored {
+		byType[p.Type] = p
+	}
+	preferences := make([]*models.NotificationPreference, 0, len(models.NotificationDefaults))
+	for t, channels := range models.NotificationDefaults {
+		p, ok := byType[t]
+		if !ok {
+			p = models.NewNotificationPreference(accountId, t, channels)
+		}
+		preferences = append(preferences, p)
+	}
+	sort.Slice(preferences, func(i, j int) bool {
+		return preferences[i].Type < preferences[j].Type
+	})
+	return preferences, nil
+}
+
+// Channels returns where notifications of the type are delivered to the
+// account
+func (u *notificationStore) Channels(ctx context.Context, accountId string, notificationType models.NotificationType) (models.NotificationChannels, error) {
+	preference := &models.NotificationPreference{}
+	err := u.db.WithContext(ctx).Model(models.NotificationPreference{}).Where("account_id = ? AND type = ?", accountId, notificationType).Take(preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.NotificationDefaults[notificationType], nil
+	} else if err != nil {
+		return models.NotificationChannels{}, err
+	}
+	return preference.NotificationChannels, nil
+}
+
+func (u *notificationStore) UpdatePreferencesFromRequest(ctx context.Context, accountId string, req *dto.NotificationPreferencesUpdateRequest) ([]*models.NotificationPreference, error) {
+	preferences := make([]*models.NotificationPreference, 0, len(req.Preferences))
+	// a type listed twice keeps its last channels, an upsert cannot touch a
+	// row twice
+	index := map[models.NotificationType]int{}
+	for _, p := range req.Preferences {
+		t := models.NotificationType(p.Type)
+		if !t.IsValid() {
+			return nil, fmt.Errorf("%w: %s", models.ErrInvalidNotification, p.Type)
+		}
+		if p.Digest && !p.InApp {
+			return nil, fmt.Errorf("%w: the digest of %s needs in_app", models.ErrInvalidPreference, p.Type)
+		}
+		preference := models.NewNotificationPreference(accountId, t, models.NotificationChannels{InApp: p.InApp, Email: p.Email, Digest: p.Digest})
+		if i, ok := index[t]; ok {
+			preferences[i] = preference
+			continue
+		}
+		index[t] = len(preferences)
+		preferences = append(preferences, preference)
+	}
+	if len(preferences) > 0 {
+		err := u.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "account_id"}, {Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "digest", "updated"}),
+		}).Create(&preferences).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return u.Preferences(ctx, accountId)
+}
+
+// DigestsDue returns the accounts with a digest preference whose last digest
+// was sent before before, or that never received one
+func (u *notificationStore) DigestsDue(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	accountIds := []string{}
+	err := u.db.WithContext(ctx).Model(models.NotificationPreference{}).
+		Distinct("notification_preferences.account_id").
+		Joins("LEFT JOIN notification_digests d ON d.account_id = notification_preferences.account_id").
+		Where("notification_preferences.digest AND (d.sent_at IS NULL OR d.sent_at < ?)", before).
+		Limit(limit).
+		Pluck("notification_preferences.account_id", &accountIds).Error
+	return accountIds, err
+}
+
+// DigestNotifications returns the unread notifications of the types the
+// account gets a digest of, created since the last digest or since since
+// when none was sent, oldest first
+func (u *notificationStore) DigestNotifications(ctx context.Context, accountId string, since time.Time, limit int) ([]*models.Notification, error) {
+	digest := &models.NotificationDigest{}
+	err := u.db.WithContext(ctx).Model(models.NotificationDigest{}).Where("account_id = ?", accountId).Take(digest).Error
+	if err == nil {
+		since = digest.SentAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	notifications := []*models.Notification{}
+	err = u.db.WithContext(ctx).Model(models.Notification{}).
+		Where("account_id = ? AND read_at IS NULL AND created > ?", accountId, since.UnixMilli()).
+		Where("type IN (?)", u.db.WithContext(ctx).Model(models.NotificationPreference{}).Select("type").Where("account_id = ? AND digest", accountId)).
+		Order("created").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkDigestSent records that the account received its digest at sentAt
+func (u *notificationStore) MarkDigestSent(ctx context.Context, accountId string, sentAt time.Time) error {
+	return u.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "account_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"sent_at"}),
+	}).Create(&models.NotificationDigest{AccountId: accountId, SentAt: sentAt}).Error
+}
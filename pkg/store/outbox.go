@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"gorm.io/gorm"
+)
+
+const outboxErrorSize = 1024
+
+type outboxStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newOutboxStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *outboxStore {
+	return &outboxStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// recordEvent writes an event of type t to the outbox in tx, so it is
+// published if and only if tx commits
+func recordEvent[T any](tx *gorm.DB, t events.Type[T], workspaceId, subjectId string, data T) error {
+	event, err := t.New(workspaceId, subjectId, data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = models.NewOutboxEvent(event.Id, event.Type, event.Topic, event.WorkspaceId, string(payload)).Create(tx)
+	return err
+}
+
+// outboxHeads returns the workspaces with undelivered events whose oldest
+// one is available, oldest first
+const outboxHeads = `SELECT workspace_id FROM (
+	SELECT DISTINCT ON (workspace_id) workspace_id, available_at, seq FROM outbox
+	WHERE delivered_at IS NULL ORDER BY workspace_id, seq
+) heads WHERE available_at <= ? ORDER BY seq LIMIT ?`
+
+// Relay claims the workspaces with undelivered events, oldest first, and
+// hands up to limit of their events to publish in the order they were
+// recorded. A workspace is claimed with a session advisory lock, so another
+// relay skips it. It is not claimed with FOR UPDATE SKIP LOCKED: row locks
+// end with the transaction while no transaction is open during publish, and
+// skipping single locked rows would let another relay publish the later
+// events of the workspace first. Events are marked delivered when publish
+// succeeds. When it fails the oldest event of each workspace is retried after
+// retryAfter(attempts), the events after it wait for it so workspaces keep
+// their order. Publish may still see an event twice when marking it delivered
+// fails, so delivery is at least once. Returns the number of events relayed.
+func (u *outboxStore) Relay(ctx context.Context, limit int, publish func(events []*events.Event) error, retryAfter func(attempts int) time.Duration) (int, error) {
+	count := 0
+	err := u.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		workspaceIds := []string{}
+		if err := conn.Raw(outboxHeads, time.Now(), limit).Scan(&workspaceIds).Error; err != nil {
+			return err
+		}
+		// the locks belong to the connection, release them before it goes
+		// back to the pool
+		defer conn.WithContext(context.WithoutCancel(ctx)).Exec("SELECT pg_advisory_unlock_all()")
+
+		var rows, heads []*models.OutboxEvent
+		for _, workspaceId := range workspaceIds {
+			if len(rows) >= limit {
+				break
+			}
+			var locked bool
+			if err := conn.Raw("SELECT pg_try_advisory_lock(hashtext(?))", "outbox::"+workspaceId).Scan(&locked).Error; err != nil {
+				return err
+			}
+			if !locked {
+				continue
+			}
+			pending := []*models.OutboxEvent{}
+			err := conn.Where("workspace_id = ? AND delivered_at IS NULL", workspaceId).
+				Order("seq").Limit(limit - len(rows)).Find(&pending).Error
+			if err != nil {
+				return err
+			}
+			// another relay may have published or retried the events since
+			if len(pending) == 0 || pending[0].AvailableAt.After(time.Now()) {
+				continue
+			}
+			rows = append(rows, pending...)
+			heads = append(heads, pending[0])
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		count = len(rows)
+		ids := make([]string, 0, len(rows))
+		batch := make([]*events.Event, 0, len(rows))
+		for _, row := range rows {
+			event := &events.Event{}
+			if err := json.Unmarshal([]byte(row.Payload), event); err != nil {
+				return err
+			}
+			ids = append(ids, row.Id)
+			batch = append(batch, event)
+		}
+		if err := publish(batch); err != nil {
+			message := strings.ToValidUTF8(truncate(err.Error(), outboxErrorSize), "")
+			for _, head := range heads {
+				err := conn.Model(&models.OutboxEvent{}).Where("id = ?", head.Id).UpdateColumns(map[string]interface{}{
+					"attempts":     head.Attempts + 1,
+					"last_error":   message,
+					"available_at": time.Now().Add(retryAfter(head.Attempts + 1)),
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return conn.Model(&models.OutboxEvent{}).Where("id IN ?", ids).UpdateColumns(map[string]interface{}{
+			"delivered_at": time.Now(),
+			"last_error":   "",
+		}).Error
+	})
+	return count, err
+}
+
+// Purge deletes the events delivered before before
+func (u *outboxStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	db := u.db.WithContext(ctx).Unscoped().Where("delivered_at < ?", before).Delete(&models.OutboxEvent{})
+	return db.RowsAffected, db.Error
+}
+
+func truncate(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	return s[:size]
+}
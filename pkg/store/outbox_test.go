@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"gorm.io/gorm"
+)
+
+func TestRelayOrder(t *testing.T) {
+	repo := newTestStore(t)
+	ctx := context.Background()
+	workspaceId := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	// events of one transaction share created, seq keeps them in order
+	want := []string{}
+	err := repo.OutboxStore.db.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < 5; i++ {
+			subjectId := fmt.Sprintf("doc-%d", i)
+			want = append(want, subjectId)
+			if err := recordEvent(tx, events.DocumentUpdated, workspaceId, subjectId, events.DocumentPayload{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retryAfter := func(attempts int) time.Duration { return 0 }
+	failed := false
+	got := []string{}
+	for i := 0; i < 10 && len(got) < len(want); i++ {
+		_, err := repo.OutboxStore.Relay(ctx, 1000, func(batch []*events.Event) error {
+			// the first batch fails and is retried in the same order
+			if !failed {
+				failed = true
+				return errors.New("bus unavailable")
+			}
+			for _, event := range batch {
+				if event.WorkspaceId == workspaceId {
+					got = append(got, event.SubjectId)
+				}
+			}
+			return nil
+		}, retryAfter)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("relayed %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+)
+
+type permissionStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newPermissionStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *permissionStore {
+	return &permissionStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// Authorize checks that the account holds at least the required role
+// on the resource and returns the workspace the resource belongs to
+func (u *permissionStore) Authorize(ctx context.Context, accountId string, resourceType models.ResourceType, resourceId string, required models.WorkspaceRole) (string, error) {
+	workspaceId, role, err := u.RoleFor(ctx, accountId, resourceType, resourceId)
+	if err != nil {
+		return workspaceId, err
+	}
+	if !role.Includes(required) {
+		return workspaceId, models.ErrForbidden
+	}
+	return workspaceId, nil
+}
+
+// RoleFor resolves the effective role of the account on the resource.
+// Workspace owners and admins have their role everywhere. For everyone else
+// the grant closest to the resource wins, walking up document -> folders ->
+// project, and the workspace membership role applies when nothing was granted.
+func (u *permissionStore) RoleFor(ctx context.Context, accountId string, resourceType models.ResourceType, resourceId string) (string, models.WorkspaceRole, error) {
+	workspaceId, chain, err := u.resolveChain(ctx, resourceType, resourceId)
+	if err != nil {
+		return "", "", err
+	}
+	member, err := u.repo.WorkspaceStore.FindMember(ctx, workspaceId, accountId)
+	if errors.Is(err, models.ErrWorkspaceMemberNotFound) {
+		return workspaceId, "", models.ErrForbidden
+	} else if err != nil {
+		return workspaceId, "", err
+	}
+	if member.Role.Includes(models.WorkspaceRoleAdmin) || len(chain) == 0 {
+		return workspaceId, member.Role, nil
+	}
+
+	grants := []*models.Permission{}
+	err = u.db.WithContext(ctx).Model(models.Permission{}).
+		Where("workspace_id = ? AND account_id = ? AND resource_id IN ?", workspaceId, accountId, chain).
+		Find(&grants).Error
+	if err != nil {
+		return workspaceId, "", err
+	}
+	byResource := make(map[string]models.WorkspaceRole, len(grants))
+	for _, g := range grants {
+		byResource[g.ResourceId] = g.Role
+	}
+	for _, id := range chain {
+		if role, ok := byResource[id]; ok {
+			return workspaceId, role, nil
+		}
+	}
+	return workspaceId, member.Role, nil
+}
+
+// resolveChain returns the workspace of the resource and the ids of the
+// resource and its parents, ordered from the resource up to its project
+func (u *permissionStore) resolveChain(ctx context.Context, resourceType models.ResourceType, resourceId string) (string, []string, error) {
+	switch resourceType {
+	case models.ResourceTypeWorkspace:
+		workspace, err := u.repo.WorkspaceStore.FindWorkspaceById(ctx, resourceId)
+		if err != nil {
+			return "", nil, err
+		}
+		return workspace.Id, nil, nil
+	case models.ResourceTypeProject:
+		project, err := u.repo.ProjectStore.FindProjectById(ctx, resourceId)
+		if err != nil {
+			return "", nil, err
+		}
+		return project.WorkspaceId, []string{project.Id}, nil
+	case models.ResourceTypeFolder:
+		folder, err := u.repo.FolderStore.FindFolderById(ctx, resourceId)
+		if err != nil {
+			return "", nil, err
+		}
+		return folder.WorkspaceId, folderChain(folder), nil
+	case models.ResourceTypeDocument:
+		document, err := u.repo.DocumentStore.FindDocumentById(ctx, resourceId)
+		if err != nil {
+			return "", nil, err
+		}
+		chain := []string{document.Id}
+		if document.FolderId == "" {
+			return document.WorkspaceId, append(chain, document.ProjectId), nil
+		}
+		folder, err := u.repo.FolderStore.FindFolderById(ctx, document.FolderId)
+		if err != nil {
+			return "", nil, err
+		}
+		return document.WorkspaceId, append(chain, folderChain(folder)...), nil
+	}
+	return "", nil, models.ErrBadRequest
+}
+
+func folderChain(folder *models.Folder) []string {
+	ancestors := folder.AncestorIds()
+	chain := make([]string, 0, len(ancestors)+2)
+	chain = append(chain, folder.Id)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		chain = append(chain, ancestors[i])
+	}
+	return append(chain, folder.ProjectId)
+}
+
+// GrantFromRequest grants or updates the role of a workspace member on a resource
+func (u *permissionStore) GrantFromRequest(ctx context.Context, workspaceId, accountId string, req *dto.PermissionGrantRequest) (*models.Permission, error) {
+	resourceType := models.ResourceType(req.ResourceType)
+	resourceWorkspaceId, _, err := u.resolveChain(ctx, resourceType, req.ResourceId)
+	if err != nil {
+		return &models.Permission{}, err
+	}
+	if resourceWorkspaceId != workspaceId {
+		return &models.Permission{}, models.ErrBadRequest
+	}
+	role := models.WorkspaceRole(req.Role)
+	if !role.IsValid() || role == models.WorkspaceRoleOwner {
+		return &models.Permission{}, models.ErrInvalidRole
+	}
+	_, err = u.repo.WorkspaceStore.FindMember(ctx, workspaceId, req.AccountId)
+	if err != nil {
+		return &models.Permission{}, err
+	}
+
+	permission := &models.Permission{}
+	err = u.db.WithContext(ctx).Model(models.Permission{}).Where("resource_id = ? AND account_id = ?", req.ResourceId, req.AccountId).Take(permission).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.NewPermission(workspaceId, resourceType, req.ResourceId, req.AccountId, role, accountId).Create(u.db.WithContext(ctx))
+	} else if err != nil {
+		return &models.Permission{}, err
+	}
+	err = u.db.WithContext(ctx).Model(models.Permission{}).Where("id = ?", permission.Id).UpdateColumns(
+		map[string]interface{}{
+			"role":        role,
+			"modified_by": accountId,
+		},
+	).Error
+	if err != nil {
+		return &models.Permission{}, err
+	}
+	permission.Role = role
+	permission.ModifiedBy = accountId
+	return permission, nil
+}
+
+func (u *permissionStore) FindPermissionById(ctx context.Context, workspaceId, permissionId string) (*models.Permission, error) {
+	permission := &models.Permission{}
+	err := u.db.WithContext(ctx).Model(models.Permission{}).Where("workspace_id = ? AND id = ?", workspaceId, permissionId).Take(permission).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Permission{}, models.ErrPermissionNotFound
+	} else if err != nil {
+		return &models.Permission{}, err
+	}
+	return permission, nil
+}
+
+// ListPermissions lists the grants of the workspace, narrowed to a single
+// resource when resourceId is set
+func (u *permissionStore) ListPermissions(ctx context.Context, workspaceId, resourceId string, page *models.Page) ([]*models.Permission, int64, error) {
+	permissions := []*models.Permission{}
+	query := u.db.WithContext(ctx).Model(models.Permission{}).Where("workspace_id = ?", workspaceId)
+	if resourceId != "" {
+		query = query.Where("resource_id = ?", resourceId)
+	}
+	total, err := paginate(query, page, &permissions)
+	return permissions, total, err
+}
+
+func (u *permissionStore) Revoke(ctx context.Context, workspaceId, permissionId string) error {
+	permission, err := u.FindPermissionById(ctx, workspaceId, permissionId)
+	if err != nil {
+		return err
+	}
+	_, err = permission.Delete(u.db.WithContext(ctx))
+	return err
+}
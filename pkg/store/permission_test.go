@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+)
+
+func TestRoleForWalksTheChain(t *testing.T) {
+	repo := newTestStore(t)
+	ctx := context.Background()
+	owner, project := newTestProject(t, repo)
+	workspaceId := project.WorkspaceId
+	viewer := newTestAccount(t, repo, "viewer")
+	_, err := repo.WorkspaceStore.AddMemberFromRequest(ctx, workspaceId, owner.Id, &dto.WorkspaceMemberAddRequest{Email: viewer.Email, Role: string(models.WorkspaceRoleViewer)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outsider := newTestAccount(t, repo, "outsider")
+	a := newTestFolder(t, repo, owner.Id, project.Id, "", "a")
+	b := newTestFolder(t, repo, owner.Id, project.Id, a.Id, "b")
+	document := newTestDocument(t, repo, owner.Id, project.Id, b.Id, "contract")
+	rootDocument := newTestDocument(t, repo, owner.Id, project.Id, "", "policy")
+
+	grant := func(resourceType models.ResourceType, resourceId string, role models.WorkspaceRole) {
+		t.Helper()
+		_, err := repo.PermissionStore.GrantFromRequest(ctx, workspaceId, owner.Id, &dto.PermissionGrantRequest{
+			ResourceType: string(resourceType),
+			ResourceId:   resourceId,
+			AccountId:    viewer.Id,
+			Role:         string(role),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(step string, accountId string, resourceType models.ResourceType, resourceId string, want models.WorkspaceRole) {
+		t.Helper()
+		_, role, err := repo.PermissionStore.RoleFor(ctx, accountId, resourceType, resourceId)
+		if err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if role != want {
+			t.Errorf("%s: role %s, want %s", step, role, want)
+		}
+	}
+
+	check("membership", viewer.Id, models.ResourceTypeDocument, document.Id, models.WorkspaceRoleViewer)
+	grant(models.ResourceTypeProject, project.Id, models.WorkspaceRoleEditor)
+	check("project grant", viewer.Id, models.ResourceTypeDocument, document.Id, models.WorkspaceRoleEditor)
+	check("project grant at the root", viewer.Id, models.ResourceTypeDocument, rootDocument.Id, models.WorkspaceRoleEditor)
+	grant(models.ResourceTypeFolder, a.Id, models.WorkspaceRoleViewer)
+	check("folder grant", viewer.Id, models.ResourceTypeDocument, document.Id, models.WorkspaceRoleViewer)
+	check("folder grant on a sub folder", viewer.Id, models.ResourceTypeFolder, b.Id, models.WorkspaceRoleViewer)
+	check("folder grant outside the folder", viewer.Id, models.ResourceTypeDocument, rootDocument.Id, models.WorkspaceRoleEditor)
+	grant(models.ResourceTypeDocument, document.Id, models.WorkspaceRoleAdmin)
+	check("document grant", viewer.Id, models.ResourceTypeDocument, document.Id, models.WorkspaceRoleAdmin)
+	check("document grant on the folder", viewer.Id, models.ResourceTypeFolder, b.Id, models.WorkspaceRoleViewer)
+	check("owner", owner.Id, models.ResourceTypeDocument, document.Id, models.WorkspaceRoleOwner)
+
+	_, err = repo.PermissionStore.Authorize(ctx, viewer.Id, models.ResourceTypeFolder, a.Id, models.WorkspaceRoleEditor)
+	if !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("viewer edits the folder: %v", err)
+	}
+	_, err = repo.PermissionStore.Authorize(ctx, outsider.Id, models.ResourceTypeDocument, document.Id, models.WorkspaceRoleViewer)
+	if !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("outsider views the document: %v", err)
+	}
+}
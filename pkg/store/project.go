@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+)
+
+type projectStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newProjectStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *projectStore {
+	return &projectStore{db: conn, cache: cache, cfg: cfg}
+}
+
+func (u *projectStore) NewProjectFromRequest(ctx context.Context, workspaceId, accountId string, req *dto.ProjectCreateRequest) (*models.Project, error) {
+	return models.NewProject(workspaceId, req.Name, req.Description, accountId).Create(u.db.WithContext(ctx))
+}
+
+func (u *projectStore) UpdateProjectFromRequest(ctx context.Context, projectId, accountId string, req *dto.ProjectUpdateRequest) (*models.Project, error) {
+	project, err := u.FindProjectById(ctx, projectId)
+	if err != nil {
+		return project, err
+	}
+	project.Name = req.Name
+	project.Description = req.Description
+	project.ModifiedBy = accountId
+	return project.Update(u.db.WithContext(ctx))
+}
+
+func (u *projectStore) FindProjectById(ctx context.Context, projectId string) (*models.Project, error) {
+	project := &models.Project{}
+	err := u.db.WithContext(ctx).Model(models.Project{}).Where("id = ?", projectId).Take(project).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Project{}, models.ErrProjectNotFound
+	} else if err != nil {
+		return &models.Project{}, err
+	}
+	return project, nil
+}
+
+func (u *projectStore) ListProjects(ctx context.Context, workspaceId string, page *models.Page) ([]*models.Project, int64, error) {
+	projects := []*models.Project{}
+	query := u.db.WithContext(ctx).Model(models.Project{}).Where("workspace_id = ?", workspaceId)
+	total, err := paginate(query, page, &projects)
+	return projects, total, err
+}
+
+// DeleteProject soft deletes the project together with its folders and documents
+func (u *projectStore) DeleteProject(ctx context.Context, projectId string) error {
+	project, err := u.FindProjectById(ctx, projectId)
+	if err != nil {
+		return err
+	}
+	var deletedIds []string
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deletedIds, err = documentIds(tx.Where("project_id = ?", project.Id))
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.Id).Delete(&models.Document{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", project.Id).Delete(&models.Folder{}).Error; err != nil {
+			return err
+		}
+		_, err := project.Delete(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	u.repo.documentsChanged(deletedIds...)
+	return nil
+}
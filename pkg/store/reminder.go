@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type reminderStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+// DueReminder is a reminder offset of a document that is due and was not sent
+// for the current expiry date yet
+type DueReminder struct {
+	DocumentId string
+	OffsetDays int
+	ExpiresAt  time.Time
+}
+
+func newReminderStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *reminderStore {
+	return &reminderStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// DueReminders returns up to limit reminders due at now, soonest expiry first
+func (u *reminderStore) DueReminders(ctx context.Context, now time.Time, limit int) ([]*DueReminder, error) {
+	due := []*DueReminder{}
+	err := u.db.WithContext(ctx).Raw(`
+		SELECT d.id AS document_id, o.offset_days, d.expires_at
+		FROM documents d
+		CROSS JOIN LATERAL (
+			SELECT value::int AS offset_days FROM jsonb_array_elements_text(d.reminder_offsets)
+		) o
+		WHERE d.deleted_at IS NULL
+			AND d.expires_at IS NOT NULL
+			AND d.status <> ?
+			AND d.expires_at - make_interval(days => o.offset_days) <= ?
+			AND NOT EXISTS (
+				SELECT 1 FROM document_reminders r
+				WHERE r.document_id = d.id AND r.offset_days = o.offset_days AND r.expires_at = d.expires_at
+			)
+		ORDER BY d.expires_at, d.id
+		LIMIT ?`, models.DocumentStatusArchived, now, limit).Scan(&due).Error
+	return due, err
+}
+
+// MarkSent records the reminders as sent and reports whether any of them was
+// not recorded before, so concurrent runs do not notify twice
+func (u *reminderStore) MarkSent(ctx context.Context, reminders []*DueReminder) (bool, error) {
+	if len(reminders) == 0 {
+		return false, nil
+	}
+	rows := make([]*models.DocumentReminder, 0, len(reminders))
+	for _, r := range reminders {
+		rows = append(rows, &models.DocumentReminder{DocumentId: r.DocumentId, OffsetDays: r.OffsetDays, ExpiresAt: r.ExpiresAt})
+	}
+	db := u.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows)
+	if db.Error != nil {
+		return false, db.Error
+	}
+	return db.RowsAffected > 0, nil
+}
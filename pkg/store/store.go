@@ -1,23 +1,135 @@
 package store
 
 import (
+	"crypto/sha256"
+	"strings"
+
 	"github.com/praveenmsp23/trackdocs/pkg/cache"
 	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
 	"gorm.io/gorm"
 )
 
 const SearchLimit = 10
 
+// CachePrefixes are the prefixes of the cache entries the stores keep, which
+// can be dropped without losing state
+var CachePrefixes = []string{
+	AccountCachePrefix,
+	AccountEmailCachePrefix,
+	WorkflowCachePrefix,
+	WorkspaceMemberCachePrefix,
+	MetadataFieldsCachePrefix,
+}
+
+// IsCachePrefix reports whether prefix selects entries of one of
+// CachePrefixes
+func IsCachePrefix(prefix string) bool {
+	for _, p := range CachePrefixes {
+		if strings.HasPrefix(prefix, p) {
+			return true
+		}
+	}
+	return false
+}
+
 // Store one stop for stores
 type Store struct {
-	AccountStore *accountStore
+	AccountStore      *accountStore
+	WorkspaceStore    *workspaceStore
+	ProjectStore      *projectStore
+	FolderStore       *folderStore
+	DocumentStore     *documentStore
+	PermissionStore   *permissionStore
+	MetadataStore     *metadataStore
+	TagStore          *tagStore
+	WorkflowStore     *workflowStore
+	ReminderStore     *reminderStore
+	VersionStore      *versionStore
+	RequestStore      *documentRequestStore
+	OutboxStore       *outboxStore
+	WebhookStore      *webhookStore
+	NotificationStore *notificationStore
+	CommentStore      *commentStore
+
+	listeners []DocumentListener
+}
+
+// DocumentListener is called with the ids of documents that were created,
+// changed or deleted, once the change is committed
+type DocumentListener func(documentIds ...string)
+
+// OnDocumentsChanged registers a listener, listeners are expected to be
+// registered at startup and to return quickly
+func (s *Store) OnDocumentsChanged(listener DocumentListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *Store) documentsChanged(documentIds ...string) {
+	if len(documentIds) == 0 {
+		return
+	}
+	for _, l := range s.listeners {
+		l(documentIds...)
+	}
 }
 
 // NewStore create all the stores
-func NewStore(conn *gorm.DB, cache *cache.Cache, cfg *config.Config) (*Store, error) {
+func NewStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) (*Store, error) {
 	repo := &Store{
-		AccountStore: newAccountStore(conn, cache, cfg),
+		AccountStore:      newAccountStore(conn, cache, cfg),
+		WorkspaceStore:    newWorkspaceStore(conn, cache, cfg),
+		ProjectStore:      newProjectStore(conn, cache, cfg),
+		FolderStore:       newFolderStore(conn, cache, cfg),
+		DocumentStore:     newDocumentStore(conn, cache, cfg),
+		PermissionStore:   newPermissionStore(conn, cache, cfg),
+		MetadataStore:     newMetadataStore(conn, cache, cfg),
+		TagStore:          newTagStore(conn, cache, cfg),
+		WorkflowStore:     newWorkflowStore(conn, cache, cfg),
+		ReminderStore:     newReminderStore(conn, cache, cfg),
+		VersionStore:      newVersionStore(conn, cache, cfg),
+		RequestStore:      newDocumentRequestStore(conn, cache, cfg),
+		OutboxStore:       newOutboxStore(conn, cache, cfg),
+		WebhookStore:      newWebhookStore(conn, cache, cfg),
+		NotificationStore: newNotificationStore(conn, cache, cfg),
+		CommentStore:      newCommentStore(conn, cache, cfg),
 	}
 	repo.AccountStore.repo = repo
+	repo.WorkspaceStore.repo = repo
+	repo.ProjectStore.repo = repo
+	repo.FolderStore.repo = repo
+	repo.DocumentStore.repo = repo
+	repo.PermissionStore.repo = repo
+	repo.MetadataStore.repo = repo
+	repo.TagStore.repo = repo
+	repo.WorkflowStore.repo = repo
+	repo.ReminderStore.repo = repo
+	repo.VersionStore.repo = repo
+	repo.RequestStore.repo = repo
+	repo.OutboxStore.repo = repo
+	repo.WebhookStore.repo = repo
+	repo.NotificationStore.repo = repo
+	repo.CommentStore.repo = repo
 	return repo, nil
 }
+
+// paginate counts the rows matched by query and loads the requested page into out
+func paginate(query *gorm.DB, page *models.Page, out interface{}) (int64, error) {
+	var total int64
+	err := page.CountPaginate(query.Session(&gorm.Session{})).Count(&total).Error
+	if err != nil {
+		return 0, err
+	}
+	err = page.Paginate(query.Session(&gorm.Session{})).Find(out).Error
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// encryptionKey derives an AES-256 key from the configured secret, it
+// encrypts the webhook secrets and the document request tokens
+func encryptionKey(cfg *config.Config) []byte {
+	sum := sha256.Sum256([]byte(cfg.Secret))
+	return sum[:]
+}
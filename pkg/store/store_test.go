@@ -0,0 +1,25 @@
+package store
+
+import "testing"
+
+func TestIsCachePrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		valid  bool
+	}{
+		{"workflow_v1::", true},
+		{"workspace_member_v1::0b8e", true},
+		{"account_v1::", true},
+		{"workflow", false},
+		{"{jobs}::", false},
+		{"rate:", false},
+		{"token_v1::", false},
+		{"lock::", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsCachePrefix(tt.prefix); got != tt.valid {
+			t.Errorf("IsCachePrefix(%q) = %v", tt.prefix, got)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tagStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newTagStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *tagStore {
+	return &tagStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// Autocomplete returns the most used tags of the workspace starting with prefix
+func (u *tagStore) Autocomplete(ctx context.Context, workspaceId, prefix string) ([]*models.Tag, error) {
+	tags := []*models.Tag{}
+	err := u.db.WithContext(ctx).Model(models.Tag{}).
+		Select("tags.*, COUNT(document_tags.document_id) AS usage_count").
+		Joins("LEFT JOIN document_tags ON document_tags.tag_id = tags.id").
+		Where("tags.workspace_id = ? AND tags.name LIKE ?", workspaceId, likePrefix(models.NormalizeTag(prefix))).
+		Group("tags.id").
+		Order("usage_count DESC, tags.name").
+		Limit(SearchLimit).
+		Find(&tags).Error
+	return tags, err
+}
+
+// SetDocumentTags replaces the tags of the document, creating missing tags
+func (u *tagStore) SetDocumentTags(tx *gorm.DB, document *models.Document, names []string) error {
+	tags, err := u.ensureTags(tx, document.WorkspaceId, names)
+	if err != nil {
+		return err
+	}
+	err = tx.Where("document_id = ?", document.Id).Delete(&models.DocumentTag{}).Error
+	if err != nil {
+		return err
+	}
+	document.Tags = make([]string, 0, len(tags))
+	if len(tags) == 0 {
+		return nil
+	}
+	links := make([]*models.DocumentTag, 0, len(tags))
+	for _, t := range tags {
+		links = append(links, &models.DocumentTag{DocumentId: document.Id, TagId: t.Id})
+		document.Tags = append(document.Tags, t.Name)
+	}
+	return tx.Create(&links).Error
+}
+
+// CopyDocumentTags gives the document "to" the same tags as "from"
+func (u *tagStore) CopyDocumentTags(tx *gorm.DB, from, to string) error {
+	return tx.Exec(
+		"INSERT INTO document_tags (document_id, tag_id, created) SELECT ?, tag_id, ? FROM document_tags WHERE document_id = ?",
+		to, time.Now().UnixMilli(), from,
+	).Error
+}
+
+// LoadTags fills the Tags of the documents
+func (u *tagStore) LoadTags(ctx context.Context, documents ...*models.Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(documents))
+	byId := make(map[string]*models.Document, len(documents))
+	for _, d := range documents {
+		ids = append(ids, d.Id)
+		byId[d.Id] = d
+		d.Tags = []string{}
+	}
+	var rows []struct {
+		DocumentId string
+		Name       string
+	}
+	err := u.db.WithContext(ctx).Model(models.DocumentTag{}).
+		Select("document_tags.document_id, tags.name").
+		Joins("JOIN tags ON tags.id = document_tags.tag_id").
+		Where("document_tags.document_id IN ?", ids).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		byId[r.DocumentId].Tags = append(byId[r.DocumentId].Tags, r.Name)
+	}
+	return nil
+}
+
+// taggedWith returns a sub query selecting the documents carrying all names
+func (u *tagStore) taggedWith(ctx context.Context, workspaceId string, names []string) *gorm.DB {
+	return u.db.WithContext(ctx).Model(models.DocumentTag{}).
+		Select("document_tags.document_id").
+		Joins("JOIN tags ON tags.id = document_tags.tag_id").
+		Where("tags.workspace_id = ? AND tags.name IN ?", workspaceId, names).
+		Group("document_tags.document_id").
+		Having("COUNT(DISTINCT tags.id) = ?", len(names))
+}
+
+func (u *tagStore) ensureTags(tx *gorm.DB, workspaceId string, names []string) ([]*models.Tag, error) {
+	tags := []*models.Tag{}
+	normalized := normalizeTags(names)
+	if len(normalized) == 0 {
+		return tags, nil
+	}
+	missing := make([]*models.Tag, 0, len(normalized))
+	for _, name := range normalized {
+		missing = append(missing, models.NewTag(workspaceId, name))
+	}
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&missing).Error
+	if err != nil {
+		return tags, err
+	}
+	err = tx.Model(models.Tag{}).Where("workspace_id = ? AND name IN ?", workspaceId, normalized).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func normalizeTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		n = models.NormalizeTag(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	return out
+}
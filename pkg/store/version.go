@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type versionStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newVersionStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *versionStore {
+	return &versionStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// CreateVersion saves the version as the next version of its document. The
+// document row is locked so concurrent uploads get distinct numbers.
+func (u *versionStore) CreateVersion(tx *gorm.DB, version *models.DocumentVersion) (*models.DocumentVersion, error) {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", version.DocumentId).Take(&models.Document{}).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.DocumentVersion{}, models.ErrDocumentNotFound
+	} else if err != nil {
+		return &models.DocumentVersion{}, err
+	}
+	var latest int
+	err = tx.Model(models.DocumentVersion{}).Where("document_id = ?", version.DocumentId).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error
+	if err != nil {
+		return &models.DocumentVersion{}, err
+	}
+	version.Version = latest + 1
+	version, err = version.Create(tx)
+	if err != nil {
+		return version, err
+	}
+	err = tx.Model(&models.Document{}).Where("id = ?", version.DocumentId).UpdateColumn("extract_status", version.ExtractStatus).Error
+	if err != nil {
+		return &models.DocumentVersion{}, err
+	}
+	return version, nil
+}
+
+// NewVersion saves the version as the next version of its document
+func (u *versionStore) NewVersion(ctx context.Context, version *models.DocumentVersion) (*models.DocumentVersion, error) {
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		version, err = u.CreateVersion(tx, version)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.DocumentVersionAdded, version.WorkspaceId, version.DocumentId, events.DocumentVersionPayload{Version: version})
+	})
+	if err != nil {
+		return &models.DocumentVersion{}, err
+	}
+	u.repo.documentsChanged(version.DocumentId)
+	return version, nil
+}
+
+func (u *versionStore) FindVersionById(ctx context.Context, documentId, versionId string) (*models.DocumentVersion, error) {
+	version := &models.DocumentVersion{}
+	err := u.db.WithContext(ctx).Model(models.DocumentVersion{}).Where("document_id = ? AND id = ?", documentId, versionId).Take(version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.DocumentVersion{}, models.ErrVersionNotFound
+	} else if err != nil {
+		return &models.DocumentVersion{}, err
+	}
+	return version, nil
+}
+
+// ListVersions lists the versions of the document, newest first unless the
+// page asks for another order
+func (u *versionStore) ListVersions(ctx context.Context, documentId string, page *models.Page) ([]*models.DocumentVersion, int64, error) {
+	versions := []*models.DocumentVersion{}
+	query := u.db.WithContext(ctx).Model(models.DocumentVersion{}).Omit("text").Where("document_id = ?", documentId)
+	if len(page.Sort) == 0 {
+		query = query.Order("version DESC")
+	}
+	total, err := paginate(query, page, &versions)
+	return versions, total, err
+}
+
+// LatestTexts returns the text of the current version of each document that
+// has one
+func (u *versionStore) LatestTexts(ctx context.Context, documentIds []string) (map[string]string, error) {
+	var rows []struct {
+		DocumentId string
+		Text       string
+	}
+	err := u.db.WithContext(ctx).Model(models.DocumentVersion{}).
+		Select("DISTINCT ON (document_id) document_id, text").
+		Where("document_id IN ?", documentIds).
+		Order("document_id, version DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	texts := make(map[string]string, len(rows))
+	for _, r := range rows {
+		texts[r.DocumentId] = r.Text
+	}
+	return texts, nil
+}
+
+// ClaimExtractions leases up to limit versions waiting for their text. The
+// lease ends an attempt that did not finish, like one of a crashed worker, so
+// the version is claimed again.
+func (u *versionStore) ClaimExtractions(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.DocumentVersion, error) {
+	versions := []*models.DocumentVersion{}
+	err := u.db.WithContext(ctx).Raw(`UPDATE document_versions SET extract_attempts = extract_attempts + 1, extract_after = ?
+		WHERE id IN (
+			SELECT id FROM document_versions
+			WHERE extract_status = ? AND deleted_at IS NULL AND (extract_after IS NULL OR extract_after <= ?)
+			ORDER BY created
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, now.Add(lease), models.ExtractStatusPending, now, limit).Scan(&versions).Error
+	return versions, err
+}
+
+// FinishExtraction saves the outcome of an extraction attempt. A pending
+// version is retried after ExtractAfter. The document reports the status of
+// its current version.
+func (u *versionStore) FinishExtraction(ctx context.Context, version *models.DocumentVersion) error {
+	columns := map[string]interface{}{
+		"extract_status": version.ExtractStatus,
+		"extract_error":  version.ExtractError,
+		"extract_after":  version.ExtractAfter,
+	}
+	if version.ExtractStatus == models.ExtractStatusDone {
+		columns["text"] = version.Text
+		columns["page_count"] = version.PageCount
+	}
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.DocumentVersion{}).Where("id = ?", version.Id).UpdateColumns(columns).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Document{}).
+			Where("id = ? AND NOT EXISTS (SELECT 1 FROM document_versions WHERE document_id = ? AND version > ?)",
+				version.DocumentId, version.DocumentId, version.Version).
+			UpdateColumn("extract_status", version.ExtractStatus).Error
+	})
+	if err != nil {
+		return err
+	}
+	if version.ExtractStatus == models.ExtractStatusDone {
+		u.repo.documentsChanged(version.DocumentId)
+	}
+	return nil
+}
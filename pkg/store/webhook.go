@@ -0,0 +1,300 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"github.com/praveenmsp23/trackdocs/pkg/netutil"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	WebhookSecretPrefix = "whsec"
+	// WebhookResponseSize is the part of a response body kept in the log
+	WebhookResponseSize = 1024
+)
+
+type webhookStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+func newWebhookStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *webhookStore {
+	return &webhookStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// NewWebhookFromRequest creates an enabled webhook with a new secret, which is
+// returned on the webhook this one time
+func (u *webhookStore) NewWebhookFromRequest(ctx context.Context, workspaceId, accountId string, req *dto.WebhookCreateRequest) (*models.Webhook, error) {
+	subscribed, err := validateWebhook(req.Url, req.Events)
+	if err != nil {
+		return &models.Webhook{}, err
+	}
+	secret := crypto.GenerateId(WebhookSecretPrefix, models.TokenSize)
+	encrypted, err := crypto.Encrypt(encryptionKey(u.cfg), []byte(secret))
+	if err != nil {
+		return &models.Webhook{}, err
+	}
+	webhook, err := models.NewWebhook(workspaceId, req.Url, req.Description, subscribed, encrypted, accountId).Create(u.db.WithContext(ctx))
+	if err != nil {
+		return webhook, err
+	}
+	webhook.Secret = secret
+	return webhook, nil
+}
+
+// UpdateWebhookFromRequest updates the webhook. Enabling a disabled webhook
+// forgets its failures.
+func (u *webhookStore) UpdateWebhookFromRequest(ctx context.Context, workspaceId, webhookId, accountId string, req *dto.WebhookUpdateRequest) (*models.Webhook, error) {
+	webhook, err := u.FindWebhookById(ctx, workspaceId, webhookId)
+	if err != nil {
+		return webhook, err
+	}
+	subscribed, err := validateWebhook(req.Url, req.Events)
+	if err != nil {
+		return webhook, err
+	}
+	if req.Enabled && !webhook.Enabled {
+		webhook.FailureCount = 0
+		webhook.DisabledAt = sql.NullTime{}
+		webhook.DisabledReason = ""
+	} else if !req.Enabled && webhook.Enabled {
+		webhook.DisabledAt = models.NewSqlNullTime(time.Now())
+		webhook.DisabledReason = "disabled by user"
+	}
+	webhook.Url = req.Url
+	webhook.Description = req.Description
+	webhook.Events = subscribed
+	webhook.Enabled = req.Enabled
+	webhook.ModifiedBy = accountId
+	return webhook.Update(u.db.WithContext(ctx))
+}
+
+// validateWebhook checks the url and returns the subscribed event types
+// without duplicates
+func validateWebhook(rawUrl string, eventTypes []string) (models.StringList, error) {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https url", models.ErrInvalidWebhook)
+	}
+	// names resolving to private addresses are refused when delivering
+	if netutil.IsLocalName(parsed.Hostname()) {
+		return nil, fmt.Errorf("%w: url must point to a public address", models.ErrInvalidWebhook)
+	}
+	subscribed := models.StringList{}
+	for _, t := range eventTypes {
+		if !slices.Contains(events.Names, t) {
+			return nil, fmt.Errorf("%w: unknown event type %s", models.ErrInvalidWebhook, t)
+		}
+		if !slices.Contains(subscribed, t) {
+			subscribed = append(subscribed, t)
+		}
+	}
+	return subscribed, nil
+}
+
+func (u *webhookStore) DeleteWebhook(ctx context.Context, workspaceId, webhookId string) error {
+	webhook, err := u.FindWebhookById(ctx, workspaceId, webhookId)
+	if err != nil {
+		return err
+	}
+	_, err = webhook.Delete(u.db.WithContext(ctx))
+	return err
+}
+
+func (u *webhookStore) FindWebhookById(ctx context.Context, workspaceId, webhookId string) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	err := u.db.WithContext(ctx).Model(models.Webhook{}).Where("workspace_id = ? AND id = ?", workspaceId, webhookId).Take(webhook).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Webhook{}, models.ErrWebhookNotFound
+	} else if err != nil {
+		return &models.Webhook{}, err
+	}
+	return webhook, nil
+}
+
+func (u *webhookStore) ListWebhooks(ctx context.Context, workspaceId string) ([]*models.Webhook, error) {
+	webhooks := []*models.Webhook{}
+	err := u.db.WithContext(ctx).Model(models.Webhook{}).Where("workspace_id = ?", workspaceId).Order("created").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Subscribers returns the enabled webhooks of the workspace that receive
+// events of eventType
+func (u *webhookStore) Subscribers(ctx context.Context, workspaceId, eventType string) ([]*models.Webhook, error) {
+	webhooks := []*models.Webhook{}
+	err := u.db.WithContext(ctx).Model(models.Webhook{}).
+		Where("workspace_id = ? AND enabled AND events @> ?", workspaceId, models.StringList{eventType}).
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Secret returns the plain secret of the webhook
+func (u *webhookStore) Secret(webhook *models.Webhook) (string, error) {
+	secret, err := crypto.Decrypt(encryptionKey(u.cfg), webhook.EncryptedSecret)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// NewDelivery creates the delivery of an event to the webhook. An event that
+// is consumed again returns the delivery created the first time.
+func (u *webhookStore) NewDelivery(ctx context.Context, webhook *models.Webhook, eventId, eventType, payload string) (*models.WebhookDelivery, error) {
+	delivery := models.NewWebhookDelivery(webhook, eventId, eventType, payload)
+	db := u.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+	if db.Error != nil {
+		return &models.WebhookDelivery{}, db.Error
+	}
+	if db.RowsAffected > 0 {
+		return delivery, nil
+	}
+	delivery = &models.WebhookDelivery{}
+	err := u.db.WithContext(ctx).Model(models.WebhookDelivery{}).Where("webhook_id = ? AND event_id = ?", webhook.Id, eventId).Take(delivery).Error
+	if err != nil {
+		return &models.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+// FindDeliveryById returns the delivery with its attempts, oldest first
+func (u *webhookStore) FindDeliveryById(ctx context.Context, webhookId, deliveryId string) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	err := u.db.WithContext(ctx).Model(models.WebhookDelivery{}).
+		Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("created") }).
+		Where("webhook_id = ? AND id = ?", webhookId, deliveryId).Take(delivery).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.WebhookDelivery{}, models.ErrWebhookDeliveryNotFound
+	} else if err != nil {
+		return &models.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+func (u *webhookStore) ListDeliveries(ctx context.Context, webhookId string, page *models.Page) ([]*models.WebhookDelivery, int64, error) {
+	deliveries := []*models.WebhookDelivery{}
+	query := u.db.WithContext(ctx).Model(models.WebhookDelivery{}).Where("webhook_id = ?", webhookId)
+	if len(page.Sort) == 0 {
+		query = query.Order("created DESC")
+	}
+	total, err := paginate(query, page, &deliveries)
+	return deliveries, total, err
+}
+
+// RecordAttempt logs an attempt of the delivery and moves it on: succeeded
+// when the attempt succeeded, pending until retryAt when retryAt is set and
+// failed otherwise. Failed attempts count against the webhook, which is
+// disabled after disableAfter failures in a row. Returns whether this attempt
+// disabled the webhook.
+func (u *webhookStore) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt, succeeded bool, retryAt sql.NullTime, disableAfter int) (bool, error) {
+	disabled := false
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attempt.DeliveryId = delivery.Id
+		attempt.ResponseBody = logText(attempt.ResponseBody)
+		attempt.Error = logText(attempt.Error)
+		_, err := attempt.Create(tx)
+		if err != nil {
+			return err
+		}
+		delivery.Attempts = attempt.Attempt
+		delivery.ResponseCode = attempt.ResponseCode
+		delivery.NextAttemptAt = sql.NullTime{}
+		switch {
+		case succeeded:
+			delivery.Status = models.WebhookDeliveryStatusSucceeded
+			delivery.DeliveredAt = models.NewSqlNullTime(time.Now())
+		case retryAt.Valid:
+			delivery.Status = models.WebhookDeliveryStatusPending
+			delivery.NextAttemptAt = retryAt
+		default:
+			delivery.Status = models.WebhookDeliveryStatusFailed
+		}
+		_, err = delivery.Update(tx)
+		if err != nil {
+			return err
+		}
+
+		// lock the webhook so concurrent deliveries count each other
+		webhook := &models.Webhook{}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", delivery.WebhookId).Take(webhook).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if succeeded {
+			if webhook.FailureCount == 0 {
+				return nil
+			}
+			webhook.FailureCount = 0
+		} else {
+			webhook.FailureCount++
+			if webhook.Enabled && webhook.FailureCount >= disableAfter {
+				webhook.Enabled = false
+				webhook.DisabledAt = models.NewSqlNullTime(time.Now())
+				webhook.DisabledReason = fmt.Sprintf("disabled after %d failed attempts in a row", webhook.FailureCount)
+				disabled = true
+			}
+		}
+		_, err = webhook.Update(tx)
+		return err
+	})
+	return disabled, err
+}
+
+// logText cuts s to WebhookResponseSize and drops what postgres does not
+// store as text, responses can be anything
+func logText(s string) string {
+	s = strings.ReplaceAll(truncate(s, WebhookResponseSize), "\x00", "")
+	return strings.ToValidUTF8(s, "")
+}
+
+// Redeliver sends a finished delivery again with a fresh set of retries.
+// Attempt numbers keep counting up, so the log never repeats one.
+func (u *webhookStore) Redeliver(ctx context.Context, webhook *models.Webhook, deliveryId string) (*models.WebhookDelivery, error) {
+	if !webhook.Enabled {
+		return &models.WebhookDelivery{}, models.ErrWebhookDisabled
+	}
+	delivery, err := u.FindDeliveryById(ctx, webhook.Id, deliveryId)
+	if err != nil {
+		return delivery, err
+	}
+	if delivery.Status == models.WebhookDeliveryStatusPending {
+		return delivery, models.ErrDeliveryPending
+	}
+	delivery.Status = models.WebhookDeliveryStatusPending
+	delivery.RedeliveredAfter = delivery.Attempts
+	delivery.NextAttemptAt = models.NewSqlNullTime(time.Now())
+	delivery.DeliveredAt = sql.NullTime{}
+	// only one of concurrent redeliveries restarts the delivery
+	db := u.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status <> ?", delivery.Id, models.WebhookDeliveryStatusPending).
+		UpdateColumns(map[string]interface{}{
+			"status":            delivery.Status,
+			"redelivered_after": delivery.RedeliveredAfter,
+			"next_attempt_at":   delivery.NextAttemptAt,
+			"delivered_at":      delivery.DeliveredAt,
+		})
+	if db.Error != nil {
+		return &models.WebhookDelivery{}, db.Error
+	}
+	if db.RowsAffected == 0 {
+		return delivery, models.ErrDeliveryPending
+	}
+	return delivery, nil
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/praveenmsp23/trackdocs/pkg/crypto"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+)
+
+func TestValidateWebhookUrl(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://hooks.example.com/trackdocs", true},
+		{"http://8.8.8.8/hook", true},
+		{"ftp://example.com/hook", false},
+		{"/relative", false},
+		{"http://localhost:8080/hook", false},
+		{"http://127.0.0.1:6379/", false},
+		{"http://169.254.169.254/latest/meta-data/", false},
+		{"http://[::1]/", false},
+		{"http://10.0.0.1/", false},
+		{"http://100.64.1.1/", false},
+	}
+	for _, tt := range tests {
+		_, err := validateWebhook(tt.url, nil)
+		if tt.valid && err != nil {
+			t.Errorf("validateWebhook(%s): %v", tt.url, err)
+		}
+		if !tt.valid && !errors.Is(err, models.ErrInvalidWebhook) {
+			t.Errorf("validateWebhook(%s): expected ErrInvalidWebhook, got %v", tt.url, err)
+		}
+	}
+}
+
+func TestRedeliverKeepsCountingAttempts(t *testing.T) {
+	repo := newTestStore(t)
+	ctx := context.Background()
+	owner, project := newTestProject(t, repo)
+	webhook, err := repo.WebhookStore.NewWebhookFromRequest(ctx, project.WorkspaceId, owner.Id, &dto.WebhookCreateRequest{
+		Url:    "https://example.com/hook",
+		Events: []string{events.DocumentCreated.Name},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery, err := repo.WebhookStore.NewDelivery(ctx, webhook, crypto.GenerateId("evt", models.IdSize), events.DocumentCreated.Name, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fail := func(n int) {
+		t.Helper()
+		_, err := repo.WebhookStore.RecordAttempt(ctx, delivery, &models.WebhookAttempt{Attempt: n, ResponseCode: 500}, false, sql.NullTime{}, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	fail(1)
+	if _, err := repo.WebhookStore.Redeliver(ctx, webhook, delivery.Id); err != nil {
+		t.Fatal(err)
+	}
+	delivery, err = repo.WebhookStore.FindDeliveryById(ctx, webhook.Id, delivery.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != models.WebhookDeliveryStatusPending || delivery.Attempts != 1 || delivery.RedeliveredAfter != 1 {
+		t.Fatalf("redelivered %s with %d attempts after %d", delivery.Status, delivery.Attempts, delivery.RedeliveredAfter)
+	}
+	fail(delivery.Attempts + 1)
+	delivery, err = repo.WebhookStore.FindDeliveryById(ctx, webhook.Id, delivery.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(delivery.Log) != 2 || delivery.Log[0].Attempt != 1 || delivery.Log[1].Attempt != 2 {
+		t.Errorf("attempts %+v, want 1 and 2", delivery.Log)
+	}
+	webhook, err = repo.WebhookStore.FindWebhookById(ctx, webhook.WorkspaceId, webhook.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if webhook.FailureCount != 2 {
+		t.Errorf("failure count %d, want 2", webhook.FailureCount)
+	}
+}
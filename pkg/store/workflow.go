@@ -0,0 +1,403 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type workflowStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+const (
+	WorkflowCachePrefix = "workflow_v1::"
+)
+
+func getWorkflowCacheKey(workspaceId string) string {
+	return fmt.Sprintf("%s%s", WorkflowCachePrefix, workspaceId)
+}
+
+func newWorkflowStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *workflowStore {
+	return &workflowStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// ListTransitions returns the workflow of the workspace, or the default
+// workflow when the workspace did not configure one
+func (u *workflowStore) ListTransitions(ctx context.Context, workspaceId string) ([]*models.WorkflowTransition, error) {
+	var err error
+	transitions := []*models.WorkflowTransition{}
+	err = u.cache.GetContext(ctx, getWorkflowCacheKey(workspaceId), &transitions)
+	if err == nil {
+		return transitions, nil
+	}
+	err = u.db.WithContext(ctx).Model(models.WorkflowTransition{}).Where("workspace_id = ?", workspaceId).Order("created").Find(&transitions).Error
+	if err != nil {
+		return transitions, err
+	}
+	if len(transitions) == 0 {
+		transitions = models.DefaultWorkflow(workspaceId)
+	}
+	err = u.cache.SetContext(ctx, getWorkflowCacheKey(workspaceId), transitions)
+	if err == nil {
+		err = u.cache.Tag(ctx, getWorkflowCacheKey(workspaceId), getWorkspaceCacheTag(workspaceId))
+	}
+	if err != nil {
+		logger.Errorf("ListTransitions error while setting cache:%s for key %s", err.Error(), getWorkflowCacheKey(workspaceId))
+	}
+	return transitions, nil
+}
+
+// UpdateWorkflowFromRequest replaces the workflow of the workspace. Pending
+// transitions keep the approval rules they were requested with.
+func (u *workflowStore) UpdateWorkflowFromRequest(ctx context.Context, workspaceId, accountId string, req *dto.WorkflowUpdateRequest) ([]*models.WorkflowTransition, error) {
+	transitions := make([]*models.WorkflowTransition, 0, len(req.Transitions))
+	seen := make(map[string]bool, len(req.Transitions))
+	for _, t := range req.Transitions {
+		if t.From == t.To {
+			return nil, fmt.Errorf("%w: %s cannot transition to itself", models.ErrInvalidWorkflow, t.From)
+		}
+		key := t.From + "->" + t.To
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate transition %s", models.ErrInvalidWorkflow, key)
+		}
+		seen[key] = true
+		if err := u.validateApprovers(ctx, workspaceId, &t); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, &models.WorkflowTransition{
+			WorkspaceId:  workspaceId,
+			From:         models.DocumentStatus(t.From),
+			To:           models.DocumentStatus(t.To),
+			ApproverRole: models.WorkspaceRole(t.ApproverRole),
+			ApproverIds:  t.ApproverIds,
+			Quorum:       t.Quorum,
+			AuditBase:    models.AuditBase{CreatedBy: accountId, ModifiedBy: accountId},
+		})
+	}
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("workspace_id = ?", workspaceId).Delete(&models.WorkflowTransition{}).Error
+		if err != nil || len(transitions) == 0 {
+			return err
+		}
+		return tx.Create(&transitions).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.invalidate(ctx, workspaceId)
+	return u.ListTransitions(ctx, workspaceId)
+}
+
+func (u *workflowStore) validateApprovers(ctx context.Context, workspaceId string, t *dto.WorkflowTransitionRequest) error {
+	if t.Quorum == 0 {
+		if t.ApproverRole != "" || len(t.ApproverIds) > 0 {
+			return fmt.Errorf("%w: %s->%s names approvers but has no quorum", models.ErrInvalidWorkflow, t.From, t.To)
+		}
+		return nil
+	}
+	if len(t.ApproverIds) == 0 {
+		if t.ApproverRole == "" {
+			return fmt.Errorf("%w: %s->%s needs an approver role or named approvers", models.ErrInvalidWorkflow, t.From, t.To)
+		}
+		return nil
+	}
+	if len(t.ApproverIds) < t.Quorum {
+		return fmt.Errorf("%w: %s->%s has a quorum above the number of approvers", models.ErrInvalidWorkflow, t.From, t.To)
+	}
+	for _, id := range t.ApproverIds {
+		_, err := u.repo.WorkspaceStore.FindMember(ctx, workspaceId, id)
+		if errors.Is(err, models.ErrWorkspaceMemberNotFound) {
+			return fmt.Errorf("%w: approver %s is not a member of the workspace", models.ErrInvalidWorkflow, id)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AvailableTransitions returns the transitions allowed from the current status
+// of the document
+func (u *workflowStore) AvailableTransitions(ctx context.Context, document *models.Document) ([]*models.WorkflowTransition, error) {
+	transitions, err := u.ListTransitions(ctx, document.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	available := []*models.WorkflowTransition{}
+	for _, t := range transitions {
+		if t.From == document.Status {
+			available = append(available, t)
+		}
+	}
+	return available, nil
+}
+
+func (u *workflowStore) findWorkflowTransition(ctx context.Context, workspaceId string, from, to models.DocumentStatus) (*models.WorkflowTransition, error) {
+	transitions, err := u.ListTransitions(ctx, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range transitions {
+		if t.From == from && t.To == to {
+			return t, nil
+		}
+	}
+	return nil, models.ErrTransitionNotAllowed
+}
+
+// RequestTransitionFromRequest moves the document to a new status. Transitions
+// without approval are applied right away, the others stay pending until
+// enough approvers agreed.
+func (u *workflowStore) RequestTransitionFromRequest(ctx context.Context, documentId, accountId string, req *dto.TransitionCreateRequest) (*models.DocumentTransition, error) {
+	document, err := u.repo.DocumentStore.FindDocumentById(ctx, documentId)
+	if err != nil {
+		return &models.DocumentTransition{}, err
+	}
+	rule, err := u.findWorkflowTransition(ctx, document.WorkspaceId, document.Status, models.DocumentStatus(req.To))
+	if err != nil {
+		return &models.DocumentTransition{}, err
+	}
+	var pending int64
+	err = u.db.WithContext(ctx).Model(models.DocumentTransition{}).
+		Where("document_id = ? AND status = ?", document.Id, models.TransitionStatusPending).
+		Count(&pending).Error
+	if err != nil {
+		return &models.DocumentTransition{}, err
+	}
+	if pending > 0 {
+		return &models.DocumentTransition{}, models.ErrTransitionPending
+	}
+
+	transition := models.NewDocumentTransition(document, rule, accountId, req.Comment)
+	if rule.NeedsApproval() {
+		err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			transition, err = transition.Create(tx)
+			if err != nil {
+				return err
+			}
+			return recordEvent(tx, events.TransitionRequested, transition.WorkspaceId, transition.DocumentId,
+				events.TransitionPayload{Transition: transition, AccountId: accountId})
+		})
+		if err != nil {
+			return &models.DocumentTransition{}, err
+		}
+		return transition, nil
+	}
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := u.repo.DocumentStore.setStatus(tx, document, transition.From, transition.To, accountId)
+		if err != nil {
+			return err
+		}
+		transition, err = transition.Create(tx)
+		if err != nil {
+			return err
+		}
+		transition, err = transition.Close(tx, models.TransitionStatusCompleted)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, events.DocumentStatusChanged, document.WorkspaceId, document.Id,
+			events.DocumentStatusPayload{Document: document, From: transition.From, To: transition.To, AccountId: accountId})
+	})
+	if err != nil {
+		return &models.DocumentTransition{}, err
+	}
+	u.repo.documentsChanged(document.Id)
+	return transition, nil
+}
+
+func (u *workflowStore) FindTransitionById(ctx context.Context, documentId, transitionId string) (*models.DocumentTransition, error) {
+	transition := &models.DocumentTransition{}
+	err := u.db.WithContext(ctx).Model(models.DocumentTransition{}).Preload("Approvals").
+		Where("document_id = ? AND id = ?", documentId, transitionId).Take(transition).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.DocumentTransition{}, models.ErrTransitionNotFound
+	} else if err != nil {
+		return &models.DocumentTransition{}, err
+	}
+	return transition, nil
+}
+
+// ListHistory lists the transitions of the document, newest first unless the
+// page asks for another order
+func (u *workflowStore) ListHistory(ctx context.Context, documentId string, page *models.Page) ([]*models.DocumentTransition, int64, error) {
+	transitions := []*models.DocumentTransition{}
+	query := u.db.WithContext(ctx).Model(models.DocumentTransition{}).Preload("Approvals").Where("document_id = ?", documentId)
+	if len(page.Sort) == 0 {
+		query = query.Order("created DESC")
+	}
+	total, err := paginate(query, page, &transitions)
+	return transitions, total, err
+}
+
+// Approve records the approval of the account and applies the transition once
+// the quorum is reached
+func (u *workflowStore) Approve(ctx context.Context, documentId, transitionId, accountId string, req *dto.TransitionApproveRequest) (*models.DocumentTransition, error) {
+	return u.decide(ctx, documentId, transitionId, accountId, models.ApprovalDecisionApprove, req.Comment)
+}
+
+// Reject records the rejection of the account and closes the transition, a
+// single rejection is enough
+func (u *workflowStore) Reject(ctx context.Context, documentId, transitionId, accountId string, req *dto.TransitionRejectRequest) (*models.DocumentTransition, error) {
+	return u.decide(ctx, documentId, transitionId, accountId, models.ApprovalDecisionReject, req.Comment)
+}
+
+func (u *workflowStore) decide(ctx context.Context, documentId, transitionId, accountId string, decision models.ApprovalDecision, comment string) (*models.DocumentTransition, error) {
+	transition, err := u.FindTransitionById(ctx, documentId, transitionId)
+	if err != nil {
+		return transition, err
+	}
+	if transition.Status != models.TransitionStatusPending {
+		return transition, models.ErrTransitionClosed
+	}
+	if err := u.canApprove(ctx, transition, accountId); err != nil {
+		return transition, err
+	}
+	for _, a := range transition.Approvals {
+		if a.AccountId == accountId {
+			return transition, models.ErrAlreadyDecided
+		}
+	}
+
+	applied := false
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// lock the transition so concurrent approvals count each other
+		locked := &models.DocumentTransition{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transition.Id).Take(locked).Error
+		if err != nil {
+			return err
+		}
+		if locked.Status != models.TransitionStatusPending {
+			return models.ErrTransitionClosed
+		}
+		approval, err := models.NewTransitionApproval(transition.Id, accountId, decision, comment).Create(tx)
+		if err != nil {
+			return err
+		}
+		decided := func() error {
+			return recordEvent(tx, events.TransitionDecided, transition.WorkspaceId, transition.DocumentId,
+				events.TransitionPayload{Transition: transition, Approval: approval, AccountId: accountId})
+		}
+		if decision == models.ApprovalDecisionReject {
+			_, err = transition.Close(tx, models.TransitionStatusRejected)
+			if err != nil {
+				return err
+			}
+			return decided()
+		}
+		var approvals int64
+		err = tx.Model(models.TransitionApproval{}).
+			Where("transition_id = ? AND decision = ?", transition.Id, models.ApprovalDecisionApprove).
+			Count(&approvals).Error
+		if err != nil {
+			return err
+		}
+		if approvals < int64(transition.Quorum) {
+			return decided()
+		}
+		document, err := u.repo.DocumentStore.FindDocumentById(ctx, documentId)
+		if err != nil {
+			return err
+		}
+		err = u.repo.DocumentStore.setStatus(tx, document, transition.From, transition.To, transition.RequestedBy)
+		if err != nil {
+			return err
+		}
+		_, err = transition.Close(tx, models.TransitionStatusCompleted)
+		if err != nil {
+			return err
+		}
+		applied = true
+		err = recordEvent(tx, events.DocumentStatusChanged, document.WorkspaceId, document.Id,
+			events.DocumentStatusPayload{Document: document, From: transition.From, To: transition.To, AccountId: transition.RequestedBy})
+		if err != nil {
+			return err
+		}
+		return decided()
+	})
+	if err != nil {
+		return &models.DocumentTransition{}, err
+	}
+	if applied {
+		u.repo.documentsChanged(documentId)
+	}
+	return u.FindTransitionById(ctx, documentId, transitionId)
+}
+
+// canApprove checks the account may decide on the transition. Requesters
+// cannot approve their own transitions.
+func (u *workflowStore) canApprove(ctx context.Context, transition *models.DocumentTransition, accountId string) error {
+	if transition.RequestedBy == accountId {
+		return models.ErrForbidden
+	}
+	if len(transition.ApproverIds) > 0 {
+		if !transition.IsNamedApprover(accountId) {
+			return models.ErrForbidden
+		}
+		return nil
+	}
+	_, err := u.repo.PermissionStore.Authorize(ctx, accountId, models.ResourceTypeDocument, transition.DocumentId, transition.ApproverRole)
+	return err
+}
+
+// Approvers returns the accounts that may decide on the transition: its named
+// approvers or the members holding its approver role on the document
+func (u *workflowStore) Approvers(ctx context.Context, transition *models.DocumentTransition) ([]string, error) {
+	candidates := []string(transition.ApproverIds)
+	if len(candidates) == 0 {
+		var err error
+		candidates, err = u.repo.WorkspaceStore.ListMemberAccountIds(ctx, transition.WorkspaceId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	approvers := []string{}
+	for _, accountId := range candidates {
+		err := u.canApprove(ctx, transition, accountId)
+		if errors.Is(err, models.ErrForbidden) || errors.Is(err, models.ErrWorkspaceMemberNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		approvers = append(approvers, accountId)
+	}
+	return approvers, nil
+}
+
+// Cancel withdraws a pending transition. Only the requester or workspace
+// admins may cancel it.
+func (u *workflowStore) Cancel(ctx context.Context, documentId, transitionId, accountId string) (*models.DocumentTransition, error) {
+	transition, err := u.FindTransitionById(ctx, documentId, transitionId)
+	if err != nil {
+		return transition, err
+	}
+	if transition.Status != models.TransitionStatusPending {
+		return transition, models.ErrTransitionClosed
+	}
+	if transition.RequestedBy != accountId {
+		_, err = u.repo.PermissionStore.Authorize(ctx, accountId, models.ResourceTypeWorkspace, transition.WorkspaceId, models.WorkspaceRoleAdmin)
+		if err != nil {
+			return transition, err
+		}
+	}
+	return transition.Close(u.db.WithContext(ctx), models.TransitionStatusCancelled)
+}
+
+func (u *workflowStore) invalidate(ctx context.Context, workspaceId string) {
+	err := u.cache.DelContext(ctx, getWorkflowCacheKey(workspaceId))
+	if err != nil {
+		logger.Errorf("workflowStore error while deleting cache:%s for key %s", err.Error(), getWorkflowCacheKey(workspaceId))
+	}
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+)
+
+func TestUpdateWorkflowValidatesApprovers(t *testing.T) {
+	repo := newTestStore(t)
+	ctx := context.Background()
+	owner, project := newTestProject(t, repo)
+	outsider := newTestAccount(t, repo, "outsider")
+	tests := []struct {
+		name       string
+		transition dto.WorkflowTransitionRequest
+	}{
+		{"to itself", dto.WorkflowTransitionRequest{From: "draft", To: "draft"}},
+		{"approvers without quorum", dto.WorkflowTransitionRequest{From: "draft", To: "in_review", ApproverRole: "admin"}},
+		{"quorum without approvers", dto.WorkflowTransitionRequest{From: "draft", To: "in_review", Quorum: 1}},
+		{"quorum above approvers", dto.WorkflowTransitionRequest{From: "draft", To: "in_review", ApproverIds: []string{owner.Id}, Quorum: 2}},
+		{"approver outside", dto.WorkflowTransitionRequest{From: "draft", To: "in_review", ApproverIds: []string{outsider.Id}, Quorum: 1}},
+	}
+	for _, tt := range tests {
+		_, err := repo.WorkflowStore.UpdateWorkflowFromRequest(ctx, project.WorkspaceId, owner.Id, &dto.WorkflowUpdateRequest{
+			Transitions: []dto.WorkflowTransitionRequest{tt.transition},
+		})
+		if !errors.Is(err, models.ErrInvalidWorkflow) {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestTransitionQuorum(t *testing.T) {
+	repo := newTestStore(t)
+	ctx := context.Background()
+	owner, project := newTestProject(t, repo)
+	workspaceId := project.WorkspaceId
+	requester := newTestMember(t, repo, workspaceId, owner.Id, "requester", models.WorkspaceRoleEditor)
+	first := newTestMember(t, repo, workspaceId, owner.Id, "first", models.WorkspaceRoleEditor)
+	second := newTestMember(t, repo, workspaceId, owner.Id, "second", models.WorkspaceRoleViewer)
+	bystander := newTestMember(t, repo, workspaceId, owner.Id, "bystander", models.WorkspaceRoleEditor)
+	_, err := repo.WorkflowStore.UpdateWorkflowFromRequest(ctx, workspaceId, owner.Id, &dto.WorkflowUpdateRequest{
+		Transitions: []dto.WorkflowTransitionRequest{
+			{From: "draft", To: "in_review", ApproverIds: []string{first.Id, second.Id}, Quorum: 2},
+			{From: "in_review", To: "approved"},
+			{From: "approved", To: "published", ApproverRole: "admin", Quorum: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := newTestDocument(t, repo, owner.Id, project.Id, "", "contract")
+	status := func(want models.DocumentStatus) {
+		t.Helper()
+		d, err := repo.DocumentStore.FindDocumentById(ctx, document.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Status != want {
+			t.Fatalf("document %s, want %s", d.Status, want)
+		}
+	}
+
+	_, err = repo.WorkflowStore.RequestTransitionFromRequest(ctx, document.Id, requester.Id, &dto.TransitionCreateRequest{To: "published"})
+	if !errors.Is(err, models.ErrTransitionNotAllowed) {
+		t.Fatalf("skipped the review: %v", err)
+	}
+	transition, err := repo.WorkflowStore.RequestTransitionFromRequest(ctx, document.Id, requester.Id, &dto.TransitionCreateRequest{To: "in_review"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transition.Status != models.TransitionStatusPending {
+		t.Fatalf("transition %s, want pending", transition.Status)
+	}
+	_, err = repo.WorkflowStore.RequestTransitionFromRequest(ctx, document.Id, requester.Id, &dto.TransitionCreateRequest{To: "in_review"})
+	if !errors.Is(err, models.ErrTransitionPending) {
+		t.Fatalf("requested twice: %v", err)
+	}
+
+	approve := &dto.TransitionApproveRequest{}
+	for _, accountId := range []string{requester.Id, bystander.Id} {
+		_, err = repo.WorkflowStore.Approve(ctx, document.Id, transition.Id, accountId, approve)
+		if !errors.Is(err, models.ErrForbidden) {
+			t.Fatalf("approved by %s: %v", accountId, err)
+		}
+	}
+	approvers, err := repo.WorkflowStore.Approvers(ctx, transition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approvers) != 2 {
+		t.Errorf("approvers %v, want the named two", approvers)
+	}
+	transition, err = repo.WorkflowStore.Approve(ctx, document.Id, transition.Id, first.Id, approve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transition.Status != models.TransitionStatusPending || len(transition.Approvals) != 1 {
+		t.Fatalf("transition %s with %d approvals after the first", transition.Status, len(transition.Approvals))
+	}
+	status(models.DocumentStatusDraft)
+	_, err = repo.WorkflowStore.Approve(ctx, document.Id, transition.Id, first.Id, approve)
+	if !errors.Is(err, models.ErrAlreadyDecided) {
+		t.Fatalf("approved twice: %v", err)
+	}
+	transition, err = repo.WorkflowStore.Approve(ctx, document.Id, transition.Id, second.Id, approve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transition.Status != models.TransitionStatusCompleted {
+		t.Fatalf("transition %s after the quorum", transition.Status)
+	}
+	status(models.DocumentStatusInReview)
+
+	// without approval the transition applies right away
+	transition, err = repo.WorkflowStore.RequestTransitionFromRequest(ctx, document.Id, requester.Id, &dto.TransitionCreateRequest{To: "approved"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transition.Status != models.TransitionStatusCompleted {
+		t.Fatalf("transition %s without approval", transition.Status)
+	}
+	status(models.DocumentStatusApproved)
+
+	// approvers by role need the role on the document, one rejection closes
+	transition, err = repo.WorkflowStore.RequestTransitionFromRequest(ctx, document.Id, requester.Id, &dto.TransitionCreateRequest{To: "published"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.WorkflowStore.Approve(ctx, document.Id, transition.Id, first.Id, approve)
+	if !errors.Is(err, models.ErrForbidden) {
+		t.Fatalf("approved by an editor: %v", err)
+	}
+	transition, err = repo.WorkflowStore.Reject(ctx, document.Id, transition.Id, owner.Id, &dto.TransitionRejectRequest{Comment: "not yet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transition.Status != models.TransitionStatusRejected {
+		t.Fatalf("transition %s after the rejection", transition.Status)
+	}
+	status(models.DocumentStatusApproved)
+	_, err = repo.WorkflowStore.Approve(ctx, document.Id, transition.Id, owner.Id, approve)
+	if !errors.Is(err, models.ErrTransitionClosed) {
+		t.Errorf("approved a rejected transition: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+	"github.com/praveenmsp23/trackdocs/pkg/models"
+	"github.com/praveenmsp23/trackdocs/pkg/models/dto"
+	"gorm.io/gorm"
+)
+
+type workspaceStore struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	cache cache.Cache
+	repo  *Store
+}
+
+const (
+	WorkspaceMemberCachePrefix = "workspace_member_v1::"
+	// WorkspaceCacheTagPrefix tags the cache entries of a workspace, see
+	// InvalidateCache
+	WorkspaceCacheTagPrefix = "workspace::"
+)
+
+func getWorkspaceMemberCacheKey(workspaceId, accountId string) string {
+	return fmt.Sprintf("%s%s::%s", WorkspaceMemberCachePrefix, workspaceId, accountId)
+}
+
+func getWorkspaceCacheTag(workspaceId string) string {
+	return fmt.Sprintf("%s%s", WorkspaceCacheTagPrefix, workspaceId)
+}
+
+func newWorkspaceStore(conn *gorm.DB, cache cache.Cache, cfg *config.Config) *workspaceStore {
+	return &workspaceStore{db: conn, cache: cache, cfg: cfg}
+}
+
+// NewWorkspaceFromRequest creates a workspace and makes the creator its owner
+func (u *workspaceStore) NewWorkspaceFromRequest(ctx context.Context, accountId string, req *dto.WorkspaceCreateRequest) (*models.Workspace, error) {
+	workspace := models.NewWorkspace(req.Name, accountId)
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		workspace, err = workspace.Create(tx)
+		if err != nil {
+			return err
+		}
+		_, err = models.NewWorkspaceMember(workspace.Id, accountId, models.WorkspaceRoleOwner, accountId).Create(tx)
+		return err
+	})
+	if err != nil {
+		return &models.Workspace{}, err
+	}
+	return workspace, nil
+}
+
+func (u *workspaceStore) UpdateWorkspaceFromRequest(ctx context.Context, workspaceId, accountId string, req *dto.WorkspaceUpdateRequest) (*models.Workspace, error) {
+	workspace, err := u.FindWorkspaceById(ctx, workspaceId)
+	if err != nil {
+		return workspace, err
+	}
+	workspace.Name = req.Name
+	workspace.ModifiedBy = accountId
+	return workspace.Update(u.db.WithContext(ctx))
+}
+
+func (u *workspaceStore) FindWorkspaceById(ctx context.Context, workspaceId string) (*models.Workspace, error) {
+	workspace := &models.Workspace{}
+	err := u.db.WithContext(ctx).Model(models.Workspace{}).Where("id = ?", workspaceId).Take(workspace).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Workspace{}, models.ErrWorkspaceNotFound
+	} else if err != nil {
+		return &models.Workspace{}, err
+	}
+	return workspace, nil
+}
+
+// ListWorkspaces lists the workspaces the account is a member of
+func (u *workspaceStore) ListWorkspaces(ctx context.Context, accountId string, page *models.Page) ([]*models.Workspace, int64, error) {
+	workspaces := []*models.Workspace{}
+	members := u.db.WithContext(ctx).Model(models.WorkspaceMember{}).Select("workspace_id").Where("account_id = ?", accountId)
+	query := u.db.WithContext(ctx).Model(models.Workspace{}).Where("id IN (?)", members)
+	total, err := paginate(query, page, &workspaces)
+	return workspaces, total, err
+}
+
+// ListWorkspaceIds returns the ids of all workspaces the account is a member of
+func (u *workspaceStore) ListWorkspaceIds(ctx context.Context, accountId string) ([]string, error) {
+	ids := []string{}
+	err := u.db.WithContext(ctx).Model(models.WorkspaceMember{}).Where("account_id = ?", accountId).Pluck("workspace_id", &ids).Error
+	return ids, err
+}
+
+// FindMember returns the membership of the account in the workspace
+func (u *workspaceStore) FindMember(ctx context.Context, workspaceId, accountId string) (*models.WorkspaceMember, error) {
+	var err error
+	member := &models.WorkspaceMember{}
+	err = u.cache.GetContext(ctx, getWorkspaceMemberCacheKey(workspaceId, accountId), member)
+	if err == nil && member.Id != "" {
+		return member, nil
+	}
+	err = u.db.WithContext(ctx).Model(models.WorkspaceMember{}).Where("workspace_id = ? AND account_id = ?", workspaceId, accountId).Take(member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.WorkspaceMember{}, models.ErrWorkspaceMemberNotFound
+	} else if err != nil {
+		return &models.WorkspaceMember{}, err
+	}
+	err = u.cache.SetContext(ctx, getWorkspaceMemberCacheKey(workspaceId, accountId), member)
+	if err == nil {
+		err = u.cache.Tag(ctx, getWorkspaceMemberCacheKey(workspaceId, accountId), getWorkspaceCacheTag(workspaceId))
+	}
+	if err != nil {
+		logger.Errorf("FindMember error while setting cache:%s for key %s", err.Error(), getWorkspaceMemberCacheKey(workspaceId, accountId))
+	}
+	return member, nil
+}
+
+func (u *workspaceStore) FindMemberById(ctx context.Context, workspaceId, memberId string) (*models.WorkspaceMember, error) {
+	member := &models.WorkspaceMember{}
+	err := u.db.WithContext(ctx).Model(models.WorkspaceMember{}).Where("workspace_id = ? AND id = ?", workspaceId, memberId).Take(member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.WorkspaceMember{}, models.ErrWorkspaceMemberNotFound
+	} else if err != nil {
+		return &models.WorkspaceMember{}, err
+	}
+	return member, nil
+}
+
+func (u *workspaceStore) ListMembers(ctx context.Context, workspaceId string, page *models.Page) ([]*models.WorkspaceMember, int64, error) {
+	members := []*models.WorkspaceMember{}
+	query := u.db.WithContext(ctx).Model(models.WorkspaceMember{}).Where("workspace_id = ?", workspaceId)
+	total, err := paginate(query, page, &members)
+	return members, total, err
+}
+
+// ListMemberAccountIds returns the account ids of every member of the
+// workspace
+func (u *workspaceStore) ListMemberAccountIds(ctx context.Context, workspaceId string) ([]string, error) {
+	ids := []string{}
+	err := u.db.WithContext(ctx).Model(models.WorkspaceMember{}).Where("workspace_id = ?", workspaceId).Order("created").Pluck("account_id", &ids).Error
+	return ids, err
+}
+
+func (u *workspaceStore) AddMemberFromRequest(ctx context.Context, workspaceId, accountId string, req *dto.WorkspaceMemberAddRequest) (*models.WorkspaceMember, error) {
+	account, err := u.repo.AccountStore.FindAccountByEmail(ctx, req.Email)
+	if err != nil {
+		return &models.WorkspaceMember{}, err
+	}
+	_, err = u.FindMember(ctx, workspaceId, account.Id)
+	if err == nil {
+		return &models.WorkspaceMember{}, models.ErrMemberExists
+	} else if !errors.Is(err, models.ErrWorkspaceMemberNotFound) {
+		return &models.WorkspaceMember{}, err
+	}
+	return models.NewWorkspaceMember(workspaceId, account.Id, models.WorkspaceRole(req.Role), accountId).Create(u.db.WithContext(ctx))
+}
+
+func (u *workspaceStore) UpdateMemberFromRequest(ctx context.Context, workspaceId, memberId, accountId string, req *dto.WorkspaceMemberUpdateRequest) (*models.WorkspaceMember, error) {
+	member, err := u.FindMemberById(ctx, workspaceId, memberId)
+	if err != nil {
+		return member, err
+	}
+	role := models.WorkspaceRole(req.Role)
+	if !role.IsValid() {
+		return member, models.ErrInvalidRole
+	}
+	if member.Role == models.WorkspaceRoleOwner && role != models.WorkspaceRoleOwner {
+		if err = u.ensureAnotherOwner(ctx, workspaceId, member.Id); err != nil {
+			return member, err
+		}
+	}
+	err = u.db.WithContext(ctx).Model(models.WorkspaceMember{}).Where("id = ?", member.Id).UpdateColumns(
+		map[string]interface{}{
+			"role":        role,
+			"modified_by": accountId,
+		},
+	).Error
+	if err != nil {
+		return member, err
+	}
+	member.Role = role
+	member.ModifiedBy = accountId
+	u.invalidateMember(ctx, member)
+	return member, nil
+}
+
+func (u *workspaceStore) RemoveMember(ctx context.Context, workspaceId, memberId string) error {
+	member, err := u.FindMemberById(ctx, workspaceId, memberId)
+	if err != nil {
+		return err
+	}
+	if member.Role == models.WorkspaceRoleOwner {
+		if err = u.ensureAnotherOwner(ctx, workspaceId, member.Id); err != nil {
+			return err
+		}
+	}
+	_, err = member.Delete(u.db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	u.invalidateMember(ctx, member)
+	return nil
+}
+
+func (u *workspaceStore) ensureAnotherOwner(ctx context.Context, workspaceId, memberId string) error {
+	var owners int64
+	err := u.db.WithContext(ctx).Model(models.WorkspaceMember{}).
+		Where("workspace_id = ? AND role = ? AND id <> ?", workspaceId, models.WorkspaceRoleOwner, memberId).
+		Count(&owners).Error
+	if err != nil {
+		return err
+	}
+	if owners == 0 {
+		return models.ErrLastOwner
+	}
+	return nil
+}
+
+// InvalidateCache deletes every cache entry tagged with the workspace and
+// returns how many were deleted
+func (u *workspaceStore) InvalidateCache(ctx context.Context, workspaceId string) (int64, error) {
+	return u.cache.InvalidateTags(ctx, getWorkspaceCacheTag(workspaceId))
+}
+
+func (u *workspaceStore) invalidateMember(ctx context.Context, member *models.WorkspaceMember) {
+	err := u.cache.DelContext(ctx, getWorkspaceMemberCacheKey(member.WorkspaceId, member.AccountId))
+	if err != nil {
+		logger.Errorf("invalidateMember error while deleting cache:%s for key %s", err.Error(), getWorkspaceMemberCacheKey(member.WorkspaceId, member.AccountId))
+	}
+}
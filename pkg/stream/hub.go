@@ -0,0 +1,323 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/praveenmsp23/trackdocs/pkg/cache"
+	"github.com/praveenmsp23/trackdocs/pkg/config"
+	"github.com/praveenmsp23/trackdocs/pkg/events"
+	"github.com/praveenmsp23/trackdocs/pkg/logger"
+)
+
+const (
+	RedisBuffer  = "stream::buffer"
+	RedisChannel = "stream::live"
+	// SubscriberBuffer is how many messages a subscriber may fall behind
+	// before it is dropped
+	SubscriberBuffer = 64
+	// hubReconnectDelay is the pause before subscribing to the channel again
+	// after Redis failed
+	hubReconnectDelay = time.Second
+	redisMessageField = "m"
+)
+
+// publishScript appends a message to the buffer and announces it on the
+// channel with its buffer id, so subscribers see messages in buffer order
+var publishScript = redis.NewScript(`
+local id = redis.call("XADD", KEYS[1], "MAXLEN", "~", ARGV[1], "*", "m", ARGV[2])
+redis.call("PUBLISH", ARGV[3], id .. " " .. ARGV[2])
+return id
+`)
+
+// Message is an event for the clients of the stream. Events of a workspace
+// go to the members allowed to see them, messages with an AccountId only to
+// that account. Id is set by the hub and orders the messages.
+type Message struct {
+	Id        string        `json:"-"`
+	AccountId string        `json:"account_id,omitempty"`
+	Event     *events.Event `json:"event"`
+}
+
+// Hub fans messages out to the subscribers of every API replica through
+// Redis pub/sub. The latest messages are kept in a capped Redis stream, so
+// clients that reconnect replay what they missed. Without redis the hub only
+// serves its own process and buffers in memory.
+type Hub struct {
+	client     redis.UniversalClient
+	bufferSize int64
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	// buffer, lastMs and lastSeq are used without redis
+	buffer  []*Message
+	lastMs  uint64
+	lastSeq uint64
+}
+
+// Subscription receives the messages published after it was created. C is
+// closed when the subscriber fell behind or the hub stopped.
+type Subscription struct {
+	C   chan *Message
+	hub *Hub
+}
+
+func NewHub(c cache.Cache, cfg *config.Config) *Hub {
+	h := &Hub{
+		bufferSize:  cfg.StreamBufferSize,
+		subscribers: map[*Subscription]struct{}{},
+	}
+	if r, ok := c.(*cache.Redis); ok {
+		h.client = r.GetClient()
+	}
+	return h
+}
+
+// Publish buffers the message and sends it to the subscribers of all
+// replicas
+func (h *Hub) Publish(ctx context.Context, message *Message) error {
+	if h.client == nil {
+		h.publishLocal(message)
+		return nil
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	id, err := publishScript.Run(ctx, h.client, []string{RedisBuffer}, h.bufferSize, data, RedisChannel).Text()
+	if err != nil {
+		return err
+	}
+	message.Id = id
+	return nil
+}
+
+// publishLocal gives message the next id like XADD, buffers it and delivers
+// it to the subscribers
+func (h *Hub) publishLocal(message *Message) {
+	h.mu.Lock()
+	ms := uint64(time.Now().UnixMilli())
+	if ms > h.lastMs {
+		h.lastMs, h.lastSeq = ms, 0
+	} else {
+		h.lastSeq++
+	}
+	message.Id = strconv.FormatUint(h.lastMs, 10) + "-" + strconv.FormatUint(h.lastSeq, 10)
+	h.buffer = append(h.buffer, message)
+	if int64(len(h.buffer)) > h.bufferSize {
+		h.buffer = h.buffer[int64(len(h.buffer))-h.bufferSize:]
+	}
+	h.mu.Unlock()
+	h.deliver(message)
+}
+
+// Subscribe returns a subscription for local delivery, Close it when done
+func (h *Hub) Subscribe() *Subscription {
+	s := &Subscription{C: make(chan *Message, SubscriberBuffer), hub: h}
+	h.mu.Lock()
+	h.subscribers[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+func (s *Subscription) Close() {
+	s.hub.drop(s)
+}
+
+func (h *Hub) drop(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[s]; ok {
+		delete(h.subscribers, s)
+		close(s.C)
+	}
+}
+
+// Replay returns up to limit buffered messages after afterId, oldest first.
+// complete is false when messages after afterId were already trimmed from
+// the buffer or more than limit are left.
+func (h *Hub) Replay(ctx context.Context, afterId string, limit int64) ([]*Message, bool, error) {
+	if h.client == nil {
+		messages, complete := h.replayLocal(afterId, limit)
+		return messages, complete, nil
+	}
+	first, err := h.client.XRangeN(ctx, RedisBuffer, "-", "+", 1).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(first) == 0 {
+		return nil, true, nil
+	}
+	complete := !Before(afterId, first[0].ID) || afterId == previous(first[0].ID)
+	entries, err := h.client.XRangeN(ctx, RedisBuffer, "("+afterId, "+", limit+1).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(entries)) > limit {
+		entries = entries[:limit]
+		complete = false
+	}
+	messages := make([]*Message, 0, len(entries))
+	for _, e := range entries {
+		data, _ := e.Values[redisMessageField].(string)
+		message := &Message{}
+		if err := json.Unmarshal([]byte(data), message); err != nil {
+			logger.Errorf("Hub skipping malformed message %s:%s", e.ID, err.Error())
+			continue
+		}
+		message.Id = e.ID
+		messages = append(messages, message)
+	}
+	return messages, complete, nil
+}
+
+func (h *Hub) replayLocal(afterId string, limit int64) ([]*Message, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.buffer) == 0 {
+		return nil, true
+	}
+	first := h.buffer[0].Id
+	complete := !Before(afterId, first) || afterId == previous(first)
+	messages := []*Message{}
+	for _, m := range h.buffer {
+		if !Before(afterId, m.Id) {
+			continue
+		}
+		if int64(len(messages)) == limit {
+			complete = false
+			break
+		}
+		messages = append(messages, m)
+	}
+	return messages, complete
+}
+
+// Last returns the id of the latest buffered message, empty when the buffer
+// is empty
+func (h *Hub) Last(ctx context.Context) (string, error) {
+	if h.client == nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if len(h.buffer) == 0 {
+			return "", nil
+		}
+		return h.buffer[len(h.buffer)-1].Id, nil
+	}
+	entries, err := h.client.XRevRangeN(ctx, RedisBuffer, "+", "-", 1).Result()
+	if err != nil || len(entries) == 0 {
+		return "", err
+	}
+	return entries[0].ID, nil
+}
+
+// Run delivers the messages published on the channel to the local
+// subscribers until ctx is done. Subscribers that fell behind are dropped so
+// they reconnect and replay instead of blocking the others.
+func (h *Hub) Run(ctx context.Context) {
+	defer h.closeAll()
+	if h.client == nil {
+		// Publish delivers directly
+		<-ctx.Done()
+		return
+	}
+	for {
+		pubsub := h.client.Subscribe(ctx, RedisChannel)
+		h.receive(ctx, pubsub)
+		pubsub.Close()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(hubReconnectDelay):
+		}
+		// messages published while the channel was down are lost for the
+		// current subscribers, make them reconnect and replay
+		h.closeAll()
+	}
+}
+
+func (h *Hub) receive(ctx context.Context, pubsub *redis.PubSub) {
+	for {
+		received, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Errorf("Hub error while receiving messages:%s", err.Error())
+			}
+			return
+		}
+		id, data, ok := strings.Cut(received.Payload, " ")
+		if !ok {
+			continue
+		}
+		message := &Message{}
+		if err := json.Unmarshal([]byte(data), message); err != nil {
+			logger.Errorf("Hub skipping malformed message %s:%s", id, err.Error())
+			continue
+		}
+		message.Id = id
+		h.deliver(message)
+	}
+}
+
+func (h *Hub) deliver(message *Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subscribers {
+		select {
+		case s.C <- message:
+		default:
+			delete(h.subscribers, s)
+			close(s.C)
+		}
+	}
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subscribers {
+		delete(h.subscribers, s)
+		close(s.C)
+	}
+}
+
+// ValidId reports whether id is a stream id as generated by Publish
+func ValidId(id string) bool {
+	_, _, ok := parseId(id)
+	return ok
+}
+
+// Before reports whether the stream id a sorts before b. Malformed ids sort
+// first.
+func Before(a, b string) bool {
+	ams, aseq, _ := parseId(a)
+	bms, bseq, _ := parseId(b)
+	if ams != bms {
+		return ams < bms
+	}
+	return aseq < bseq
+}
+
+// previous returns the largest id before id
+func previous(id string) string {
+	ms, seq, _ := parseId(id)
+	if seq > 0 {
+		return strconv.FormatUint(ms, 10) + "-" + strconv.FormatUint(seq-1, 10)
+	}
+	if ms == 0 {
+		return "0-0"
+	}
+	return strconv.FormatUint(ms-1, 10) + "-18446744073709551615"
+}
+
+func parseId(id string) (uint64, uint64, bool) {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	ms, msErr := strconv.ParseUint(msPart, 10, 64)
+	seq, seqErr := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq, ok && msErr == nil && seqErr == nil
+}
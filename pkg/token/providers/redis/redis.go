@@ -29,7 +29,7 @@ func GetProvider(cfg *config.Config) (*RedisProvider, error) {
 
 type RedisTokenStore struct {
 	tid         string
-	client      *cache.Cache
+	client      cache.Cache
 	maxlifetime int64
 }
 
@@ -55,7 +55,7 @@ func (st *RedisTokenStore) TokenID() string {
 }
 
 type RedisProvider struct {
-	client      *cache.Cache
+	client      cache.Cache
 	maxlifetime int64
 }
 